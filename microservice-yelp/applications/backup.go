@@ -0,0 +1,220 @@
+package applications
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+
+	"gitlab.cs.washington.edu/syslab/cse453-welp/proto/mydatabase"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+var ErrCorruptBackup = errors.New("backup: corrupt or truncated file")
+
+const (
+	// BackupFormatVersion is the version of the backup file format written by BackupWriter.
+	BackupFormatVersion = 1
+
+	backupMagic       = "MYDBBAK1"
+	backupScanBatch   = 1000    // records read per storage scan while taking a backup
+	maxBackupFrame    = 1 << 30 // largest record or manifest a backup file may hold
+	backupRecordTag   = 'r'
+	backupManifestTag = 'm'
+)
+
+// BackupWriter writes a backup file: a magic header, then each record as a tagged,
+// length-prefixed protobuf, then a trailing manifest holding the number of records and a
+// SHA-256 checksum of everything before it.
+type BackupWriter struct {
+	w     *bufio.Writer
+	hash  hash.Hash
+	count int64
+}
+
+func NewBackupWriter(w io.Writer) (*BackupWriter, error) {
+	b := &BackupWriter{w: bufio.NewWriter(w), hash: sha256.New()}
+	if err := b.frame(0, []byte(backupMagic)); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// frameHeader returns the tag and length prefix of a frame.
+func frameHeader(tag byte, length int) []byte {
+	header := make([]byte, 1+binary.MaxVarintLen64)
+	header[0] = tag
+	return header[:1+binary.PutUvarint(header[1:], uint64(length))]
+}
+
+// frame writes a tagged, length-prefixed frame, or data alone if tag is 0.
+func (b *BackupWriter) frame(tag byte, data []byte) error {
+	var header []byte
+	if tag != 0 {
+		header = frameHeader(tag, len(data))
+	}
+	out := io.MultiWriter(b.w, b.hash)
+	if _, err := out.Write(header); err != nil {
+		return err
+	}
+	_, err := out.Write(data)
+	return err
+}
+
+func (b *BackupWriter) Write(record *mydatabase.DatabaseRecord) error {
+	data, err := proto.Marshal(record)
+	if err != nil {
+		return err
+	}
+	b.count++
+	return b.frame(backupRecordTag, data)
+}
+
+// Close completes manifest with the format version, record count and checksum, writes it
+// and flushes the file. It does not close the underlying writer.
+func (b *BackupWriter) Close(manifest *mydatabase.BackupManifest) error {
+	manifest.FormatVersion = BackupFormatVersion
+	manifest.RecordCount = b.count
+	manifest.Checksum = hex.EncodeToString(b.hash.Sum(nil))
+	data, err := protojson.Marshal(manifest)
+	if err != nil {
+		return err
+	}
+	if _, err := b.w.Write(frameHeader(backupManifestTag, len(data))); err != nil {
+		return err
+	}
+	if _, err := b.w.Write(data); err != nil {
+		return err
+	}
+	return b.w.Flush()
+}
+
+// WriteBackup writes a backup of the records in storage as of the commit timestamp ts to
+// w. It scans the storage app in batches, so writes are only held up briefly. The snapshot
+// at ts must stay readable until the backup completes, either by the backup finishing
+// within the version retention window or by the caller pinning it with PinSnapshot.
+func WriteBackup(w io.Writer, storage Storage, ts int64, manifest *mydatabase.BackupManifest) error {
+	b, err := NewBackupWriter(w)
+	if err != nil {
+		return err
+	}
+	manifest.SnapshotTimestamp = ts
+
+	options := ScanOptions{At: ts}
+	for {
+		batch := make([]*mydatabase.DatabaseRecord, 0, backupScanBatch)
+		err := storage.Scan(options, func(record *mydatabase.DatabaseRecord) bool {
+			batch = append(batch, record)
+			return len(batch) < backupScanBatch
+		})
+		if err != nil {
+			return err
+		}
+		for _, record := range batch {
+			if err := b.Write(record); err != nil {
+				return err
+			}
+		}
+		if len(batch) < backupScanBatch {
+			return b.Close(manifest)
+		}
+		options.Start = batch[len(batch)-1].Key + "\x00"
+	}
+}
+
+// ReadBackup reads a backup file, calling fn with each of its records, and checks the file
+// against its manifest once they have all been read. fn may be nil to only verify the file;
+// callers that must not act on a corrupt file should verify it before reading it again.
+func ReadBackup(r io.Reader, fn func(record *mydatabase.DatabaseRecord) error) (*mydatabase.BackupManifest, error) {
+	in := &backupReader{r: bufio.NewReader(r), hash: sha256.New()}
+
+	magic := make([]byte, len(backupMagic))
+	if _, err := io.ReadFull(in, magic); err != nil || string(magic) != backupMagic {
+		return nil, fmt.Errorf("%w: not a backup file", ErrCorruptBackup)
+	}
+
+	var count int64
+	for {
+		// the manifest frame is not covered by the checksum, so peek at its tag first
+		tag, err := in.r.Peek(1)
+		if err != nil {
+			return nil, fmt.Errorf("%w: missing manifest", ErrCorruptBackup)
+		}
+		if tag[0] == backupManifestTag {
+			break
+		}
+		data, err := readBackupFrame(in, backupRecordTag)
+		if err != nil {
+			return nil, err
+		}
+		var record mydatabase.DatabaseRecord
+		if err := proto.Unmarshal(data, &record); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrCorruptBackup, err)
+		}
+		count++
+		if fn != nil {
+			if err := fn(&record); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	checksum := hex.EncodeToString(in.hash.Sum(nil))
+	data, err := readBackupFrame(in, backupManifestTag)
+	if err != nil {
+		return nil, err
+	}
+	var manifest mydatabase.BackupManifest
+	if err := protojson.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("%w: invalid manifest: %v", ErrCorruptBackup, err)
+	}
+	switch {
+	case manifest.GetFormatVersion() != BackupFormatVersion:
+		return nil, fmt.Errorf("backup: unsupported format version %d", manifest.GetFormatVersion())
+	case manifest.GetRecordCount() != count:
+		return nil, fmt.Errorf("%w: manifest lists %d records, file holds %d", ErrCorruptBackup, manifest.GetRecordCount(), count)
+	case manifest.GetChecksum() != checksum:
+		return nil, fmt.Errorf("%w: checksum mismatch", ErrCorruptBackup)
+	}
+	return &manifest, nil
+}
+
+// backupReader hashes the bytes of a backup file as they are consumed.
+type backupReader struct {
+	r    *bufio.Reader
+	hash hash.Hash
+}
+
+func (b *backupReader) Read(p []byte) (int, error) {
+	n, err := b.r.Read(p)
+	b.hash.Write(p[:n])
+	return n, err
+}
+
+func (b *backupReader) ReadByte() (byte, error) {
+	c, err := b.r.ReadByte()
+	if err == nil {
+		b.hash.Write([]byte{c})
+	}
+	return c, err
+}
+
+func readBackupFrame(r *backupReader, tag byte) ([]byte, error) {
+	if t, err := r.ReadByte(); err != nil || t != tag {
+		return nil, fmt.Errorf("%w: unexpected frame", ErrCorruptBackup)
+	}
+	n, err := binary.ReadUvarint(r)
+	if err != nil || n > maxBackupFrame {
+		return nil, fmt.Errorf("%w: invalid frame length", ErrCorruptBackup)
+	}
+	data := make([]byte, n)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCorruptBackup, err)
+	}
+	return data, nil
+}
@@ -0,0 +1,106 @@
+package applications
+
+import (
+	"errors"
+	"hash/fnv"
+	"math"
+)
+
+var (
+	ErrCorruptBloomFilter = errors.New("bloom: corrupt filter")
+)
+
+// BloomFilter is a fixed-size bloom filter using double hashing over a 64-bit FNV-1a hash.
+type BloomFilter struct {
+	bits   []uint64
+	hashes uint32
+}
+
+// NewBloomFilter creates a filter sized for the expected number of keys at the given
+// number of bits per key (10 bits per key gives roughly a 1% false-positive rate).
+func NewBloomFilter(expectedKeys, bitsPerKey int) *BloomFilter {
+	if expectedKeys < 1 {
+		expectedKeys = 1
+	}
+	if bitsPerKey < 1 {
+		bitsPerKey = 1
+	}
+	// k = ln(2) * bits/key minimizes the false-positive rate
+	hashes := uint32(math.Round(float64(bitsPerKey) * math.Ln2))
+	if hashes < 1 {
+		hashes = 1
+	}
+	if hashes > 30 {
+		hashes = 30
+	}
+	words := (expectedKeys*bitsPerKey + 63) / 64
+	return &BloomFilter{
+		bits:   make([]uint64, words),
+		hashes: hashes,
+	}
+}
+
+func bloomHash(key string) (uint32, uint32) {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	sum := h.Sum64()
+	return uint32(sum), uint32(sum>>32) | 1
+}
+
+// Add inserts key into the filter.
+func (f *BloomFilter) Add(key string) {
+	h1, h2 := bloomHash(key)
+	n := uint32(len(f.bits) * 64)
+	for i := uint32(0); i < f.hashes; i++ {
+		bit := (h1 + i*h2) % n
+		f.bits[bit/64] |= 1 << (bit % 64)
+	}
+}
+
+// MayContain reports whether key may be in the filter. A false result is definitive.
+func (f *BloomFilter) MayContain(key string) bool {
+	h1, h2 := bloomHash(key)
+	n := uint32(len(f.bits) * 64)
+	for i := uint32(0); i < f.hashes; i++ {
+		bit := (h1 + i*h2) % n
+		if f.bits[bit/64]&(1<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// SizeBytes returns the memory used by the filter's bit array.
+func (f *BloomFilter) SizeBytes() int {
+	return len(f.bits) * 8
+}
+
+// MarshalBinary encodes the filter as one byte holding the hash count followed by
+// the bit array in little-endian words.
+func (f *BloomFilter) MarshalBinary() ([]byte, error) {
+	buf := make([]byte, 1+len(f.bits)*8)
+	buf[0] = byte(f.hashes)
+	for i, word := range f.bits {
+		for j := 0; j < 8; j++ {
+			buf[1+i*8+j] = byte(word >> (8 * j))
+		}
+	}
+	return buf, nil
+}
+
+// UnmarshalBinary decodes a filter produced by MarshalBinary.
+func (f *BloomFilter) UnmarshalBinary(data []byte) error {
+	if len(data) < 9 || (len(data)-1)%8 != 0 || data[0] == 0 {
+		return ErrCorruptBloomFilter
+	}
+	f.hashes = uint32(data[0])
+	f.bits = make([]uint64, (len(data)-1)/8)
+	for i := range f.bits {
+		var word uint64
+		for j := 0; j < 8; j++ {
+			word |= uint64(data[1+i*8+j]) << (8 * j)
+		}
+		f.bits[i] = word
+	}
+	return nil
+}
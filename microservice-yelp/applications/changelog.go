@@ -0,0 +1,180 @@
+package applications
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"gitlab.cs.washington.edu/syslab/cse453-welp/proto/mydatabase"
+)
+
+var ErrChangesUnavailable = errors.New("changelog: changes not retained")
+
+// ChangeLogOptions bounds the history a ChangeLog buffers for subscribers to resume from.
+type ChangeLogOptions struct {
+	// Enabled records changes at all. Recording serializes writes and reads the old value
+	// of every record written, so databases nobody streams changes from leave it unset.
+	Enabled bool
+
+	// MaxEvents is the number of most recent changes kept.
+	MaxEvents int
+
+	// Retention is how long a change is kept; 0 keeps changes until MaxEvents evicts them.
+	Retention time.Duration
+}
+
+// ChangeLog wraps a storage app and records every write applied through it as a change
+// event, numbering them in the order they were applied. The most recent changes are
+// buffered in memory, within the bounds of its options, for subscribers to read from any
+// sequence number still held.
+type ChangeLog struct {
+	Storage
+	options ChangeLogOptions
+
+	writeMu sync.Mutex // serializes writes, so old values and timestamps match each change
+
+	mu      sync.Mutex
+	events  []*mydatabase.ChangeEvent // buffered changes, oldest first
+	first   uint64                    // sequence number of events[0]
+	next    uint64                    // sequence number of the next change
+	changed chan struct{}             // closed and replaced whenever changes are added
+	evicted uint64
+}
+
+func NewChangeLog(storage Storage, options ChangeLogOptions) *ChangeLog {
+	return &ChangeLog{
+		Storage: storage,
+		options: options,
+		first:   1,
+		next:    1,
+		changed: make(chan struct{}),
+	}
+}
+
+func (c *ChangeLog) Set(record *mydatabase.DatabaseRecord) error {
+	return c.Apply([]Mutation{{Key: record.Key, Value: record.Value}})
+}
+
+func (c *ChangeLog) Delete(key string) error {
+	return c.Apply([]Mutation{{Key: key, Delete: true}})
+}
+
+// Apply applies the mutations to the storage app and records a change for each of them,
+// including deletions of records that did not exist.
+func (c *ChangeLog) Apply(mutations []Mutation) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	events := make([]*mydatabase.ChangeEvent, len(mutations))
+	for i, m := range mutations {
+		e := &mydatabase.ChangeEvent{Key: m.Key, NewValue: m.Value}
+		if m.Delete {
+			e.Operation = mydatabase.ChangeOperation_CHANGE_DELETE
+			e.NewValue = nil
+		}
+		events[i] = e
+	}
+	// read the old values in parallel, so a batch, such as a group commit, waits for one
+	// device access rather than one per record
+	var wg sync.WaitGroup
+	for _, e := range events {
+		wg.Add(1)
+		go func(e *mydatabase.ChangeEvent) {
+			defer wg.Done()
+			if old, ok := c.Storage.Get(e.Key); ok {
+				e.Existed, e.OldValue = true, old.Value
+			}
+		}(e)
+	}
+	wg.Wait()
+	if err := c.Storage.Apply(mutations); err != nil {
+		return err
+	}
+	// a batch is applied with a single commit timestamp
+	ts := c.Storage.Timestamp()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, e := range events {
+		e.Sequence, e.Timestamp = c.next, ts
+		c.next++
+	}
+	c.events = append(c.events, events...)
+	c.evict()
+	close(c.changed)
+	c.changed = make(chan struct{})
+	return nil
+}
+
+// evict drops the changes beyond the log's bounds. The caller must hold mu.
+func (c *ChangeLog) evict() {
+	n := 0
+	if limit := c.options.MaxEvents; limit > 0 && len(c.events) > limit {
+		n = len(c.events) - limit
+	}
+	if c.options.Retention > 0 {
+		horizon := time.Now().Add(-c.options.Retention).UnixNano()
+		for n < len(c.events) && c.events[n].Timestamp < horizon {
+			n++
+		}
+	}
+	if n == 0 {
+		return
+	}
+	// copy so the evicted events can be collected
+	c.events = append([]*mydatabase.ChangeEvent(nil), c.events[n:]...)
+	c.first += uint64(n)
+	c.evicted += uint64(n)
+}
+
+// Read returns up to limit buffered changes starting at sequence number from. If there are
+// none yet, it returns a channel that is closed once there are. It fails with
+// ErrChangesUnavailable if the changes from from on are no longer, or not yet, held; 0
+// reads from the next change.
+func (c *ChangeLog) Read(from uint64, limit int) ([]*mydatabase.ChangeEvent, <-chan struct{}, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.evict()
+	if from == 0 {
+		from = c.next
+	}
+	switch {
+	case from < c.first:
+		return nil, nil, fmt.Errorf("%w: the oldest change held is %d", ErrChangesUnavailable, c.first)
+	case from > c.next:
+		return nil, nil, fmt.Errorf("%w: the next change will be %d", ErrChangesUnavailable, c.next)
+	case from == c.next:
+		return nil, c.changed, nil
+	}
+	events := c.events[from-c.first:]
+	if len(events) > limit {
+		events = events[:limit]
+	}
+	return append([]*mydatabase.ChangeEvent(nil), events...), nil, nil
+}
+
+// Next returns the sequence number of the next change.
+func (c *ChangeLog) Next() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.next
+}
+
+// Stats reports the storage app's statistics along with the change log's.
+func (c *ChangeLog) Stats() *mydatabase.StorageStats {
+	stats := c.Storage.Stats()
+	if stats.Metrics == nil {
+		stats.Metrics = make(map[string]float64)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats.Metrics["changelog_first_sequence"] = float64(c.first)
+	stats.Metrics["changelog_next_sequence"] = float64(c.next)
+	stats.Metrics["changelog_buffered_events"] = float64(len(c.events))
+	stats.Metrics["changelog_evicted_events"] = float64(c.evicted)
+	return stats
+}
@@ -0,0 +1,153 @@
+package applications
+
+import (
+	"hash/fnv"
+	"math"
+	"math/rand"
+)
+
+const (
+	cuckooBucketSize = 4   // fingerprints per bucket
+	cuckooMaxKicks   = 500 // relocations tried before an insertion gives up
+)
+
+// CuckooFilter is a cuckoo filter of 16-bit fingerprints held in buckets of four. Unlike a
+// BloomFilter it supports removing keys, provided only keys that were added are removed;
+// removing any other key may remove the fingerprint of one that was. A key's fingerprint
+// lives in one of two buckets, the second derived from the first and the fingerprint, so
+// fingerprints can be moved between their buckets to make room without knowing their keys.
+type CuckooFilter struct {
+	buckets [][cuckooBucketSize]uint16 // 0 marks an empty slot
+	mask    uint64
+	count   int
+	rand    *rand.Rand
+}
+
+// NewCuckooFilter creates a filter with room for at least capacity keys. Insertions start
+// failing as the filter nears 95% full.
+func NewCuckooFilter(capacity int) *CuckooFilter {
+	// a power of two number of buckets lets the alternate bucket be found by XOR
+	n := 1
+	for n*cuckooBucketSize < capacity {
+		n <<= 1
+	}
+	return &CuckooFilter{
+		buckets: make([][cuckooBucketSize]uint16, n),
+		mask:    uint64(n - 1),
+		rand:    rand.New(rand.NewSource(rand.Int63())),
+	}
+}
+
+// cuckooHash returns the hash locating key's first bucket and its nonzero fingerprint.
+func cuckooHash(key string) (uint64, uint16) {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	// FNV's high bits barely depend on the last bytes of a key, so mix them in before
+	// taking the fingerprint from the top
+	sum := h.Sum64()
+	sum ^= sum >> 33
+	sum *= 0xff51afd7ed558ccd
+	sum ^= sum >> 33
+	fp := uint16(sum >> 48)
+	if fp == 0 {
+		fp = 1
+	}
+	return sum, fp
+}
+
+// altBucket returns the other bucket a fingerprint in bucket i may live in.
+func (f *CuckooFilter) altBucket(i uint64, fp uint16) uint64 {
+	// mix the fingerprint so that alternate buckets spread over the whole table
+	return (i ^ (uint64(fp) * 0x5bd1e995)) & f.mask
+}
+
+// insert places fp in an empty slot of bucket i, if it has one.
+func (f *CuckooFilter) insert(i uint64, fp uint16) bool {
+	for slot, v := range f.buckets[i] {
+		if v == 0 {
+			f.buckets[i][slot] = fp
+			return true
+		}
+	}
+	return false
+}
+
+// Add inserts key into the filter. It returns false if the filter is too full to make room
+// for it; the filter may then have dropped another key's fingerprint, and must be rebuilt
+// before it is consulted again.
+func (f *CuckooFilter) Add(key string) bool {
+	h, fp := cuckooHash(key)
+	i := h & f.mask
+	alt := f.altBucket(i, fp)
+	if f.insert(i, fp) || f.insert(alt, fp) {
+		f.count++
+		return true
+	}
+	if f.rand.Intn(2) == 0 {
+		i = alt
+	}
+	// evict fingerprints to their other buckets until one finds an empty slot
+	for n := 0; n < cuckooMaxKicks; n++ {
+		slot := f.rand.Intn(cuckooBucketSize)
+		fp, f.buckets[i][slot] = f.buckets[i][slot], fp
+		i = f.altBucket(i, fp)
+		if f.insert(i, fp) {
+			f.count++
+			return true
+		}
+	}
+	return false
+}
+
+// Remove deletes one copy of key's fingerprint from the filter. It must only be called for
+// keys that were added.
+func (f *CuckooFilter) Remove(key string) bool {
+	h, fp := cuckooHash(key)
+	i := h & f.mask
+	for _, b := range []uint64{i, f.altBucket(i, fp)} {
+		for slot, v := range f.buckets[b] {
+			if v == fp {
+				f.buckets[b][slot] = 0
+				f.count--
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// MayContain reports whether key may be in the filter. A false result is definitive.
+func (f *CuckooFilter) MayContain(key string) bool {
+	h, fp := cuckooHash(key)
+	i := h & f.mask
+	for _, b := range []uint64{i, f.altBucket(i, fp)} {
+		for _, v := range f.buckets[b] {
+			if v == fp {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Len returns the number of fingerprints in the filter.
+func (f *CuckooFilter) Len() int {
+	return f.count
+}
+
+// Capacity returns the number of fingerprint slots in the filter.
+func (f *CuckooFilter) Capacity() int {
+	return len(f.buckets) * cuckooBucketSize
+}
+
+// SizeBytes returns the memory used by the filter's buckets.
+func (f *CuckooFilter) SizeBytes() int {
+	return len(f.buckets) * cuckooBucketSize * 2
+}
+
+// FalsePositiveRate estimates the chance that MayContain reports an absent key as present
+// at the filter's current load: a lookup compares against the fingerprints in two buckets.
+func (f *CuckooFilter) FalsePositiveRate() float64 {
+	load := float64(f.count) / float64(f.Capacity())
+	return 1 - math.Pow(1-1/float64(math.MaxUint16), 2*cuckooBucketSize*load)
+}
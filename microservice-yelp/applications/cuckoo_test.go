@@ -0,0 +1,132 @@
+package applications
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+func TestCuckooFilterAddRemove(t *testing.T) {
+	f := NewCuckooFilter(1024)
+	const n = 800
+	for i := 0; i < n; i++ {
+		if !f.Add(fmt.Sprintf("key-%d", i)) {
+			t.Fatalf("Add(key-%d) failed at load %d/%d", i, f.Len(), f.Capacity())
+		}
+	}
+	if f.Len() != n {
+		t.Fatalf("Len = %d, want %d", f.Len(), n)
+	}
+	for i := 0; i < n; i++ {
+		if !f.MayContain(fmt.Sprintf("key-%d", i)) {
+			t.Fatalf("MayContain(key-%d) = false for an added key", i)
+		}
+	}
+
+	for i := 0; i < n; i += 2 {
+		if !f.Remove(fmt.Sprintf("key-%d", i)) {
+			t.Fatalf("Remove(key-%d) = false for an added key", i)
+		}
+	}
+	if f.Len() != n/2 {
+		t.Fatalf("Len after removals = %d, want %d", f.Len(), n/2)
+	}
+	// removing a key never removes another's fingerprint
+	for i := 1; i < n; i += 2 {
+		if !f.MayContain(fmt.Sprintf("key-%d", i)) {
+			t.Fatalf("MayContain(key-%d) = false after removing other keys", i)
+		}
+	}
+	// with 16-bit fingerprints a handful of removed or absent keys may still match
+	falsePositives := 0
+	for i := 0; i < n; i += 2 {
+		if f.MayContain(fmt.Sprintf("key-%d", i)) {
+			falsePositives++
+		}
+		if f.MayContain(fmt.Sprintf("absent-%d", i)) {
+			falsePositives++
+		}
+	}
+	if falsePositives > n/100 {
+		t.Fatalf("%d of %d lookups of absent keys matched", falsePositives, n)
+	}
+}
+
+func TestCuckooFilterDuplicates(t *testing.T) {
+	f := NewCuckooFilter(64)
+	f.Add("a")
+	f.Add("a")
+	if !f.Remove("a") || !f.MayContain("a") {
+		t.Fatal("removing one copy of a key added twice removed both")
+	}
+	if !f.Remove("a") || f.MayContain("a") {
+		t.Fatal("removing the second copy left the key")
+	}
+	if f.Remove("a") {
+		t.Fatal("Remove of a key no longer in the filter = true")
+	}
+}
+
+func TestCuckooFilterAltBucketIsSymmetric(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, capacity := range []int{4, 64, 1000, 1 << 16} {
+		f := NewCuckooFilter(capacity)
+		for n := 0; n < 1000; n++ {
+			i := uint64(r.Int63()) & f.mask
+			fp := uint16(r.Intn(1<<16-1) + 1)
+			alt := f.altBucket(i, fp)
+			if alt > f.mask {
+				t.Fatalf("capacity %d: altBucket(%d, %d) = %d, outside the table", capacity, i, fp, alt)
+			}
+			if back := f.altBucket(alt, fp); back != i {
+				t.Fatalf("capacity %d: altBucket(altBucket(%d, %d)) = %d, want %d", capacity, i, fp, back, i)
+			}
+		}
+	}
+}
+
+func TestCuckooFilterFillsBeforeFailing(t *testing.T) {
+	f := NewCuckooFilter(256)
+	added := 0
+	for f.Add(fmt.Sprintf("key-%d", added)) {
+		added++
+	}
+	// eviction lets the filter fill most of its slots before an insertion fails
+	if load := float64(added) / float64(f.Capacity()); load < 0.8 {
+		t.Fatalf("Add failed at load %.2f", load)
+	}
+}
+
+func TestVersionStoreRebuildsFullFilter(t *testing.T) {
+	s := newVersionStore()
+	const n = 5 * keyFilterCapacity
+	for i := 0; i < n; i++ {
+		s.put(fmt.Sprintf("key-%d", i), Version{Timestamp: int64(i + 1), Value: []byte("v")})
+	}
+	if s.filterRebuilds == 0 {
+		t.Fatalf("filter of capacity %d was not rebuilt for %d keys", keyFilterCapacity, n)
+	}
+	if s.filter.Len() != n {
+		t.Fatalf("filter holds %d keys, want %d", s.filter.Len(), n)
+	}
+	// a key dropped by the failed insertion would now be reported absent
+	for i := 0; i < n; i++ {
+		key := fmt.Sprintf("key-%d", i)
+		if _, ok := s.getLatest(key); !ok {
+			t.Fatalf("getLatest(%s) missed a live key after %d rebuilds", key, s.filterRebuilds)
+		}
+	}
+
+	// deleted keys leave the filter, which keeps every other key
+	for i := 0; i < n; i += 2 {
+		s.put(fmt.Sprintf("key-%d", i), Version{Timestamp: int64(n + i + 1), Deleted: true})
+	}
+	if s.filter.Len() != n/2 {
+		t.Fatalf("filter holds %d keys after deletions, want %d", s.filter.Len(), n/2)
+	}
+	for i := 1; i < n; i += 2 {
+		if !s.mayContain(fmt.Sprintf("key-%d", i)) {
+			t.Fatalf("mayContain(key-%d) = false after other keys were deleted", i)
+		}
+	}
+}
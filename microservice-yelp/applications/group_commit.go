@@ -0,0 +1,199 @@
+package applications
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gitlab.cs.washington.edu/syslab/cse453-welp/proto/mydatabase"
+)
+
+var ErrStorageClosed = errors.New("storage: closed")
+
+// commitRequest is a write waiting to be committed with a group.
+type commitRequest struct {
+	mutations []Mutation
+	done      chan error
+}
+
+// GroupCommitStorage wraps a storage app and commits concurrent writes together: writes
+// that arrive within window of the first one of a group, or while the previous group is
+// being committed, are applied to the storage app as a single batch of at most size
+// writes. The group costs one device access or one log fsync instead of one per write, and
+// each writer returns only once the whole group has been applied. Writes to the same key
+// are never grouped, so every write sees the one before it, as if applied alone.
+type GroupCommitStorage struct {
+	Storage
+	window time.Duration
+	size   int
+
+	requests chan *commitRequest
+	stop     chan struct{}
+	wg       sync.WaitGroup
+
+	groups  uint64 // atomic
+	writes  uint64 // atomic
+	retries uint64 // atomic
+}
+
+// NewGroupCommitStorage starts committing the writes applied through it in groups. A zero
+// window groups only the writes that queue up while a group is being committed, so a lone
+// writer never waits.
+func NewGroupCommitStorage(storage Storage, window time.Duration, size int) *GroupCommitStorage {
+	s := &GroupCommitStorage{
+		Storage:  storage,
+		window:   window,
+		size:     size,
+		requests: make(chan *commitRequest),
+		stop:     make(chan struct{}),
+	}
+	s.wg.Add(1)
+	go s.run()
+	return s
+}
+
+func (s *GroupCommitStorage) Set(record *mydatabase.DatabaseRecord) error {
+	return s.Apply([]Mutation{{Key: record.Key, Value: record.Value}})
+}
+
+func (s *GroupCommitStorage) Delete(key string) error {
+	return s.Apply([]Mutation{{Key: key, Delete: true}})
+}
+
+// Apply waits for the mutations to be committed with the group they join. They are still
+// applied all-or-nothing.
+func (s *GroupCommitStorage) Apply(mutations []Mutation) error {
+	if len(mutations) == 0 {
+		return nil
+	}
+	r := &commitRequest{mutations: mutations, done: make(chan error, 1)}
+	select {
+	case s.requests <- r:
+	case <-s.stop:
+		return ErrStorageClosed
+	}
+	return <-r.done
+}
+
+// run collects the writes into groups and commits them, one group at a time.
+func (s *GroupCommitStorage) run() {
+	defer s.wg.Done()
+
+	var next *commitRequest // a write held back from the previous group
+	for {
+		if next == nil {
+			select {
+			case next = <-s.requests:
+			case <-s.stop:
+				return
+			}
+		}
+		group := []*commitRequest{next}
+		keys := make(map[string]struct{})
+		for _, m := range next.mutations {
+			keys[m.Key] = struct{}{}
+		}
+		next = nil
+
+		var timer *time.Timer
+		if s.window > 0 {
+			timer = time.NewTimer(s.window)
+		}
+	collect:
+		for len(group) < s.size {
+			var r *commitRequest
+			if timer == nil {
+				select {
+				case r = <-s.requests:
+				default:
+					break collect
+				}
+			} else {
+				select {
+				case r = <-s.requests:
+				case <-timer.C:
+					break collect
+				}
+			}
+			if overlaps(keys, r.mutations) {
+				// a second write to a key starts the next group
+				next = r
+				break collect
+			}
+			for _, m := range r.mutations {
+				keys[m.Key] = struct{}{}
+			}
+			group = append(group, r)
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		s.commit(group)
+	}
+}
+
+// overlaps reports whether any of the mutations writes one of keys.
+func overlaps(keys map[string]struct{}, mutations []Mutation) bool {
+	for _, m := range mutations {
+		if _, ok := keys[m.Key]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// commit applies the writes of a group as one batch and acknowledges each of them.
+func (s *GroupCommitStorage) commit(group []*commitRequest) {
+	atomic.AddUint64(&s.groups, 1)
+	atomic.AddUint64(&s.writes, uint64(len(group)))
+	if len(group) == 1 {
+		group[0].done <- s.Storage.Apply(group[0].mutations)
+		return
+	}
+
+	var mutations []Mutation
+	for _, r := range group {
+		mutations = append(mutations, r.mutations...)
+	}
+	err := s.Storage.Apply(mutations)
+	if errors.Is(err, ErrQuotaExceeded) {
+		// one write can take its namespace over quota and fail the whole group, so apply
+		// them one at a time for only the writes that exceed it to fail
+		atomic.AddUint64(&s.retries, 1)
+		for _, r := range group {
+			r.done <- s.Storage.Apply(r.mutations)
+		}
+		return
+	}
+	for _, r := range group {
+		r.done <- err
+	}
+}
+
+// Close stops committing writes and closes the storage app. Writes applied after Close
+// fail with ErrStorageClosed.
+func (s *GroupCommitStorage) Close() error {
+	close(s.stop)
+	s.wg.Wait()
+	return s.Storage.Close()
+}
+
+// Stats adds the number of groups committed and the writes they held to the storage app's
+// statistics.
+func (s *GroupCommitStorage) Stats() *mydatabase.StorageStats {
+	stats := s.Storage.Stats()
+	if stats.Metrics == nil {
+		stats.Metrics = make(map[string]float64)
+	}
+
+	groups := atomic.LoadUint64(&s.groups)
+	writes := atomic.LoadUint64(&s.writes)
+	stats.Metrics["group_commits"] = float64(groups)
+	stats.Metrics["group_commit_writes"] = float64(writes)
+	stats.Metrics["group_commit_retries"] = float64(atomic.LoadUint64(&s.retries))
+	if groups > 0 {
+		stats.Metrics["group_commit_mean_size"] = float64(writes) / float64(groups)
+	}
+	return stats
+}
@@ -0,0 +1,197 @@
+package applications
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// batchRecorder is a storage app that records the batches applied to it, failing them with
+// the error fail returns.
+type batchRecorder struct {
+	Storage
+
+	mu      sync.Mutex
+	batches [][]Mutation
+	errs    []error
+	fail    func(batch int, mutations []Mutation) error
+}
+
+func (r *batchRecorder) Apply(mutations []Mutation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var err error
+	if r.fail != nil {
+		err = r.fail(len(r.batches), mutations)
+	}
+	r.batches = append(r.batches, mutations)
+	r.errs = append(r.errs, err)
+	return err
+}
+
+func (r *batchRecorder) Close() error {
+	return nil
+}
+
+// batchOf returns the index of the batch that wrote key, and the error it failed with.
+func (r *batchRecorder) batchOf(key string) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, batch := range r.batches {
+		for _, m := range batch {
+			if m.Key == key {
+				return i, r.errs[i]
+			}
+		}
+	}
+	return -1, nil
+}
+
+// applyConcurrently applies a write of each key from its own goroutine, returning the
+// error each write got back.
+func applyConcurrently(s *GroupCommitStorage, keys []string) map[string]error {
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	errs := make(map[string]error)
+	for _, key := range keys {
+		key := key
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := s.Apply([]Mutation{{Key: key, Value: []byte(key)}})
+			mu.Lock()
+			errs[key] = err
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	return errs
+}
+
+func writeKeys(n int) []string {
+	keys := make([]string, n)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("key-%d", i)
+	}
+	return keys
+}
+
+func TestGroupCommitBatchesConcurrentWriters(t *testing.T) {
+	storage := &batchRecorder{}
+	// a long window closes each group only once it holds size writes
+	s := NewGroupCommitStorage(storage, time.Minute, 4)
+	defer s.Close()
+
+	keys := writeKeys(8)
+	for key, err := range applyConcurrently(s, keys) {
+		if err != nil {
+			t.Fatalf("Apply(%s) = %v", key, err)
+		}
+	}
+	if len(storage.batches) != 2 {
+		t.Fatalf("8 concurrent writes were applied in %d batches, want 2 of 4", len(storage.batches))
+	}
+	for i, batch := range storage.batches {
+		if len(batch) != 4 {
+			t.Fatalf("batch %d holds %d writes, want 4", i, len(batch))
+		}
+	}
+	for _, key := range keys {
+		if batch, _ := storage.batchOf(key); batch < 0 {
+			t.Fatalf("write of %s was not applied", key)
+		}
+	}
+}
+
+func TestGroupCommitDoesNotGroupWritesToOneKey(t *testing.T) {
+	storage := &batchRecorder{}
+	s := NewGroupCommitStorage(storage, 50*time.Millisecond, 3)
+	defer s.Close()
+
+	// whichever write of "a" comes second ends its group and starts the next
+	errs := make(chan error, 3)
+	for _, key := range []string{"a", "a", "b"} {
+		key := key
+		go func() { errs <- s.Apply([]Mutation{{Key: key}}) }()
+	}
+	for i := 0; i < 3; i++ {
+		if err := <-errs; err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i, batch := range storage.batches {
+		seen := make(map[string]bool)
+		for _, m := range batch {
+			if seen[m.Key] {
+				t.Fatalf("batch %d writes %s twice", i, m.Key)
+			}
+			seen[m.Key] = true
+		}
+	}
+}
+
+func TestGroupCommitReturnsEachWriterItsBatchError(t *testing.T) {
+	storage := &batchRecorder{fail: func(batch int, mutations []Mutation) error {
+		// fail every other batch, with an error naming it
+		if batch%2 == 0 {
+			return fmt.Errorf("batch %d failed", batch)
+		}
+		return nil
+	}}
+	s := NewGroupCommitStorage(storage, time.Minute, 4)
+	defer s.Close()
+
+	keys := writeKeys(16)
+	for key, err := range applyConcurrently(s, keys) {
+		batch, want := storage.batchOf(key)
+		if batch < 0 {
+			t.Fatalf("write of %s was not applied", key)
+		}
+		if err != want {
+			t.Fatalf("Apply(%s) in batch %d = %v, want %v", key, batch, err, want)
+		}
+	}
+	if len(storage.batches) != 4 {
+		t.Fatalf("16 writes were applied in %d batches, want 4", len(storage.batches))
+	}
+}
+
+func TestGroupCommitRetriesQuotaErrorsAlone(t *testing.T) {
+	storage := &batchRecorder{fail: func(batch int, mutations []Mutation) error {
+		for _, m := range mutations {
+			if m.Key == "key-0" {
+				return ErrQuotaExceeded
+			}
+		}
+		return nil
+	}}
+	s := NewGroupCommitStorage(storage, time.Minute, 4)
+	defer s.Close()
+
+	// only the write over quota fails, not the others grouped with it
+	for key, err := range applyConcurrently(s, writeKeys(4)) {
+		if key == "key-0" && !errors.Is(err, ErrQuotaExceeded) {
+			t.Fatalf("Apply(%s) = %v, want %v", key, err, ErrQuotaExceeded)
+		}
+		if key != "key-0" && err != nil {
+			t.Fatalf("Apply(%s) = %v, want it applied alone", key, err)
+		}
+	}
+	if len(storage.batches) != 5 {
+		t.Fatalf("group was applied in %d batches, want 1 and then 4 alone", len(storage.batches))
+	}
+}
+
+func TestGroupCommitClosed(t *testing.T) {
+	s := NewGroupCommitStorage(&batchRecorder{}, 0, 4)
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Apply([]Mutation{{Key: "a"}}); !errors.Is(err, ErrStorageClosed) {
+		t.Fatalf("Apply after Close = %v, want %v", err, ErrStorageClosed)
+	}
+}
@@ -0,0 +1,285 @@
+package applications
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"gitlab.cs.washington.edu/syslab/cse453-welp/proto/mydatabase"
+)
+
+var (
+	ErrUnknownIndex   = errors.New("index: no such index")
+	ErrEmptyQuery     = errors.New("index: query has no conditions")
+	ErrDuplicateIndex = errors.New("index: duplicate index name")
+)
+
+// Index is a secondary index over the records of a namespace of a storage app.
+type Index struct {
+	// Namespace holds the records indexed.
+	Namespace string
+
+	// Name identifies the index in queries of its namespace.
+	Name string
+
+	// Extract returns the values a record is indexed under, or none if it is not indexed.
+	// Values containing NUL bytes are ignored.
+	Extract func(key string, value []byte) []string
+}
+
+// id identifies the index among those of every namespace.
+func (x Index) id() string {
+	return NamespaceKey(x.Namespace, x.Name)
+}
+
+// IndexCondition matches records by the values an index holds for them.
+type IndexCondition struct {
+	Index string
+
+	// Value matches records indexed under exactly this value, unless Range is set.
+	Value string
+
+	// Range matches records indexed under a value in [Start, End) with the given Prefix
+	// instead. Empty bounds are open.
+	Range  bool
+	Start  string
+	End    string
+	Prefix string
+}
+
+// matches reports whether the condition matches a record indexed under value.
+func (c IndexCondition) matches(value string) bool {
+	if !c.Range {
+		return value == c.Value
+	}
+	return value >= c.Start && (c.End == "" || value < c.End) && strings.HasPrefix(value, c.Prefix)
+}
+
+// entries returns the range of index entries holding the values the condition matches.
+func (c IndexCondition) entries() ScanOptions {
+	if !c.Range {
+		return ScanOptions{Prefix: c.Value + "\x00"}
+	}
+	return ScanOptions{Start: c.Start, End: c.End, Prefix: c.Prefix}
+}
+
+// EncodeIndexInt encodes n as an index value that sorts in numeric order, so that numeric
+// ranges can be queried as IndexCondition ranges.
+func EncodeIndexInt(n int64) string {
+	return fmt.Sprintf("%016x", uint64(n)^(1<<63))
+}
+
+// secondaryIndex holds an entry, the value and key joined by a NUL byte, for every value
+// of every indexed record, so that entries sort by value and then by key. Keys are those
+// of the records within the index's namespace.
+type secondaryIndex struct {
+	Index
+	entries *keyIndex
+	values  map[string][]string // the sorted values each indexed record is indexed under
+}
+
+// add indexes the record stored under stored, if it belongs to the index's namespace.
+func (x *secondaryIndex) add(stored string, value []byte) {
+	namespace, key := SplitNamespaceKey(stored)
+	if namespace != x.Namespace {
+		return
+	}
+	var values []string
+	for _, v := range x.Extract(key, value) {
+		if !strings.ContainsRune(v, 0) {
+			values = append(values, v)
+		}
+	}
+	if len(values) == 0 {
+		return
+	}
+	sort.Strings(values)
+	n := 1
+	for _, v := range values[1:] {
+		if v != values[n-1] {
+			values[n] = v
+			n++
+		}
+	}
+	values = values[:n]
+	for _, v := range values {
+		x.entries.Insert(v + "\x00" + key)
+	}
+	x.values[key] = values
+}
+
+// remove drops the record stored under stored from the index.
+func (x *secondaryIndex) remove(stored string) {
+	namespace, key := SplitNamespaceKey(stored)
+	if namespace != x.Namespace {
+		return
+	}
+	for _, v := range x.values[key] {
+		x.entries.Remove(v + "\x00" + key)
+	}
+	delete(x.values, key)
+}
+
+// IndexedStorage wraps a storage app and maintains secondary indexes over its records as
+// they are written. The indexes are held in memory and rebuilt from the records when the
+// storage app is opened.
+type IndexedStorage struct {
+	Storage
+
+	writeMu sync.Mutex // serializes writes, so the indexes are updated in the order they apply
+
+	mu      sync.RWMutex
+	indexes map[string]*secondaryIndex // by Index.id
+}
+
+// NewIndexedStorage scans storage to build the given indexes over its records.
+func NewIndexedStorage(storage Storage, indexes ...Index) (*IndexedStorage, error) {
+	s := &IndexedStorage{
+		Storage: storage,
+		indexes: make(map[string]*secondaryIndex),
+	}
+	for _, index := range indexes {
+		if _, ok := s.indexes[index.id()]; ok {
+			return nil, fmt.Errorf("%w: %s in namespace %q", ErrDuplicateIndex, index.Name, index.Namespace)
+		}
+		s.indexes[index.id()] = &secondaryIndex{
+			Index:   index,
+			entries: newKeyIndex(),
+			values:  make(map[string][]string),
+		}
+	}
+	if len(indexes) == 0 {
+		return s, nil
+	}
+	err := storage.Scan(ScanOptions{}, func(record *mydatabase.DatabaseRecord) bool {
+		for _, x := range s.indexes {
+			x.add(record.Key, record.Value)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *IndexedStorage) Set(record *mydatabase.DatabaseRecord) error {
+	return s.Apply([]Mutation{{Key: record.Key, Value: record.Value}})
+}
+
+func (s *IndexedStorage) Delete(key string) error {
+	return s.Apply([]Mutation{{Key: key, Delete: true}})
+}
+
+// Apply applies the mutations to the storage app and then updates the indexes, which may
+// briefly lag behind the records.
+func (s *IndexedStorage) Apply(mutations []Mutation) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	if err := s.Storage.Apply(mutations); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, x := range s.indexes {
+		for _, m := range mutations {
+			x.remove(m.Key)
+			if !m.Delete {
+				x.add(m.Key, m.Value)
+			}
+		}
+	}
+	return nil
+}
+
+// Query returns the keys of up to limit records of namespace matching every condition on
+// its indexes, ordered by the value the first condition's index holds for them and then by
+// key. A record indexed under several values matching the first condition is returned once,
+// at the first of them. Entries are visited after the one named by after, if set. The entry
+// each key was found at is returned alongside it, to resume the query from, and more reports
+// whether further records match.
+func (s *IndexedStorage) Query(namespace string, conditions []IndexCondition, after string, limit int) (keys, entries []string, more bool, err error) {
+	if len(conditions) == 0 {
+		return nil, nil, false, ErrEmptyQuery
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	indexes := make([]*secondaryIndex, len(conditions))
+	for i, c := range conditions {
+		x, ok := s.indexes[NamespaceKey(namespace, c.Index)]
+		if !ok {
+			return nil, nil, false, fmt.Errorf("%w: %s in namespace %q", ErrUnknownIndex, c.Index, namespace)
+		}
+		indexes[i] = x
+	}
+
+	options := conditions[0].entries()
+	if after != "" && after+"\x00" > options.Start {
+		options.Start = after + "\x00"
+	}
+	scanIndex(indexes[0].entries, options, func(entry string) bool {
+		i := strings.IndexByte(entry, 0)
+		value, key := entry[:i], entry[i+1:]
+		if !s.matches(indexes, conditions, key, value) {
+			return true
+		}
+		if len(keys) == limit {
+			more = true
+			return false
+		}
+		keys = append(keys, key)
+		entries = append(entries, entry)
+		return true
+	})
+	return keys, entries, more, nil
+}
+
+// matches reports whether the record reached through the entry for value in the first
+// condition's index matches every condition, and value is the first of its values to
+// match the first one. The caller must hold mu.
+func (s *IndexedStorage) matches(indexes []*secondaryIndex, conditions []IndexCondition, key, value string) bool {
+	for _, v := range indexes[0].values[key] {
+		if v == value {
+			break
+		}
+		if conditions[0].matches(v) {
+			return false
+		}
+	}
+	for i := 1; i < len(conditions); i++ {
+		found := false
+		for _, v := range indexes[i].values[key] {
+			if conditions[i].matches(v) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// Stats reports the storage app's statistics along with the size of each index.
+func (s *IndexedStorage) Stats() *mydatabase.StorageStats {
+	stats := s.Storage.Stats()
+	if stats.Metrics == nil {
+		stats.Metrics = make(map[string]float64)
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, x := range s.indexes {
+		name := x.Name
+		if x.Namespace != "" {
+			name = x.Namespace + "." + x.Name
+		}
+		stats.Metrics["index_"+name+"_entries"] = float64(x.entries.Len())
+	}
+	return stats
+}
@@ -0,0 +1,177 @@
+package applications
+
+import (
+	"container/heap"
+)
+
+// kvIterator walks key-value entries in key order (ascending unless the iterator was
+// created for a reverse scan). A key may have several entries, one per version, which are
+// always adjacent; forward iterators return them newest first. Deleted keys are surfaced as
+// tombstones so that newer versions can shadow older ones.
+type kvIterator interface {
+	// Next advances to the next entry and reports whether one exists.
+	Next() bool
+
+	// Key returns the key of the current entry.
+	Key() string
+
+	// Timestamp returns the commit timestamp of the current entry.
+	Timestamp() int64
+
+	// Value returns the value of the current entry (nil for tombstones).
+	Value() []byte
+
+	// Tombstone reports whether the current entry marks a deleted key.
+	Tombstone() bool
+
+	// Err returns the first error encountered while iterating.
+	Err() error
+}
+
+// kvEntry is a single version of a key-value pair, or a tombstone.
+type kvEntry struct {
+	key       string
+	ts        int64
+	value     []byte
+	tombstone bool
+}
+
+// mergeItem is a source iterator positioned at its current entry inside the merge heap.
+type mergeItem struct {
+	it       kvIterator
+	priority int // index of the source; lower is newer
+}
+
+type mergeHeap struct {
+	items   []*mergeItem
+	reverse bool
+}
+
+func (h *mergeHeap) Len() int { return len(h.items) }
+
+// Order by key (descending for reverse scans), and for equal keys put the newest version,
+// then the newest source, first.
+func (h *mergeHeap) Less(i, j int) bool {
+	ki, kj := h.items[i].it.Key(), h.items[j].it.Key()
+	if ki != kj {
+		return (ki < kj) != h.reverse
+	}
+	ti, tj := h.items[i].it.Timestamp(), h.items[j].it.Timestamp()
+	if ti != tj {
+		return ti > tj
+	}
+	return h.items[i].priority < h.items[j].priority
+}
+
+func (h *mergeHeap) Swap(i, j int) { h.items[i], h.items[j] = h.items[j], h.items[i] }
+
+func (h *mergeHeap) Push(x interface{}) {
+	h.items = append(h.items, x.(*mergeItem))
+}
+
+func (h *mergeHeap) Pop() interface{} {
+	old := h.items
+	n := len(old)
+	item := old[n-1]
+	old[n-1] = nil
+	h.items = old[0 : n-1]
+	return item
+}
+
+// mergingIterator merges several sorted sources into one sorted stream. Sources are
+// given newest first and must all iterate in the same direction as the merge. Every version
+// of every key is returned; callers pick the versions they need with visibleEntries or
+// versionFilter.
+type mergingIterator struct {
+	heap    mergeHeap
+	current kvEntry
+	err     error
+	started bool
+	sources []kvIterator
+}
+
+func newMergingIterator(reverse bool, sources ...kvIterator) *mergingIterator {
+	return &mergingIterator{
+		heap:    mergeHeap{reverse: reverse},
+		sources: sources,
+	}
+}
+
+func (m *mergingIterator) init() {
+	m.started = true
+	for i, it := range m.sources {
+		if it.Next() {
+			m.heap.items = append(m.heap.items, &mergeItem{it: it, priority: i})
+		} else if err := it.Err(); err != nil {
+			m.err = err
+		}
+	}
+	heap.Init(&m.heap)
+}
+
+// advance moves item to its next entry, removing it from the heap once exhausted.
+func (m *mergingIterator) advance(item *mergeItem) {
+	if item.it.Next() {
+		heap.Fix(&m.heap, 0)
+		return
+	}
+	if err := item.it.Err(); err != nil && m.err == nil {
+		m.err = err
+	}
+	heap.Pop(&m.heap)
+}
+
+func (m *mergingIterator) Next() bool {
+	if !m.started {
+		m.init()
+	}
+	if m.err != nil || m.heap.Len() == 0 {
+		return false
+	}
+
+	top := m.heap.items[0]
+	m.current = kvEntry{
+		key:       top.it.Key(),
+		ts:        top.it.Timestamp(),
+		value:     top.it.Value(),
+		tombstone: top.it.Tombstone(),
+	}
+	m.advance(top)
+	return m.err == nil
+}
+
+func (m *mergingIterator) Key() string      { return m.current.key }
+func (m *mergingIterator) Timestamp() int64 { return m.current.ts }
+func (m *mergingIterator) Value() []byte    { return m.current.value }
+func (m *mergingIterator) Tombstone() bool  { return m.current.tombstone }
+func (m *mergingIterator) Err() error       { return m.err }
+
+// visibleEntries calls fn with the version of each key that was current at ts, skipping
+// keys that were deleted (or not yet written) at that time, until fn returns false.
+func visibleEntries(it kvIterator, ts int64, fn func(e kvEntry) bool) error {
+	var best kvEntry
+	found := false
+	emit := func() bool {
+		return !found || best.tombstone || fn(best)
+	}
+
+	for it.Next() {
+		key := it.Key()
+		if found && key != best.key {
+			if !emit() {
+				return nil
+			}
+			found = false
+		}
+		// versions of a key are adjacent but not necessarily ordered, so check them all
+		if t := it.Timestamp(); t <= ts && (!found || t > best.ts) {
+			best = kvEntry{key: key, ts: t, value: it.Value(), tombstone: it.Tombstone()}
+			found = true
+		}
+	}
+	if err := it.Err(); err != nil {
+		return err
+	}
+	emit()
+	return nil
+}
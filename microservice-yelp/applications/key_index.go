@@ -0,0 +1,175 @@
+package applications
+
+import (
+	"math/rand"
+)
+
+const (
+	keyIndexMaxLevel = 24
+	keyIndexP        = 4 // each level holds roughly 1/keyIndexP of the keys below it
+)
+
+// keyNode is an entry in a keyIndex. Level 0 is doubly linked so the index can be walked
+// in either direction.
+type keyNode struct {
+	key  string
+	next []*keyNode
+	prev *keyNode
+}
+
+// Next returns the following key in ascending order, or nil at the end.
+func (n *keyNode) Next() *keyNode {
+	return n.next[0]
+}
+
+// Prev returns the preceding key in ascending order, or nil at the start.
+func (n *keyNode) Prev() *keyNode {
+	return n.prev
+}
+
+// keyIndex is an ordered set of keys backed by a skip list. It gives the map-based storage
+// apps sorted iteration without giving up their O(1) point lookups. It is not safe for
+// concurrent use; callers guard it with the same lock as the map it indexes.
+type keyIndex struct {
+	head   *keyNode
+	tail   *keyNode
+	level  int
+	length int
+	rand   *rand.Rand
+}
+
+func newKeyIndex() *keyIndex {
+	return &keyIndex{
+		head:  &keyNode{next: make([]*keyNode, keyIndexMaxLevel)},
+		level: 1,
+		rand:  rand.New(rand.NewSource(rand.Int63())),
+	}
+}
+
+func (x *keyIndex) randomLevel() int {
+	level := 1
+	for level < keyIndexMaxLevel && x.rand.Intn(keyIndexP) == 0 {
+		level++
+	}
+	return level
+}
+
+// findPredecessors fills update with the last node before key on every level.
+func (x *keyIndex) findPredecessors(key string, update []*keyNode) *keyNode {
+	n := x.head
+	for level := x.level - 1; level >= 0; level-- {
+		for n.next[level] != nil && n.next[level].key < key {
+			n = n.next[level]
+		}
+		if update != nil {
+			update[level] = n
+		}
+	}
+	return n
+}
+
+// Len returns the number of keys in the index.
+func (x *keyIndex) Len() int {
+	return x.length
+}
+
+// Insert adds key to the index. It is a no-op if the key is already present.
+func (x *keyIndex) Insert(key string) {
+	update := make([]*keyNode, keyIndexMaxLevel)
+	pred := x.findPredecessors(key, update)
+	if next := pred.next[0]; next != nil && next.key == key {
+		return
+	}
+
+	level := x.randomLevel()
+	if level > x.level {
+		for l := x.level; l < level; l++ {
+			update[l] = x.head
+		}
+		x.level = level
+	}
+
+	n := &keyNode{key: key, next: make([]*keyNode, level)}
+	for l := 0; l < level; l++ {
+		n.next[l] = update[l].next[l]
+		update[l].next[l] = n
+	}
+	if update[0] != x.head {
+		n.prev = update[0]
+	}
+	if n.next[0] != nil {
+		n.next[0].prev = n
+	} else {
+		x.tail = n
+	}
+	x.length++
+}
+
+// Remove deletes key from the index. It is a no-op if the key is not present.
+func (x *keyIndex) Remove(key string) {
+	update := make([]*keyNode, keyIndexMaxLevel)
+	pred := x.findPredecessors(key, update)
+	n := pred.next[0]
+	if n == nil || n.key != key {
+		return
+	}
+
+	for l := 0; l < len(n.next); l++ {
+		if update[l].next[l] == n {
+			update[l].next[l] = n.next[l]
+		}
+	}
+	if n.next[0] != nil {
+		n.next[0].prev = n.prev
+	} else {
+		x.tail = n.prev
+	}
+	for x.level > 1 && x.head.next[x.level-1] == nil {
+		x.level--
+	}
+	x.length--
+}
+
+// Seek returns the first node whose key is >= key, or nil if there is none.
+func (x *keyIndex) Seek(key string) *keyNode {
+	return x.findPredecessors(key, nil).next[0]
+}
+
+// SeekBefore returns the last node whose key is < key, or nil if there is none. An empty
+// key means "no upper bound" and returns the last node.
+func (x *keyIndex) SeekBefore(key string) *keyNode {
+	if key == "" {
+		return x.tail
+	}
+	n := x.findPredecessors(key, nil)
+	if n == x.head {
+		return nil
+	}
+	return n
+}
+
+// Ascend calls fn for every key in [start, end) in ascending order until fn returns false.
+// An empty end means there is no upper bound.
+func (x *keyIndex) Ascend(start, end string, fn func(key string) bool) {
+	for n := x.Seek(start); n != nil; n = n.Next() {
+		if end != "" && n.key >= end {
+			return
+		}
+		if !fn(n.key) {
+			return
+		}
+	}
+}
+
+// Descend calls fn for every key in [start, end) in descending order until fn returns false.
+// An empty end means there is no upper bound.
+func (x *keyIndex) Descend(start, end string, fn func(key string) bool) {
+	for n := x.SeekBefore(end); n != nil; n = n.Prev() {
+		if n.key < start {
+			return
+		}
+		if !fn(n.key) {
+			return
+		}
+	}
+}
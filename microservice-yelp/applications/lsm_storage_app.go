@@ -0,0 +1,1136 @@
+package applications
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gitlab.cs.washington.edu/syslab/cse453-welp/proto/mydatabase"
+)
+
+const (
+	lsmMemtableSize        = 4 << 20  // memtable size that triggers a flush to level 0
+	lsmL0CompactionTrigger = 4        // number of level 0 tables that triggers a compaction
+	lsmL0StopTrigger       = 12       // number of level 0 tables at which writes stall
+	lsmMaxLevels           = 7        // number of levels, including level 0
+	lsmLevelBaseBytes      = 10 << 20 // maximum size of level 1
+	lsmLevelMultiplier     = 10       // size ratio between adjacent levels
+	lsmTargetTableSize     = 2 << 20  // size at which compaction output is split into a new table
+	lsmMemtableOverhead    = 32       // approximate per-entry bookkeeping cost of the memtable
+	lsmManifestFileName    = "MANIFEST.json"
+	lsmTableSuffix         = ".sst"
+)
+
+// tableMeta describes an SSTable file recorded in the manifest.
+type tableMeta struct {
+	ID       uint64 `json:"id"`
+	Level    int    `json:"level"`
+	Smallest string `json:"smallest"`
+	Largest  string `json:"largest"`
+	Size     uint64 `json:"size"`
+	Entries  uint64 `json:"entries"`
+
+	// Newest is the largest commit timestamp in the table.
+	Newest int64 `json:"newest,omitempty"`
+	// Garbage counts the overwritten versions and tombstones in the table, which garbage
+	// collection can drop once the horizon reaches CollectibleAt.
+	Garbage       uint64 `json:"garbage,omitempty"`
+	CollectibleAt int64  `json:"collectible_at,omitempty"`
+}
+
+// lsmManifest is the persisted layout of an LSMStorageApp. LogSegment is the first
+// write-ahead log segment whose entries have not yet been flushed into a table.
+type lsmManifest struct {
+	NextTableID uint64      `json:"next_table_id"`
+	LogSegment  uint64      `json:"log_segment"`
+	Tables      []tableMeta `json:"tables"`
+}
+
+func lsmTableName(id uint64) string {
+	return fmt.Sprintf("%06d%s", id, lsmTableSuffix)
+}
+
+// maxBytesForLevel returns the size above which a level (>= 1) is compacted into the next.
+func maxBytesForLevel(level int) uint64 {
+	size := uint64(lsmLevelBaseBytes)
+	for l := 1; l < level; l++ {
+		size *= lsmLevelMultiplier
+	}
+	return size
+}
+
+// memtable buffers recent writes in memory until they are flushed to a level 0 table.
+// Every version of a key is kept, oldest first.
+type memtable struct {
+	entries map[string][]kvEntry
+	keys    *keyIndex
+	size    int
+}
+
+func newMemtable() *memtable {
+	return &memtable{
+		entries: make(map[string][]kvEntry),
+		keys:    newKeyIndex(),
+	}
+}
+
+// put adds a version of e.key. A version with the same timestamp as the newest one,
+// written by the same batch, replaces it.
+func (m *memtable) put(e kvEntry) {
+	versions := m.entries[e.key]
+	if len(versions) == 0 {
+		m.keys.Insert(e.key)
+	}
+	if n := len(versions); n > 0 && versions[n-1].ts >= e.ts {
+		old := versions[n-1]
+		m.size -= len(old.key) + len(old.value) + lsmMemtableOverhead
+		versions = versions[:n-1]
+	}
+	m.entries[e.key] = append(versions, e)
+	m.size += len(e.key) + len(e.value) + lsmMemtableOverhead
+}
+
+// get returns the newest version of key with a timestamp at or before ts.
+func (m *memtable) get(key string, ts int64) (kvEntry, bool) {
+	versions := m.entries[key]
+	for i := len(versions) - 1; i >= 0; i-- {
+		if versions[i].ts <= ts {
+			return versions[i], true
+		}
+	}
+	return kvEntry{}, false
+}
+
+// versions calls fn for every version of key, newest first, until fn returns false.
+func (m *memtable) versions(key string, fn func(e kvEntry) bool) {
+	versions := m.entries[key]
+	for i := len(versions) - 1; i >= 0; i-- {
+		if !fn(versions[i]) {
+			return
+		}
+	}
+}
+
+// iterator returns an iterator over the memtable's entries in [start, end), returning the
+// versions of each key newest first. The memtable must not be modified while the iterator
+// is in use.
+func (m *memtable) iterator(start, end string, reverse bool) kvIterator {
+	return &memtableIterator{m: m, start: start, end: end, reverse: reverse}
+}
+
+// memtableIterator walks a memtable's key index.
+type memtableIterator struct {
+	m          *memtable
+	node       *keyNode
+	versions   []kvEntry // versions of node's key
+	version    int       // index into versions
+	start, end string
+	reverse    bool
+	started    bool
+}
+
+func (it *memtableIterator) Next() bool {
+	if it.node != nil && it.version > 0 {
+		it.version--
+		return true
+	}
+
+	switch {
+	case !it.started && it.reverse:
+		it.node = it.m.keys.SeekBefore(it.end)
+	case !it.started:
+		it.node = it.m.keys.Seek(it.start)
+	case it.node == nil:
+		return false
+	case it.reverse:
+		it.node = it.node.Prev()
+	default:
+		it.node = it.node.Next()
+	}
+	it.started = true
+
+	if it.node == nil {
+		return false
+	}
+	if it.reverse && it.node.key < it.start {
+		it.node = nil
+	} else if !it.reverse && it.end != "" && it.node.key >= it.end {
+		it.node = nil
+	}
+	if it.node == nil {
+		return false
+	}
+	it.versions = it.m.entries[it.node.key]
+	it.version = len(it.versions) - 1
+	return true
+}
+
+func (it *memtableIterator) Key() string      { return it.node.key }
+func (it *memtableIterator) Timestamp() int64 { return it.versions[it.version].ts }
+func (it *memtableIterator) Value() []byte    { return it.versions[it.version].value }
+func (it *memtableIterator) Tombstone() bool  { return it.versions[it.version].tombstone }
+func (it *memtableIterator) Err() error       { return nil }
+
+// compaction describes merging tables from one level into the next.
+type compaction struct {
+	level          int        // input level
+	output         int        // output level: level+1, or level itself when rewriting the last level
+	inputs         []*sstable // tables taken from level, newest first
+	overlaps       []*sstable // tables in the output level overlapping the inputs
+	dropTombstones bool       // true if no deeper level can hold an older version of any key
+	gc             bool       // picked to collect old versions rather than because a level is full
+}
+
+// LSMStorageApp is a log-structured merge-tree storage engine. Writes go to a write-ahead
+// log and an in-memory memtable, which is flushed to an immutable sorted table (SSTable) on
+// level 0 once full. A background goroutine merges tables into progressively larger levels
+// (leveled compaction), discarding versions older than the retention window and tombstones
+// along the way. Tables holding collectible versions are also compacted periodically, so
+// old versions are dropped even when no level is over its size limit.
+type LSMStorageApp struct {
+	mu     sync.RWMutex
+	bgDone *sync.Cond // broadcast (with mu held) after every flush or compaction
+
+	dir            string
+	retention      time.Duration
+	pins           snapshotPins
+	clock          commitClock
+	wal            *writeAheadLog
+	mem            *memtable
+	imm            *memtable // memtable being flushed, if any
+	immSegment     uint64    // first log segment holding writes newer than imm
+	levels         [lsmMaxLevels][]*sstable
+	nextTableID    uint64
+	logSegment     uint64
+	compactPointer [lsmMaxLevels]string // largest key of the last compaction per level
+	bgErr          error                // sticky background error; fails subsequent writes
+
+	work chan struct{}
+	done chan struct{}
+	wg   sync.WaitGroup
+
+	// statistics, guarded by mu unless noted
+	liveKeys            int64 // keys whose newest version is not a deletion
+	liveBytes           int64 // bytes of the keys and values of their newest versions
+	userBytes           uint64
+	flushBytes          uint64
+	compactionBytes     uint64
+	flushes             uint64
+	compactions         uint64
+	gcCompactions       uint64
+	trivialMoves        uint64
+	versionsDropped     uint64
+	tableLookups        uint64 // atomic
+	bloomNegatives      uint64 // atomic
+	bloomFalsePositives uint64 // atomic
+}
+
+// NewLSMStorageApp opens (or creates) an LSM store in options.DataDir, replaying any
+// write-ahead log entries that had not been flushed before the last shutdown.
+func NewLSMStorageApp(options StorageOptions) (*LSMStorageApp, error) {
+	log.Printf("data dir: %v, fsync policy: %v", options.DataDir, options.SyncPolicy)
+	if err := os.MkdirAll(options.DataDir, 0755); err != nil {
+		return nil, err
+	}
+
+	db := &LSMStorageApp{
+		dir:         options.DataDir,
+		retention:   options.VersionRetention,
+		mem:         newMemtable(),
+		nextTableID: 1,
+		work:        make(chan struct{}, 1),
+		done:        make(chan struct{}),
+	}
+	db.bgDone = sync.NewCond(&db.mu)
+
+	if err := db.loadManifest(); err != nil {
+		db.closeTables()
+		return nil, err
+	}
+
+	var err error
+	db.wal, err = openWriteAheadLog(options.DataDir, options.SyncPolicy, db.logSegment, func(e walEntry) {
+		db.clock.observe(e.ts)
+		db.mem.put(kvEntry{key: e.key, ts: e.ts, value: e.value, tombstone: e.op == walOpDelete})
+	})
+	if err != nil {
+		db.closeTables()
+		return nil, err
+	}
+	// writes keep the live keys counted from here on; flushes and compactions only drop
+	// versions that are not the newest, so they never change the count
+	db.Scan(ScanOptions{}, func(record *mydatabase.DatabaseRecord) bool {
+		db.liveKeys++
+		db.liveBytes += int64(len(record.Key) + len(record.Value))
+		return true
+	})
+
+	db.wg.Add(1)
+	go db.backgroundLoop()
+	if options.SyncPolicy == SyncInterval && options.SyncInterval > 0 {
+		runPeriodically(db.done, &db.wg, options.SyncInterval, db.wal.sync)
+	}
+	// wake the background goroutine regularly so it notices tables whose old versions
+	// have aged out of the retention window
+	runPeriodically(db.done, &db.wg, versionGCInterval, func() error {
+		db.schedule()
+		return nil
+	})
+	db.schedule()
+	return db, nil
+}
+
+// Get retrieves the newest version of the record for the specified key.
+func (db *LSMStorageApp) Get(key string) (*mydatabase.DatabaseRecord, bool) {
+	return db.GetAt(key, math.MaxInt64)
+}
+
+// GetAt retrieves the version of the record that was current at ts, checking the memtables
+// first and then each level from newest to oldest.
+func (db *LSMStorageApp) GetAt(key string, ts int64) (*mydatabase.DatabaseRecord, bool) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	e, found, err := db.lookup(key, ts)
+	if err != nil {
+		log.Printf("lsm: error reading key %q: %v", key, err)
+		return nil, false
+	}
+	if !found || e.tombstone {
+		return nil, false
+	}
+	return &mydatabase.DatabaseRecord{Key: e.key, Value: e.value, Timestamp: e.ts}, true
+}
+
+// lookup finds the newest entry for key with a timestamp at or before ts. Sources are
+// checked from newest to oldest, and every version in a newer source is newer than any
+// version of the same key in an older one, so the first match wins. The caller must hold mu.
+func (db *LSMStorageApp) lookup(key string, ts int64) (kvEntry, bool, error) {
+	if e, ok := db.mem.get(key, ts); ok {
+		return e, true, nil
+	}
+	if db.imm != nil {
+		if e, ok := db.imm.get(key, ts); ok {
+			return e, true, nil
+		}
+	}
+
+	// level 0 tables may overlap, so check all of them from newest to oldest
+	for _, t := range db.levels[0] {
+		if key < t.meta.Smallest || key > t.meta.Largest {
+			continue
+		}
+		if e, found, err := db.lookupTable(t, key, ts); err != nil || found {
+			return e, found, err
+		}
+	}
+
+	// deeper levels are sorted and non-overlapping, so at most one table can hold key
+	for level := 1; level < lsmMaxLevels; level++ {
+		tables := db.levels[level]
+		i := sort.Search(len(tables), func(i int) bool { return tables[i].meta.Largest >= key })
+		if i == len(tables) || key < tables[i].meta.Smallest {
+			continue
+		}
+		if e, found, err := db.lookupTable(tables[i], key, ts); err != nil || found {
+			return e, found, err
+		}
+	}
+	return kvEntry{}, false, nil
+}
+
+func (db *LSMStorageApp) lookupTable(t *sstable, key string, ts int64) (kvEntry, bool, error) {
+	atomic.AddUint64(&db.tableLookups, 1)
+	if !t.filter.MayContain(key) {
+		atomic.AddUint64(&db.bloomNegatives, 1)
+		return kvEntry{}, false, nil
+	}
+	e, found, err := t.get(key, ts)
+	if err == nil && !found {
+		atomic.AddUint64(&db.bloomFalsePositives, 1)
+	}
+	return e, found, err
+}
+
+// History visits every retained version of key, newest first, from the memtables and then
+// each table that may hold the key.
+func (db *LSMStorageApp) History(key string, fn func(v Version) bool) error {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	sources := []func(fn func(e kvEntry) bool) error{
+		func(fn func(e kvEntry) bool) error { db.mem.versions(key, fn); return nil },
+	}
+	if db.imm != nil {
+		imm := db.imm
+		sources = append(sources, func(fn func(e kvEntry) bool) error { imm.versions(key, fn); return nil })
+	}
+	for _, tables := range db.levels {
+		for _, t := range tables {
+			if key < t.meta.Smallest || key > t.meta.Largest || !t.filter.MayContain(key) {
+				continue
+			}
+			t := t
+			sources = append(sources, func(fn func(e kvEntry) bool) error { return t.versions(key, fn) })
+		}
+	}
+
+	done := false
+	for _, source := range sources {
+		err := source(func(e kvEntry) bool {
+			done = !fn(Version{Timestamp: e.ts, Value: e.value, Deleted: e.tombstone})
+			return !done
+		})
+		if err != nil || done {
+			return err
+		}
+	}
+	return nil
+}
+
+// Timestamp returns the commit time of the most recent write.
+func (db *LSMStorageApp) Timestamp() int64 {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	return db.clock.last
+}
+
+// PinSnapshot keeps flushes and compactions from discarding the versions current at ts.
+func (db *LSMStorageApp) PinSnapshot(ts int64) func() {
+	return db.pins.pin(ts)
+}
+
+func (db *LSMStorageApp) Set(record *mydatabase.DatabaseRecord) error {
+	return db.Apply([]Mutation{{Key: record.Key, Value: record.Value}})
+}
+
+func (db *LSMStorageApp) Delete(key string) error {
+	return db.Apply([]Mutation{{Key: key, Delete: true}})
+}
+
+// Apply logs the mutations as a single write-ahead log entry and adds them to the memtable.
+// A batch always lands in one memtable, so it is flushed to level 0 as a unit.
+func (db *LSMStorageApp) Apply(mutations []Mutation) error {
+	if len(mutations) == 0 {
+		return nil
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if err := db.makeRoomForWrite(); err != nil {
+		return err
+	}
+	keys, bytes, err := db.liveChange(mutations)
+	if err != nil {
+		return err
+	}
+	ts := db.clock.next()
+	if err := db.wal.append(batchWALEntry(ts, mutations)); err != nil {
+		return err
+	}
+	for _, m := range mutations {
+		e := m.walEntry(ts)
+		db.mem.put(kvEntry{key: e.key, ts: ts, value: e.value, tombstone: m.Delete})
+		db.userBytes += uint64(len(e.key) + len(e.value))
+	}
+	db.liveKeys += keys
+	db.liveBytes += bytes
+	return nil
+}
+
+// liveChange returns how many keys the mutations make live, less those they delete, and
+// how many bytes the newest versions of the keys they write grow by. The caller must hold mu.
+func (db *LSMStorageApp) liveChange(mutations []Mutation) (keys, bytes int64, err error) {
+	// the size of the newest version of each key written so far, or -1 if it is deleted
+	sizes := make(map[string]int, len(mutations))
+	for _, m := range mutations {
+		size, ok := sizes[m.Key]
+		if !ok {
+			e, found, err := db.lookup(m.Key, math.MaxInt64)
+			if err != nil {
+				return 0, 0, err
+			}
+			size = -1
+			if found && !e.tombstone {
+				size = len(e.key) + len(e.value)
+			}
+		}
+		if size >= 0 {
+			keys, bytes = keys-1, bytes-int64(size)
+		}
+		size = -1
+		if !m.Delete {
+			size = len(m.Key) + len(m.Value)
+			keys, bytes = keys+1, bytes+int64(size)
+		}
+		sizes[m.Key] = size
+	}
+	return keys, bytes, nil
+}
+
+// makeRoomForWrite switches to a fresh memtable once the current one is full, stalling the
+// writer while a previous memtable is still being flushed or level 0 has too many tables.
+// The caller must hold mu.
+func (db *LSMStorageApp) makeRoomForWrite() error {
+	for {
+		if db.bgErr != nil {
+			return db.bgErr
+		}
+		if len(db.levels[0]) >= lsmL0StopTrigger {
+			db.bgDone.Wait()
+			continue
+		}
+		if db.mem.size < lsmMemtableSize {
+			return nil
+		}
+		if db.imm != nil {
+			db.bgDone.Wait()
+			continue
+		}
+
+		segment, err := db.wal.rotate()
+		if err != nil {
+			return err
+		}
+		db.imm = db.mem
+		db.immSegment = segment
+		db.mem = newMemtable()
+		db.schedule()
+	}
+}
+
+// Close stops background work and closes the write-ahead log and every table. Unflushed
+// writes stay in the log and are replayed on the next open.
+func (db *LSMStorageApp) Close() error {
+	close(db.done)
+	db.wg.Wait()
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	err := db.wal.close()
+	db.closeTables()
+	return err
+}
+
+func (db *LSMStorageApp) closeTables() {
+	for level := range db.levels {
+		for _, t := range db.levels[level] {
+			t.close()
+		}
+		db.levels[level] = nil
+	}
+}
+
+// Scan visits live records in key order, merging the memtables and every level so that
+// only the newest version of each key, as of options.At, is returned and deleted keys are
+// skipped.
+func (db *LSMStorageApp) Scan(options ScanOptions, fn func(record *mydatabase.DatabaseRecord) bool) error {
+	start, end := options.bounds()
+	if end != "" && start >= end {
+		return nil
+	}
+
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	// sources are ordered newest first so the merge keeps the newest version of each key
+	sources := []kvIterator{db.mem.iterator(start, end, options.Reverse)}
+	if db.imm != nil {
+		sources = append(sources, db.imm.iterator(start, end, options.Reverse))
+	}
+	for level := range db.levels {
+		for _, t := range db.levels[level] {
+			if t.meta.Largest < start || (end != "" && t.meta.Smallest >= end) {
+				continue
+			}
+			sources = append(sources, t.rangeIterator(start, end, options.Reverse))
+		}
+	}
+
+	ts := int64(math.MaxInt64)
+	if options.At != 0 {
+		ts = options.At
+	}
+	it := newMergingIterator(options.Reverse, sources...)
+	return visibleEntries(it, ts, func(e kvEntry) bool {
+		return fn(&mydatabase.DatabaseRecord{Key: e.key, Value: e.value, Timestamp: e.ts})
+	})
+}
+
+// Stats reports the level layout, write amplification and other engine counters. The
+// memtables and tables hold every version and tombstone of a key, so the live keys are
+// counted as they are written rather than from the tables.
+func (db *LSMStorageApp) Stats() *mydatabase.StorageStats {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	stats := &mydatabase.StorageStats{
+		Backend: "lsm",
+		Metrics: make(map[string]float64),
+	}
+
+	filterBytes := 0
+	for level, tables := range db.levels {
+		var size uint64
+		for _, t := range tables {
+			size += t.meta.Size
+			filterBytes += t.filter.SizeBytes()
+		}
+		stats.Levels = append(stats.Levels, &mydatabase.LevelStats{
+			Level:      int32(level),
+			TableCount: int32(len(tables)),
+			SizeBytes:  int64(size),
+		})
+	}
+	stats.RecordCount = db.liveKeys
+
+	walBytes := db.wal.bytesWritten()
+	writeAmplification := 0.0
+	if db.userBytes > 0 {
+		writeAmplification = float64(walBytes+db.flushBytes+db.compactionBytes) / float64(db.userBytes)
+	}
+	stats.Metrics["write_amplification"] = writeAmplification
+	stats.Metrics["user_bytes_written"] = float64(db.userBytes)
+	stats.Metrics["wal_bytes_written"] = float64(walBytes)
+	stats.Metrics["flush_bytes_written"] = float64(db.flushBytes)
+	stats.Metrics["compaction_bytes_written"] = float64(db.compactionBytes)
+	stats.Metrics["flushes"] = float64(db.flushes)
+	stats.Metrics["compactions"] = float64(db.compactions)
+	stats.Metrics["gc_compactions"] = float64(db.gcCompactions)
+	stats.Metrics["versions_collected"] = float64(db.versionsDropped)
+	stats.Metrics["trivial_moves"] = float64(db.trivialMoves)
+	stats.Metrics["memtable_bytes"] = float64(db.mem.size)
+	stats.Metrics["live_bytes"] = float64(db.liveBytes)
+	stats.Metrics["table_lookups"] = float64(atomic.LoadUint64(&db.tableLookups))
+	stats.Metrics["bloom_filter_negatives"] = float64(atomic.LoadUint64(&db.bloomNegatives))
+
+	// the tables' bloom filters keep lookups of absent keys off disk, like the filter of
+	// present keys of the map-based storage apps; each table lookup counts separately
+	negatives := atomic.LoadUint64(&db.bloomNegatives)
+	falsePositives := atomic.LoadUint64(&db.bloomFalsePositives)
+	rate := 0.0
+	if negatives+falsePositives > 0 {
+		rate = float64(falsePositives) / float64(negatives+falsePositives)
+	}
+	stats.Metrics["filter_memory_bytes"] = float64(filterBytes)
+	stats.Metrics["filter_negatives"] = float64(negatives)
+	stats.Metrics["filter_false_positives"] = float64(falsePositives)
+	stats.Metrics["filter_false_positive_rate"] = rate
+	return stats
+}
+
+// schedule wakes the background goroutine without blocking.
+func (db *LSMStorageApp) schedule() {
+	select {
+	case db.work <- struct{}{}:
+	default:
+	}
+}
+
+func (db *LSMStorageApp) backgroundLoop() {
+	defer db.wg.Done()
+	for {
+		select {
+		case <-db.done:
+			return
+		case <-db.work:
+		}
+		for db.backgroundStep() {
+			select {
+			case <-db.done:
+				return
+			default:
+			}
+		}
+	}
+}
+
+// backgroundStep performs a single flush or compaction and reports whether there may be
+// more work to do.
+func (db *LSMStorageApp) backgroundStep() bool {
+	db.mu.Lock()
+	imm := db.imm
+	var c *compaction
+	if imm == nil {
+		c = db.pickCompaction()
+	}
+	db.mu.Unlock()
+
+	var err error
+	switch {
+	case imm != nil:
+		err = db.flushMemtable(imm)
+	case c != nil:
+		err = db.runCompaction(c)
+	default:
+		return false
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if err != nil {
+		log.Printf("lsm: background error: %v", err)
+		db.bgErr = err
+	}
+	db.bgDone.Broadcast()
+	return err == nil
+}
+
+// flushMemtable writes the immutable memtable to a new level 0 table and drops the log
+// segments that are now covered by it.
+func (db *LSMStorageApp) flushMemtable(imm *memtable) error {
+	tables, err := db.writeTables(0, imm.iterator("", "", false), db.pins.horizon(db.retention), false, false)
+	if err != nil {
+		return err
+	}
+
+	db.mu.Lock()
+	// level 0 is ordered newest first
+	db.levels[0] = append(tables, db.levels[0]...)
+	for _, t := range tables {
+		db.flushBytes += t.meta.Size
+	}
+	db.imm = nil
+	db.logSegment = db.immSegment
+	db.flushes++
+	err = db.saveManifest()
+	segment := db.logSegment
+	db.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+	return db.wal.removeBefore(segment)
+}
+
+// pickCompaction chooses the next compaction, if any level is over its limit or a table
+// holds versions that have aged out of the retention window. The caller must hold mu.
+func (db *LSMStorageApp) pickCompaction() *compaction {
+	var c *compaction
+	if len(db.levels[0]) >= lsmL0CompactionTrigger {
+		c = &compaction{level: 0, output: 1, inputs: append([]*sstable(nil), db.levels[0]...)}
+	} else {
+		for level := 1; level < lsmMaxLevels-1; level++ {
+			if levelSize(db.levels[level]) <= maxBytesForLevel(level) {
+				continue
+			}
+			// rotate through the level's key space so every table is eventually compacted
+			tables := db.levels[level]
+			pick := tables[0]
+			for _, t := range tables {
+				if t.meta.Smallest > db.compactPointer[level] {
+					pick = t
+					break
+				}
+			}
+			c = &compaction{level: level, output: level + 1, inputs: []*sstable{pick}}
+			break
+		}
+	}
+	if c == nil {
+		c = db.pickGCCompaction()
+	}
+	if c == nil {
+		return nil
+	}
+
+	smallest, largest := keyRange(c.inputs)
+	if c.output == c.level {
+		// rewriting tables in place: the inputs are their own key range on the level
+		c.dropTombstones = true
+		return c
+	}
+	c.overlaps = overlappingTables(db.levels[c.output], smallest, largest)
+	if len(c.overlaps) > 0 {
+		s, l := keyRange(c.overlaps)
+		if s < smallest {
+			smallest = s
+		}
+		if l > largest {
+			largest = l
+		}
+	}
+	c.dropTombstones = true
+	for level := c.output + 1; level < lsmMaxLevels; level++ {
+		if len(overlappingTables(db.levels[level], smallest, largest)) > 0 {
+			c.dropTombstones = false
+			break
+		}
+	}
+	return c
+}
+
+// pickGCCompaction picks the table whose overwritten versions became collectible first,
+// once the retention horizon has passed them. Level 0 tables are compacted together into
+// level 1 as usual, and tables on the last level are rewritten in place. The caller must
+// hold mu.
+func (db *LSMStorageApp) pickGCCompaction() *compaction {
+	horizon := db.pins.horizon(db.retention)
+	var pick *sstable
+	for _, tables := range db.levels {
+		for _, t := range tables {
+			if t.meta.Garbage == 0 || t.meta.CollectibleAt > horizon {
+				continue
+			}
+			if pick == nil || t.meta.CollectibleAt < pick.meta.CollectibleAt {
+				pick = t
+			}
+		}
+	}
+	switch {
+	case pick == nil:
+		return nil
+	case pick.meta.Level == 0:
+		return &compaction{level: 0, output: 1, inputs: append([]*sstable(nil), db.levels[0]...), gc: true}
+	case pick.meta.Level == lsmMaxLevels-1:
+		return &compaction{level: pick.meta.Level, output: pick.meta.Level, inputs: []*sstable{pick}, gc: true}
+	}
+	return &compaction{level: pick.meta.Level, output: pick.meta.Level + 1, inputs: []*sstable{pick}, gc: true}
+}
+
+// runCompaction merges the compaction's inputs into the output level and installs the result.
+func (db *LSMStorageApp) runCompaction(c *compaction) error {
+	// a single table with nothing to merge against can simply move down a level, unless it
+	// has to be rewritten to drop old versions
+	if c.level > 0 && !c.gc && len(c.inputs) == 1 && len(c.overlaps) == 0 {
+		db.mu.Lock()
+		defer db.mu.Unlock()
+
+		t := c.inputs[0]
+		db.levels[c.level] = removeTables(db.levels[c.level], c.inputs)
+		t.meta.Level = c.output
+		db.levels[c.output] = insertSorted(db.levels[c.output], t)
+		db.compactPointer[c.level] = t.meta.Largest
+		db.trivialMoves++
+		return db.saveManifest()
+	}
+
+	// inputs are newer than the overlapping tables, and are already ordered newest first
+	var sources []kvIterator
+	for _, t := range c.inputs {
+		sources = append(sources, t.iterator())
+	}
+	for _, t := range c.overlaps {
+		sources = append(sources, t.iterator())
+	}
+	horizon := db.pins.horizon(db.retention)
+	outputs, err := db.writeTables(c.output, newMergingIterator(false, sources...), horizon, c.dropTombstones, true)
+	if err != nil {
+		return err
+	}
+
+	db.mu.Lock()
+	db.levels[c.level] = removeTables(db.levels[c.level], c.inputs)
+	db.levels[c.output] = removeTables(db.levels[c.output], c.overlaps)
+	var entriesIn, entriesOut uint64
+	for _, t := range append(c.inputs, c.overlaps...) {
+		entriesIn += t.meta.Entries
+	}
+	for _, t := range outputs {
+		db.levels[c.output] = insertSorted(db.levels[c.output], t)
+		db.compactionBytes += t.meta.Size
+		entriesOut += t.meta.Entries
+	}
+	db.versionsDropped += entriesIn - entriesOut
+	_, db.compactPointer[c.level] = keyRange(c.inputs)
+	db.compactions++
+	if c.gc {
+		db.gcCompactions++
+	}
+	err = db.saveManifest()
+	db.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	// no reader can still be using the obsolete tables since they were replaced under mu
+	for _, t := range append(c.inputs, c.overlaps...) {
+		t.close()
+		if err := os.Remove(filepath.Join(db.dir, lsmTableName(t.meta.ID))); err != nil {
+			log.Printf("lsm: failed to remove obsolete table %d: %v", t.meta.ID, err)
+		}
+	}
+	return nil
+}
+
+// writeTables writes the iterator's entries into one or more new tables on the given level,
+// starting a new table every lsmTargetTableSize bytes if split is set. Versions that are not
+// needed to read at any time after horizon are dropped along the way (see versionFilter).
+// Tables are only split between keys, so that all versions of a key land in the same table.
+func (db *LSMStorageApp) writeTables(level int, it kvIterator, horizon int64, dropTombstones, split bool) ([]*sstable, error) {
+	var tables []*sstable
+	var w *sstableWriter
+	var meta tableMeta
+
+	abort := func() {
+		if w != nil {
+			w.abort()
+		}
+		for _, t := range tables {
+			t.close()
+			os.Remove(filepath.Join(db.dir, lsmTableName(t.meta.ID)))
+		}
+	}
+
+	finish := func() error {
+		size, err := w.finish()
+		if err != nil {
+			return err
+		}
+		meta.Size = size
+		meta.Entries = w.entries
+		t, err := openSSTable(filepath.Join(db.dir, lsmTableName(meta.ID)), meta)
+		if err != nil {
+			return err
+		}
+		tables = append(tables, t)
+		w = nil
+		return nil
+	}
+
+	// garbage accounting for the table being written: prevTS is the timestamp of the
+	// previous (newer) version of the same key, or 0 at the start of a key
+	var prevKey string
+	var prevTS int64
+	collectible := func(ts int64) {
+		meta.Garbage++
+		if meta.CollectibleAt == 0 || ts < meta.CollectibleAt {
+			meta.CollectibleAt = ts
+		}
+	}
+
+	filter := newVersionFilter(it, horizon, dropTombstones)
+	for filter.Next() {
+		key, ts := filter.Key(), filter.Timestamp()
+		newKey := w == nil || key != prevKey
+		if split && newKey && w != nil && w.size() >= lsmTargetTableSize {
+			if err := finish(); err != nil {
+				abort()
+				return nil, err
+			}
+		}
+		if w == nil {
+			db.mu.Lock()
+			id := db.nextTableID
+			db.nextTableID++
+			db.mu.Unlock()
+
+			var err error
+			w, err = newSSTableWriter(filepath.Join(db.dir, lsmTableName(id)))
+			if err != nil {
+				abort()
+				return nil, err
+			}
+			meta = tableMeta{ID: id, Level: level, Smallest: key}
+			newKey = true
+		}
+		if err := w.add(key, ts, filter.Value(), filter.Tombstone()); err != nil {
+			abort()
+			return nil, err
+		}
+
+		meta.Largest = key
+		if ts > meta.Newest {
+			meta.Newest = ts
+		}
+		switch {
+		case !newKey:
+			// an overwritten version can go once the version replacing it is past the horizon
+			collectible(prevTS)
+		case filter.Tombstone():
+			// the newest version is a deletion, which can go once it is past the horizon
+			collectible(ts)
+		}
+		prevKey, prevTS = key, ts
+	}
+	if err := filter.Err(); err != nil {
+		abort()
+		return nil, err
+	}
+	if w != nil {
+		if err := finish(); err != nil {
+			abort()
+			return nil, err
+		}
+	}
+	return tables, nil
+}
+
+// versionFilter drops the versions from a forward iterator that no read at or after horizon
+// can observe: for each key it keeps every version newer than horizon plus the newest one at
+// or before it, which is itself dropped if it is a tombstone and dropTombstones is set.
+// Duplicate copies of a version (same key and timestamp) are reduced to the first one.
+type versionFilter struct {
+	kvIterator
+	horizon        int64
+	dropTombstones bool
+
+	key     string
+	ts      int64
+	started bool
+	base    bool // true once the current key's newest version at or before horizon was seen
+}
+
+func newVersionFilter(it kvIterator, horizon int64, dropTombstones bool) *versionFilter {
+	return &versionFilter{kvIterator: it, horizon: horizon, dropTombstones: dropTombstones}
+}
+
+func (f *versionFilter) Next() bool {
+	for f.kvIterator.Next() {
+		key, ts := f.Key(), f.Timestamp()
+		if !f.started || key != f.key {
+			f.key, f.ts, f.started, f.base = key, ts, true, false
+		} else if ts == f.ts {
+			continue
+		}
+		f.ts = ts
+
+		if f.base {
+			continue
+		}
+		if ts <= f.horizon {
+			f.base = true
+			if f.dropTombstones && f.Tombstone() {
+				continue
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// loadManifest opens every table listed in the manifest and removes table files that are
+// not referenced by it, such as the output of a compaction interrupted by a crash.
+func (db *LSMStorageApp) loadManifest() error {
+	data, err := os.ReadFile(filepath.Join(db.dir, lsmManifestFileName))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	var manifest lsmManifest
+	if err == nil {
+		if err := json.Unmarshal(data, &manifest); err != nil {
+			return err
+		}
+		db.nextTableID = manifest.NextTableID
+		db.logSegment = manifest.LogSegment
+	}
+
+	live := make(map[string]bool)
+	for _, meta := range manifest.Tables {
+		if meta.Level < 0 || meta.Level >= lsmMaxLevels {
+			return ErrCorruptTable
+		}
+		t, err := openSSTable(filepath.Join(db.dir, lsmTableName(meta.ID)), meta)
+		if err != nil {
+			return err
+		}
+		db.levels[meta.Level] = append(db.levels[meta.Level], t)
+		db.clock.observe(meta.Newest)
+		live[lsmTableName(meta.ID)] = true
+	}
+	sort.Slice(db.levels[0], func(i, j int) bool { return db.levels[0][i].meta.ID > db.levels[0][j].meta.ID })
+	for level := 1; level < lsmMaxLevels; level++ {
+		tables := db.levels[level]
+		sort.Slice(tables, func(i, j int) bool { return tables[i].meta.Smallest < tables[j].meta.Smallest })
+	}
+
+	entries, err := os.ReadDir(db.dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasSuffix(name, lsmTableSuffix) && !live[name] {
+			log.Printf("lsm: removing unreferenced table %s", name)
+			os.Remove(filepath.Join(db.dir, name))
+		}
+	}
+	return nil
+}
+
+// saveManifest persists the current table layout. The caller must hold mu.
+func (db *LSMStorageApp) saveManifest() error {
+	manifest := lsmManifest{
+		NextTableID: db.nextTableID,
+		LogSegment:  db.logSegment,
+	}
+	for _, tables := range db.levels {
+		for _, t := range tables {
+			manifest.Tables = append(manifest.Tables, t.meta)
+		}
+	}
+	data, err := json.Marshal(&manifest)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(db.dir, lsmManifestFileName, data)
+}
+
+func levelSize(tables []*sstable) uint64 {
+	var size uint64
+	for _, t := range tables {
+		size += t.meta.Size
+	}
+	return size
+}
+
+// keyRange returns the smallest and largest key covered by a set of tables.
+func keyRange(tables []*sstable) (string, string) {
+	smallest, largest := tables[0].meta.Smallest, tables[0].meta.Largest
+	for _, t := range tables[1:] {
+		if t.meta.Smallest < smallest {
+			smallest = t.meta.Smallest
+		}
+		if t.meta.Largest > largest {
+			largest = t.meta.Largest
+		}
+	}
+	return smallest, largest
+}
+
+// overlappingTables returns the tables whose key range intersects [smallest, largest].
+func overlappingTables(tables []*sstable, smallest, largest string) []*sstable {
+	var overlaps []*sstable
+	for _, t := range tables {
+		if t.meta.Largest >= smallest && t.meta.Smallest <= largest {
+			overlaps = append(overlaps, t)
+		}
+	}
+	return overlaps
+}
+
+// removeTables returns tables without the members of remove.
+func removeTables(tables, remove []*sstable) []*sstable {
+	removed := make(map[*sstable]bool, len(remove))
+	for _, t := range remove {
+		removed[t] = true
+	}
+	kept := make([]*sstable, 0, len(tables))
+	for _, t := range tables {
+		if !removed[t] {
+			kept = append(kept, t)
+		}
+	}
+	return kept
+}
+
+// insertSorted inserts t into a level ordered by smallest key.
+func insertSorted(tables []*sstable, t *sstable) []*sstable {
+	i := sort.Search(len(tables), func(i int) bool { return tables[i].meta.Smallest > t.meta.Smallest })
+	tables = append(tables, nil)
+	copy(tables[i+1:], tables[i:])
+	tables[i] = t
+	return tables
+}
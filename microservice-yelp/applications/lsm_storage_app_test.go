@@ -0,0 +1,59 @@
+package applications
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"gitlab.cs.washington.edu/syslab/cse453-welp/proto/mydatabase"
+)
+
+func TestLSMStatsCountsLiveKeys(t *testing.T) {
+	db, err := NewLSMStorageApp(StorageOptions{DataDir: t.TempDir(), SyncPolicy: SyncNever, VersionRetention: 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// enough overwrites to flush versions of the same keys to several tables
+	value := bytes.Repeat([]byte("v"), 2048)
+	for round := 0; round < 3; round++ {
+		for i := 0; i < 1000; i++ {
+			if err := db.Set(&mydatabase.DatabaseRecord{Key: fmt.Sprintf("key-%04d", i), Value: value}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	for i := 0; i < 1000; i += 4 {
+		if err := db.Delete(fmt.Sprintf("key-%04d", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// only the last mutation of a key in a batch counts
+	if err := db.Apply([]Mutation{{Key: "key-0000", Value: value}, {Key: "key-0000", Delete: true}, {Key: "key-0001", Delete: true}, {Key: "key-0001", Value: value}}); err != nil {
+		t.Fatal(err)
+	}
+	checkLive := func(db *LSMStorageApp) {
+		t.Helper()
+		stats := db.Stats()
+		if got := stats.GetRecordCount(); got != 750 {
+			t.Fatalf("RecordCount = %d, want 750 live keys", got)
+		}
+		if got, want := stats.GetMetrics()["live_bytes"], float64(750*(8+len(value))); got != want {
+			t.Fatalf("live_bytes = %v, want %v", got, want)
+		}
+	}
+	checkLive(db)
+
+	// the count survives a restart, whether the keys were flushed or are replayed from the log
+	dir := db.dir
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+	db, err = NewLSMStorageApp(StorageOptions{DataDir: dir, SyncPolicy: SyncNever, VersionRetention: 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	checkLive(db)
+}
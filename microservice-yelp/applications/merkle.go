@@ -0,0 +1,85 @@
+package applications
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+
+	"gitlab.cs.washington.edu/syslab/cse453-welp/proto/mydatabase"
+)
+
+// MaxMerkleDepth bounds the depth of a MerkleTree, which holds 2^(depth+1)-1 hashes.
+const MaxMerkleDepth = 20
+
+// MerkleTree summarizes the records in a storage app so that replicas can find the records
+// they disagree on by comparing a few hashes. Records are spread over 2^depth leaves by the
+// hash of their key, so every leaf covers a range of key hashes. A leaf's hash combines the
+// digests of its records, and an inner node's hash the hashes of its two children.
+type MerkleTree struct {
+	depth  int
+	levels [][]uint64 // levels[l] holds the 2^l nodes at level l, the root being level 0
+}
+
+// BuildMerkleTree scans storage and builds a tree of the given depth over its records.
+func BuildMerkleTree(storage Storage, depth int) (*MerkleTree, error) {
+	if depth < 0 || depth > MaxMerkleDepth {
+		return nil, fmt.Errorf("merkle tree depth %d out of range [0, %d]", depth, MaxMerkleDepth)
+	}
+	leaves := make([]uint64, 1<<depth)
+	err := storage.Scan(ScanOptions{}, func(record *mydatabase.DatabaseRecord) bool {
+		// summing digests makes a leaf's hash independent of the order of its records
+		leaves[MerkleLeaf(record.Key, depth)] += RecordDigest(record.Key, record.Value)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	levels := make([][]uint64, depth+1)
+	levels[depth] = leaves
+	var buf [16]byte
+	for l := depth - 1; l >= 0; l-- {
+		children := levels[l+1]
+		levels[l] = make([]uint64, 1<<l)
+		for i := range levels[l] {
+			binary.BigEndian.PutUint64(buf[:8], children[2*i])
+			binary.BigEndian.PutUint64(buf[8:], children[2*i+1])
+			h := fnv.New64a()
+			h.Write(buf[:])
+			levels[l][i] = h.Sum64()
+		}
+	}
+	return &MerkleTree{depth: depth, levels: levels}, nil
+}
+
+// Depth returns the level of the tree's leaves.
+func (t *MerkleTree) Depth() int {
+	return t.depth
+}
+
+// Node returns the hash of the node at index within level, or false if there is none.
+func (t *MerkleTree) Node(level, index int) (uint64, bool) {
+	if level < 0 || level > t.depth || index < 0 || index >= len(t.levels[level]) {
+		return 0, false
+	}
+	return t.levels[level][index], true
+}
+
+// MerkleLeaf returns the leaf of a tree of the given depth that covers key.
+func MerkleLeaf(key string, depth int) int {
+	if depth == 0 {
+		return 0
+	}
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	return int(h.Sum64() >> (64 - depth))
+}
+
+// RecordDigest returns a hash identifying the value of a record.
+func RecordDigest(key string, value []byte) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	h.Write([]byte{0})
+	h.Write(value)
+	return h.Sum64()
+}
@@ -0,0 +1,353 @@
+package applications
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"gitlab.cs.washington.edu/syslab/cse453-welp/proto/mydatabase"
+)
+
+var (
+	ErrQuotaExceeded    = errors.New("namespace: quota exceeded")
+	ErrInvalidNamespace = errors.New("namespace: invalid namespace or key")
+	ErrInvalidQuota     = errors.New("invalid namespace quota")
+)
+
+// Records of the default namespace, "", are stored under their own keys. Records of any
+// other namespace are stored under the namespace and the key, each preceded by a NUL byte,
+// which keeps every namespace's records together and ahead of the default namespace's.
+const namespaceMarker = "\x00"
+
+// NamespaceKey returns the key a record of namespace is stored under.
+func NamespaceKey(namespace, key string) string {
+	if namespace == "" {
+		return key
+	}
+	return namespaceMarker + namespace + namespaceMarker + key
+}
+
+// SplitNamespaceKey returns the namespace and key of the record stored under stored.
+func SplitNamespaceKey(stored string) (string, string) {
+	if !strings.HasPrefix(stored, namespaceMarker) {
+		return "", stored
+	}
+	i := strings.Index(stored[1:], namespaceMarker)
+	if i < 0 {
+		return "", stored
+	}
+	return stored[1 : 1+i], stored[2+i:]
+}
+
+// CheckNamespaceKey returns ErrInvalidNamespace if a record of namespace cannot be stored
+// under key: namespaces may not contain NUL bytes, and keys of the default namespace may
+// not start with one.
+func CheckNamespaceKey(namespace, key string) error {
+	switch {
+	case strings.Contains(namespace, namespaceMarker):
+		return fmt.Errorf("%w: namespace %q contains a NUL byte", ErrInvalidNamespace, namespace)
+	case namespace == "" && strings.HasPrefix(key, namespaceMarker):
+		return fmt.Errorf("%w: keys of the default namespace may not start with a NUL byte", ErrInvalidNamespace)
+	}
+	return nil
+}
+
+// NamespaceScan converts options selecting keys of namespace into options selecting the
+// keys its records are stored under.
+func NamespaceScan(namespace string, options ScanOptions) ScanOptions {
+	if namespace == "" {
+		// skip the other namespaces, whose keys sort first
+		if options.Start < "\x01" {
+			options.Start = "\x01"
+		}
+		return options
+	}
+	prefix := NamespaceKey(namespace, "")
+	if options.Start != "" {
+		options.Start = prefix + options.Start
+	}
+	if options.End != "" {
+		options.End = prefix + options.End
+	}
+	options.Prefix = prefix + options.Prefix
+	return options
+}
+
+// NamespaceUsage is the number of records a namespace holds and the bytes of their keys
+// and values.
+type NamespaceUsage struct {
+	Records int64
+	Bytes   int64
+}
+
+// NamespaceQuota bounds the usage of a namespace. Zero fields are unlimited.
+type NamespaceQuota struct {
+	MaxRecords int64
+	MaxBytes   int64
+}
+
+// exceededBy reports whether growing usage by delta takes it over the quota. Writes that
+// do not grow a namespace are allowed even if it is already over its quota.
+func (q NamespaceQuota) exceededBy(usage, delta NamespaceUsage) bool {
+	return q.MaxRecords > 0 && delta.Records > 0 && usage.Records+delta.Records > q.MaxRecords ||
+		q.MaxBytes > 0 && delta.Bytes > 0 && usage.Bytes+delta.Bytes > q.MaxBytes
+}
+
+// ParseNamespaceQuotas parses comma separated quotas of the form namespace=records:bytes,
+// e.g. "detail=10000:67108864,review=0:1073741824". An empty name is the default namespace
+// and 0 is unlimited.
+func ParseNamespaceQuotas(s string) (map[string]NamespaceQuota, error) {
+	quotas := make(map[string]NamespaceQuota)
+	if s == "" {
+		return quotas, nil
+	}
+	for _, entry := range strings.Split(s, ",") {
+		namespace, limits, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("%w: %q is not namespace=records:bytes", ErrInvalidQuota, entry)
+		}
+		records, bytes, ok := strings.Cut(limits, ":")
+		if !ok {
+			return nil, fmt.Errorf("%w: %q is not namespace=records:bytes", ErrInvalidQuota, entry)
+		}
+		var q NamespaceQuota
+		var err error
+		if q.MaxRecords, err = strconv.ParseInt(records, 10, 64); err != nil || q.MaxRecords < 0 {
+			return nil, fmt.Errorf("%w: invalid record limit %q", ErrInvalidQuota, records)
+		}
+		if q.MaxBytes, err = strconv.ParseInt(bytes, 10, 64); err != nil || q.MaxBytes < 0 {
+			return nil, fmt.Errorf("%w: invalid byte limit %q", ErrInvalidQuota, bytes)
+		}
+		if _, ok := quotas[namespace]; ok {
+			return nil, fmt.Errorf("%w: namespace %q listed twice", ErrInvalidQuota, namespace)
+		}
+		quotas[namespace] = q
+	}
+	return quotas, nil
+}
+
+// NamespacedStorage wraps a storage app and accounts for the records and bytes each
+// namespace holds as they are written. The usage, and the size of every record, is held in
+// memory and recounted from the records when the storage app is opened.
+type NamespacedStorage struct {
+	Storage
+
+	// writes of the same key are applied and accounted for one at a time, under the lock the
+	// key hashes to, so the usage always matches the records; mu only guards the usage
+	keyLocks [namespaceKeyLocks]sync.Mutex
+	mu       sync.Mutex
+	sizes    map[string]int64
+	usage    map[string]NamespaceUsage
+}
+
+const namespaceKeyLocks = 64 // locks serializing the writes of the keys hashing to them
+
+// NewNamespacedStorage scans storage to count the usage of every namespace.
+func NewNamespacedStorage(storage Storage) (*NamespacedStorage, error) {
+	s := &NamespacedStorage{
+		Storage: storage,
+		sizes:   make(map[string]int64),
+		usage:   make(map[string]NamespaceUsage),
+	}
+	err := storage.Scan(ScanOptions{}, func(record *mydatabase.DatabaseRecord) bool {
+		namespace, key := SplitNamespaceKey(record.Key)
+		size := int64(len(key) + len(record.Value))
+		s.sizes[record.Key] = size
+		u := s.usage[namespace]
+		u.Records++
+		u.Bytes += size
+		s.usage[namespace] = u
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *NamespacedStorage) Set(record *mydatabase.DatabaseRecord) error {
+	return s.Apply([]Mutation{{Key: record.Key, Value: record.Value}})
+}
+
+func (s *NamespacedStorage) Delete(key string) error {
+	return s.Apply([]Mutation{{Key: key, Delete: true}})
+}
+
+// Apply applies the mutations to the storage app and accounts for them. Writes of other keys
+// are applied at the same time.
+func (s *NamespacedStorage) Apply(mutations []Mutation) error {
+	unlock := s.lockKeys(mutations)
+	defer unlock()
+
+	if err := s.Storage.Apply(mutations); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	deltas, sizes := s.deltas(mutations)
+	for key, size := range sizes {
+		if size < 0 {
+			delete(s.sizes, key)
+		} else {
+			s.sizes[key] = size
+		}
+	}
+	for namespace, d := range deltas {
+		u := s.usage[namespace]
+		u.Records += d.Records
+		u.Bytes += d.Bytes
+		if u == (NamespaceUsage{}) {
+			delete(s.usage, namespace)
+		} else {
+			s.usage[namespace] = u
+		}
+	}
+	return nil
+}
+
+// lockKeys locks the locks of the keys the mutations write, in order, so that writes of
+// overlapping keys do not deadlock, and returns a function unlocking them.
+func (s *NamespacedStorage) lockKeys(mutations []Mutation) func() {
+	var locks []int
+	held := make(map[int]bool, len(mutations))
+	for _, m := range mutations {
+		h := fnv.New32a()
+		h.Write([]byte(m.Key))
+		if i := int(h.Sum32() % namespaceKeyLocks); !held[i] {
+			held[i] = true
+			locks = append(locks, i)
+		}
+	}
+	sort.Ints(locks)
+	for _, i := range locks {
+		s.keyLocks[i].Lock()
+	}
+	return func() {
+		for _, i := range locks {
+			s.keyLocks[i].Unlock()
+		}
+	}
+}
+
+// deltas returns how the mutations change the usage of each namespace, and the size each
+// key they write ends up with, or -1 if it is deleted. The caller must hold mu.
+func (s *NamespacedStorage) deltas(mutations []Mutation) (map[string]NamespaceUsage, map[string]int64) {
+	deltas := make(map[string]NamespaceUsage)
+	sizes := make(map[string]int64)
+	for _, m := range mutations {
+		old, ok := sizes[m.Key]
+		if !ok {
+			if old, ok = s.sizes[m.Key]; !ok {
+				old = -1
+			}
+		}
+		namespace, key := SplitNamespaceKey(m.Key)
+		size := int64(-1)
+		if !m.Delete {
+			size = int64(len(key) + len(m.Value))
+		}
+
+		d := deltas[namespace]
+		switch {
+		case old < 0 && size >= 0:
+			d.Records++
+			d.Bytes += size
+		case old >= 0 && size < 0:
+			d.Records--
+			d.Bytes -= old
+		case old >= 0:
+			d.Bytes += size - old
+		}
+		deltas[namespace] = d
+		sizes[m.Key] = size
+	}
+	return deltas, sizes
+}
+
+// Check returns ErrQuotaExceeded if applying the mutations would take a namespace over its
+// quota.
+func (s *NamespacedStorage) Check(mutations []Mutation, quotas map[string]NamespaceQuota) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	deltas, _ := s.deltas(mutations)
+	for namespace, d := range deltas {
+		q, ok := quotas[namespace]
+		if !ok || !q.exceededBy(s.usage[namespace], d) {
+			continue
+		}
+		u := s.usage[namespace]
+		return fmt.Errorf("%w: namespace %q holds %d records of %d bytes, limited to %d records of %d bytes",
+			ErrQuotaExceeded, namespace, u.Records, u.Bytes, q.MaxRecords, q.MaxBytes)
+	}
+	return nil
+}
+
+// Usage returns the usage of every namespace holding records.
+func (s *NamespacedStorage) Usage() map[string]NamespaceUsage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	usage := make(map[string]NamespaceUsage, len(s.usage))
+	for namespace, u := range s.usage {
+		usage[namespace] = u
+	}
+	return usage
+}
+
+// QuotaStorage enforces namespace quotas on the writes applied through it, against the
+// usage accounted by a NamespacedStorage beneath it, possibly across replication. Writes to
+// namespaces with quotas are serialized, so each is checked against the usage left by the
+// ones before it.
+type QuotaStorage struct {
+	Storage
+	usage  *NamespacedStorage
+	quotas map[string]NamespaceQuota
+
+	mu sync.Mutex
+}
+
+func NewQuotaStorage(storage Storage, usage *NamespacedStorage, quotas map[string]NamespaceQuota) *QuotaStorage {
+	return &QuotaStorage{Storage: storage, usage: usage, quotas: quotas}
+}
+
+func (s *QuotaStorage) Set(record *mydatabase.DatabaseRecord) error {
+	return s.Apply([]Mutation{{Key: record.Key, Value: record.Value}})
+}
+
+func (s *QuotaStorage) Delete(key string) error {
+	return s.Apply([]Mutation{{Key: key, Delete: true}})
+}
+
+// Apply applies the mutations unless they take a namespace over its quota, in which case
+// it applies none of them and returns ErrQuotaExceeded.
+func (s *QuotaStorage) Apply(mutations []Mutation) error {
+	limited := false
+	for _, m := range mutations {
+		namespace, _ := SplitNamespaceKey(m.Key)
+		if _, ok := s.quotas[namespace]; ok {
+			limited = true
+			break
+		}
+	}
+	if !limited {
+		return s.Storage.Apply(mutations)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.usage.Check(mutations, s.quotas); err != nil {
+		return err
+	}
+	return s.Storage.Apply(mutations)
+}
+
+// Quotas returns the quota of every namespace that has one.
+func (s *QuotaStorage) Quotas() map[string]NamespaceQuota {
+	return s.quotas
+}
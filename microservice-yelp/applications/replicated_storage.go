@@ -0,0 +1,164 @@
+package applications
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"time"
+
+	"gitlab.cs.washington.edu/syslab/cse453-welp/proto/mydatabase"
+	"gitlab.cs.washington.edu/syslab/cse453-welp/raft"
+)
+
+const (
+	replicatedApplyTimeout = 10 * time.Second // how long a write waits to be committed by the group
+	restoreBatchSize       = 1000             // mutations per Apply when restoring a snapshot
+)
+
+// ReplicatedStorage replicates a storage app across a Raft group. Writes are proposed to
+// the group and applied to every member's local storage app once committed, so they only
+// succeed on the leader; reads are served by the local storage app, which may lag behind
+// the leader on followers. Use Node().ReadIndex before reading for linearizable reads.
+//
+// Commit timestamps are assigned by each member's storage app as it applies a write, so
+// versions of the same write carry slightly different timestamps on different members.
+type ReplicatedStorage struct {
+	Storage
+	node    *raft.Node
+	storage raft.Storage
+}
+
+// NewReplicatedStorage starts a Raft node that replicates writes to local. The Raft log and
+// snapshots are kept in storage.
+func NewReplicatedStorage(local Storage, config raft.Config, transport raft.Transport, storage raft.Storage) (*ReplicatedStorage, error) {
+	node, err := raft.NewNode(config, &replicatedStateMachine{storage: local}, transport, storage)
+	if err != nil {
+		return nil, err
+	}
+	return &ReplicatedStorage{Storage: local, node: node, storage: storage}, nil
+}
+
+// Node returns the Raft node, to serve its RPCs and to check leadership.
+func (s *ReplicatedStorage) Node() *raft.Node {
+	return s.node
+}
+
+func (s *ReplicatedStorage) Set(record *mydatabase.DatabaseRecord) error {
+	return s.Apply([]Mutation{{Key: record.Key, Value: record.Value}})
+}
+
+func (s *ReplicatedStorage) Delete(key string) error {
+	return s.Apply([]Mutation{{Key: key, Delete: true}})
+}
+
+// Apply commits the mutations through the Raft group as a single log entry and waits until
+// the local storage app has applied them. It fails with raft.ErrNotLeader on followers.
+func (s *ReplicatedStorage) Apply(mutations []Mutation) error {
+	ctx, cancel := context.WithTimeout(context.Background(), replicatedApplyTimeout)
+	defer cancel()
+
+	result, err := s.node.Propose(ctx, encodeWALEntry(batchWALEntry(0, mutations)))
+	if err != nil {
+		return err
+	}
+	if err, ok := result.(error); ok {
+		return err
+	}
+	return nil
+}
+
+// Close stops the Raft node and closes the local storage app.
+func (s *ReplicatedStorage) Close() error {
+	s.node.Stop()
+	if err := s.storage.Close(); err != nil {
+		s.Storage.Close()
+		return err
+	}
+	return s.Storage.Close()
+}
+
+// Stats reports the local storage app's statistics along with the Raft node's.
+func (s *ReplicatedStorage) Stats() *mydatabase.StorageStats {
+	stats := s.Storage.Stats()
+	if stats.Metrics == nil {
+		stats.Metrics = make(map[string]float64)
+	}
+	for name, value := range s.node.Metrics() {
+		stats.Metrics[name] = value
+	}
+	return stats
+}
+
+// replicatedStateMachine applies committed log entries, which are encoded like write-ahead
+// log entries, to a member's local storage app.
+type replicatedStateMachine struct {
+	storage Storage
+}
+
+// Apply returns the error from the storage app, if any.
+func (m *replicatedStateMachine) Apply(command []byte) interface{} {
+	e, _, err := readWALEntry(bytes.NewReader(command), int64(len(command)))
+	if err != nil {
+		return err
+	}
+	if err := m.storage.Apply(e.mutations()); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Snapshot encodes the newest version of every live record as a sequence of log entries.
+func (m *replicatedStateMachine) Snapshot() ([]byte, error) {
+	var buf bytes.Buffer
+	err := m.storage.Scan(ScanOptions{}, func(record *mydatabase.DatabaseRecord) bool {
+		buf.Write(encodeWALEntry(walEntry{op: walOpSet, key: record.Key, value: record.Value}))
+		return true
+	})
+	return buf.Bytes(), err
+}
+
+// Restore brings the storage app in line with a snapshot, writing only the records that
+// differ and deleting the ones the snapshot does not contain.
+func (m *replicatedStateMachine) Restore(data []byte) error {
+	records := make(map[string][]byte)
+	r := bytes.NewReader(data)
+	for {
+		e, _, err := readWALEntry(r, int64(r.Len()))
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		records[e.key] = e.value
+	}
+
+	var mutations []Mutation
+	err := m.storage.Scan(ScanOptions{}, func(record *mydatabase.DatabaseRecord) bool {
+		value, ok := records[record.Key]
+		switch {
+		case !ok:
+			mutations = append(mutations, Mutation{Key: record.Key, Delete: true})
+		case bytes.Equal(value, record.Value):
+			delete(records, record.Key)
+		}
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	for key, value := range records {
+		mutations = append(mutations, Mutation{Key: key, Value: value})
+	}
+
+	for start := 0; start < len(mutations); start += restoreBatchSize {
+		end := start + restoreBatchSize
+		if end > len(mutations) {
+			end = len(mutations)
+		}
+		if err := m.storage.Apply(mutations[start:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,536 @@
+package applications
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"os"
+	"sort"
+)
+
+var (
+	ErrCorruptTable = errors.New("sstable: corrupt table")
+)
+
+// SSTable layout:
+//
+//	[data block 0][data block 1]...[index block][filter block][footer]
+//
+// A data block is a run of entries encoded as
+//
+//	uvarint(len(key)) | key | flags | uvarint(ts) | uvarint(len(value)) | value
+//
+// where flags is 1 for tombstones. Entries are sorted by key and then by descending
+// timestamp, and all versions of a key are kept in the same block. The index block holds one entry per data block:
+//
+//	uvarint(len(lastKey)) | lastKey | uvarint(offset) | uvarint(size) | crc32c(block)
+//
+// and the footer is a fixed-size trailer locating the index and filter blocks. The bloom
+// filter holds every distinct key in the table.
+const (
+	sstableBlockSize    = 4 << 10
+	sstableBloomBits    = 10
+	sstableFooterSize   = 6 * 8
+	sstableMagic        = 0x77656c70_6c736d32 // "welplsm2"
+	sstableTombstoneBit = 1
+)
+
+// sstableIndexEntry locates one data block. lastKey is the largest key in the block.
+type sstableIndexEntry struct {
+	lastKey  string
+	offset   uint64
+	size     uint64
+	checksum uint32
+}
+
+// sstableWriter builds an SSTable from entries added in key order, newest version first.
+type sstableWriter struct {
+	file    *os.File
+	w       *bufio.Writer
+	offset  uint64
+	block   []byte
+	lastKey string
+	index   []sstableIndexEntry
+	keys    []string
+	entries uint64
+}
+
+func newSSTableWriter(path string) (*sstableWriter, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &sstableWriter{
+		file: file,
+		w:    bufio.NewWriterSize(file, 64<<10),
+	}, nil
+}
+
+// add appends an entry. Entries must be sorted by key and then by descending timestamp.
+func (t *sstableWriter) add(key string, ts int64, value []byte, tombstone bool) error {
+	var scratch [binary.MaxVarintLen64]byte
+
+	newKey := t.entries == 0 || key != t.lastKey
+	// only start a new block between keys so that all versions of a key share a block
+	if newKey && len(t.block) >= sstableBlockSize {
+		if err := t.flushBlock(); err != nil {
+			return err
+		}
+	}
+
+	n := binary.PutUvarint(scratch[:], uint64(len(key)))
+	t.block = append(t.block, scratch[:n]...)
+	t.block = append(t.block, key...)
+	var flags byte
+	if tombstone {
+		flags |= sstableTombstoneBit
+	}
+	t.block = append(t.block, flags)
+	n = binary.PutUvarint(scratch[:], uint64(ts))
+	t.block = append(t.block, scratch[:n]...)
+	n = binary.PutUvarint(scratch[:], uint64(len(value)))
+	t.block = append(t.block, scratch[:n]...)
+	t.block = append(t.block, value...)
+
+	if newKey {
+		t.keys = append(t.keys, key)
+	}
+	t.lastKey = key
+	t.entries++
+	return nil
+}
+
+func (t *sstableWriter) flushBlock() error {
+	if len(t.block) == 0 {
+		return nil
+	}
+	if _, err := t.w.Write(t.block); err != nil {
+		return err
+	}
+	t.index = append(t.index, sstableIndexEntry{
+		lastKey:  t.lastKey,
+		offset:   t.offset,
+		size:     uint64(len(t.block)),
+		checksum: crc32.Checksum(t.block, walCRCTable),
+	})
+	t.offset += uint64(len(t.block))
+	t.block = t.block[:0]
+	return nil
+}
+
+// size returns the number of bytes written so far, including the pending block.
+func (t *sstableWriter) size() uint64 {
+	return t.offset + uint64(len(t.block))
+}
+
+// finish writes the index, filter and footer, fsyncs the file and returns its total size.
+func (t *sstableWriter) finish() (uint64, error) {
+	if err := t.flushBlock(); err != nil {
+		t.file.Close()
+		return 0, err
+	}
+
+	var scratch [binary.MaxVarintLen64]byte
+	var index []byte
+	for _, e := range t.index {
+		n := binary.PutUvarint(scratch[:], uint64(len(e.lastKey)))
+		index = append(index, scratch[:n]...)
+		index = append(index, e.lastKey...)
+		n = binary.PutUvarint(scratch[:], e.offset)
+		index = append(index, scratch[:n]...)
+		n = binary.PutUvarint(scratch[:], e.size)
+		index = append(index, scratch[:n]...)
+		var sum [4]byte
+		binary.LittleEndian.PutUint32(sum[:], e.checksum)
+		index = append(index, sum[:]...)
+	}
+
+	filter := NewBloomFilter(len(t.keys), sstableBloomBits)
+	for _, key := range t.keys {
+		filter.Add(key)
+	}
+	filterData, _ := filter.MarshalBinary()
+
+	indexOffset := t.offset
+	filterOffset := indexOffset + uint64(len(index))
+	footer := make([]byte, sstableFooterSize)
+	binary.LittleEndian.PutUint64(footer[0:], indexOffset)
+	binary.LittleEndian.PutUint64(footer[8:], uint64(len(index)))
+	binary.LittleEndian.PutUint64(footer[16:], filterOffset)
+	binary.LittleEndian.PutUint64(footer[24:], uint64(len(filterData)))
+	binary.LittleEndian.PutUint64(footer[32:], t.entries)
+	binary.LittleEndian.PutUint64(footer[40:], sstableMagic)
+
+	for _, chunk := range [][]byte{index, filterData, footer} {
+		if _, err := t.w.Write(chunk); err != nil {
+			t.file.Close()
+			return 0, err
+		}
+	}
+	if err := t.w.Flush(); err != nil {
+		t.file.Close()
+		return 0, err
+	}
+	if err := t.file.Sync(); err != nil {
+		t.file.Close()
+		return 0, err
+	}
+	total := filterOffset + uint64(len(filterData)) + sstableFooterSize
+	return total, t.file.Close()
+}
+
+// abort closes and removes a partially written table.
+func (t *sstableWriter) abort() {
+	t.file.Close()
+	os.Remove(t.file.Name())
+}
+
+// sstable is an open, immutable table. The index and bloom filter are kept in memory
+// and data blocks are read on demand.
+type sstable struct {
+	meta    tableMeta
+	file    *os.File
+	index   []sstableIndexEntry
+	filter  *BloomFilter
+	entries uint64
+}
+
+func openSSTable(path string, meta tableMeta) (*sstable, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	t := &sstable{meta: meta, file: file}
+	if err := t.load(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return t, nil
+}
+
+func (t *sstable) load() error {
+	info, err := t.file.Stat()
+	if err != nil {
+		return err
+	}
+	if info.Size() < sstableFooterSize {
+		return ErrCorruptTable
+	}
+	footer := make([]byte, sstableFooterSize)
+	if _, err := t.file.ReadAt(footer, info.Size()-sstableFooterSize); err != nil {
+		return err
+	}
+	if binary.LittleEndian.Uint64(footer[40:]) != sstableMagic {
+		return ErrCorruptTable
+	}
+	indexOffset := binary.LittleEndian.Uint64(footer[0:])
+	indexSize := binary.LittleEndian.Uint64(footer[8:])
+	filterOffset := binary.LittleEndian.Uint64(footer[16:])
+	filterSize := binary.LittleEndian.Uint64(footer[24:])
+	t.entries = binary.LittleEndian.Uint64(footer[32:])
+
+	index := make([]byte, indexSize)
+	if _, err := t.file.ReadAt(index, int64(indexOffset)); err != nil {
+		return err
+	}
+	for len(index) > 0 {
+		keyLen, n := binary.Uvarint(index)
+		if n <= 0 || uint64(len(index)-n) < keyLen {
+			return ErrCorruptTable
+		}
+		index = index[n:]
+		e := sstableIndexEntry{lastKey: string(index[:keyLen])}
+		index = index[keyLen:]
+		if e.offset, n = binary.Uvarint(index); n <= 0 {
+			return ErrCorruptTable
+		}
+		index = index[n:]
+		if e.size, n = binary.Uvarint(index); n <= 0 {
+			return ErrCorruptTable
+		}
+		index = index[n:]
+		if len(index) < 4 {
+			return ErrCorruptTable
+		}
+		e.checksum = binary.LittleEndian.Uint32(index)
+		index = index[4:]
+		t.index = append(t.index, e)
+	}
+
+	filterData := make([]byte, filterSize)
+	if _, err := t.file.ReadAt(filterData, int64(filterOffset)); err != nil {
+		return err
+	}
+	t.filter = &BloomFilter{}
+	return t.filter.UnmarshalBinary(filterData)
+}
+
+func (t *sstable) readBlock(i int) ([]byte, error) {
+	e := t.index[i]
+	block := make([]byte, e.size)
+	if _, err := t.file.ReadAt(block, int64(e.offset)); err != nil {
+		return nil, err
+	}
+	if crc32.Checksum(block, walCRCTable) != e.checksum {
+		return nil, ErrCorruptTable
+	}
+	return block, nil
+}
+
+// get looks up the newest version of key with a timestamp at or before ts. found is false
+// if the table has no such entry; otherwise the entry is returned, which may be a tombstone.
+// Callers are expected to consult t.filter first to skip tables that definitely do not
+// hold the key.
+func (t *sstable) get(key string, ts int64) (entry kvEntry, found bool, err error) {
+	err = t.versions(key, func(e kvEntry) bool {
+		if e.ts <= ts {
+			entry, found = e, true
+			return false
+		}
+		return true
+	})
+	return entry, found, err
+}
+
+// versions calls fn for every entry of key in the table, newest first, until fn returns false.
+func (t *sstable) versions(key string, fn func(e kvEntry) bool) error {
+	i := sort.Search(len(t.index), func(i int) bool { return t.index[i].lastKey >= key })
+	if i == len(t.index) {
+		return nil
+	}
+	block, err := t.readBlock(i)
+	if err != nil {
+		return err
+	}
+	it := &blockIterator{block: block}
+	for it.Next() {
+		if it.Key() > key {
+			break
+		}
+		if it.Key() == key && !fn(it.entry) {
+			break
+		}
+	}
+	return it.Err()
+}
+
+func (t *sstable) close() error {
+	return t.file.Close()
+}
+
+// iterator returns an iterator over every entry in the table.
+func (t *sstable) iterator() kvIterator {
+	return &sstableIterator{table: t, blockIndex: -1}
+}
+
+// blockIterator decodes entries from a single data block.
+type blockIterator struct {
+	block []byte
+	entry kvEntry
+	err   error
+}
+
+func (it *blockIterator) Next() bool {
+	if it.err != nil || len(it.block) == 0 {
+		return false
+	}
+	keyLen, n := binary.Uvarint(it.block)
+	if n <= 0 || uint64(len(it.block)-n) < keyLen+1 {
+		it.err = ErrCorruptTable
+		return false
+	}
+	it.block = it.block[n:]
+	key := string(it.block[:keyLen])
+	flags := it.block[keyLen]
+	it.block = it.block[keyLen+1:]
+	ts, n := binary.Uvarint(it.block)
+	if n <= 0 {
+		it.err = ErrCorruptTable
+		return false
+	}
+	it.block = it.block[n:]
+	valueLen, n := binary.Uvarint(it.block)
+	if n <= 0 || uint64(len(it.block)-n) < valueLen {
+		it.err = ErrCorruptTable
+		return false
+	}
+	it.block = it.block[n:]
+	it.entry = kvEntry{
+		key:       key,
+		ts:        int64(ts),
+		value:     it.block[:valueLen:valueLen],
+		tombstone: flags&sstableTombstoneBit != 0,
+	}
+	if it.entry.tombstone {
+		it.entry.value = nil
+	}
+	it.block = it.block[valueLen:]
+	return true
+}
+
+func (it *blockIterator) Key() string      { return it.entry.key }
+func (it *blockIterator) Timestamp() int64 { return it.entry.ts }
+func (it *blockIterator) Value() []byte    { return it.entry.value }
+func (it *blockIterator) Tombstone() bool  { return it.entry.tombstone }
+func (it *blockIterator) Err() error       { return it.err }
+
+// sstableIterator walks every block of a table in order.
+type sstableIterator struct {
+	table      *sstable
+	blockIndex int
+	block      *blockIterator
+	err        error
+}
+
+func (it *sstableIterator) Next() bool {
+	for {
+		if it.err != nil {
+			return false
+		}
+		if it.block != nil && it.block.Next() {
+			return true
+		}
+		if it.block != nil && it.block.Err() != nil {
+			it.err = it.block.Err()
+			return false
+		}
+		it.blockIndex++
+		if it.blockIndex >= len(it.table.index) {
+			return false
+		}
+		data, err := it.table.readBlock(it.blockIndex)
+		if err != nil {
+			it.err = err
+			return false
+		}
+		it.block = &blockIterator{block: data}
+	}
+}
+
+func (it *sstableIterator) Key() string      { return it.block.Key() }
+func (it *sstableIterator) Timestamp() int64 { return it.block.Timestamp() }
+func (it *sstableIterator) Value() []byte    { return it.block.Value() }
+func (it *sstableIterator) Tombstone() bool  { return it.block.Tombstone() }
+func (it *sstableIterator) Err() error       { return it.err }
+
+// rangeIterator returns an iterator over the table's entries in [start, end), walking
+// backwards if reverse is set. An empty end means there is no upper bound.
+func (t *sstable) rangeIterator(start, end string, reverse bool) kvIterator {
+	return &sstableRangeIterator{table: t, start: start, end: end, reverse: reverse}
+}
+
+// sstableRangeIterator decodes one block at a time into memory so that it can walk the
+// entries in either direction.
+type sstableRangeIterator struct {
+	table      *sstable
+	start, end string
+	reverse    bool
+	started    bool
+	blockIndex int
+	entries    []kvEntry
+	pos        int
+	done       bool
+	err        error
+}
+
+// decodeBlock reads block i and returns its entries in table order.
+func (t *sstable) decodeBlock(i int) ([]kvEntry, error) {
+	data, err := t.readBlock(i)
+	if err != nil {
+		return nil, err
+	}
+	var entries []kvEntry
+	it := &blockIterator{block: data}
+	for it.Next() {
+		entries = append(entries, it.entry)
+	}
+	return entries, it.Err()
+}
+
+// loadBlock decodes block i and positions the iterator just outside it, so that the next
+// step lands on its first entry in iteration order.
+func (it *sstableRangeIterator) loadBlock(i int) bool {
+	if i < 0 || i >= len(it.table.index) {
+		it.done = true
+		return false
+	}
+	it.blockIndex = i
+	it.entries, it.err = it.table.decodeBlock(i)
+	if it.err != nil {
+		return false
+	}
+	if it.reverse {
+		it.pos = len(it.entries)
+	} else {
+		it.pos = -1
+	}
+	return true
+}
+
+// seek loads the first block that can hold a key in range for the iteration direction.
+func (it *sstableRangeIterator) seek() bool {
+	index := it.table.index
+	if !it.reverse {
+		return it.loadBlock(sort.Search(len(index), func(i int) bool { return index[i].lastKey >= it.start }))
+	}
+	if it.end == "" {
+		return it.loadBlock(len(index) - 1)
+	}
+	i := sort.Search(len(index), func(i int) bool { return index[i].lastKey >= it.end })
+	if i == len(index) {
+		i--
+	}
+	return it.loadBlock(i)
+}
+
+func (it *sstableRangeIterator) Next() bool {
+	if !it.started {
+		it.started = true
+		if !it.seek() {
+			return false
+		}
+	}
+	for !it.done && it.err == nil {
+		if it.reverse {
+			it.pos--
+		} else {
+			it.pos++
+		}
+		if it.pos < 0 {
+			it.loadBlock(it.blockIndex - 1)
+			continue
+		}
+		if it.pos >= len(it.entries) {
+			it.loadBlock(it.blockIndex + 1)
+			continue
+		}
+
+		key := it.entries[it.pos].key
+		if it.reverse {
+			if it.end != "" && key >= it.end {
+				continue
+			}
+			if key < it.start {
+				it.done = true
+				return false
+			}
+		} else {
+			if key < it.start {
+				continue
+			}
+			if it.end != "" && key >= it.end {
+				it.done = true
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+func (it *sstableRangeIterator) Key() string      { return it.entries[it.pos].key }
+func (it *sstableRangeIterator) Timestamp() int64 { return it.entries[it.pos].ts }
+func (it *sstableRangeIterator) Value() []byte    { return it.entries[it.pos].value }
+func (it *sstableRangeIterator) Tombstone() bool  { return it.entries[it.pos].tombstone }
+func (it *sstableRangeIterator) Err() error       { return it.err }
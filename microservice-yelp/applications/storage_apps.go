@@ -3,9 +3,9 @@ package applications
 import (
 	"encoding/json"
 	"errors"
-	"io/ioutil"
 	"log"
 	"os"
+	"path/filepath"
 	"sync"
 	"time"
 
@@ -15,18 +15,221 @@ import (
 var (
 	ErrRecordNotFound    = errors.New("storage: item not found")
 	ErrInvalidDeviceType = errors.New("invalid device type")
+	ErrInvalidBackend    = errors.New("invalid storage backend")
 )
 
+// Storage is a simple key-value storage interface implemented by every storage app. Every
+// write creates a new version of the record, stamped with its commit time; versions that
+// have been overwritten stay readable for the configured retention window.
+type Storage interface {
+	// Get retrieves the newest version of the record for the specified key.
+	Get(key string) (*mydatabase.DatabaseRecord, bool)
+
+	// GetAt retrieves the version of the record that was current at ts (unix nanoseconds).
+	GetAt(key string, ts int64) (*mydatabase.DatabaseRecord, bool)
+
+	// History calls fn for every retained version of key, including deletions, newest first,
+	// until fn returns false.
+	History(key string, fn func(v Version) bool) error
+
+	// Timestamp returns the commit time of the most recent write. Reading at this timestamp
+	// observes every write that has completed so far.
+	Timestamp() int64
+
+	// PinSnapshot keeps the records as they were at ts readable, even once ts leaves the
+	// retention window, until release is called. ts must still be within the window.
+	PinSnapshot(ts int64) (release func())
+
+	// Set inserts or overwrites the record stored under record.Key.
+	Set(record *mydatabase.DatabaseRecord) error
+
+	// Delete removes the record for the specified key.
+	Delete(key string) error
+
+	// Apply performs every mutation or none of them; readers never observe a partially
+	// applied batch, and persistent backends recover it all-or-nothing after a crash.
+	Apply(mutations []Mutation) error
+
+	// Scan calls fn for each record in the range selected by options, in key order, until fn
+	// returns false. fn is called with the storage app's lock held, so it must not call back
+	// into the storage app.
+	Scan(options ScanOptions, fn func(record *mydatabase.DatabaseRecord) bool) error
+
+	// Close flushes any buffered state and releases the underlying resources.
+	Close() error
+
+	// Stats reports the record count and backend specific statistics.
+	Stats() *mydatabase.StorageStats
+}
+
+// StorageOptions configures the storage app backing a database server.
+type StorageOptions struct {
+	// Backend selects the implementation: "emulated", "persistent" or "lsm".
+	Backend string
+
+	// DeviceType is the emulated device latency model: "ssd", "disk" or "cloud".
+	DeviceType string
+
+	// DataDir holds the checkpoint and write-ahead log of persistent backends.
+	DataDir string
+
+	// SyncPolicy controls when the write-ahead log is fsynced.
+	SyncPolicy SyncPolicy
+
+	// SyncInterval is how often the write-ahead log is fsynced under SyncInterval.
+	SyncInterval time.Duration
+
+	// CheckpointInterval is how often the in-memory state is checkpointed and the log trimmed.
+	CheckpointInterval time.Duration
+
+	// VersionRetention is how long overwritten and deleted versions remain readable. Zero
+	// keeps only the newest version of each record. The lsm backend drops expired versions
+	// as it compacts them together, so they may remain readable for longer.
+	VersionRetention time.Duration
+
+	// GroupCommitSize is the most concurrent writes a database commits together, paying one
+	// device access or log fsync for all of them. Zero or one commits every write alone.
+	GroupCommitSize int
+
+	// GroupCommitWindow is how long the first write of a group waits for others to join it.
+	// Zero groups only the writes that arrive while the previous group is being committed.
+	GroupCommitWindow time.Duration
+}
+
+// Mutation is a single write in a batch passed to Storage.Apply.
+type Mutation struct {
+	Key string
+
+	// Value is the new value of the record; it is ignored if Delete is set.
+	Value []byte
+
+	// Delete removes the record instead of writing Value.
+	Delete bool
+}
+
+// walEntry converts the mutation into a log entry committed at ts.
+func (m Mutation) walEntry(ts int64) walEntry {
+	if m.Delete {
+		return walEntry{op: walOpDelete, ts: ts, key: m.Key}
+	}
+	return walEntry{op: walOpSet, ts: ts, key: m.Key, value: m.Value}
+}
+
+// batchWALEntry logs a group of mutations committed at ts as one entry, so they are
+// replayed atomically.
+func batchWALEntry(ts int64, mutations []Mutation) walEntry {
+	if len(mutations) == 1 {
+		return mutations[0].walEntry(ts)
+	}
+	e := walEntry{op: walOpBatch, ts: ts, batch: make([]walEntry, len(mutations))}
+	for i, m := range mutations {
+		e.batch[i] = m.walEntry(ts)
+	}
+	return e
+}
+
+// mutations converts a log entry back into the mutations it records.
+func (e walEntry) mutations() []Mutation {
+	if e.op != walOpBatch {
+		return []Mutation{{Key: e.key, Value: e.value, Delete: e.op == walOpDelete}}
+	}
+	mutations := make([]Mutation, len(e.batch))
+	for i, b := range e.batch {
+		mutations[i] = Mutation{Key: b.key, Value: b.value, Delete: b.op == walOpDelete}
+	}
+	return mutations
+}
+
+// ScanOptions selects the records visited by Storage.Scan.
+type ScanOptions struct {
+	// Start is the inclusive lower bound of the range ("" for the first key).
+	Start string
+
+	// End is the exclusive upper bound of the range ("" for no upper bound).
+	End string
+
+	// Prefix restricts the scan to keys with this prefix, intersected with [Start, End).
+	Prefix string
+
+	// Reverse visits keys in descending order.
+	Reverse bool
+
+	// At reads the records as they were at this commit timestamp, which must lie within the
+	// version retention window (0 for the newest versions).
+	At int64
+}
+
+// bounds returns the effective [start, end) range of the scan.
+func (o ScanOptions) bounds() (string, string) {
+	start, end := o.Start, o.End
+	if o.Prefix != "" {
+		if o.Prefix > start {
+			start = o.Prefix
+		}
+		if limit := prefixSuccessor(o.Prefix); limit != "" && (end == "" || limit < end) {
+			end = limit
+		}
+	}
+	return start, end
+}
+
+// prefixSuccessor returns the smallest key greater than every key with the given prefix,
+// or "" if no such key exists (the prefix is all 0xff bytes).
+func prefixSuccessor(prefix string) string {
+	b := []byte(prefix)
+	for i := len(b) - 1; i >= 0; i-- {
+		if b[i] < 0xff {
+			b[i]++
+			return string(b[:i+1])
+		}
+	}
+	return ""
+}
+
+// scanIndex walks index over the range selected by options, calling fn for every key until
+// it returns false.
+func scanIndex(index *keyIndex, options ScanOptions, fn func(key string) bool) {
+	start, end := options.bounds()
+	if end != "" && start >= end {
+		return
+	}
+	if options.Reverse {
+		index.Descend(start, end, fn)
+	} else {
+		index.Ascend(start, end, fn)
+	}
+}
+
+// NewStorageApp creates the storage app selected by options.Backend.
+func NewStorageApp(options StorageOptions) (Storage, error) {
+	log.Printf("storage backend: %v", options.Backend)
+	switch options.Backend {
+	case "emulated":
+		return NewEmulatedStorageApp(options.DeviceType, options.VersionRetention)
+	case "persistent":
+		return NewPersistentStorageApp(options)
+	case "lsm":
+		return NewLSMStorageApp(options)
+	}
+	return nil, ErrInvalidBackend
+}
+
 // EmulatedStorageApp is an in-memory emulated storage layer.
 type EmulatedStorageApp struct {
-	data    map[string]*mydatabase.DatabaseRecord
-	mu      sync.Mutex
-	dist    string
-	latency time.Duration
+	store     *versionStore
+	mu        sync.Mutex
+	dist      string
+	latency   time.Duration
+	retention time.Duration
+	pins      snapshotPins
+
+	done chan struct{}
+	wg   sync.WaitGroup
 }
 
-// NewEmulatedStorageApp creates a new instance of EmulatedStorage. DeviceType must be 'disk' or 'ssd'
-func NewEmulatedStorageApp(deviceType string) (*EmulatedStorageApp, error) {
+// NewEmulatedStorageApp creates a new instance of EmulatedStorage. DeviceType must be 'disk' or 'ssd'.
+// Overwritten versions are kept for the retention window.
+func NewEmulatedStorageApp(deviceType string, retention time.Duration) (*EmulatedStorageApp, error) {
 	log.Printf("device type: %v", deviceType)
 	// latency constants specified in microseconds
 	validDevice := map[string]int{
@@ -41,59 +244,197 @@ func NewEmulatedStorageApp(deviceType string) (*EmulatedStorageApp, error) {
 	if !ok {
 		return nil, ErrInvalidDeviceType
 	}
-	return &EmulatedStorageApp{
-		data:    make(map[string]*mydatabase.DatabaseRecord),
-		dist:    "uniform",
-		latency: time.Duration(latency) * time.Microsecond,
-	}, nil
+	s := &EmulatedStorageApp{
+		store:     newVersionStore(),
+		dist:      "uniform",
+		latency:   time.Duration(latency) * time.Microsecond,
+		retention: retention,
+		done:      make(chan struct{}),
+	}
+	runPeriodically(s.done, &s.wg, versionGCInterval, s.collectGarbage)
+	return s, nil
 }
 
 func (s *EmulatedStorageApp) sleep() {
 	time.Sleep(s.latency)
 }
 
+// Get skips the emulated device access for keys the filter of present keys rules out.
 func (s *EmulatedStorageApp) Get(key string) (*mydatabase.DatabaseRecord, bool) {
+	s.mu.Lock()
+	present := s.store.mayContain(key)
+	s.mu.Unlock()
+	if !present {
+		return nil, false
+	}
+
 	s.sleep()
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	value, ok := s.data[key]
-	return value, ok
+	return s.store.getLatest(key)
 }
 
-func (s *EmulatedStorageApp) Set(record *mydatabase.DatabaseRecord) {
+func (s *EmulatedStorageApp) GetAt(key string, ts int64) (*mydatabase.DatabaseRecord, bool) {
 	s.sleep()
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	s.data[record.Key] = record
+	return s.store.get(key, ts)
 }
 
-func (s *EmulatedStorageApp) Delete(key string) {
+// History visits the retained versions of key. It costs a single emulated device access.
+func (s *EmulatedStorageApp) History(key string, fn func(v Version) bool) error {
 	s.sleep()
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	delete(s.data, key)
+	s.store.history(key, fn)
+	return nil
+}
+
+func (s *EmulatedStorageApp) Timestamp() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.store.clock.last
+}
+
+func (s *EmulatedStorageApp) PinSnapshot(ts int64) func() {
+	return s.pins.pin(ts)
 }
 
+func (s *EmulatedStorageApp) Set(record *mydatabase.DatabaseRecord) error {
+	return s.Apply([]Mutation{{Key: record.Key, Value: record.Value}})
+}
+
+func (s *EmulatedStorageApp) Delete(key string) error {
+	return s.Apply([]Mutation{{Key: key, Delete: true}})
+}
+
+// Apply performs the mutations under a single lock acquisition, costing one emulated
+// device access for the whole batch.
+func (s *EmulatedStorageApp) Apply(mutations []Mutation) error {
+	s.sleep()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ts := s.store.clock.next()
+	for _, m := range mutations {
+		v := Version{Timestamp: ts, Value: m.Value, Deleted: m.Delete}
+		if m.Delete {
+			v.Value = nil
+		}
+		s.store.put(m.Key, v)
+	}
+	return nil
+}
+
+// Scan visits records in key order. The whole scan costs a single emulated device access.
+func (s *EmulatedStorageApp) Scan(options ScanOptions, fn func(record *mydatabase.DatabaseRecord) bool) error {
+	s.sleep()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.store.scan(options, fn)
+	return nil
+}
+
+// Close stops the background garbage collection. Emulated storage keeps nothing outside of memory.
+func (s *EmulatedStorageApp) Close() error {
+	close(s.done)
+	s.wg.Wait()
+	return nil
+}
+
+// collectGarbage drops versions that have aged out of the retention window.
+func (s *EmulatedStorageApp) collectGarbage() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.store.gc(s.pins.horizon(s.retention))
+	return nil
+}
+
+// Stats reports the number of stored records, the emulated device latency and the filter
+// of present keys.
+func (s *EmulatedStorageApp) Stats() *mydatabase.StorageStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := &mydatabase.StorageStats{
+		Backend:     "emulated",
+		RecordCount: int64(s.store.live),
+		Metrics: map[string]float64{
+			"device_latency_us":  float64(s.latency.Microseconds()),
+			"versions_retained":  float64(s.store.retained),
+			"versions_collected": float64(s.store.collected),
+		},
+	}
+	s.store.filterMetrics(stats.Metrics)
+	return stats
+}
+
+const checkpointFileName = "checkpoint.json"
+
+// checkpoint is the on-disk snapshot of a PersistentStorageApp. Segment is the first
+// write-ahead log segment that is not already reflected in Versions.
+type checkpoint struct {
+	Segment  uint64               `json:"segment"`
+	Versions map[string][]Version `json:"versions"`
+}
+
+// PersistentStorageApp is an in-memory key-value store made durable by a write-ahead log.
+// Every mutation is appended to the log before it is applied, and a background goroutine
+// periodically checkpoints the whole map so that older log segments can be discarded.
 type PersistentStorageApp struct {
-	data      map[string]*mydatabase.DatabaseRecord
+	store     *versionStore
 	dataMutex sync.RWMutex
-	filePath  string
+	dataDir   string
+	wal       *writeAheadLog
+	retention time.Duration
+	pins      snapshotPins
+
+	// number of mutations applied (or replayed) since the last checkpoint
+	pending int
+
+	done chan struct{}
+	wg   sync.WaitGroup
 }
 
-func NewPersistentStorageApp(filePath string) (*PersistentStorageApp, error) {
+// NewPersistentStorageApp loads the latest checkpoint from options.DataDir, replays the
+// write-ahead log on top of it, and starts the background fsync, checkpoint and garbage
+// collection loops.
+func NewPersistentStorageApp(options StorageOptions) (*PersistentStorageApp, error) {
+	log.Printf("data dir: %v, fsync policy: %v", options.DataDir, options.SyncPolicy)
+	if err := os.MkdirAll(options.DataDir, 0755); err != nil {
+		return nil, err
+	}
+
 	kvs := &PersistentStorageApp{
-		data:     make(map[string]*mydatabase.DatabaseRecord),
-		filePath: filePath,
+		store:     newVersionStore(),
+		dataDir:   options.DataDir,
+		retention: options.VersionRetention,
+		done:      make(chan struct{}),
+	}
+
+	segment, err := kvs.loadCheckpoint()
+	if err != nil {
+		return nil, err
 	}
 
-	err := kvs.loadFromFile()
+	kvs.wal, err = openWriteAheadLog(options.DataDir, options.SyncPolicy, segment, kvs.apply)
 	if err != nil {
 		return nil, err
 	}
 
+	if options.SyncPolicy == SyncInterval && options.SyncInterval > 0 {
+		runPeriodically(kvs.done, &kvs.wg, options.SyncInterval, kvs.wal.sync)
+	}
+	if options.CheckpointInterval > 0 {
+		runPeriodically(kvs.done, &kvs.wg, options.CheckpointInterval, kvs.checkpoint)
+	}
+	runPeriodically(kvs.done, &kvs.wg, versionGCInterval, kvs.collectGarbage)
 	return kvs, nil
 }
 
@@ -101,61 +442,237 @@ func (s *PersistentStorageApp) Get(key string) (*mydatabase.DatabaseRecord, bool
 	s.dataMutex.RLock()
 	defer s.dataMutex.RUnlock()
 
-	record, ok := s.data[key]
-	return record, ok
+	return s.store.getLatest(key)
 }
 
-func (kvs *PersistentStorageApp) Set(record *mydatabase.DatabaseRecord) {
+func (s *PersistentStorageApp) GetAt(key string, ts int64) (*mydatabase.DatabaseRecord, bool) {
+	s.dataMutex.RLock()
+	defer s.dataMutex.RUnlock()
+
+	return s.store.get(key, ts)
+}
+
+func (kvs *PersistentStorageApp) History(key string, fn func(v Version) bool) error {
+	kvs.dataMutex.RLock()
+	defer kvs.dataMutex.RUnlock()
+
+	kvs.store.history(key, fn)
+	return nil
+}
+
+func (kvs *PersistentStorageApp) Timestamp() int64 {
+	kvs.dataMutex.RLock()
+	defer kvs.dataMutex.RUnlock()
+
+	return kvs.store.clock.last
+}
+
+func (kvs *PersistentStorageApp) PinSnapshot(ts int64) func() {
+	return kvs.pins.pin(ts)
+}
+
+func (kvs *PersistentStorageApp) Set(record *mydatabase.DatabaseRecord) error {
+	return kvs.Apply([]Mutation{{Key: record.Key, Value: record.Value}})
+}
+
+func (kvs *PersistentStorageApp) Delete(key string) error {
+	return kvs.Apply([]Mutation{{Key: key, Delete: true}})
+}
+
+// Apply logs the mutations as a single write-ahead log entry and then applies them.
+// Holding the write lock across both keeps the log order identical to the apply order.
+func (kvs *PersistentStorageApp) Apply(mutations []Mutation) error {
+	if len(mutations) == 0 {
+		return nil
+	}
+
 	kvs.dataMutex.Lock()
 	defer kvs.dataMutex.Unlock()
 
-	kvs.data[record.Key] = record
+	e := batchWALEntry(kvs.store.clock.next(), mutations)
+	if err := kvs.wal.append(e); err != nil {
+		return err
+	}
+	kvs.apply(e)
+	return nil
+}
 
-	err := kvs.saveToFile()
-	if err != nil {
-		log.Println("Error saving key-value store to file:", err)
+// Scan visits records in key order.
+func (kvs *PersistentStorageApp) Scan(options ScanOptions, fn func(record *mydatabase.DatabaseRecord) bool) error {
+	kvs.dataMutex.RLock()
+	defer kvs.dataMutex.RUnlock()
+
+	kvs.store.scan(options, fn)
+	return nil
+}
+
+// Close stops the background loops, takes a final checkpoint and closes the log.
+func (kvs *PersistentStorageApp) Close() error {
+	close(kvs.done)
+	kvs.wg.Wait()
+
+	if err := kvs.checkpoint(); err != nil {
+		kvs.wal.close()
+		return err
 	}
+	return kvs.wal.close()
 }
 
-func (kvs *PersistentStorageApp) loadFromFile() error {
-	_, err := os.Stat(kvs.filePath)
-	if os.IsNotExist(err) {
-		// Create the file if it doesn't exist
-		_, err := os.Create(kvs.filePath)
-		if err != nil {
-			return err
+// apply applies a logged mutation to the in-memory map. The caller must hold the write lock
+// (or be the only user of kvs, as during recovery).
+func (kvs *PersistentStorageApp) apply(e walEntry) {
+	switch e.op {
+	case walOpBatch:
+		for _, b := range e.batch {
+			kvs.apply(b)
 		}
+		return
+	case walOpSet:
+		kvs.store.put(e.key, Version{Timestamp: e.ts, Value: e.value})
+	case walOpDelete:
+		kvs.store.put(e.key, Version{Timestamp: e.ts, Deleted: true})
 	}
+	kvs.pending++
+}
+
+// collectGarbage drops versions that have aged out of the retention window. The dropped
+// versions disappear from disk with the next checkpoint.
+func (kvs *PersistentStorageApp) collectGarbage() error {
+	kvs.dataMutex.Lock()
+	defer kvs.dataMutex.Unlock()
+
+	kvs.store.gc(kvs.pins.horizon(kvs.retention))
+	return nil
+}
 
-	data, err := ioutil.ReadFile(kvs.filePath)
+// Stats reports the number of stored records, write-ahead log activity and the filter of
+// present keys.
+func (kvs *PersistentStorageApp) Stats() *mydatabase.StorageStats {
+	kvs.dataMutex.RLock()
+	defer kvs.dataMutex.RUnlock()
+
+	stats := &mydatabase.StorageStats{
+		Backend:     "persistent",
+		RecordCount: int64(kvs.store.live),
+		Metrics: map[string]float64{
+			"wal_bytes_written":          float64(kvs.wal.bytesWritten()),
+			"mutations_since_checkpoint": float64(kvs.pending),
+			"versions_retained":          float64(kvs.store.retained),
+			"versions_collected":         float64(kvs.store.collected),
+		},
+	}
+	kvs.store.filterMetrics(stats.Metrics)
+	return stats
+}
+
+// checkpoint writes the current map to disk and drops the log segments it covers. The log is
+// rotated under the write lock so the snapshot and the new segment split history exactly.
+func (kvs *PersistentStorageApp) checkpoint() error {
+	kvs.dataMutex.Lock()
+	if kvs.pending == 0 {
+		kvs.dataMutex.Unlock()
+		return nil
+	}
+	segment, err := kvs.wal.rotate()
 	if err != nil {
+		kvs.dataMutex.Unlock()
 		return err
 	}
+	versions := kvs.store.snapshot()
+	kvs.pending = 0
+	kvs.dataMutex.Unlock()
 
-	if len(data) == 0 {
-		// File is empty, initialize kvs.data as an empty map
-		kvs.data = make(map[string]*mydatabase.DatabaseRecord)
-		return nil
+	if err := kvs.saveCheckpoint(&checkpoint{Segment: segment, Versions: versions}); err != nil {
+		return err
 	}
+	return kvs.wal.removeBefore(segment)
+}
 
-	err = json.Unmarshal(data, &kvs.data)
+// loadCheckpoint reads the checkpoint file, if any, into kvs.store and returns the first log
+// segment that still needs to be replayed.
+func (kvs *PersistentStorageApp) loadCheckpoint() (uint64, error) {
+	data, err := os.ReadFile(filepath.Join(kvs.dataDir, checkpointFileName))
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	return nil
+	var cp checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return 0, err
+	}
+	for key, versions := range cp.Versions {
+		for _, v := range versions {
+			kvs.store.put(key, v)
+		}
+	}
+	return cp.Segment, nil
 }
 
-func (kvs *PersistentStorageApp) saveToFile() error {
-	data, err := json.Marshal(kvs.data)
+// saveCheckpoint atomically replaces the checkpoint file.
+func (kvs *PersistentStorageApp) saveCheckpoint(cp *checkpoint) error {
+	data, err := json.Marshal(cp)
 	if err != nil {
 		return err
 	}
 
-	err = ioutil.WriteFile(kvs.filePath, data, 0644)
+	return writeFileAtomic(kvs.dataDir, checkpointFileName, data)
+}
+
+// runPeriodically calls fn every interval from a new goroutine until done is closed.
+func runPeriodically(done <-chan struct{}, wg *sync.WaitGroup, interval time.Duration, fn func() error) {
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := fn(); err != nil {
+					log.Println("Error in storage background task:", err)
+				}
+			}
+		}
+	}()
+}
+
+// writeFileAtomic replaces dir/name with data by writing a temporary file, fsyncing it
+// and renaming it into place.
+func writeFileAtomic(dir, name string, data []byte) error {
+	tmp, err := os.CreateTemp(dir, name+".tmp-*")
 	if err != nil {
 		return err
 	}
+	defer os.Remove(tmp.Name())
 
-	return nil
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(dir, name)); err != nil {
+		return err
+	}
+	return syncDir(dir)
+}
+
+// syncDir fsyncs a directory so that renames and newly created files inside it are durable.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
 }
@@ -0,0 +1,36 @@
+package applications
+
+import (
+	"testing"
+
+	"gitlab.cs.washington.edu/syslab/cse453-welp/proto/mydatabase"
+)
+
+func TestPinnedSnapshotOutlivesRetention(t *testing.T) {
+	s, err := NewPersistentStorageApp(StorageOptions{DataDir: t.TempDir(), SyncPolicy: SyncAlways})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	s.Set(&mydatabase.DatabaseRecord{Key: "a", Value: []byte("1")})
+	ts := s.Timestamp()
+	release := s.PinSnapshot(ts)
+	s.Set(&mydatabase.DatabaseRecord{Key: "a", Value: []byte("2")})
+
+	// with no retention window, only the pin keeps the first version
+	if err := s.collectGarbage(); err != nil {
+		t.Fatal(err)
+	}
+	if record, ok := s.GetAt("a", ts); !ok || string(record.Value) != "1" {
+		t.Fatalf("GetAt pinned snapshot = %v, %v, want 1", record, ok)
+	}
+
+	release()
+	if err := s.collectGarbage(); err != nil {
+		t.Fatal(err)
+	}
+	if record, ok := s.GetAt("a", ts); ok && string(record.Value) == "1" {
+		t.Fatal("released snapshot was not collected")
+	}
+}
@@ -0,0 +1,259 @@
+package applications
+
+import (
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+
+	"gitlab.cs.washington.edu/syslab/cse453-welp/proto/mydatabase"
+)
+
+var (
+	ErrTransactionConflict = errors.New("transaction: write-write conflict")
+	ErrTransactionNotFound = errors.New("transaction: not found, expired or already finished")
+)
+
+// transactionSweepInterval is how often expired transactions are aborted.
+const transactionSweepInterval = time.Second
+
+// transaction is an open transaction. Writes are buffered until commit.
+type transaction struct {
+	mu       sync.Mutex
+	snapshot int64  // storage timestamp the transaction reads at
+	release  func() // unpins the snapshot once the transaction is finished
+	writes   map[string]Mutation
+	started  time.Time
+	done     bool
+}
+
+// TransactionManager provides snapshot isolation on top of a Storage. Each transaction
+// reads the storage's versions as of the moment it began (plus its own writes) and buffers
+// its writes until commit, when they are applied in a single Storage.Apply. A commit fails
+// with ErrTransactionConflict if another transaction, or a plain write, committed a newer
+// version of one of the same keys after the snapshot was taken (first committer wins).
+type TransactionManager struct {
+	storage Storage
+	timeout time.Duration // lifetime after which a transaction is aborted; 0 disables expiry
+
+	// mu is held for writing while a commit is validated and applied, and for reading by
+	// plain writes, so that no write can slip in between the two.
+	mu     sync.RWMutex
+	active map[uint64]*transaction
+
+	commits   uint64
+	aborts    uint64
+	conflicts uint64
+	expired   uint64
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewTransactionManager creates a transaction manager for storage. Transactions open for
+// longer than timeout are aborted. Each pins its snapshot until it finishes, so snapshots
+// remain readable whatever the storage's version retention.
+func NewTransactionManager(storage Storage, timeout time.Duration) *TransactionManager {
+	m := &TransactionManager{
+		storage: storage,
+		timeout: timeout,
+		active:  make(map[uint64]*transaction),
+		done:    make(chan struct{}),
+	}
+	if timeout > 0 {
+		runPeriodically(m.done, &m.wg, transactionSweepInterval, m.sweep)
+	}
+	return m
+}
+
+// Begin starts a transaction and returns its id. The versions its snapshot reads are kept
+// until it commits, aborts or expires.
+func (m *TransactionManager) Begin() uint64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	id := rand.Uint64()
+	for id == 0 || m.active[id] != nil {
+		id = rand.Uint64()
+	}
+	snapshot := m.storage.Timestamp()
+	m.active[id] = &transaction{
+		snapshot: snapshot,
+		release:  m.storage.PinSnapshot(snapshot),
+		writes:   make(map[string]Mutation),
+		started:  time.Now(),
+	}
+	return id
+}
+
+// open returns the transaction with the given id, locked. The caller must unlock it.
+func (m *TransactionManager) open(id uint64) (*transaction, error) {
+	m.mu.RLock()
+	txn := m.active[id]
+	m.mu.RUnlock()
+	if txn == nil {
+		return nil, ErrTransactionNotFound
+	}
+
+	txn.mu.Lock()
+	if txn.done {
+		txn.mu.Unlock()
+		return nil, ErrTransactionNotFound
+	}
+	return txn, nil
+}
+
+// Get reads key as of the transaction's snapshot, reflecting the transaction's own writes.
+func (m *TransactionManager) Get(id uint64, key string) (*mydatabase.DatabaseRecord, bool, error) {
+	txn, err := m.open(id)
+	if err != nil {
+		return nil, false, err
+	}
+	write, written := txn.writes[key]
+	snapshot := txn.snapshot
+	txn.mu.Unlock()
+
+	if written {
+		if write.Delete {
+			return nil, false, nil
+		}
+		return &mydatabase.DatabaseRecord{Key: write.Key, Value: write.Value}, true, nil
+	}
+	record, ok := m.storage.GetAt(key, snapshot)
+	return record, ok, nil
+}
+
+// Write buffers a mutation in the transaction. It becomes visible to others on commit.
+func (m *TransactionManager) Write(id uint64, mutation Mutation) error {
+	txn, err := m.open(id)
+	if err != nil {
+		return err
+	}
+	defer txn.mu.Unlock()
+
+	txn.writes[mutation.Key] = mutation
+	return nil
+}
+
+// Commit validates the transaction against writes committed since its snapshot and, if
+// none of them touched a key it wrote, applies its writes atomically. The transaction is
+// finished either way; after ErrTransactionConflict the caller may retry from Begin.
+func (m *TransactionManager) Commit(id uint64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	txn := m.finish(id)
+	if txn == nil {
+		return ErrTransactionNotFound
+	}
+	// the versions committed since the snapshot are kept until they are checked
+	defer txn.release()
+
+	mutations := make([]Mutation, 0, len(txn.writes))
+	for key, mutation := range txn.writes {
+		conflict := false
+		err := m.storage.History(key, func(v Version) bool {
+			conflict = v.Timestamp > txn.snapshot
+			return false
+		})
+		if err != nil {
+			m.aborts++
+			return err
+		}
+		if conflict {
+			m.conflicts++
+			m.aborts++
+			return ErrTransactionConflict
+		}
+		mutations = append(mutations, mutation)
+	}
+	if len(mutations) > 0 {
+		if err := m.storage.Apply(mutations); err != nil {
+			m.aborts++
+			return err
+		}
+	}
+	m.commits++
+	return nil
+}
+
+// Abort discards the transaction and its buffered writes.
+func (m *TransactionManager) Abort(id uint64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	txn := m.finish(id)
+	if txn == nil {
+		return ErrTransactionNotFound
+	}
+	txn.release()
+	m.aborts++
+	return nil
+}
+
+// Apply commits mutations outside of any transaction. They still count as a commit, so
+// open transactions that wrote one of the same keys will fail to commit.
+func (m *TransactionManager) Apply(mutations []Mutation) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.storage.Apply(mutations)
+}
+
+// finish removes the transaction from the active set and marks it done, returning nil if
+// it was not active. The caller must hold mu for writing, and release the transaction's
+// snapshot once it no longer reads it.
+func (m *TransactionManager) finish(id uint64) *transaction {
+	txn := m.active[id]
+	if txn == nil {
+		return nil
+	}
+	delete(m.active, id)
+
+	txn.mu.Lock()
+	txn.done = true
+	txn.mu.Unlock()
+	return txn
+}
+
+// sweep aborts transactions that have been open for longer than the timeout.
+func (m *TransactionManager) sweep() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	for id, txn := range m.active {
+		if now.Sub(txn.started) > m.timeout {
+			m.finish(id).release()
+			m.expired++
+			m.aborts++
+		}
+	}
+	return nil
+}
+
+// Metrics reports transaction counters for inclusion in the storage stats.
+func (m *TransactionManager) Metrics() map[string]float64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return map[string]float64{
+		"transactions_active":   float64(len(m.active)),
+		"transaction_commits":   float64(m.commits),
+		"transaction_aborts":    float64(m.aborts),
+		"transaction_conflicts": float64(m.conflicts),
+		"transactions_expired":  float64(m.expired),
+	}
+}
+
+// Close stops the background sweep. Open transactions are discarded.
+func (m *TransactionManager) Close() {
+	close(m.done)
+	m.wg.Wait()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for id := range m.active {
+		m.finish(id).release()
+	}
+}
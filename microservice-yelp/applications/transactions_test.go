@@ -0,0 +1,192 @@
+package applications
+
+import (
+	"errors"
+	"testing"
+
+	"gitlab.cs.washington.edu/syslab/cse453-welp/proto/mydatabase"
+)
+
+// newTransactionManager returns a transaction manager over a fresh persistent store that
+// keeps no old versions unless they are pinned.
+func newTransactionManager(t *testing.T) (*TransactionManager, *PersistentStorageApp) {
+	t.Helper()
+	s, err := NewPersistentStorageApp(StorageOptions{DataDir: t.TempDir(), SyncPolicy: SyncNever})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := NewTransactionManager(s, 0)
+	t.Cleanup(func() {
+		m.Close()
+		s.Close()
+	})
+	return m, s
+}
+
+// txnValue reads key in transaction id, returning "" if it does not exist.
+func txnValue(t *testing.T, m *TransactionManager, id uint64, key string) string {
+	t.Helper()
+	record, ok, err := m.Get(id, key)
+	if err != nil {
+		t.Fatalf("Get(%q): %v", key, err)
+	}
+	if !ok {
+		return ""
+	}
+	return string(record.Value)
+}
+
+func TestTransactionWriteWriteConflict(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		other func(m *TransactionManager) error // commits a write of "a" after the transaction began
+	}{
+		{"transaction", func(m *TransactionManager) error {
+			id := m.Begin()
+			if err := m.Write(id, Mutation{Key: "a", Value: []byte("other")}); err != nil {
+				return err
+			}
+			return m.Commit(id)
+		}},
+		{"plain write", func(m *TransactionManager) error {
+			return m.Apply([]Mutation{{Key: "a", Value: []byte("other")}})
+		}},
+		{"plain delete", func(m *TransactionManager) error {
+			return m.Apply([]Mutation{{Key: "a", Delete: true}})
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			m, s := newTransactionManager(t)
+			s.Set(&mydatabase.DatabaseRecord{Key: "a", Value: []byte("0")})
+
+			id := m.Begin()
+			if err := m.Write(id, Mutation{Key: "a", Value: []byte("mine")}); err != nil {
+				t.Fatal(err)
+			}
+			if err := tc.other(m); err != nil {
+				t.Fatal(err)
+			}
+			if err := m.Commit(id); !errors.Is(err, ErrTransactionConflict) {
+				t.Fatalf("Commit = %v, want %v", err, ErrTransactionConflict)
+			}
+			if record, ok := s.Get("a"); ok && string(record.Value) == "mine" {
+				t.Fatal("the conflicting write was applied")
+			}
+		})
+	}
+}
+
+func TestTransactionDisjointWritesCommit(t *testing.T) {
+	m, s := newTransactionManager(t)
+
+	first, second := m.Begin(), m.Begin()
+	m.Write(first, Mutation{Key: "a", Value: []byte("1")})
+	m.Write(second, Mutation{Key: "b", Value: []byte("2")})
+	if err := m.Commit(first); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Commit(second); err != nil {
+		t.Fatalf("Commit of a disjoint write = %v", err)
+	}
+	for key, want := range map[string]string{"a": "1", "b": "2"} {
+		if record, ok := s.Get(key); !ok || string(record.Value) != want {
+			t.Fatalf("Get(%q) = %v, %v, want %s", key, record, ok, want)
+		}
+	}
+}
+
+func TestTransactionReadsItsSnapshotAndOwnWrites(t *testing.T) {
+	m, s := newTransactionManager(t)
+	s.Set(&mydatabase.DatabaseRecord{Key: "a", Value: []byte("0")})
+	s.Set(&mydatabase.DatabaseRecord{Key: "b", Value: []byte("0")})
+
+	id := m.Begin()
+	if err := m.Apply([]Mutation{{Key: "a", Value: []byte("committed later")}}); err != nil {
+		t.Fatal(err)
+	}
+	if got := txnValue(t, m, id, "a"); got != "0" {
+		t.Fatalf("read of a write committed after Begin = %q, want the snapshot's 0", got)
+	}
+
+	m.Write(id, Mutation{Key: "b", Value: []byte("mine")})
+	m.Write(id, Mutation{Key: "c", Value: []byte("new")})
+	if got := txnValue(t, m, id, "b"); got != "mine" {
+		t.Fatalf("read of an overwrite = %q, want mine", got)
+	}
+	if got := txnValue(t, m, id, "c"); got != "new" {
+		t.Fatalf("read of an insert = %q, want new", got)
+	}
+	m.Write(id, Mutation{Key: "b", Delete: true})
+	if got := txnValue(t, m, id, "b"); got != "" {
+		t.Fatalf("read of a deleted key = %q, want none", got)
+	}
+
+	// the writes stay invisible to others until the transaction commits
+	other := m.Begin()
+	if got := txnValue(t, m, other, "c"); got != "" {
+		t.Fatalf("other transaction read uncommitted %q", got)
+	}
+	m.Abort(other)
+	if err := m.Commit(id); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := s.Get("b"); ok {
+		t.Fatal("committed delete of b was not applied")
+	}
+	if record, ok := s.Get("c"); !ok || string(record.Value) != "new" {
+		t.Fatalf("Get(c) = %v, %v, want new", record, ok)
+	}
+}
+
+func TestTransactionAbortDiscardsWrites(t *testing.T) {
+	m, s := newTransactionManager(t)
+	s.Set(&mydatabase.DatabaseRecord{Key: "a", Value: []byte("0")})
+
+	id := m.Begin()
+	m.Write(id, Mutation{Key: "a", Value: []byte("1")})
+	m.Write(id, Mutation{Key: "b", Value: []byte("1")})
+	if err := m.Abort(id); err != nil {
+		t.Fatal(err)
+	}
+	if record, ok := s.Get("a"); !ok || string(record.Value) != "0" {
+		t.Fatalf("Get(a) = %v, %v, want 0", record, ok)
+	}
+	if _, ok := s.Get("b"); ok {
+		t.Fatal("aborted insert of b was applied")
+	}
+
+	// a finished transaction can no longer be used
+	if _, _, err := m.Get(id, "a"); !errors.Is(err, ErrTransactionNotFound) {
+		t.Fatalf("Get after Abort = %v, want %v", err, ErrTransactionNotFound)
+	}
+	if err := m.Write(id, Mutation{Key: "a", Value: []byte("2")}); !errors.Is(err, ErrTransactionNotFound) {
+		t.Fatalf("Write after Abort = %v, want %v", err, ErrTransactionNotFound)
+	}
+	if err := m.Commit(id); !errors.Is(err, ErrTransactionNotFound) {
+		t.Fatalf("Commit after Abort = %v, want %v", err, ErrTransactionNotFound)
+	}
+}
+
+func TestTransactionPinsItsSnapshot(t *testing.T) {
+	m, s := newTransactionManager(t)
+	s.Set(&mydatabase.DatabaseRecord{Key: "a", Value: []byte("0")})
+
+	id := m.Begin()
+	m.Apply([]Mutation{{Key: "a", Value: []byte("1")}})
+	// with no retention window, only the transaction's pin keeps the version it reads
+	if err := s.collectGarbage(); err != nil {
+		t.Fatal(err)
+	}
+	if got := txnValue(t, m, id, "a"); got != "0" {
+		t.Fatalf("read after garbage collection = %q, want the snapshot's 0", got)
+	}
+
+	ts := m.active[id].snapshot
+	m.Abort(id)
+	if err := s.collectGarbage(); err != nil {
+		t.Fatal(err)
+	}
+	if record, ok := s.GetAt("a", ts); ok && string(record.Value) == "0" {
+		t.Fatal("the snapshot of an aborted transaction was not released")
+	}
+}
@@ -0,0 +1,307 @@
+package applications
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gitlab.cs.washington.edu/syslab/cse453-welp/proto/mydatabase"
+)
+
+const (
+	// versionGCInterval is how often storage apps discard versions older than their retention window.
+	versionGCInterval = 30 * time.Second
+
+	// keyFilterCapacity is the number of live keys the filter of present keys starts with room for.
+	keyFilterCapacity = 1024
+)
+
+// Version is one timestamped version of a record. Timestamps are commit times in unix
+// nanoseconds, assigned by the storage app and strictly increasing across its writes.
+type Version struct {
+	Timestamp int64  `json:"ts"`
+	Value     []byte `json:"value,omitempty"`
+	Deleted   bool   `json:"deleted,omitempty"`
+}
+
+// record converts the version into the DatabaseRecord returned to clients.
+func (v Version) record(key string) *mydatabase.DatabaseRecord {
+	return &mydatabase.DatabaseRecord{Key: key, Value: v.Value, Timestamp: v.Timestamp}
+}
+
+// commitClock hands out commit timestamps that follow wall-clock time but never repeat or
+// go backwards, even if the system clock does. It is guarded by its owner's write lock.
+type commitClock struct {
+	last int64
+}
+
+func (c *commitClock) next() int64 {
+	ts := time.Now().UnixNano()
+	if ts <= c.last {
+		ts = c.last + 1
+	}
+	c.last = ts
+	return ts
+}
+
+// observe advances the clock past a timestamp recovered from disk.
+func (c *commitClock) observe(ts int64) {
+	if ts > c.last {
+		c.last = ts
+	}
+}
+
+// gcHorizon returns the timestamp below which only the newest version of a key needs to be
+// kept for a given retention window.
+func gcHorizon(retention time.Duration) int64 {
+	return time.Now().Add(-retention).UnixNano()
+}
+
+// snapshotPins holds garbage collection back for the snapshots being read past the
+// retention window, such as by a long backup. It is safe for concurrent use.
+type snapshotPins struct {
+	mu   sync.Mutex
+	pins map[int64]int // pinned timestamps, with how many readers pinned each
+}
+
+// pin keeps the versions current at ts until release is called.
+func (p *snapshotPins) pin(ts int64) (release func()) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.pins == nil {
+		p.pins = make(map[int64]int)
+	}
+	p.pins[ts]++
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			p.mu.Lock()
+			defer p.mu.Unlock()
+			if p.pins[ts]--; p.pins[ts] == 0 {
+				delete(p.pins, ts)
+			}
+		})
+	}
+}
+
+// horizon returns the gcHorizon for retention, held back to the oldest pinned snapshot.
+func (p *snapshotPins) horizon(retention time.Duration) int64 {
+	horizon := gcHorizon(retention)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for ts := range p.pins {
+		if ts < horizon {
+			horizon = ts
+		}
+	}
+	return horizon
+}
+
+// versionStore keeps the retained versions of every key in memory, oldest first. It backs
+// the map-based storage apps and is not safe for concurrent use.
+type versionStore struct {
+	versions map[string][]Version
+	keys     *keyIndex
+	clock    commitClock
+
+	live      int                 // keys whose newest version is not a deletion
+	stale     map[string]struct{} // keys holding versions that gc may be able to drop
+	retained  int                 // total number of versions held
+	collected uint64              // versions dropped by gc
+
+	// filter holds the live keys, so lookups of absent keys can skip the store
+	filter               *CuckooFilter
+	filterRebuilds       uint64
+	filterNegatives      uint64 // atomic
+	filterFalsePositives uint64 // atomic
+}
+
+func newVersionStore() *versionStore {
+	return &versionStore{
+		versions: make(map[string][]Version),
+		keys:     newKeyIndex(),
+		stale:    make(map[string]struct{}),
+		filter:   NewCuckooFilter(keyFilterCapacity),
+	}
+}
+
+// put records a new version of key. A version with the same timestamp as the newest one,
+// written by the same batch, replaces it.
+func (s *versionStore) put(key string, v Version) {
+	s.clock.observe(v.Timestamp)
+
+	versions := s.versions[key]
+	wasLive := len(versions) > 0 && !versions[len(versions)-1].Deleted
+	if wasLive {
+		s.live--
+	}
+	if n := len(versions); n > 0 && versions[n-1].Timestamp >= v.Timestamp {
+		// copy rather than overwrite in place, since snapshots may share the slice
+		versions = append(versions[:n-1:n-1], v)
+	} else {
+		versions = append(versions, v)
+		s.retained++
+	}
+	if !v.Deleted {
+		s.live++
+	}
+	if len(versions) == 1 {
+		s.keys.Insert(key)
+	}
+	if len(versions) > 1 || v.Deleted {
+		s.stale[key] = struct{}{}
+	}
+	s.versions[key] = versions
+
+	switch {
+	case !wasLive && !v.Deleted && !s.filter.Add(key):
+		s.rebuildFilter(2 * s.filter.Capacity())
+	case wasLive && v.Deleted:
+		s.filter.Remove(key)
+	}
+}
+
+// rebuildFilter replaces the filter of present keys with one of the given capacity holding
+// every live key, growing it further if they do not fit.
+func (s *versionStore) rebuildFilter(capacity int) {
+	s.filterRebuilds++
+	for {
+		s.filter = NewCuckooFilter(capacity)
+		full := false
+		for key, versions := range s.versions {
+			if !versions[len(versions)-1].Deleted && !s.filter.Add(key) {
+				full = true
+				break
+			}
+		}
+		if !full {
+			return
+		}
+		capacity *= 2
+	}
+}
+
+// mayContain reports whether key may be live. A false result is definitive, so the lookup
+// can skip the store; such lookups are counted.
+func (s *versionStore) mayContain(key string) bool {
+	if s.filter.MayContain(key) {
+		return true
+	}
+	atomic.AddUint64(&s.filterNegatives, 1)
+	return false
+}
+
+// getLatest returns the newest version of key if it is live, consulting the filter of
+// present keys first.
+func (s *versionStore) getLatest(key string) (*mydatabase.DatabaseRecord, bool) {
+	if !s.mayContain(key) {
+		return nil, false
+	}
+	record, ok := s.get(key, math.MaxInt64)
+	if !ok {
+		atomic.AddUint64(&s.filterFalsePositives, 1)
+	}
+	return record, ok
+}
+
+// filterMetrics adds the statistics of the filter of present keys to metrics. The observed
+// false-positive rate is the fraction of lookups of absent keys the filter let through.
+func (s *versionStore) filterMetrics(metrics map[string]float64) {
+	negatives := atomic.LoadUint64(&s.filterNegatives)
+	falsePositives := atomic.LoadUint64(&s.filterFalsePositives)
+	rate := 0.0
+	if negatives+falsePositives > 0 {
+		rate = float64(falsePositives) / float64(negatives+falsePositives)
+	}
+	metrics["filter_memory_bytes"] = float64(s.filter.SizeBytes())
+	metrics["filter_keys"] = float64(s.filter.Len())
+	metrics["filter_load_factor"] = float64(s.filter.Len()) / float64(s.filter.Capacity())
+	metrics["filter_rebuilds"] = float64(s.filterRebuilds)
+	metrics["filter_negatives"] = float64(negatives)
+	metrics["filter_false_positives"] = float64(falsePositives)
+	metrics["filter_false_positive_rate"] = rate
+	metrics["filter_expected_false_positive_rate"] = s.filter.FalsePositiveRate()
+}
+
+// get returns the newest version of key with a timestamp at or before ts.
+func (s *versionStore) get(key string, ts int64) (*mydatabase.DatabaseRecord, bool) {
+	versions := s.versions[key]
+	i := sort.Search(len(versions), func(i int) bool { return versions[i].Timestamp > ts })
+	if i == 0 || versions[i-1].Deleted {
+		return nil, false
+	}
+	return versions[i-1].record(key), true
+}
+
+// history calls fn for every retained version of key, newest first, until fn returns false.
+func (s *versionStore) history(key string, fn func(v Version) bool) {
+	versions := s.versions[key]
+	for i := len(versions) - 1; i >= 0; i-- {
+		if !fn(versions[i]) {
+			return
+		}
+	}
+}
+
+// scan calls fn with the newest version of every live key in the selected range, or the
+// version current at options.At if set.
+func (s *versionStore) scan(options ScanOptions, fn func(record *mydatabase.DatabaseRecord) bool) {
+	scanIndex(s.keys, options, func(key string) bool {
+		if options.At != 0 {
+			record, ok := s.get(key, options.At)
+			return !ok || fn(record)
+		}
+		versions := s.versions[key]
+		newest := versions[len(versions)-1]
+		if newest.Deleted {
+			return true
+		}
+		return fn(newest.record(key))
+	})
+}
+
+// gc drops the versions that are not needed to read at any timestamp after horizon: for
+// each key it keeps the versions newer than horizon plus the newest one at or before it,
+// unless that one is a deletion.
+func (s *versionStore) gc(horizon int64) {
+	for key := range s.stale {
+		versions := s.versions[key]
+		base := sort.Search(len(versions), func(i int) bool { return versions[i].Timestamp > horizon }) - 1
+		if base < 0 {
+			continue
+		}
+		if versions[base].Deleted {
+			base++
+		}
+		if base == 0 {
+			continue
+		}
+
+		s.retained -= base
+		s.collected += uint64(base)
+		versions = versions[base:]
+		if len(versions) == 0 {
+			delete(s.versions, key)
+			delete(s.stale, key)
+			s.keys.Remove(key)
+			continue
+		}
+		s.versions[key] = versions
+		if len(versions) == 1 && !versions[0].Deleted {
+			delete(s.stale, key)
+		}
+	}
+}
+
+// snapshot returns a copy of the version map that later writes and gc will not modify.
+func (s *versionStore) snapshot() map[string][]Version {
+	versions := make(map[string][]Version, len(s.versions))
+	for key, v := range s.versions {
+		// the slice's existing elements are never modified, only replaced or appended to
+		versions[key] = v[:len(v):len(v)]
+	}
+	return versions
+}
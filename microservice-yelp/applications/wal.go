@@ -0,0 +1,488 @@
+package applications
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var (
+	ErrInvalidSyncPolicy = errors.New("invalid fsync policy")
+	ErrCorruptLogEntry   = errors.New("wal: corrupt log entry")
+	ErrLogFailed         = errors.New("wal: log failed")
+)
+
+// SyncPolicy controls when the write-ahead log is flushed to stable storage.
+type SyncPolicy int
+
+const (
+	// SyncAlways fsyncs the log before every write is acknowledged.
+	SyncAlways SyncPolicy = iota
+	// SyncInterval fsyncs the log from a background goroutine on a fixed interval.
+	SyncInterval
+	// SyncNever leaves flushing to the operating system.
+	SyncNever
+)
+
+// ParseSyncPolicy converts a flag value (always, interval or never) into a SyncPolicy.
+func ParseSyncPolicy(policy string) (SyncPolicy, error) {
+	switch policy {
+	case "always":
+		return SyncAlways, nil
+	case "interval":
+		return SyncInterval, nil
+	case "never":
+		return SyncNever, nil
+	}
+	return SyncNever, ErrInvalidSyncPolicy
+}
+
+func (p SyncPolicy) String() string {
+	switch p {
+	case SyncAlways:
+		return "always"
+	case SyncInterval:
+		return "interval"
+	case SyncNever:
+		return "never"
+	}
+	return fmt.Sprintf("SyncPolicy(%d)", int(p))
+}
+
+type walOp byte
+
+const (
+	walOpSet    walOp = 1
+	walOpDelete walOp = 2
+	walOpBatch  walOp = 3
+)
+
+// walEntry is a single logged mutation, or for walOpBatch a group of mutations that are
+// replayed all together or not at all. The members of a batch share its timestamp.
+type walEntry struct {
+	op    walOp
+	ts    int64
+	key   string
+	value []byte
+	batch []walEntry
+}
+
+const (
+	walHeaderSize    = 8 // crc32 (4 bytes) + payload length (4 bytes)
+	walSegmentPrefix = "wal-"
+	walSegmentSuffix = ".log"
+)
+
+var walCRCTable = crc32.MakeTable(crc32.Castagnoli)
+
+// encodeWALEntry frames an entry as [crc32c][length][payload], where the payload is
+// op | uvarint(ts) | uvarint(len(key)) | key | value and the checksum covers the payload.
+// A batch payload is instead op | uvarint(ts) | uvarint(count) followed by count entries,
+// each encoded as op | uvarint(len(key)) | key | uvarint(len(value)) | value.
+func encodeWALEntry(e walEntry) []byte {
+	var scratch [binary.MaxVarintLen64]byte
+	payload := []byte{byte(e.op)}
+	n := binary.PutUvarint(scratch[:], uint64(e.ts))
+	payload = append(payload, scratch[:n]...)
+	if e.op == walOpBatch {
+		n = binary.PutUvarint(scratch[:], uint64(len(e.batch)))
+		payload = append(payload, scratch[:n]...)
+		for _, b := range e.batch {
+			payload = append(payload, byte(b.op))
+			n = binary.PutUvarint(scratch[:], uint64(len(b.key)))
+			payload = append(payload, scratch[:n]...)
+			payload = append(payload, b.key...)
+			n = binary.PutUvarint(scratch[:], uint64(len(b.value)))
+			payload = append(payload, scratch[:n]...)
+			payload = append(payload, b.value...)
+		}
+	} else {
+		n = binary.PutUvarint(scratch[:], uint64(len(e.key)))
+		payload = append(payload, scratch[:n]...)
+		payload = append(payload, e.key...)
+		payload = append(payload, e.value...)
+	}
+
+	buf := make([]byte, walHeaderSize+len(payload))
+	binary.LittleEndian.PutUint32(buf[0:4], crc32.Checksum(payload, walCRCTable))
+	binary.LittleEndian.PutUint32(buf[4:8], uint32(len(payload)))
+	copy(buf[walHeaderSize:], payload)
+	return buf
+}
+
+func decodeWALPayload(payload []byte) (walEntry, error) {
+	if len(payload) < 1 {
+		return walEntry{}, ErrCorruptLogEntry
+	}
+	op := walOp(payload[0])
+	t, n := binary.Uvarint(payload[1:])
+	if n <= 0 {
+		return walEntry{}, ErrCorruptLogEntry
+	}
+	ts := int64(t)
+	payload = payload[1+n:]
+	if op == walOpBatch {
+		return decodeWALBatch(ts, payload)
+	}
+	if op != walOpSet && op != walOpDelete {
+		return walEntry{}, ErrCorruptLogEntry
+	}
+	keyLen, n := binary.Uvarint(payload)
+	if n <= 0 || uint64(len(payload)-n) < keyLen {
+		return walEntry{}, ErrCorruptLogEntry
+	}
+	keyStart := n
+	keyEnd := keyStart + int(keyLen)
+	value := make([]byte, len(payload)-keyEnd)
+	copy(value, payload[keyEnd:])
+	return walEntry{
+		op:    op,
+		ts:    ts,
+		key:   string(payload[keyStart:keyEnd]),
+		value: value,
+	}, nil
+}
+
+func decodeWALBatch(ts int64, data []byte) (walEntry, error) {
+	count, n := binary.Uvarint(data)
+	if n <= 0 {
+		return walEntry{}, ErrCorruptLogEntry
+	}
+	data = data[n:]
+
+	// readBytes consumes a uvarint length and that many bytes from data
+	readBytes := func() ([]byte, bool) {
+		length, n := binary.Uvarint(data)
+		if n <= 0 || uint64(len(data)-n) < length {
+			return nil, false
+		}
+		b := data[n : n+int(length)]
+		data = data[n+int(length):]
+		return b, true
+	}
+
+	batch := walEntry{op: walOpBatch, ts: ts}
+	for i := uint64(0); i < count; i++ {
+		if len(data) < 1 {
+			return walEntry{}, ErrCorruptLogEntry
+		}
+		op := walOp(data[0])
+		if op != walOpSet && op != walOpDelete {
+			return walEntry{}, ErrCorruptLogEntry
+		}
+		data = data[1:]
+		key, ok := readBytes()
+		if !ok {
+			return walEntry{}, ErrCorruptLogEntry
+		}
+		value, ok := readBytes()
+		if !ok {
+			return walEntry{}, ErrCorruptLogEntry
+		}
+		batch.batch = append(batch.batch, walEntry{
+			op:    op,
+			ts:    ts,
+			key:   string(key),
+			value: append([]byte(nil), value...),
+		})
+	}
+	if len(data) != 0 {
+		return walEntry{}, ErrCorruptLogEntry
+	}
+	return batch, nil
+}
+
+// readWALEntry reads the next entry from r, which has remaining bytes left. It returns
+// io.EOF at a clean end of the log and io.ErrUnexpectedEOF or ErrCorruptLogEntry for a torn
+// or damaged entry. A length running past the bytes left is torn, and is not allocated.
+func readWALEntry(r io.Reader, remaining int64) (walEntry, int, error) {
+	var header [walHeaderSize]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return walEntry{}, 0, err
+	}
+	checksum := binary.LittleEndian.Uint32(header[0:4])
+	length := binary.LittleEndian.Uint32(header[4:8])
+	if int64(length) > remaining-walHeaderSize {
+		return walEntry{}, 0, io.ErrUnexpectedEOF
+	}
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return walEntry{}, 0, err
+	}
+	if crc32.Checksum(payload, walCRCTable) != checksum {
+		return walEntry{}, 0, ErrCorruptLogEntry
+	}
+	e, err := decodeWALPayload(payload)
+	return e, walHeaderSize + int(length), err
+}
+
+// walFile is the segment file a writeAheadLog appends to.
+type walFile interface {
+	io.Writer
+	Sync() error
+	Truncate(size int64) error
+	Close() error
+}
+
+// writeAheadLog is an append-only log split into numbered segment files. Segments
+// are rotated on checkpoint so that everything covered by a checkpoint can be removed.
+type writeAheadLog struct {
+	mu      sync.Mutex
+	dir     string
+	policy  SyncPolicy
+	file    walFile
+	size    int64 // bytes in the current segment, all of them complete entries
+	segment uint64
+	dirty   bool   // true if there are writes that have not been fsynced
+	written uint64 // bytes appended since the log was opened
+	// failed is set once the log can no longer promise that what it acknowledged is
+	// durable and complete, and fails every later append
+	failed error
+}
+
+func walSegmentName(segment uint64) string {
+	return fmt.Sprintf("%s%016d%s", walSegmentPrefix, segment, walSegmentSuffix)
+}
+
+// listWALSegments returns the segment numbers present in dir in ascending order.
+func listWALSegments(dir string) ([]uint64, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var segments []uint64
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, walSegmentPrefix) || !strings.HasSuffix(name, walSegmentSuffix) {
+			continue
+		}
+		var segment uint64
+		if _, err := fmt.Sscanf(strings.TrimSuffix(strings.TrimPrefix(name, walSegmentPrefix), walSegmentSuffix), "%d", &segment); err != nil {
+			continue
+		}
+		segments = append(segments, segment)
+	}
+	sort.Slice(segments, func(i, j int) bool { return segments[i] < segments[j] })
+	return segments, nil
+}
+
+// openWriteAheadLog replays every segment numbered fromSegment or later through apply and
+// then opens the newest segment for appending. A torn entry at the end of the newest segment
+// is the expected result of a crash mid-write, so the segment is truncated back to the last
+// complete entry; damage anywhere else is reported as an error.
+func openWriteAheadLog(dir string, policy SyncPolicy, fromSegment uint64, apply func(walEntry)) (*writeAheadLog, error) {
+	segments, err := listWALSegments(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &writeAheadLog{
+		dir:     dir,
+		policy:  policy,
+		segment: fromSegment,
+	}
+
+	replayed := 0
+	for i, segment := range segments {
+		if segment < fromSegment {
+			continue
+		}
+		last := i == len(segments)-1
+		n, err := w.replaySegment(segment, last, apply)
+		if err != nil {
+			return nil, err
+		}
+		replayed += n
+		w.segment = segment
+	}
+	if replayed > 0 {
+		log.Printf("wal: replayed %d entries from %s", replayed, dir)
+	}
+
+	if w.segment == 0 {
+		w.segment = 1
+	}
+	if err := w.openSegment(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// openSegment opens the current segment for appending.
+func (w *writeAheadLog) openSegment() error {
+	file, err := os.OpenFile(filepath.Join(w.dir, walSegmentName(w.segment)), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	w.file, w.size = file, info.Size()
+	return nil
+}
+
+func (w *writeAheadLog) replaySegment(segment uint64, last bool, apply func(walEntry)) (int, error) {
+	path := filepath.Join(w.dir, walSegmentName(segment))
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return 0, err
+	}
+
+	r := bufio.NewReader(file)
+	var offset int64
+	count := 0 // mutations replayed, counting each member of a batch
+	for {
+		e, size, err := readWALEntry(r, info.Size()-offset)
+		if err == io.EOF {
+			return count, nil
+		}
+		if err != nil {
+			if !last {
+				return count, fmt.Errorf("%w in %s at offset %d", ErrCorruptLogEntry, path, offset)
+			}
+			log.Printf("wal: truncating %s at offset %d after incomplete entry: %v", path, offset, err)
+			return count, os.Truncate(path, offset)
+		}
+		if e.op == walOpBatch {
+			for _, b := range e.batch {
+				apply(b)
+			}
+			count += len(e.batch)
+		} else {
+			apply(e)
+			count++
+		}
+		offset += int64(size)
+	}
+}
+
+// append writes an entry to the current segment, fsyncing it first if the policy requires.
+// A write that fails part way is cut off again, so that later entries follow the last
+// complete one rather than garbage recovery would stop at; if it cannot be, or an fsync
+// fails, the log fails every later append instead.
+func (w *writeAheadLog) append(e walEntry) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.failed != nil {
+		return w.failed
+	}
+	buf := encodeWALEntry(e)
+	if _, err := w.file.Write(buf); err != nil {
+		if terr := w.file.Truncate(w.size); terr != nil {
+			w.failed = fmt.Errorf("%w: truncating torn entry: %v", ErrLogFailed, terr)
+		}
+		return err
+	}
+	w.size += int64(len(buf))
+	w.written += uint64(len(buf))
+	if w.policy == SyncAlways {
+		return w.syncFile()
+	}
+	w.dirty = true
+	return nil
+}
+
+// sync fsyncs the current segment if it has unsynced writes.
+func (w *writeAheadLog) sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.failed != nil {
+		return w.failed
+	}
+	if !w.dirty {
+		return nil
+	}
+	w.dirty = false
+	return w.syncFile()
+}
+
+// syncFile fsyncs the current segment. A failed fsync may have dropped writes already
+// acknowledged, which retrying would not bring back, so it fails the log.
+func (w *writeAheadLog) syncFile() error {
+	if err := w.file.Sync(); err != nil {
+		w.failed = fmt.Errorf("%w: fsync: %v", ErrLogFailed, err)
+		return w.failed
+	}
+	return nil
+}
+
+// bytesWritten returns the number of bytes appended since the log was opened.
+func (w *writeAheadLog) bytesWritten() uint64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.written
+}
+
+// rotate closes the current segment and starts a new one, returning the new segment's number.
+// Every entry appended before rotate returns lives in a segment older than the returned one.
+func (w *writeAheadLog) rotate() (uint64, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.failed != nil {
+		return 0, w.failed
+	}
+	if err := w.syncFile(); err != nil {
+		return 0, err
+	}
+	if err := w.file.Close(); err != nil {
+		return 0, err
+	}
+	w.dirty = false
+	w.segment++
+	if err := w.openSegment(); err != nil {
+		return 0, err
+	}
+	return w.segment, nil
+}
+
+// removeBefore deletes every segment older than the given segment number.
+func (w *writeAheadLog) removeBefore(segment uint64) error {
+	segments, err := listWALSegments(w.dir)
+	if err != nil {
+		return err
+	}
+	for _, s := range segments {
+		if s >= segment {
+			break
+		}
+		if err := os.Remove(filepath.Join(w.dir, walSegmentName(s))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// close fsyncs and closes the current segment.
+func (w *writeAheadLog) close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.file.Sync(); err != nil {
+		w.file.Close()
+		return err
+	}
+	return w.file.Close()
+}
@@ -0,0 +1,245 @@
+package applications
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"gitlab.cs.washington.edu/syslab/cse453-welp/proto/mydatabase"
+)
+
+// replayWAL opens the log in dir, returning it and the mutations it replayed.
+func replayWAL(t *testing.T, dir string) (*writeAheadLog, []walEntry) {
+	t.Helper()
+	var replayed []walEntry
+	w, err := openWriteAheadLog(dir, SyncAlways, 0, func(e walEntry) { replayed = append(replayed, e) })
+	if err != nil {
+		t.Fatalf("openWriteAheadLog: %v", err)
+	}
+	return w, replayed
+}
+
+func appendWAL(t *testing.T, w *writeAheadLog, entries ...walEntry) {
+	t.Helper()
+	for _, e := range entries {
+		if err := w.append(e); err != nil {
+			t.Fatalf("append: %v", err)
+		}
+	}
+}
+
+func walKeys(entries []walEntry) []string {
+	var keys []string
+	for _, e := range entries {
+		keys = append(keys, e.key)
+	}
+	return keys
+}
+
+func walSegmentPath(dir string, segment uint64) string {
+	return filepath.Join(dir, walSegmentName(segment))
+}
+
+func TestWALReplaysEntries(t *testing.T) {
+	dir := t.TempDir()
+	w, _ := replayWAL(t, dir)
+	appendWAL(t, w,
+		walEntry{op: walOpSet, ts: 1, key: "a", value: []byte("1")},
+		walEntry{op: walOpDelete, ts: 2, key: "b"},
+		batchWALEntry(3, []Mutation{{Key: "c", Value: []byte("3")}, {Key: "d", Delete: true}}),
+	)
+	if err := w.close(); err != nil {
+		t.Fatal(err)
+	}
+
+	w, replayed := replayWAL(t, dir)
+	defer w.close()
+	want := []string{"1 1 a 1", "2 2 b ", "1 3 c 3", "2 3 d "}
+	var got []string
+	for _, e := range replayed {
+		got = append(got, fmt.Sprintf("%d %d %s %s", e.op, e.ts, e.key, e.value))
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("replayed %q, want %q", got, want)
+	}
+}
+
+func TestWALTruncatesTornTail(t *testing.T) {
+	dir := t.TempDir()
+	w, _ := replayWAL(t, dir)
+	appendWAL(t, w, walEntry{op: walOpSet, key: "a"}, walEntry{op: walOpSet, key: "b"})
+	w.close()
+
+	path := walSegmentPath(dir, 1)
+	info, _ := os.Stat(path)
+	torn := encodeWALEntry(walEntry{op: walOpSet, key: "c", value: []byte("lost")})
+	f, _ := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	f.Write(torn[:len(torn)/2])
+	f.Close()
+
+	w, replayed := replayWAL(t, dir)
+	if got := walKeys(replayed); !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Fatalf("replayed %v, want [a b]", got)
+	}
+	if after, _ := os.Stat(path); after.Size() != info.Size() {
+		t.Fatalf("segment is %d bytes after recovery, want %d", after.Size(), info.Size())
+	}
+
+	// entries appended after recovery follow the last complete one
+	appendWAL(t, w, walEntry{op: walOpSet, key: "d"})
+	w.close()
+	w, replayed = replayWAL(t, dir)
+	defer w.close()
+	if got := walKeys(replayed); !reflect.DeepEqual(got, []string{"a", "b", "d"}) {
+		t.Fatalf("replayed %v, want [a b d]", got)
+	}
+}
+
+func TestWALTreatsOversizedLengthAsTorn(t *testing.T) {
+	dir := t.TempDir()
+	w, _ := replayWAL(t, dir)
+	appendWAL(t, w, walEntry{op: walOpSet, key: "a"})
+	w.close()
+
+	// a header claiming a 4 GiB entry, as a damaged length would
+	var header [walHeaderSize]byte
+	binary.LittleEndian.PutUint32(header[4:8], 0xFFFFFFFF)
+	f, _ := os.OpenFile(walSegmentPath(dir, 1), os.O_WRONLY|os.O_APPEND, 0644)
+	f.Write(header[:])
+	f.Write([]byte("short"))
+	f.Close()
+
+	w, replayed := replayWAL(t, dir)
+	defer w.close()
+	if got := walKeys(replayed); !reflect.DeepEqual(got, []string{"a"}) {
+		t.Fatalf("replayed %v, want [a]", got)
+	}
+}
+
+func TestWALRejectsDamageBeforeTheLastSegment(t *testing.T) {
+	dir := t.TempDir()
+	w, _ := replayWAL(t, dir)
+	appendWAL(t, w, walEntry{op: walOpSet, key: "a", value: []byte("value")})
+	if _, err := w.rotate(); err != nil {
+		t.Fatal(err)
+	}
+	appendWAL(t, w, walEntry{op: walOpSet, key: "b"})
+	w.close()
+
+	path := walSegmentPath(dir, 1)
+	data, _ := os.ReadFile(path)
+	data[len(data)-1] ^= 0xFF
+	os.WriteFile(path, data, 0644)
+
+	_, err := openWriteAheadLog(dir, SyncAlways, 0, func(walEntry) {})
+	if !errors.Is(err, ErrCorruptLogEntry) {
+		t.Fatalf("openWriteAheadLog returned %v, want %v", err, ErrCorruptLogEntry)
+	}
+}
+
+// faultyFile fails the next write after writing half of it, or every fsync.
+type faultyFile struct {
+	*os.File
+	shortWrite bool
+	failSync   bool
+}
+
+var errInjected = errors.New("injected failure")
+
+func (f *faultyFile) Write(p []byte) (int, error) {
+	if f.shortWrite {
+		f.shortWrite = false
+		n, _ := f.File.Write(p[:len(p)/2])
+		return n, errInjected
+	}
+	return f.File.Write(p)
+}
+
+func (f *faultyFile) Sync() error {
+	if f.failSync {
+		return errInjected
+	}
+	return f.File.Sync()
+}
+
+func TestWALCutsOffFailedAppend(t *testing.T) {
+	dir := t.TempDir()
+	w, _ := replayWAL(t, dir)
+	appendWAL(t, w, walEntry{op: walOpSet, key: "a"})
+
+	file := &faultyFile{File: w.file.(*os.File), shortWrite: true}
+	w.file = file
+	if err := w.append(walEntry{op: walOpSet, key: "torn", value: []byte("garbage")}); err == nil {
+		t.Fatal("append succeeded despite a failed write")
+	}
+	appendWAL(t, w, walEntry{op: walOpSet, key: "b"})
+	w.close()
+
+	w, replayed := replayWAL(t, dir)
+	defer w.close()
+	if got := walKeys(replayed); !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Fatalf("replayed %v, want [a b]", got)
+	}
+}
+
+func TestWALFailsAfterFailedFsync(t *testing.T) {
+	dir := t.TempDir()
+	w, _ := replayWAL(t, dir)
+	file := &faultyFile{File: w.file.(*os.File), failSync: true}
+	w.file = file
+	if err := w.append(walEntry{op: walOpSet, key: "a"}); !errors.Is(err, ErrLogFailed) {
+		t.Fatalf("append returned %v, want %v", err, ErrLogFailed)
+	}
+	file.failSync = false
+	if err := w.append(walEntry{op: walOpSet, key: "b"}); !errors.Is(err, ErrLogFailed) {
+		t.Fatalf("append after a failed fsync returned %v, want %v", err, ErrLogFailed)
+	}
+	w.close()
+}
+
+func TestPersistentStorageRecoversFromLog(t *testing.T) {
+	dir := t.TempDir()
+	options := StorageOptions{DataDir: dir, SyncPolicy: SyncAlways}
+	s, err := NewPersistentStorageApp(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Set(&mydatabase.DatabaseRecord{Key: "a", Value: []byte("1")})
+	s.Set(&mydatabase.DatabaseRecord{Key: "b", Value: []byte("2")})
+	s.Delete("a")
+	// a checkpoint covers the writes so far, and the log the rest
+	if err := s.checkpoint(); err != nil {
+		t.Fatal(err)
+	}
+	s.Set(&mydatabase.DatabaseRecord{Key: "c", Value: []byte("3")})
+	s.Set(&mydatabase.DatabaseRecord{Key: "d", Value: []byte("4")})
+
+	// crash without closing, tearing the last write
+	close(s.done)
+	s.wg.Wait()
+	segment := s.wal.segment
+	s.wal.file.Close()
+	path := walSegmentPath(dir, segment)
+	info, _ := os.Stat(path)
+	os.Truncate(path, info.Size()-3)
+
+	recovered, err := NewPersistentStorageApp(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer recovered.Close()
+	for key, want := range map[string]string{"b": "2", "c": "3"} {
+		if record, ok := recovered.Get(key); !ok || string(record.Value) != want {
+			t.Errorf("Get(%q) = %v, %v, want %q", key, record, ok, want)
+		}
+	}
+	for _, key := range []string{"a", "d"} {
+		if record, ok := recovered.Get(key); ok {
+			t.Errorf("Get(%q) = %v, want no record", key, record)
+		}
+	}
+}
@@ -4,7 +4,10 @@ import (
 	"flag"
 	"log"
 	"os"
+	"strings"
+	"time"
 
+	apps "gitlab.cs.washington.edu/syslab/cse453-welp/applications"
 	services "gitlab.cs.washington.edu/syslab/cse453-welp/services"
 )
 
@@ -60,6 +63,24 @@ func main() {
 		// database for each replica
 		databasePort1           = flag.Int("databaseport1", 27017, "port used by all databases-1")
 		storageDeviceType       = flag.String("storage_device_type", "ssd", "specifies emulated storage device type, e.g. option `ssd` or `disk`")
+		storageBackend          = flag.String("storage_backend", "emulated", "specifies the storage backend, e.g. option `emulated`, `persistent` or `lsm`")
+		storageDataDir          = flag.String("storage_data_dir", "/var/lib/data", "directory holding checkpoints and write-ahead logs of persistent storage")
+		storageSyncPolicy       = flag.String("storage_fsync", "interval", "write-ahead log fsync policy, e.g. option `always`, `interval` or `never`")
+		storageSyncInterval     = flag.Duration("storage_fsync_interval", 100*time.Millisecond, "how often the write-ahead log is fsynced under the `interval` policy")
+		storageCheckpointPeriod = flag.Duration("storage_checkpoint_interval", time.Minute, "how often persistent storage is checkpointed and its write-ahead log trimmed")
+		storageRetention        = flag.Duration("storage_version_retention", 0, "how long overwritten and deleted record versions remain readable, for record history and reads at a snapshot (open transactions keep the versions they read, whatever the retention); every version written in that time is kept, in memory for the emulated and persistent backends")
+		groupCommitSize         = flag.Int("storage_group_commit_size", 64, "most concurrent writes committed together with a single device access or fsync; 1 commits every write alone")
+		groupCommitWindow       = flag.Duration("storage_group_commit_window", 0, "how long the first write of a group commit waits for others to join it; 0 groups only writes that arrive during the previous commit")
+		raftID                  = flag.String("raft_id", "", "address other members of the database's raft group reach it at; empty runs a standalone database")
+		raftPeers               = flag.String("raft_peers", "", "comma separated addresses of the initial members of the database's raft group, including raft_id")
+		raftElectionTimeout     = flag.Duration("raft_election_timeout", time.Second, "how long raft followers wait to hear from a leader before electing a new one")
+		raftSnapshotThreshold   = flag.Uint64("raft_snapshot_threshold", 10000, "number of writes after which the raft log is compacted into a snapshot")
+		antiEntropyPeers        = flag.String("anti_entropy_peers", "", "comma separated addresses of the other databases holding the same records, to repair records from; empty disables anti-entropy")
+		antiEntropyInterval     = flag.Duration("anti_entropy_interval", time.Minute, "time between anti-entropy rounds, each comparing the database with every peer")
+		changeLog               = flag.Bool("changelog", false, "record the changes applied to the database for StreamChanges subscribers, at the cost of serializing writes and reading each record's old value")
+		changeLogEvents         = flag.Int("changelog_max_events", 10000, "number of recent changes the database buffers for StreamChanges subscribers to resume from")
+		changeLogRetention      = flag.Duration("changelog_retention", time.Hour, "how long the database buffers a change for StreamChanges subscribers; 0 keeps changes until changelog_max_events evicts them")
+		maxConcurrent           = flag.Int("database_max_concurrent", 0, "number of requests a database serves at once, others queuing by priority class and deadline; 0 serves every request at once")
 		detailDatabaseAddr1     = flag.String("detail_mydatabase_addr1", "mydatabase-detail-1:27017", "details-1 mydatabase address")
 		reviewDatabaseAddr1     = flag.String("review_mydatabase_addr1", "mydatabase-review-1:27017", "review-1 mydatabase address")
 		reservationDatabaseAddr = flag.String("reservation_mydatabase_addr", "mydatabase-reservation:27017", "reservation mydatabase address")
@@ -71,13 +92,88 @@ func main() {
 		databasePort3       = flag.Int("databaseport3", 27019, "port used by all databases-3")
 		detailDatabaseAddr3 = flag.String("detail_mydatabase_addr3", "mydatabase-detail-3:27019", "details-3 mydatabase address")
 		reviewDatabaseAddr3 = flag.String("review_mydatabase_addr3", "mydatabase-review-3:27019", "review-3 mydatabase address")
+
+		databaseQuorum = flag.Bool("database_quorum", false, "replicate detail and review records across all three of the service's databases with quorum reads and writes")
+		quorumW        = flag.Int("quorum_w", 2, "number of databases that must acknowledge a quorum write")
+		quorumR        = flag.Int("quorum_r", 2, "number of databases that must answer a quorum read")
+		quorumSiblings = flag.Bool("quorum_siblings", false, "return conflicting versions of a record written concurrently instead of only the last one written")
+
+		databaseNamespaces = flag.Bool("database_namespaces", false, "keep detail, review and reservation records in namespaces of their own, so that one database can host all of them")
+		databaseQuotas     = flag.String("database_quotas", "", "comma separated per-namespace quotas of the form namespace=records:bytes, e.g. `detail=10000:0,review=0:1073741824`; 0 is unlimited")
 	)
 
+	// The command and its component come first, followed by the flags, as in
+	// `detail-1 database-1 -storage_backend=persistent`; flag.Parse would stop at the command.
+	args := os.Args[1:]
+	for len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		args = args[1:]
+	}
+	positional := os.Args[1 : len(os.Args)-len(args)]
+
 	// Parse the flags
-	flag.Parse()
+	flag.CommandLine.Parse(args)
+	if len(positional) == 0 {
+		log.Fatalf("usage: %s <cmd> [subcmd] [flags]", os.Args[0])
+	}
+
+	syncPolicy, err := apps.ParseSyncPolicy(*storageSyncPolicy)
+	if err != nil {
+		log.Fatalf("invalid -storage_fsync %q: %v", *storageSyncPolicy, err)
+	}
+	storageOptions := apps.StorageOptions{
+		Backend:            *storageBackend,
+		DeviceType:         *storageDeviceType,
+		DataDir:            *storageDataDir,
+		SyncPolicy:         syncPolicy,
+		SyncInterval:       *storageSyncInterval,
+		CheckpointInterval: *storageCheckpointPeriod,
+		VersionRetention:   *storageRetention,
+		GroupCommitSize:    *groupCommitSize,
+		GroupCommitWindow:  *groupCommitWindow,
+	}
+	replicationOptions := services.ReplicationOptions{
+		ID:                *raftID,
+		ElectionTimeout:   *raftElectionTimeout,
+		SnapshotThreshold: *raftSnapshotThreshold,
+	}
+	if *raftPeers != "" {
+		replicationOptions.Peers = strings.Split(*raftPeers, ",")
+	}
+	antiEntropyOptions := services.AntiEntropyOptions{
+		Interval: *antiEntropyInterval,
+	}
+	if *antiEntropyPeers != "" {
+		antiEntropyOptions.Peers = strings.Split(*antiEntropyPeers, ",")
+	}
+	schedulerOptions := services.SchedulerOptions{
+		MaxConcurrent: *maxConcurrent,
+	}
+	changeLogOptions := apps.ChangeLogOptions{
+		Enabled:   *changeLog,
+		MaxEvents: *changeLogEvents,
+		Retention: *changeLogRetention,
+	}
+
+	namespaceQuotas, err := apps.ParseNamespaceQuotas(*databaseQuotas)
+	if err != nil {
+		log.Fatalf("invalid -database_quotas %q: %v", *databaseQuotas, err)
+	}
+	var detailNamespace, reviewNamespace, reservationNamespace string
+	if *databaseNamespaces {
+		detailNamespace, reviewNamespace, reservationNamespace = "detail", "review", "reservation"
+	}
+
+	detailQuorum := services.QuorumOptions{W: *quorumW, R: *quorumR, Siblings: *quorumSiblings}
+	reviewQuorum := detailQuorum
+	if *databaseQuorum {
+		detailQuorum.Replicas = []string{*detailDatabaseAddr1, *detailDatabaseAddr2, *detailDatabaseAddr3}
+		reviewQuorum.Replicas = []string{*reviewDatabaseAddr1, *reviewDatabaseAddr2, *reviewDatabaseAddr3}
+	}
+	// the review and reservation services look up the restaurants named without their ID
+	detailAddrs := []string{*detailAddr1, *detailAddr2, *detailAddr3}
 
 	var srv server
-	var cmd = os.Args[1]
+	var cmd = positional[0]
 
 	// Switch statement to create the correct service based on the command
 	switch cmd {
@@ -97,179 +193,252 @@ func main() {
 		)
 	case "detail-1":
 		switch {
-		case len(os.Args) < 3:
+		case len(positional) < 2:
 			// Create a new detail service with the specified port
 			srv = services.NewDetail(
 				"detail-1",
 				*detailPort1,
 				*detailCacheAddr1,
 				*detailDatabaseAddr1,
+				detailNamespace,
+				detailQuorum,
 			)
-		case os.Args[2] == "cache-1":
+		case positional[1] == "cache-1":
 			srv = services.NewMyCache(
 				"detail-1-cache",
 				*cachePort1,
 				*detailCacheCapacity,
 			)
-		case os.Args[2] == "database-1":
+		case positional[1] == "database-1":
 			srv = services.NewMyDatabase(
 				"detail-1-database",
 				*databasePort1,
-				*storageDeviceType,
+				storageOptions,
+				replicationOptions,
+				antiEntropyOptions,
+				changeLogOptions,
+				schedulerOptions,
+				namespaceQuotas,
+				services.DetailIndexes(detailNamespace)...,
 			)
 		default:
-			log.Fatalf("unknown subcmd for detail service: %s", os.Args[2])
+			log.Fatalf("unknown subcmd for detail service: %s", positional[1])
 		}
 	case "detail-2":
 		switch {
-		case len(os.Args) < 3:
+		case len(positional) < 2:
 			// Create a new detail service with the specified port
 			srv = services.NewDetail(
 				"detail-2",
 				*detailPort2,
 				*detailCacheAddr2,
 				*detailDatabaseAddr2,
+				detailNamespace,
+				detailQuorum,
 			)
-		case os.Args[2] == "cache-2":
+		case positional[1] == "cache-2":
 			srv = services.NewMyCache(
 				"detail-2-cache",
 				*cachePort2,
 				*detailCacheCapacity,
 			)
-		case os.Args[2] == "database-2":
+		case positional[1] == "database-2":
 			srv = services.NewMyDatabase(
 				"detail-2-database",
 				*databasePort2,
-				*storageDeviceType,
+				storageOptions,
+				replicationOptions,
+				antiEntropyOptions,
+				changeLogOptions,
+				schedulerOptions,
+				namespaceQuotas,
+				services.DetailIndexes(detailNamespace)...,
 			)
 		default:
-			log.Fatalf("unknown subcmd for detail service: %s", os.Args[2])
+			log.Fatalf("unknown subcmd for detail service: %s", positional[1])
 		}
 	case "detail-3":
 		switch {
-		case len(os.Args) < 3:
+		case len(positional) < 2:
 			// Create a new detail service with the specified port
 			srv = services.NewDetail(
 				"detail-3",
 				*detailPort3,
 				*detailCacheAddr3,
 				*detailDatabaseAddr3,
+				detailNamespace,
+				detailQuorum,
 			)
-		case os.Args[2] == "cache-3":
+		case positional[1] == "cache-3":
 			srv = services.NewMyCache(
 				"detail-3-cache",
 				*cachePort3,
 				*detailCacheCapacity,
 			)
-		case os.Args[2] == "database-3":
+		case positional[1] == "database-3":
 			srv = services.NewMyDatabase(
 				"detail-3-database",
 				*databasePort3,
-				*storageDeviceType,
+				storageOptions,
+				replicationOptions,
+				antiEntropyOptions,
+				changeLogOptions,
+				schedulerOptions,
+				namespaceQuotas,
+				services.DetailIndexes(detailNamespace)...,
 			)
 		default:
-			log.Fatalf("unknown subcmd for detail service: %s", os.Args[2])
+			log.Fatalf("unknown subcmd for detail service: %s", positional[1])
 		}
 	case "reservation":
 		switch {
-		case len(os.Args) < 3:
+		case len(positional) < 2:
 			// Create a new reservation service with the specified port
 			srv = services.NewReservation(
 				"reservation",
 				*reservationsPort,
 				*reservationCacheAddr,
 				*reservationDatabaseAddr,
+				reservationNamespace,
+				detailAddrs,
 			)
-		case os.Args[2] == "cache":
+		case positional[1] == "cache":
 			srv = services.NewMyCache(
 				"reservation-cache",
 				*cachePort1,
 				*reservationCacheCapacity,
 			)
-		case os.Args[2] == "database":
+		case positional[1] == "database":
 			srv = services.NewMyDatabase(
 				"reservation-database",
 				*databasePort1,
-				*storageDeviceType,
+				storageOptions,
+				replicationOptions,
+				antiEntropyOptions,
+				changeLogOptions,
+				schedulerOptions,
+				namespaceQuotas,
 			)
 		default:
-			log.Fatalf("unknown subcmd for reservation service: %s", os.Args[2])
+			log.Fatalf("unknown subcmd for reservation service: %s", positional[1])
 		}
 	case "review-1":
 		switch {
-		case len(os.Args) < 3:
+		case len(positional) < 2:
 			// Create a new review service with the specified port
 			srv = services.NewReview(
 				"review-1",
 				*reviewPort1,
 				*reviewCacheAddr1,
 				*reviewDatabaseAddr1,
+				reviewNamespace,
+				reviewQuorum,
+				detailAddrs,
 			)
-		case os.Args[2] == "cache-1":
+		case positional[1] == "cache-1":
 			srv = services.NewMyCache(
 				"review-1-cache",
 				*cachePort1,
 				*reviewCacheCapacity,
 			)
-		case os.Args[2] == "database-1":
+		case positional[1] == "database-1":
 			srv = services.NewMyDatabase(
 				"review-1-database",
 				*databasePort1,
-				*storageDeviceType,
+				storageOptions,
+				replicationOptions,
+				antiEntropyOptions,
+				changeLogOptions,
+				schedulerOptions,
+				namespaceQuotas,
 			)
 		default:
-			log.Fatalf("unknown subcmd for review service: %s", os.Args[2])
+			log.Fatalf("unknown subcmd for review service: %s", positional[1])
 		}
 	case "review-2":
 		switch {
-		case len(os.Args) < 3:
+		case len(positional) < 2:
 			// Create a new review service with the specified port
 			srv = services.NewReview(
 				"review-2",
 				*reviewPort2,
 				*reviewCacheAddr2,
 				*reviewDatabaseAddr2,
+				reviewNamespace,
+				reviewQuorum,
+				detailAddrs,
 			)
-		case os.Args[2] == "cache-2":
+		case positional[1] == "cache-2":
 			srv = services.NewMyCache(
 				"review-2-cache",
 				*cachePort2,
 				*reviewCacheCapacity,
 			)
-		case os.Args[2] == "database-2":
+		case positional[1] == "database-2":
 			srv = services.NewMyDatabase(
 				"review-2-database",
 				*databasePort2,
-				*storageDeviceType,
+				storageOptions,
+				replicationOptions,
+				antiEntropyOptions,
+				changeLogOptions,
+				schedulerOptions,
+				namespaceQuotas,
 			)
 		default:
-			log.Fatalf("unknown subcmd for review service: %s", os.Args[2])
+			log.Fatalf("unknown subcmd for review service: %s", positional[1])
 		}
 	case "review-3":
 		switch {
-		case len(os.Args) < 3:
+		case len(positional) < 2:
 			// Create a new review service with the specified port
 			srv = services.NewReview(
 				"review-3",
 				*reviewPort3,
 				*reviewCacheAddr3,
 				*reviewDatabaseAddr3,
+				reviewNamespace,
+				reviewQuorum,
+				detailAddrs,
 			)
-		case os.Args[2] == "cache-3":
+		case positional[1] == "cache-3":
 			srv = services.NewMyCache(
 				"review-3-cache",
 				*cachePort3,
 				*reviewCacheCapacity,
 			)
-		case os.Args[2] == "database-3":
+		case positional[1] == "database-3":
 			srv = services.NewMyDatabase(
 				"review-3-database",
 				*databasePort3,
-				*storageDeviceType,
+				storageOptions,
+				replicationOptions,
+				antiEntropyOptions,
+				changeLogOptions,
+				schedulerOptions,
+				namespaceQuotas,
 			)
 		default:
-			log.Fatalf("unknown subcmd for review service: %s", os.Args[2])
+			log.Fatalf("unknown subcmd for review service: %s", positional[1])
 		}
+	case "backup", "restore", "export", "import":
+		// Database tools run against a live database server and exit
+		runDatabaseTool(cmd, positional[1:])
+		return
+	case "raft-add", "raft-remove":
+		// Membership changes run against a live member of a database's raft group and exit
+		runRaftTool(cmd, positional[1:])
+		return
+	case "migrate-ids":
+		// The migration runs against the services' databases and exits
+		runMigrateTool(
+			services.MigrationShards("migrate-ids-detail", []string{*detailDatabaseAddr1, *detailDatabaseAddr2, *detailDatabaseAddr3}, detailNamespace, detailQuorum),
+			services.MigrationShards("migrate-ids-review", []string{*reviewDatabaseAddr1, *reviewDatabaseAddr2, *reviewDatabaseAddr3}, reviewNamespace, reviewQuorum),
+			services.MigrationShards("migrate-ids-review-records", []string{*reviewDatabaseAddr1, *reviewDatabaseAddr2, *reviewDatabaseAddr3}, services.ReviewRecordNamespace(reviewNamespace), reviewQuorum),
+			services.MigrationShards("migrate-ids-review-index", []string{*reviewDatabaseAddr1, *reviewDatabaseAddr2, *reviewDatabaseAddr3}, services.ReviewIndexNamespace(reviewNamespace), reviewQuorum),
+			services.MigrationShards("migrate-ids-reservation", []string{*reservationDatabaseAddr}, reservationNamespace, services.QuorumOptions{}),
+		)
+		return
 	default:
 		// If an unknown command is provided, log an error and exit
 		log.Fatalf("unknown cmd: %s", cmd)
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	"gitlab.cs.washington.edu/syslab/cse453-welp/proto/mydatabase"
+	services "gitlab.cs.washington.edu/syslab/cse453-welp/services"
+)
+
+// runDatabaseTool runs one of the database tools:
+//
+//	backup <database address> <file>                      write a backup file
+//	restore <database address> <file> [replace|merge]      restore from a backup file
+//	export <database address> <file>                       write the records as NDJSON
+//	import <database address> <file> [replace|merge]       restore from NDJSON records
+//
+// restore and import replace the database's contents by default; merge keeps the records
+// the file does not contain.
+func runDatabaseTool(cmd string, args []string) {
+	if len(args) < 2 {
+		log.Fatalf("usage: %s <database address> <file> [replace|merge]", cmd)
+	}
+	addr, path := args[0], args[1]
+
+	mode := mydatabase.RestoreMode_RESTORE_REPLACE
+	if len(args) > 2 {
+		switch args[2] {
+		case "replace":
+		case "merge":
+			mode = mydatabase.RestoreMode_RESTORE_MERGE
+		default:
+			log.Fatalf("unknown restore mode %q, expected replace or merge", args[2])
+		}
+	}
+
+	switch cmd {
+	case "backup", "export":
+		backup := services.BackupDatabase
+		if cmd == "export" {
+			backup = services.ExportDatabase
+		}
+		manifest, err := backup(addr, path)
+		if err != nil {
+			log.Fatalf("%s of %s failed: %v", cmd, addr, err)
+		}
+		log.Printf("%s of %s written to %s: %d records as of %d, checksum %s", cmd, manifest.GetDatabase(), path, manifest.GetRecordCount(), manifest.GetSnapshotTimestamp(), manifest.GetChecksum())
+	case "restore", "import":
+		restore := services.RestoreDatabase
+		if cmd == "import" {
+			restore = services.ImportDatabase
+		}
+		resp, err := restore(addr, path, mode)
+		if err != nil {
+			log.Fatalf("%s of %s failed: %v", cmd, addr, err)
+		}
+		log.Printf("%s of %s from %s: %d records restored, %d deleted", cmd, addr, path, resp.GetRecordsRestored(), resp.GetRecordsDeleted())
+	}
+}
+
+// runRaftTool changes the membership of a database's Raft group:
+//
+//	raft-add <database address> <server id>       add a server, once it has caught up
+//	raft-remove <database address> <server id>    remove a server
+//
+// The database address can be any member of the group; the change is sent on to the leader.
+func runRaftTool(cmd string, args []string) {
+	if len(args) < 2 {
+		log.Fatalf("usage: %s <database address> <server id>", cmd)
+	}
+	addr, id := args[0], args[1]
+
+	members, err := services.ChangeMembership(context.Background(), addr, id, cmd == "raft-add")
+	if err != nil {
+		log.Fatalf("%s of %s via %s failed: %v", cmd, id, addr, err)
+	}
+	log.Printf("%s of %s: members are %s", cmd, id, strings.Join(members, ", "))
+}
+
+// runMigrateTool re-keys the details and reviews stored by restaurant name to restaurant
+// IDs, splits the reviews kept a restaurant to a record into a record per review, and fills
+// in the restaurant IDs of the reservations. Run it once, with the services stopped, against
+// the databases the services are configured with:
+//
+//	migrate-ids [flags]
+func runMigrateTool(detailShards, reviewShards, reviewRecordShards, reviewIndexShards, reservationShards []mydatabase.DatabaseServiceClient) {
+	ctx := context.Background()
+	n, err := services.MigrateDetails(ctx, detailShards)
+	if err != nil {
+		log.Fatalf("migration of details failed after %d records: %v", n, err)
+	}
+	log.Printf("%d detail records re-keyed to restaurant IDs", n)
+	n, err = services.MigrateReviews(ctx, reviewShards)
+	if err != nil {
+		log.Fatalf("migration of reviews failed after %d records: %v", n, err)
+	}
+	log.Printf("%d review records re-keyed to restaurant IDs", n)
+	n, err = services.SplitReviews(ctx, reviewShards, reviewRecordShards, reviewIndexShards)
+	if err != nil {
+		log.Fatalf("split of reviews failed after %d records: %v", n, err)
+	}
+	log.Printf("%d review records split into a record per review", n)
+	n, err = services.MigrateReservations(ctx, reservationShards[0])
+	if err != nil {
+		log.Fatalf("migration of reservations failed after %d records: %v", n, err)
+	}
+	log.Printf("%d reservation records given restaurant IDs", n)
+}
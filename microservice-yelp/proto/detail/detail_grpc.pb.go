@@ -22,10 +22,44 @@ const _ = grpc.SupportPackageIsVersion7
 //
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type DetailServiceClient interface {
-	// Post detail for a restaurant
+	// PostDetail is an RPC method for adding or updating restaurant details.
 	PostDetail(ctx context.Context, in *PostDetailRequest, opts ...grpc.CallOption) (*PostDetailResponse, error)
-	// Get restaurant details based on restaurant name
+	// GetDetail is an RPC method for retrieving details of a restaurant based on its ID,
+	// or its name. If there is no restaurant by that name, the NotFound error carries the
+	// RestaurantSuggestions of similarly named restaurants as a detail; if there are
+	// several, the request fails with FAILED_PRECONDITION.
 	GetDetail(ctx context.Context, in *GetDetailRequest, opts ...grpc.CallOption) (*GetDetailResponse, error)
+	// DeleteDetail is an RPC method for removing the details of a restaurant.
+	DeleteDetail(ctx context.Context, in *DeleteDetailRequest, opts ...grpc.CallOption) (*DeleteDetailResponse, error)
+	// PatchDetail is an RPC method for updating some of the details of a restaurant,
+	// leaving the others as they are.
+	PatchDetail(ctx context.Context, in *PatchDetailRequest, opts ...grpc.CallOption) (*PatchDetailResponse, error)
+	// ListRestaurants is an RPC method for browsing the restaurants whose details match
+	// a filter, a page at a time.
+	ListRestaurants(ctx context.Context, in *ListRestaurantsRequest, opts ...grpc.CallOption) (*ListRestaurantsResponse, error)
+	// NearbyRestaurants is an RPC method for finding the restaurants within a distance of
+	// a point, nearest first.
+	NearbyRestaurants(ctx context.Context, in *NearbyRestaurantsRequest, opts ...grpc.CallOption) (*NearbyRestaurantsResponse, error)
+	// IsOpen is an RPC method for checking whether a restaurant is open at a moment, or on
+	// a date, according to its opening hours.
+	IsOpen(ctx context.Context, in *IsOpenRequest, opts ...grpc.CallOption) (*IsOpenResponse, error)
+	// UploadPhoto is an RPC method for adding a photo of a restaurant, streamed in chunks.
+	// Photos must be GIF, JPEG or PNG images, and are stored once however often they are
+	// uploaded.
+	UploadPhoto(ctx context.Context, opts ...grpc.CallOption) (DetailService_UploadPhotoClient, error)
+	// DownloadPhoto is an RPC method for retrieving a photo, streamed in chunks.
+	DownloadPhoto(ctx context.Context, in *DownloadPhotoRequest, opts ...grpc.CallOption) (DetailService_DownloadPhotoClient, error)
+	// GetDetailHistory is an RPC method for listing the revisions of a restaurant's
+	// details, newest first: who made each edit, when, and what it changed.
+	GetDetailHistory(ctx context.Context, in *GetDetailHistoryRequest, opts ...grpc.CallOption) (*GetDetailHistoryResponse, error)
+	// RevertDetail is an RPC method for restoring the details of a restaurant to those of
+	// an earlier revision, as a new revision.
+	RevertDetail(ctx context.Context, in *RevertDetailRequest, opts ...grpc.CallOption) (*RevertDetailResponse, error)
+	// UpdateRating is an RPC method for recording the average rating of a restaurant's
+	// reviews, as the review service reports it when one is posted. It is not an edit of
+	// the details: it makes no revision and leaves their etag as it is. A rating taken from
+	// older stats than the one recorded is ignored.
+	UpdateRating(ctx context.Context, in *UpdateRatingRequest, opts ...grpc.CallOption) (*UpdateRatingResponse, error)
 }
 
 type detailServiceClient struct {
@@ -54,14 +88,186 @@ func (c *detailServiceClient) GetDetail(ctx context.Context, in *GetDetailReques
 	return out, nil
 }
 
+func (c *detailServiceClient) DeleteDetail(ctx context.Context, in *DeleteDetailRequest, opts ...grpc.CallOption) (*DeleteDetailResponse, error) {
+	out := new(DeleteDetailResponse)
+	err := c.cc.Invoke(ctx, "/detail.DetailService/DeleteDetail", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *detailServiceClient) PatchDetail(ctx context.Context, in *PatchDetailRequest, opts ...grpc.CallOption) (*PatchDetailResponse, error) {
+	out := new(PatchDetailResponse)
+	err := c.cc.Invoke(ctx, "/detail.DetailService/PatchDetail", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *detailServiceClient) ListRestaurants(ctx context.Context, in *ListRestaurantsRequest, opts ...grpc.CallOption) (*ListRestaurantsResponse, error) {
+	out := new(ListRestaurantsResponse)
+	err := c.cc.Invoke(ctx, "/detail.DetailService/ListRestaurants", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *detailServiceClient) NearbyRestaurants(ctx context.Context, in *NearbyRestaurantsRequest, opts ...grpc.CallOption) (*NearbyRestaurantsResponse, error) {
+	out := new(NearbyRestaurantsResponse)
+	err := c.cc.Invoke(ctx, "/detail.DetailService/NearbyRestaurants", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *detailServiceClient) IsOpen(ctx context.Context, in *IsOpenRequest, opts ...grpc.CallOption) (*IsOpenResponse, error) {
+	out := new(IsOpenResponse)
+	err := c.cc.Invoke(ctx, "/detail.DetailService/IsOpen", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *detailServiceClient) UploadPhoto(ctx context.Context, opts ...grpc.CallOption) (DetailService_UploadPhotoClient, error) {
+	stream, err := c.cc.NewStream(ctx, &DetailService_ServiceDesc.Streams[0], "/detail.DetailService/UploadPhoto", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &detailServiceUploadPhotoClient{stream}
+	return x, nil
+}
+
+type DetailService_UploadPhotoClient interface {
+	Send(*UploadPhotoRequest) error
+	CloseAndRecv() (*UploadPhotoResponse, error)
+	grpc.ClientStream
+}
+
+type detailServiceUploadPhotoClient struct {
+	grpc.ClientStream
+}
+
+func (x *detailServiceUploadPhotoClient) Send(m *UploadPhotoRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *detailServiceUploadPhotoClient) CloseAndRecv() (*UploadPhotoResponse, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(UploadPhotoResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *detailServiceClient) DownloadPhoto(ctx context.Context, in *DownloadPhotoRequest, opts ...grpc.CallOption) (DetailService_DownloadPhotoClient, error) {
+	stream, err := c.cc.NewStream(ctx, &DetailService_ServiceDesc.Streams[1], "/detail.DetailService/DownloadPhoto", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &detailServiceDownloadPhotoClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type DetailService_DownloadPhotoClient interface {
+	Recv() (*DownloadPhotoResponse, error)
+	grpc.ClientStream
+}
+
+type detailServiceDownloadPhotoClient struct {
+	grpc.ClientStream
+}
+
+func (x *detailServiceDownloadPhotoClient) Recv() (*DownloadPhotoResponse, error) {
+	m := new(DownloadPhotoResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *detailServiceClient) GetDetailHistory(ctx context.Context, in *GetDetailHistoryRequest, opts ...grpc.CallOption) (*GetDetailHistoryResponse, error) {
+	out := new(GetDetailHistoryResponse)
+	err := c.cc.Invoke(ctx, "/detail.DetailService/GetDetailHistory", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *detailServiceClient) RevertDetail(ctx context.Context, in *RevertDetailRequest, opts ...grpc.CallOption) (*RevertDetailResponse, error) {
+	out := new(RevertDetailResponse)
+	err := c.cc.Invoke(ctx, "/detail.DetailService/RevertDetail", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *detailServiceClient) UpdateRating(ctx context.Context, in *UpdateRatingRequest, opts ...grpc.CallOption) (*UpdateRatingResponse, error) {
+	out := new(UpdateRatingResponse)
+	err := c.cc.Invoke(ctx, "/detail.DetailService/UpdateRating", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // DetailServiceServer is the server API for DetailService service.
 // All implementations must embed UnimplementedDetailServiceServer
 // for forward compatibility
 type DetailServiceServer interface {
-	// Post detail for a restaurant
+	// PostDetail is an RPC method for adding or updating restaurant details.
 	PostDetail(context.Context, *PostDetailRequest) (*PostDetailResponse, error)
-	// Get restaurant details based on restaurant name
+	// GetDetail is an RPC method for retrieving details of a restaurant based on its ID,
+	// or its name. If there is no restaurant by that name, the NotFound error carries the
+	// RestaurantSuggestions of similarly named restaurants as a detail; if there are
+	// several, the request fails with FAILED_PRECONDITION.
 	GetDetail(context.Context, *GetDetailRequest) (*GetDetailResponse, error)
+	// DeleteDetail is an RPC method for removing the details of a restaurant.
+	DeleteDetail(context.Context, *DeleteDetailRequest) (*DeleteDetailResponse, error)
+	// PatchDetail is an RPC method for updating some of the details of a restaurant,
+	// leaving the others as they are.
+	PatchDetail(context.Context, *PatchDetailRequest) (*PatchDetailResponse, error)
+	// ListRestaurants is an RPC method for browsing the restaurants whose details match
+	// a filter, a page at a time.
+	ListRestaurants(context.Context, *ListRestaurantsRequest) (*ListRestaurantsResponse, error)
+	// NearbyRestaurants is an RPC method for finding the restaurants within a distance of
+	// a point, nearest first.
+	NearbyRestaurants(context.Context, *NearbyRestaurantsRequest) (*NearbyRestaurantsResponse, error)
+	// IsOpen is an RPC method for checking whether a restaurant is open at a moment, or on
+	// a date, according to its opening hours.
+	IsOpen(context.Context, *IsOpenRequest) (*IsOpenResponse, error)
+	// UploadPhoto is an RPC method for adding a photo of a restaurant, streamed in chunks.
+	// Photos must be GIF, JPEG or PNG images, and are stored once however often they are
+	// uploaded.
+	UploadPhoto(DetailService_UploadPhotoServer) error
+	// DownloadPhoto is an RPC method for retrieving a photo, streamed in chunks.
+	DownloadPhoto(*DownloadPhotoRequest, DetailService_DownloadPhotoServer) error
+	// GetDetailHistory is an RPC method for listing the revisions of a restaurant's
+	// details, newest first: who made each edit, when, and what it changed.
+	GetDetailHistory(context.Context, *GetDetailHistoryRequest) (*GetDetailHistoryResponse, error)
+	// RevertDetail is an RPC method for restoring the details of a restaurant to those of
+	// an earlier revision, as a new revision.
+	RevertDetail(context.Context, *RevertDetailRequest) (*RevertDetailResponse, error)
+	// UpdateRating is an RPC method for recording the average rating of a restaurant's
+	// reviews, as the review service reports it when one is posted. It is not an edit of
+	// the details: it makes no revision and leaves their etag as it is. A rating taken from
+	// older stats than the one recorded is ignored.
+	UpdateRating(context.Context, *UpdateRatingRequest) (*UpdateRatingResponse, error)
 	mustEmbedUnimplementedDetailServiceServer()
 }
 
@@ -75,6 +281,36 @@ func (UnimplementedDetailServiceServer) PostDetail(context.Context, *PostDetailR
 func (UnimplementedDetailServiceServer) GetDetail(context.Context, *GetDetailRequest) (*GetDetailResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetDetail not implemented")
 }
+func (UnimplementedDetailServiceServer) DeleteDetail(context.Context, *DeleteDetailRequest) (*DeleteDetailResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteDetail not implemented")
+}
+func (UnimplementedDetailServiceServer) PatchDetail(context.Context, *PatchDetailRequest) (*PatchDetailResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PatchDetail not implemented")
+}
+func (UnimplementedDetailServiceServer) ListRestaurants(context.Context, *ListRestaurantsRequest) (*ListRestaurantsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListRestaurants not implemented")
+}
+func (UnimplementedDetailServiceServer) NearbyRestaurants(context.Context, *NearbyRestaurantsRequest) (*NearbyRestaurantsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method NearbyRestaurants not implemented")
+}
+func (UnimplementedDetailServiceServer) IsOpen(context.Context, *IsOpenRequest) (*IsOpenResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method IsOpen not implemented")
+}
+func (UnimplementedDetailServiceServer) UploadPhoto(DetailService_UploadPhotoServer) error {
+	return status.Errorf(codes.Unimplemented, "method UploadPhoto not implemented")
+}
+func (UnimplementedDetailServiceServer) DownloadPhoto(*DownloadPhotoRequest, DetailService_DownloadPhotoServer) error {
+	return status.Errorf(codes.Unimplemented, "method DownloadPhoto not implemented")
+}
+func (UnimplementedDetailServiceServer) GetDetailHistory(context.Context, *GetDetailHistoryRequest) (*GetDetailHistoryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetDetailHistory not implemented")
+}
+func (UnimplementedDetailServiceServer) RevertDetail(context.Context, *RevertDetailRequest) (*RevertDetailResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RevertDetail not implemented")
+}
+func (UnimplementedDetailServiceServer) UpdateRating(context.Context, *UpdateRatingRequest) (*UpdateRatingResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateRating not implemented")
+}
 func (UnimplementedDetailServiceServer) mustEmbedUnimplementedDetailServiceServer() {}
 
 // UnsafeDetailServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -124,6 +360,197 @@ func _DetailService_GetDetail_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _DetailService_DeleteDetail_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteDetailRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DetailServiceServer).DeleteDetail(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/detail.DetailService/DeleteDetail",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DetailServiceServer).DeleteDetail(ctx, req.(*DeleteDetailRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DetailService_PatchDetail_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PatchDetailRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DetailServiceServer).PatchDetail(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/detail.DetailService/PatchDetail",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DetailServiceServer).PatchDetail(ctx, req.(*PatchDetailRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DetailService_ListRestaurants_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListRestaurantsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DetailServiceServer).ListRestaurants(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/detail.DetailService/ListRestaurants",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DetailServiceServer).ListRestaurants(ctx, req.(*ListRestaurantsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DetailService_NearbyRestaurants_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(NearbyRestaurantsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DetailServiceServer).NearbyRestaurants(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/detail.DetailService/NearbyRestaurants",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DetailServiceServer).NearbyRestaurants(ctx, req.(*NearbyRestaurantsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DetailService_IsOpen_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IsOpenRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DetailServiceServer).IsOpen(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/detail.DetailService/IsOpen",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DetailServiceServer).IsOpen(ctx, req.(*IsOpenRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DetailService_UploadPhoto_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(DetailServiceServer).UploadPhoto(&detailServiceUploadPhotoServer{stream})
+}
+
+type DetailService_UploadPhotoServer interface {
+	SendAndClose(*UploadPhotoResponse) error
+	Recv() (*UploadPhotoRequest, error)
+	grpc.ServerStream
+}
+
+type detailServiceUploadPhotoServer struct {
+	grpc.ServerStream
+}
+
+func (x *detailServiceUploadPhotoServer) SendAndClose(m *UploadPhotoResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *detailServiceUploadPhotoServer) Recv() (*UploadPhotoRequest, error) {
+	m := new(UploadPhotoRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _DetailService_DownloadPhoto_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(DownloadPhotoRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(DetailServiceServer).DownloadPhoto(m, &detailServiceDownloadPhotoServer{stream})
+}
+
+type DetailService_DownloadPhotoServer interface {
+	Send(*DownloadPhotoResponse) error
+	grpc.ServerStream
+}
+
+type detailServiceDownloadPhotoServer struct {
+	grpc.ServerStream
+}
+
+func (x *detailServiceDownloadPhotoServer) Send(m *DownloadPhotoResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _DetailService_GetDetailHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetDetailHistoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DetailServiceServer).GetDetailHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/detail.DetailService/GetDetailHistory",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DetailServiceServer).GetDetailHistory(ctx, req.(*GetDetailHistoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DetailService_RevertDetail_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RevertDetailRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DetailServiceServer).RevertDetail(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/detail.DetailService/RevertDetail",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DetailServiceServer).RevertDetail(ctx, req.(*RevertDetailRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DetailService_UpdateRating_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateRatingRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DetailServiceServer).UpdateRating(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/detail.DetailService/UpdateRating",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DetailServiceServer).UpdateRating(ctx, req.(*UpdateRatingRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // DetailService_ServiceDesc is the grpc.ServiceDesc for DetailService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -139,7 +566,50 @@ var DetailService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetDetail",
 			Handler:    _DetailService_GetDetail_Handler,
 		},
+		{
+			MethodName: "DeleteDetail",
+			Handler:    _DetailService_DeleteDetail_Handler,
+		},
+		{
+			MethodName: "PatchDetail",
+			Handler:    _DetailService_PatchDetail_Handler,
+		},
+		{
+			MethodName: "ListRestaurants",
+			Handler:    _DetailService_ListRestaurants_Handler,
+		},
+		{
+			MethodName: "NearbyRestaurants",
+			Handler:    _DetailService_NearbyRestaurants_Handler,
+		},
+		{
+			MethodName: "IsOpen",
+			Handler:    _DetailService_IsOpen_Handler,
+		},
+		{
+			MethodName: "GetDetailHistory",
+			Handler:    _DetailService_GetDetailHistory_Handler,
+		},
+		{
+			MethodName: "RevertDetail",
+			Handler:    _DetailService_RevertDetail_Handler,
+		},
+		{
+			MethodName: "UpdateRating",
+			Handler:    _DetailService_UpdateRating_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "UploadPhoto",
+			Handler:       _DetailService_UploadPhoto_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "DownloadPhoto",
+			Handler:       _DetailService_DownloadPhoto_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "proto/detail/detail.proto",
 }
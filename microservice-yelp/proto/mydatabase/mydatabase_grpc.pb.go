@@ -26,10 +26,37 @@ type DatabaseServiceClient interface {
 	SetRecord(ctx context.Context, in *SetRecordRequest, opts ...grpc.CallOption) (*SetRecordResponse, error)
 	// Get a record from the database
 	GetRecord(ctx context.Context, in *GetRecordRequest, opts ...grpc.CallOption) (*GetRecordResponse, error)
+	// List the retained versions of a record, newest first
+	GetHistory(ctx context.Context, in *GetHistoryRequest, opts ...grpc.CallOption) (*GetHistoryResponse, error)
 	// Update an existing record in the database
 	UpdateRecord(ctx context.Context, in *UpdateRecordRequest, opts ...grpc.CallOption) (*UpdateRecordResponse, error)
 	// Delete a record from the database
 	DeleteRecord(ctx context.Context, in *DeleteRecordRequest, opts ...grpc.CallOption) (*DeleteRecordResponse, error)
+	// Get statistics about the storage backend
+	GetStats(ctx context.Context, in *GetStatsRequest, opts ...grpc.CallOption) (*GetStatsResponse, error)
+	// Stream the records in a key range or under a key prefix, in key order
+	ScanRecords(ctx context.Context, in *ScanRecordsRequest, opts ...grpc.CallOption) (DatabaseService_ScanRecordsClient, error)
+	// Start a snapshot isolated transaction
+	BeginTransaction(ctx context.Context, in *BeginTransactionRequest, opts ...grpc.CallOption) (*BeginTransactionResponse, error)
+	// Atomically apply a transaction's writes; fails with ABORTED on a write-write conflict
+	CommitTransaction(ctx context.Context, in *CommitTransactionRequest, opts ...grpc.CallOption) (*CommitTransactionResponse, error)
+	// Discard a transaction's writes
+	AbortTransaction(ctx context.Context, in *AbortTransactionRequest, opts ...grpc.CallOption) (*AbortTransactionResponse, error)
+	// Get hashes of nodes of the Merkle tree over the database's records, to find the
+	// records a replica disagrees on
+	GetMerkleNodes(ctx context.Context, in *GetMerkleNodesRequest, opts ...grpc.CallOption) (*GetMerkleNodesResponse, error)
+	// Stream the records in Merkle tree leaves that differ from the caller's
+	SyncRecords(ctx context.Context, in *SyncRecordsRequest, opts ...grpc.CallOption) (DatabaseService_SyncRecordsClient, error)
+	// Stream a backup file holding a consistent snapshot of the database, taken without
+	// blocking other requests
+	CreateBackup(ctx context.Context, in *CreateBackupRequest, opts ...grpc.CallOption) (DatabaseService_CreateBackupClient, error)
+	// Restore the database from a streamed backup file, after checking it against its manifest
+	RestoreBackup(ctx context.Context, opts ...grpc.CallOption) (DatabaseService_RestoreBackupClient, error)
+	// Stream the changes made to the database's records in the order they were applied,
+	// starting from a sequence number the subscriber last saw plus one
+	StreamChanges(ctx context.Context, in *StreamChangesRequest, opts ...grpc.CallOption) (DatabaseService_StreamChangesClient, error)
+	// Find the keys of records matching conditions on the database's secondary indexes
+	QueryIndex(ctx context.Context, in *QueryIndexRequest, opts ...grpc.CallOption) (*QueryIndexResponse, error)
 }
 
 type databaseServiceClient struct {
@@ -58,6 +85,15 @@ func (c *databaseServiceClient) GetRecord(ctx context.Context, in *GetRecordRequ
 	return out, nil
 }
 
+func (c *databaseServiceClient) GetHistory(ctx context.Context, in *GetHistoryRequest, opts ...grpc.CallOption) (*GetHistoryResponse, error) {
+	out := new(GetHistoryResponse)
+	err := c.cc.Invoke(ctx, "/mydatabase.DatabaseService/GetHistory", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *databaseServiceClient) UpdateRecord(ctx context.Context, in *UpdateRecordRequest, opts ...grpc.CallOption) (*UpdateRecordResponse, error) {
 	out := new(UpdateRecordResponse)
 	err := c.cc.Invoke(ctx, "/mydatabase.DatabaseService/UpdateRecord", in, out, opts...)
@@ -76,6 +112,222 @@ func (c *databaseServiceClient) DeleteRecord(ctx context.Context, in *DeleteReco
 	return out, nil
 }
 
+func (c *databaseServiceClient) GetStats(ctx context.Context, in *GetStatsRequest, opts ...grpc.CallOption) (*GetStatsResponse, error) {
+	out := new(GetStatsResponse)
+	err := c.cc.Invoke(ctx, "/mydatabase.DatabaseService/GetStats", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *databaseServiceClient) ScanRecords(ctx context.Context, in *ScanRecordsRequest, opts ...grpc.CallOption) (DatabaseService_ScanRecordsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &DatabaseService_ServiceDesc.Streams[0], "/mydatabase.DatabaseService/ScanRecords", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &databaseServiceScanRecordsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type DatabaseService_ScanRecordsClient interface {
+	Recv() (*ScanRecordsResponse, error)
+	grpc.ClientStream
+}
+
+type databaseServiceScanRecordsClient struct {
+	grpc.ClientStream
+}
+
+func (x *databaseServiceScanRecordsClient) Recv() (*ScanRecordsResponse, error) {
+	m := new(ScanRecordsResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *databaseServiceClient) BeginTransaction(ctx context.Context, in *BeginTransactionRequest, opts ...grpc.CallOption) (*BeginTransactionResponse, error) {
+	out := new(BeginTransactionResponse)
+	err := c.cc.Invoke(ctx, "/mydatabase.DatabaseService/BeginTransaction", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *databaseServiceClient) CommitTransaction(ctx context.Context, in *CommitTransactionRequest, opts ...grpc.CallOption) (*CommitTransactionResponse, error) {
+	out := new(CommitTransactionResponse)
+	err := c.cc.Invoke(ctx, "/mydatabase.DatabaseService/CommitTransaction", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *databaseServiceClient) AbortTransaction(ctx context.Context, in *AbortTransactionRequest, opts ...grpc.CallOption) (*AbortTransactionResponse, error) {
+	out := new(AbortTransactionResponse)
+	err := c.cc.Invoke(ctx, "/mydatabase.DatabaseService/AbortTransaction", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *databaseServiceClient) GetMerkleNodes(ctx context.Context, in *GetMerkleNodesRequest, opts ...grpc.CallOption) (*GetMerkleNodesResponse, error) {
+	out := new(GetMerkleNodesResponse)
+	err := c.cc.Invoke(ctx, "/mydatabase.DatabaseService/GetMerkleNodes", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *databaseServiceClient) SyncRecords(ctx context.Context, in *SyncRecordsRequest, opts ...grpc.CallOption) (DatabaseService_SyncRecordsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &DatabaseService_ServiceDesc.Streams[1], "/mydatabase.DatabaseService/SyncRecords", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &databaseServiceSyncRecordsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type DatabaseService_SyncRecordsClient interface {
+	Recv() (*SyncRecordsResponse, error)
+	grpc.ClientStream
+}
+
+type databaseServiceSyncRecordsClient struct {
+	grpc.ClientStream
+}
+
+func (x *databaseServiceSyncRecordsClient) Recv() (*SyncRecordsResponse, error) {
+	m := new(SyncRecordsResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *databaseServiceClient) CreateBackup(ctx context.Context, in *CreateBackupRequest, opts ...grpc.CallOption) (DatabaseService_CreateBackupClient, error) {
+	stream, err := c.cc.NewStream(ctx, &DatabaseService_ServiceDesc.Streams[2], "/mydatabase.DatabaseService/CreateBackup", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &databaseServiceCreateBackupClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type DatabaseService_CreateBackupClient interface {
+	Recv() (*BackupChunk, error)
+	grpc.ClientStream
+}
+
+type databaseServiceCreateBackupClient struct {
+	grpc.ClientStream
+}
+
+func (x *databaseServiceCreateBackupClient) Recv() (*BackupChunk, error) {
+	m := new(BackupChunk)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *databaseServiceClient) RestoreBackup(ctx context.Context, opts ...grpc.CallOption) (DatabaseService_RestoreBackupClient, error) {
+	stream, err := c.cc.NewStream(ctx, &DatabaseService_ServiceDesc.Streams[3], "/mydatabase.DatabaseService/RestoreBackup", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &databaseServiceRestoreBackupClient{stream}
+	return x, nil
+}
+
+type DatabaseService_RestoreBackupClient interface {
+	Send(*RestoreBackupRequest) error
+	CloseAndRecv() (*RestoreBackupResponse, error)
+	grpc.ClientStream
+}
+
+type databaseServiceRestoreBackupClient struct {
+	grpc.ClientStream
+}
+
+func (x *databaseServiceRestoreBackupClient) Send(m *RestoreBackupRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *databaseServiceRestoreBackupClient) CloseAndRecv() (*RestoreBackupResponse, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(RestoreBackupResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *databaseServiceClient) StreamChanges(ctx context.Context, in *StreamChangesRequest, opts ...grpc.CallOption) (DatabaseService_StreamChangesClient, error) {
+	stream, err := c.cc.NewStream(ctx, &DatabaseService_ServiceDesc.Streams[4], "/mydatabase.DatabaseService/StreamChanges", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &databaseServiceStreamChangesClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type DatabaseService_StreamChangesClient interface {
+	Recv() (*ChangeEvent, error)
+	grpc.ClientStream
+}
+
+type databaseServiceStreamChangesClient struct {
+	grpc.ClientStream
+}
+
+func (x *databaseServiceStreamChangesClient) Recv() (*ChangeEvent, error) {
+	m := new(ChangeEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *databaseServiceClient) QueryIndex(ctx context.Context, in *QueryIndexRequest, opts ...grpc.CallOption) (*QueryIndexResponse, error) {
+	out := new(QueryIndexResponse)
+	err := c.cc.Invoke(ctx, "/mydatabase.DatabaseService/QueryIndex", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // DatabaseServiceServer is the server API for DatabaseService service.
 // All implementations must embed UnimplementedDatabaseServiceServer
 // for forward compatibility
@@ -84,10 +336,37 @@ type DatabaseServiceServer interface {
 	SetRecord(context.Context, *SetRecordRequest) (*SetRecordResponse, error)
 	// Get a record from the database
 	GetRecord(context.Context, *GetRecordRequest) (*GetRecordResponse, error)
+	// List the retained versions of a record, newest first
+	GetHistory(context.Context, *GetHistoryRequest) (*GetHistoryResponse, error)
 	// Update an existing record in the database
 	UpdateRecord(context.Context, *UpdateRecordRequest) (*UpdateRecordResponse, error)
 	// Delete a record from the database
 	DeleteRecord(context.Context, *DeleteRecordRequest) (*DeleteRecordResponse, error)
+	// Get statistics about the storage backend
+	GetStats(context.Context, *GetStatsRequest) (*GetStatsResponse, error)
+	// Stream the records in a key range or under a key prefix, in key order
+	ScanRecords(*ScanRecordsRequest, DatabaseService_ScanRecordsServer) error
+	// Start a snapshot isolated transaction
+	BeginTransaction(context.Context, *BeginTransactionRequest) (*BeginTransactionResponse, error)
+	// Atomically apply a transaction's writes; fails with ABORTED on a write-write conflict
+	CommitTransaction(context.Context, *CommitTransactionRequest) (*CommitTransactionResponse, error)
+	// Discard a transaction's writes
+	AbortTransaction(context.Context, *AbortTransactionRequest) (*AbortTransactionResponse, error)
+	// Get hashes of nodes of the Merkle tree over the database's records, to find the
+	// records a replica disagrees on
+	GetMerkleNodes(context.Context, *GetMerkleNodesRequest) (*GetMerkleNodesResponse, error)
+	// Stream the records in Merkle tree leaves that differ from the caller's
+	SyncRecords(*SyncRecordsRequest, DatabaseService_SyncRecordsServer) error
+	// Stream a backup file holding a consistent snapshot of the database, taken without
+	// blocking other requests
+	CreateBackup(*CreateBackupRequest, DatabaseService_CreateBackupServer) error
+	// Restore the database from a streamed backup file, after checking it against its manifest
+	RestoreBackup(DatabaseService_RestoreBackupServer) error
+	// Stream the changes made to the database's records in the order they were applied,
+	// starting from a sequence number the subscriber last saw plus one
+	StreamChanges(*StreamChangesRequest, DatabaseService_StreamChangesServer) error
+	// Find the keys of records matching conditions on the database's secondary indexes
+	QueryIndex(context.Context, *QueryIndexRequest) (*QueryIndexResponse, error)
 	mustEmbedUnimplementedDatabaseServiceServer()
 }
 
@@ -101,12 +380,48 @@ func (UnimplementedDatabaseServiceServer) SetRecord(context.Context, *SetRecordR
 func (UnimplementedDatabaseServiceServer) GetRecord(context.Context, *GetRecordRequest) (*GetRecordResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetRecord not implemented")
 }
+func (UnimplementedDatabaseServiceServer) GetHistory(context.Context, *GetHistoryRequest) (*GetHistoryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetHistory not implemented")
+}
 func (UnimplementedDatabaseServiceServer) UpdateRecord(context.Context, *UpdateRecordRequest) (*UpdateRecordResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method UpdateRecord not implemented")
 }
 func (UnimplementedDatabaseServiceServer) DeleteRecord(context.Context, *DeleteRecordRequest) (*DeleteRecordResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method DeleteRecord not implemented")
 }
+func (UnimplementedDatabaseServiceServer) GetStats(context.Context, *GetStatsRequest) (*GetStatsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetStats not implemented")
+}
+func (UnimplementedDatabaseServiceServer) ScanRecords(*ScanRecordsRequest, DatabaseService_ScanRecordsServer) error {
+	return status.Errorf(codes.Unimplemented, "method ScanRecords not implemented")
+}
+func (UnimplementedDatabaseServiceServer) BeginTransaction(context.Context, *BeginTransactionRequest) (*BeginTransactionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BeginTransaction not implemented")
+}
+func (UnimplementedDatabaseServiceServer) CommitTransaction(context.Context, *CommitTransactionRequest) (*CommitTransactionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CommitTransaction not implemented")
+}
+func (UnimplementedDatabaseServiceServer) AbortTransaction(context.Context, *AbortTransactionRequest) (*AbortTransactionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AbortTransaction not implemented")
+}
+func (UnimplementedDatabaseServiceServer) GetMerkleNodes(context.Context, *GetMerkleNodesRequest) (*GetMerkleNodesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetMerkleNodes not implemented")
+}
+func (UnimplementedDatabaseServiceServer) SyncRecords(*SyncRecordsRequest, DatabaseService_SyncRecordsServer) error {
+	return status.Errorf(codes.Unimplemented, "method SyncRecords not implemented")
+}
+func (UnimplementedDatabaseServiceServer) CreateBackup(*CreateBackupRequest, DatabaseService_CreateBackupServer) error {
+	return status.Errorf(codes.Unimplemented, "method CreateBackup not implemented")
+}
+func (UnimplementedDatabaseServiceServer) RestoreBackup(DatabaseService_RestoreBackupServer) error {
+	return status.Errorf(codes.Unimplemented, "method RestoreBackup not implemented")
+}
+func (UnimplementedDatabaseServiceServer) StreamChanges(*StreamChangesRequest, DatabaseService_StreamChangesServer) error {
+	return status.Errorf(codes.Unimplemented, "method StreamChanges not implemented")
+}
+func (UnimplementedDatabaseServiceServer) QueryIndex(context.Context, *QueryIndexRequest) (*QueryIndexResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method QueryIndex not implemented")
+}
 func (UnimplementedDatabaseServiceServer) mustEmbedUnimplementedDatabaseServiceServer() {}
 
 // UnsafeDatabaseServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -156,6 +471,24 @@ func _DatabaseService_GetRecord_Handler(srv interface{}, ctx context.Context, de
 	return interceptor(ctx, in, info, handler)
 }
 
+func _DatabaseService_GetHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetHistoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DatabaseServiceServer).GetHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/mydatabase.DatabaseService/GetHistory",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DatabaseServiceServer).GetHistory(ctx, req.(*GetHistoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _DatabaseService_UpdateRecord_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(UpdateRecordRequest)
 	if err := dec(in); err != nil {
@@ -192,6 +525,224 @@ func _DatabaseService_DeleteRecord_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _DatabaseService_GetStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetStatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DatabaseServiceServer).GetStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/mydatabase.DatabaseService/GetStats",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DatabaseServiceServer).GetStats(ctx, req.(*GetStatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DatabaseService_ScanRecords_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ScanRecordsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(DatabaseServiceServer).ScanRecords(m, &databaseServiceScanRecordsServer{stream})
+}
+
+type DatabaseService_ScanRecordsServer interface {
+	Send(*ScanRecordsResponse) error
+	grpc.ServerStream
+}
+
+type databaseServiceScanRecordsServer struct {
+	grpc.ServerStream
+}
+
+func (x *databaseServiceScanRecordsServer) Send(m *ScanRecordsResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _DatabaseService_BeginTransaction_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BeginTransactionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DatabaseServiceServer).BeginTransaction(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/mydatabase.DatabaseService/BeginTransaction",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DatabaseServiceServer).BeginTransaction(ctx, req.(*BeginTransactionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DatabaseService_CommitTransaction_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CommitTransactionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DatabaseServiceServer).CommitTransaction(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/mydatabase.DatabaseService/CommitTransaction",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DatabaseServiceServer).CommitTransaction(ctx, req.(*CommitTransactionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DatabaseService_AbortTransaction_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AbortTransactionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DatabaseServiceServer).AbortTransaction(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/mydatabase.DatabaseService/AbortTransaction",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DatabaseServiceServer).AbortTransaction(ctx, req.(*AbortTransactionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DatabaseService_GetMerkleNodes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetMerkleNodesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DatabaseServiceServer).GetMerkleNodes(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/mydatabase.DatabaseService/GetMerkleNodes",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DatabaseServiceServer).GetMerkleNodes(ctx, req.(*GetMerkleNodesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DatabaseService_SyncRecords_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SyncRecordsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(DatabaseServiceServer).SyncRecords(m, &databaseServiceSyncRecordsServer{stream})
+}
+
+type DatabaseService_SyncRecordsServer interface {
+	Send(*SyncRecordsResponse) error
+	grpc.ServerStream
+}
+
+type databaseServiceSyncRecordsServer struct {
+	grpc.ServerStream
+}
+
+func (x *databaseServiceSyncRecordsServer) Send(m *SyncRecordsResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _DatabaseService_CreateBackup_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(CreateBackupRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(DatabaseServiceServer).CreateBackup(m, &databaseServiceCreateBackupServer{stream})
+}
+
+type DatabaseService_CreateBackupServer interface {
+	Send(*BackupChunk) error
+	grpc.ServerStream
+}
+
+type databaseServiceCreateBackupServer struct {
+	grpc.ServerStream
+}
+
+func (x *databaseServiceCreateBackupServer) Send(m *BackupChunk) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _DatabaseService_RestoreBackup_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(DatabaseServiceServer).RestoreBackup(&databaseServiceRestoreBackupServer{stream})
+}
+
+type DatabaseService_RestoreBackupServer interface {
+	SendAndClose(*RestoreBackupResponse) error
+	Recv() (*RestoreBackupRequest, error)
+	grpc.ServerStream
+}
+
+type databaseServiceRestoreBackupServer struct {
+	grpc.ServerStream
+}
+
+func (x *databaseServiceRestoreBackupServer) SendAndClose(m *RestoreBackupResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *databaseServiceRestoreBackupServer) Recv() (*RestoreBackupRequest, error) {
+	m := new(RestoreBackupRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _DatabaseService_StreamChanges_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamChangesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(DatabaseServiceServer).StreamChanges(m, &databaseServiceStreamChangesServer{stream})
+}
+
+type DatabaseService_StreamChangesServer interface {
+	Send(*ChangeEvent) error
+	grpc.ServerStream
+}
+
+type databaseServiceStreamChangesServer struct {
+	grpc.ServerStream
+}
+
+func (x *databaseServiceStreamChangesServer) Send(m *ChangeEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _DatabaseService_QueryIndex_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(QueryIndexRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DatabaseServiceServer).QueryIndex(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/mydatabase.DatabaseService/QueryIndex",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DatabaseServiceServer).QueryIndex(ctx, req.(*QueryIndexRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // DatabaseService_ServiceDesc is the grpc.ServiceDesc for DatabaseService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -207,6 +758,10 @@ var DatabaseService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetRecord",
 			Handler:    _DatabaseService_GetRecord_Handler,
 		},
+		{
+			MethodName: "GetHistory",
+			Handler:    _DatabaseService_GetHistory_Handler,
+		},
 		{
 			MethodName: "UpdateRecord",
 			Handler:    _DatabaseService_UpdateRecord_Handler,
@@ -215,7 +770,57 @@ var DatabaseService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "DeleteRecord",
 			Handler:    _DatabaseService_DeleteRecord_Handler,
 		},
+		{
+			MethodName: "GetStats",
+			Handler:    _DatabaseService_GetStats_Handler,
+		},
+		{
+			MethodName: "BeginTransaction",
+			Handler:    _DatabaseService_BeginTransaction_Handler,
+		},
+		{
+			MethodName: "CommitTransaction",
+			Handler:    _DatabaseService_CommitTransaction_Handler,
+		},
+		{
+			MethodName: "AbortTransaction",
+			Handler:    _DatabaseService_AbortTransaction_Handler,
+		},
+		{
+			MethodName: "GetMerkleNodes",
+			Handler:    _DatabaseService_GetMerkleNodes_Handler,
+		},
+		{
+			MethodName: "QueryIndex",
+			Handler:    _DatabaseService_QueryIndex_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ScanRecords",
+			Handler:       _DatabaseService_ScanRecords_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "SyncRecords",
+			Handler:       _DatabaseService_SyncRecords_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "CreateBackup",
+			Handler:       _DatabaseService_CreateBackup_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "RestoreBackup",
+			Handler:       _DatabaseService_RestoreBackup_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "StreamChanges",
+			Handler:       _DatabaseService_StreamChanges_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "proto/mydatabase/mydatabase.proto",
 }
@@ -22,10 +22,11 @@ const _ = grpc.SupportPackageIsVersion7
 //
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type ReservationServiceClient interface {
-	// Make a reservation
+	// MakeReservation is an RPC method for adding or updating restaurant reservations.
 	MakeReservation(ctx context.Context, in *MakeReservationRequest, opts ...grpc.CallOption) (*MakeReservationResponse, error)
-	// Get all reservations for a user
+	// GetReservation is an RPC method for retrieving restaurant reservations.
 	GetReservation(ctx context.Context, in *GetReservationRequest, opts ...grpc.CallOption) (*GetReservationResponse, error)
+	// MostPopular is an RPC method for retrieving most popular restaurants.
 	MostPopular(ctx context.Context, in *MostPopularRequest, opts ...grpc.CallOption) (*MostPopularResponse, error)
 }
 
@@ -68,10 +69,11 @@ func (c *reservationServiceClient) MostPopular(ctx context.Context, in *MostPopu
 // All implementations must embed UnimplementedReservationServiceServer
 // for forward compatibility
 type ReservationServiceServer interface {
-	// Make a reservation
+	// MakeReservation is an RPC method for adding or updating restaurant reservations.
 	MakeReservation(context.Context, *MakeReservationRequest) (*MakeReservationResponse, error)
-	// Get all reservations for a user
+	// GetReservation is an RPC method for retrieving restaurant reservations.
 	GetReservation(context.Context, *GetReservationRequest) (*GetReservationResponse, error)
+	// MostPopular is an RPC method for retrieving most popular restaurants.
 	MostPopular(context.Context, *MostPopularRequest) (*MostPopularResponse, error)
 	mustEmbedUnimplementedReservationServiceServer()
 }
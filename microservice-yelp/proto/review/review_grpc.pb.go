@@ -22,11 +22,11 @@ const _ = grpc.SupportPackageIsVersion7
 //
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type ReviewServiceClient interface {
-	// Post a review for a restaurant
+	// PostReview is an RPC method for adding restaurant review.
 	PostReview(ctx context.Context, in *PostReviewRequest, opts ...grpc.CallOption) (*PostReviewResponse, error)
-	// Get a user review for a restaurant
+	// GetReview is an RPC method for getting restaurant review of a user.
 	GetReview(ctx context.Context, in *GetReviewRequest, opts ...grpc.CallOption) (*GetReviewResponse, error)
-	// Get all reviews for a restaurant and apply search filter
+	// SearchReviews is an RPC method for search all reviews of a restaurant.
 	SearchReviews(ctx context.Context, in *SearchReviewsRequest, opts ...grpc.CallOption) (*SearchReviewsResponse, error)
 }
 
@@ -69,11 +69,11 @@ func (c *reviewServiceClient) SearchReviews(ctx context.Context, in *SearchRevie
 // All implementations must embed UnimplementedReviewServiceServer
 // for forward compatibility
 type ReviewServiceServer interface {
-	// Post a review for a restaurant
+	// PostReview is an RPC method for adding restaurant review.
 	PostReview(context.Context, *PostReviewRequest) (*PostReviewResponse, error)
-	// Get a user review for a restaurant
+	// GetReview is an RPC method for getting restaurant review of a user.
 	GetReview(context.Context, *GetReviewRequest) (*GetReviewResponse, error)
-	// Get all reviews for a restaurant and apply search filter
+	// SearchReviews is an RPC method for search all reviews of a restaurant.
 	SearchReviews(context.Context, *SearchReviewsRequest) (*SearchReviewsResponse, error)
 	mustEmbedUnimplementedReviewServiceServer()
 }
@@ -0,0 +1,100 @@
+package raft
+
+import (
+	pb "gitlab.cs.washington.edu/syslab/cse453-welp/proto/raft"
+	"google.golang.org/protobuf/proto"
+)
+
+// raftLog holds the entries of a node's log that follow its latest snapshot. Entries are
+// never modified once appended, so they can be shared with in-flight messages.
+type raftLog struct {
+	snapshotIndex uint64 // index of the last entry covered by the snapshot
+	snapshotTerm  uint64
+	entries       []*pb.Entry // entries[i] has index snapshotIndex+1+i
+}
+
+func (l *raftLog) lastIndex() uint64 {
+	return l.snapshotIndex + uint64(len(l.entries))
+}
+
+func (l *raftLog) lastTerm() uint64 {
+	if len(l.entries) == 0 {
+		return l.snapshotTerm
+	}
+	return l.entries[len(l.entries)-1].GetTerm()
+}
+
+// term returns the term of the entry at index, or false if the entry was compacted into
+// the snapshot or does not exist yet.
+func (l *raftLog) term(index uint64) (uint64, bool) {
+	switch {
+	case index == l.snapshotIndex:
+		return l.snapshotTerm, true
+	case index < l.snapshotIndex || index > l.lastIndex():
+		return 0, false
+	}
+	return l.entries[index-l.snapshotIndex-1].GetTerm(), true
+}
+
+// slice returns the entries from index from through to, inclusive, limited to maxBytes of
+// entry data but always including at least one entry. Both ends must be within the log.
+func (l *raftLog) slice(from, to uint64, maxBytes int) []*pb.Entry {
+	if from > to {
+		return nil
+	}
+	entries := l.entries[from-l.snapshotIndex-1 : to-l.snapshotIndex]
+	size := 0
+	for i, e := range entries {
+		size += len(e.GetData())
+		if i > 0 && maxBytes > 0 && size > maxBytes {
+			entries = entries[:i]
+			break
+		}
+	}
+	return append([]*pb.Entry(nil), entries...)
+}
+
+// append adds entries to the log, replacing any existing entries from the first one's index.
+func (l *raftLog) append(entries ...*pb.Entry) {
+	if len(entries) == 0 {
+		return
+	}
+	l.truncate(entries[0].GetIndex())
+	l.entries = append(l.entries, entries...)
+}
+
+// truncate removes the entries from index on.
+func (l *raftLog) truncate(index uint64) {
+	if index <= l.lastIndex() {
+		l.entries = l.entries[:index-l.snapshotIndex-1]
+	}
+}
+
+// compact discards the entries up to and including index, which a snapshot now covers.
+func (l *raftLog) compact(index, term uint64) {
+	if index >= l.lastIndex() {
+		l.entries = nil
+	} else {
+		l.entries = append([]*pb.Entry(nil), l.entries[index-l.snapshotIndex:]...)
+	}
+	l.snapshotIndex, l.snapshotTerm = index, term
+}
+
+// configuration returns the latest membership recorded in the log at or before index,
+// falling back to base, the membership as of the snapshot, if the log has none.
+func (l *raftLog) configuration(index uint64, base []string) ([]string, uint64) {
+	if index > l.lastIndex() {
+		index = l.lastIndex()
+	}
+	for i := index; i > l.snapshotIndex; i-- {
+		e := l.entries[i-l.snapshotIndex-1]
+		if e.GetType() != pb.EntryType_ENTRY_CONFIGURATION {
+			continue
+		}
+		var config pb.Configuration
+		if err := proto.Unmarshal(e.GetData(), &config); err == nil {
+			return config.GetVoters(), i
+		}
+	}
+	return base, l.snapshotIndex
+}
@@ -0,0 +1,1305 @@
+// Package raft implements the Raft consensus algorithm, used to replicate a database shard
+// across a group of servers. It provides leader election (with pre-votes, so that a server
+// rejoining after a partition does not disrupt the group), log replication, snapshots, and
+// membership changes that add or remove one server at a time.
+package raft
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	pb "gitlab.cs.washington.edu/syslab/cse453-welp/proto/raft"
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	ErrNotLeader            = errors.New("raft: not the leader")
+	ErrStopped              = errors.New("raft: node stopped")
+	ErrProposalDropped      = errors.New("raft: leadership changed before the proposal was applied; it may or may not have taken effect")
+	ErrConfigurationPending = errors.New("raft: another membership change is in progress")
+	ErrNotMember            = errors.New("raft: server is not a member of the group")
+)
+
+const (
+	defaultElectionTimeout   = time.Second
+	defaultSnapshotThreshold = 10000
+	defaultMaxAppendEntries  = 512
+	maxAppendBytes           = 1 << 20 // entry data per AppendEntries message
+	snapshotChunkSize        = 1 << 20 // snapshot data per InstallSnapshot message
+)
+
+// StateMachine is the replicated state a group agrees on. Its methods are only ever called
+// from a single goroutine.
+type StateMachine interface {
+	// Apply applies a committed command and returns the result handed back to Propose.
+	Apply(command []byte) interface{}
+
+	// Snapshot serializes the state, reflecting every command applied so far.
+	Snapshot() ([]byte, error)
+
+	// Restore replaces the state with a snapshot returned by Snapshot.
+	Restore(data []byte) error
+}
+
+// Config configures a node.
+type Config struct {
+	// ID identifies the node within its group. With GRPCTransport it is the address the
+	// node's Server is reachable at.
+	ID string
+
+	// Peers are the ids of the group's initial members, including ID. They are only used
+	// if the node has no persisted state; leave them empty for a node that will be added to
+	// an existing group with AddServer.
+	Peers []string
+
+	// ElectionTimeout is the minimum time a follower waits without hearing from a leader
+	// before starting an election. The actual timeout is randomized up to twice as long.
+	ElectionTimeout time.Duration
+
+	// HeartbeatInterval is how often a leader contacts idle followers. It defaults to a
+	// tenth of the election timeout.
+	HeartbeatInterval time.Duration
+
+	// SnapshotThreshold is the number of applied entries after which the log is compacted
+	// into a snapshot.
+	SnapshotThreshold uint64
+
+	// MaxAppendEntries limits the number of entries sent in one AppendEntries message.
+	MaxAppendEntries int
+}
+
+// State is the role a node currently plays in its group.
+type State int
+
+const (
+	Follower State = iota
+	Candidate
+	Leader
+)
+
+func (s State) String() string {
+	switch s {
+	case Follower:
+		return "follower"
+	case Candidate:
+		return "candidate"
+	case Leader:
+		return "leader"
+	}
+	return fmt.Sprintf("State(%d)", int(s))
+}
+
+// peer is the leader's view of another server it replicates to.
+type peer struct {
+	id      string
+	next    uint64 // index of the next entry to send
+	match   uint64 // highest index known to be replicated on the server
+	acked   uint64 // highest read round the server acknowledged (see ReadIndex)
+	contact time.Time
+	learner bool // being caught up before AddServer makes it a voter
+
+	// snapshot transfer in progress
+	snapshot *pb.Snapshot
+	offset   uint64
+
+	trigger chan struct{}
+	stop    chan struct{}
+}
+
+// notify wakes the peer's replicator, which sends it whatever it is missing.
+func (p *peer) notify() {
+	select {
+	case p.trigger <- struct{}{}:
+	default:
+	}
+}
+
+type proposalResult struct {
+	value interface{}
+	err   error
+}
+
+// proposal is a client waiting for an entry it proposed to be applied.
+type proposal struct {
+	term uint64
+	done chan proposalResult
+}
+
+// Node is a member of a Raft group. Commands proposed on the leader are appended to its log,
+// replicated to a majority of the group, and then applied to the state machine of every
+// member in the same order.
+type Node struct {
+	id        string
+	config    Config
+	fsm       StateMachine
+	transport Transport
+	storage   Storage
+
+	mu     sync.Mutex
+	state  State
+	term   uint64
+	vote   string
+	leader string
+	log    raftLog
+
+	// latest snapshot, kept in memory to send to followers that fall behind the log
+	snapshot *pb.Snapshot
+	// membership: voters is the latest configuration in the log, which takes effect as
+	// soon as it is appended; baseVoters is the configuration as of the snapshot
+	voters      map[string]bool
+	baseVoters  []string
+	configIndex uint64
+
+	commitIndex uint64
+	lastApplied uint64
+
+	electionDeadline time.Time
+	leaderContact    time.Time // when a leader was last heard from
+
+	// candidate state
+	preVote bool
+	votes   map[string]bool
+
+	// leader state
+	peers       map[string]*peer
+	leaderSince time.Time
+	noopIndex   uint64 // first entry of the leader's term
+	readRound   uint64
+
+	proposals map[uint64]*proposal
+
+	// snapshot being received from the leader, and a received one for the applier to restore
+	incoming        *pb.Snapshot
+	pendingSnapshot *pb.Snapshot
+
+	changed chan struct{} // closed and replaced whenever state that waiters check changes
+	applyCh chan struct{}
+	done    chan struct{}
+	stopped bool
+	wg      sync.WaitGroup
+	rand    *rand.Rand
+}
+
+// NewNode restores a node from storage and starts it. The node begins as a follower; it
+// handles RPCs from the other members as soon as it is returned, so it must be made
+// reachable through the transport right away.
+func NewNode(config Config, fsm StateMachine, transport Transport, storage Storage) (*Node, error) {
+	if config.ElectionTimeout <= 0 {
+		config.ElectionTimeout = defaultElectionTimeout
+	}
+	if config.HeartbeatInterval <= 0 {
+		config.HeartbeatInterval = config.ElectionTimeout / 10
+	}
+	if config.SnapshotThreshold == 0 {
+		config.SnapshotThreshold = defaultSnapshotThreshold
+	}
+	if config.MaxAppendEntries <= 0 {
+		config.MaxAppendEntries = defaultMaxAppendEntries
+	}
+
+	state, snapshot, entries, err := storage.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	n := &Node{
+		id:         config.ID,
+		config:     config,
+		fsm:        fsm,
+		transport:  transport,
+		storage:    storage,
+		term:       state.Term,
+		vote:       state.Vote,
+		baseVoters: config.Peers,
+		proposals:  make(map[uint64]*proposal),
+		changed:    make(chan struct{}),
+		applyCh:    make(chan struct{}, 1),
+		done:       make(chan struct{}),
+		rand:       rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+	if snapshot != nil {
+		if err := fsm.Restore(snapshot.GetData()); err != nil {
+			return nil, err
+		}
+		meta := snapshot.GetMetadata()
+		n.snapshot = snapshot
+		n.baseVoters = meta.GetConfiguration().GetVoters()
+		n.log = raftLog{snapshotIndex: meta.GetIndex(), snapshotTerm: meta.GetTerm()}
+		n.commitIndex = meta.GetIndex()
+		n.lastApplied = meta.GetIndex()
+	}
+	n.log.append(entries...)
+	n.reloadConfiguration()
+	n.resetElectionTimer()
+
+	n.wg.Add(2)
+	go n.run()
+	go n.runApplier()
+	return n, nil
+}
+
+// Stop shuts the node down. Pending proposals fail with ErrStopped.
+func (n *Node) Stop() {
+	n.mu.Lock()
+	if n.stopped {
+		n.mu.Unlock()
+		return
+	}
+	n.stopped = true
+	close(n.done)
+	n.stopPeers()
+	for index, p := range n.proposals {
+		p.done <- proposalResult{err: ErrStopped}
+		delete(n.proposals, index)
+	}
+	n.notify()
+	n.mu.Unlock()
+
+	n.wg.Wait()
+}
+
+// ID returns the node's id.
+func (n *Node) ID() string {
+	return n.id
+}
+
+// Status reports the node's role and term, and the id of the leader it knows of ("" if none).
+func (n *Node) Status() (State, uint64, string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return n.state, n.term, n.leader
+}
+
+// Members returns the ids of the group's voting members, sorted.
+func (n *Node) Members() []string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return n.members()
+}
+
+func (n *Node) members() []string {
+	members := make([]string, 0, len(n.voters))
+	for id := range n.voters {
+		members = append(members, id)
+	}
+	sort.Strings(members)
+	return members
+}
+
+// Metrics reports the node's replication state for inclusion in the storage stats.
+func (n *Node) Metrics() map[string]float64 {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	leader := 0.0
+	if n.state == Leader {
+		leader = 1
+	}
+	return map[string]float64{
+		"raft_term":           float64(n.term),
+		"raft_is_leader":      leader,
+		"raft_members":        float64(len(n.voters)),
+		"raft_commit_index":   float64(n.commitIndex),
+		"raft_applied_index":  float64(n.lastApplied),
+		"raft_snapshot_index": float64(n.log.snapshotIndex),
+		"raft_log_entries":    float64(len(n.log.entries)),
+	}
+}
+
+// Propose replicates command and waits until it has been applied, returning the state
+// machine's result. It fails with ErrNotLeader on any node but the leader. If the context
+// ends or ErrProposalDropped is returned, the command may still be applied later.
+func (n *Node) Propose(ctx context.Context, command []byte) (interface{}, error) {
+	n.mu.Lock()
+	if n.stopped {
+		n.mu.Unlock()
+		return nil, ErrStopped
+	}
+	if n.state != Leader {
+		n.mu.Unlock()
+		return nil, ErrNotLeader
+	}
+	p, err := n.propose(pb.EntryType_ENTRY_NORMAL, command)
+	n.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	return n.wait(ctx, p)
+}
+
+// propose appends an entry to the leader's log and registers a proposal waiting for it.
+func (n *Node) propose(typ pb.EntryType, data []byte) (*proposal, error) {
+	index, err := n.appendEntry(typ, data)
+	if err != nil {
+		return nil, err
+	}
+	p := &proposal{term: n.term, done: make(chan proposalResult, 1)}
+	n.proposals[index] = p
+	return p, nil
+}
+
+func (n *Node) wait(ctx context.Context, p *proposal) (interface{}, error) {
+	select {
+	case result := <-p.done:
+		return result.value, result.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// ReadIndex waits until the node's state machine reflects every write committed before the
+// call, after confirming with a majority of the group that the node is still the leader.
+// Reading the state machine afterwards is linearizable. It fails with ErrNotLeader on any
+// node but the leader.
+func (n *Node) ReadIndex(ctx context.Context) error {
+	var term, round, readIndex uint64
+	started := false
+	return n.waitFor(ctx, func() (bool, error) {
+		if n.state != Leader || (started && n.term != term) {
+			return false, ErrNotLeader
+		}
+		if !started {
+			// the leader only knows the latest commit index once an entry from its own
+			// term has committed
+			if n.commitIndex < n.noopIndex {
+				return false, nil
+			}
+			started = true
+			term, readIndex = n.term, n.commitIndex
+			n.readRound++
+			round = n.readRound
+			for _, p := range n.peers {
+				p.notify()
+			}
+		}
+		acks := 0
+		for id := range n.voters {
+			if id == n.id || (n.peers[id] != nil && n.peers[id].acked >= round) {
+				acks++
+			}
+		}
+		return acks > len(n.voters)/2 && n.lastApplied >= readIndex, nil
+	})
+}
+
+// AddServer adds a server to the group. The server is first caught up with the leader's
+// log, so that adding it does not stall commits, and then becomes a voting member. Start
+// the new node with no Peers. Only one membership change may be in progress at a time.
+func (n *Node) AddServer(ctx context.Context, id string) error {
+	var p *peer
+	var target uint64
+	err := n.waitFor(ctx, func() (bool, error) {
+		if n.state != Leader {
+			return false, ErrNotLeader
+		}
+		if p == nil {
+			if n.voters[id] {
+				return true, nil
+			}
+			if err := n.checkConfigurationChange(); err != nil {
+				return false, err
+			}
+			if p = n.peers[id]; p == nil {
+				p = n.startPeer(id, true)
+			}
+			target = n.log.lastIndex()
+		}
+		return p.match >= target, nil
+	})
+	if err == nil && p != nil {
+		err = n.changeConfiguration(ctx, func(voters map[string]bool) error {
+			voters[id] = true
+			return nil
+		})
+	}
+	if err != nil && p != nil {
+		n.mu.Lock()
+		if p.learner && n.peers[id] == p {
+			n.stopPeer(p)
+		}
+		n.mu.Unlock()
+	}
+	return err
+}
+
+// RemoveServer removes a server from the group. A leader removing itself keeps managing
+// the group until the change commits, then steps down.
+func (n *Node) RemoveServer(ctx context.Context, id string) error {
+	return n.changeConfiguration(ctx, func(voters map[string]bool) error {
+		if !voters[id] {
+			return ErrNotMember
+		}
+		if len(voters) == 1 {
+			return fmt.Errorf("raft: cannot remove the last member of the group")
+		}
+		delete(voters, id)
+		return nil
+	})
+}
+
+// changeConfiguration appends a configuration entry with the membership modified by change
+// and waits for it to be applied.
+func (n *Node) changeConfiguration(ctx context.Context, change func(voters map[string]bool) error) error {
+	n.mu.Lock()
+	if n.state != Leader {
+		n.mu.Unlock()
+		return ErrNotLeader
+	}
+	if err := n.checkConfigurationChange(); err != nil {
+		n.mu.Unlock()
+		return err
+	}
+	voters := make(map[string]bool, len(n.voters)+1)
+	for id := range n.voters {
+		voters[id] = true
+	}
+	if err := change(voters); err != nil {
+		n.mu.Unlock()
+		return err
+	}
+	config := &pb.Configuration{}
+	for id := range voters {
+		config.Voters = append(config.Voters, id)
+	}
+	sort.Strings(config.Voters)
+	data, err := proto.Marshal(config)
+	if err != nil {
+		n.mu.Unlock()
+		return err
+	}
+	p, err := n.propose(pb.EntryType_ENTRY_CONFIGURATION, data)
+	n.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	_, err = n.wait(ctx, p)
+	return err
+}
+
+// checkConfigurationChange reports whether the leader may start a membership change: the
+// previous change must have committed, and so must an entry from the leader's own term
+// (which guarantees that no uncommitted change from an earlier leader is still pending).
+func (n *Node) checkConfigurationChange() error {
+	if n.configIndex > n.commitIndex || n.noopIndex > n.commitIndex {
+		return ErrConfigurationPending
+	}
+	return nil
+}
+
+// waitFor calls cond with mu held whenever the node's state changes, until it returns true
+// or an error, or ctx ends.
+func (n *Node) waitFor(ctx context.Context, cond func() (bool, error)) error {
+	for {
+		n.mu.Lock()
+		if n.stopped {
+			n.mu.Unlock()
+			return ErrStopped
+		}
+		ok, err := cond()
+		changed := n.changed
+		n.mu.Unlock()
+		if ok || err != nil {
+			return err
+		}
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// notify wakes every waitFor. The caller must hold mu.
+func (n *Node) notify() {
+	close(n.changed)
+	n.changed = make(chan struct{})
+}
+
+// run drives elections and heartbeats.
+func (n *Node) run() {
+	defer n.wg.Done()
+	ticker := time.NewTicker(n.config.HeartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-n.done:
+			return
+		case <-ticker.C:
+		}
+
+		n.mu.Lock()
+		now := time.Now()
+		switch {
+		case n.stopped:
+		case n.state == Leader:
+			// step down if cut off from a majority, so clients look for the new leader
+			contacted := 0
+			for id := range n.voters {
+				if p := n.peers[id]; id == n.id || (p != nil && now.Sub(p.contact) < n.config.ElectionTimeout) {
+					contacted++
+				}
+			}
+			if contacted <= len(n.voters)/2 && now.Sub(n.leaderSince) > n.config.ElectionTimeout {
+				log.Printf("raft %s: lost contact with a majority in term %d, stepping down", n.id, n.term)
+				n.becomeFollower(n.term, "")
+				break
+			}
+			for _, p := range n.peers {
+				p.notify()
+			}
+		case now.After(n.electionDeadline):
+			if n.voters[n.id] {
+				n.campaign(true)
+			} else {
+				n.resetElectionTimer()
+			}
+		}
+		n.mu.Unlock()
+	}
+}
+
+func (n *Node) resetElectionTimer() {
+	timeout := n.config.ElectionTimeout + time.Duration(n.rand.Int63n(int64(n.config.ElectionTimeout)))
+	n.electionDeadline = time.Now().Add(timeout)
+}
+
+// setHardState persists a new term and vote before adopting them.
+func (n *Node) setHardState(term uint64, vote string) error {
+	if term == n.term && vote == n.vote {
+		return nil
+	}
+	if err := n.storage.SaveHardState(HardState{Term: term, Vote: vote}); err != nil {
+		return err
+	}
+	n.term, n.vote = term, vote
+	return nil
+}
+
+// becomeFollower moves to term (if it is newer) as a follower of leader ("" if unknown).
+func (n *Node) becomeFollower(term uint64, leader string) error {
+	if term > n.term {
+		if err := n.setHardState(term, ""); err != nil {
+			return err
+		}
+	}
+	if n.state == Leader {
+		n.stopPeers()
+	}
+	n.state = Follower
+	n.leader = leader
+	n.votes = nil
+	n.resetElectionTimer()
+	n.notify()
+	return nil
+}
+
+// campaign starts an election. A pre-vote round first checks that the node could win
+// without incrementing any terms, so a node that was partitioned away cannot force the
+// group to elect a new leader when it returns.
+func (n *Node) campaign(preVote bool) {
+	term := n.term + 1
+	if !preVote {
+		if err := n.setHardState(term, n.id); err != nil {
+			log.Printf("raft %s: failed to persist vote: %v", n.id, err)
+			return
+		}
+	}
+	n.state = Candidate
+	n.leader = ""
+	n.preVote = preVote
+	n.votes = map[string]bool{n.id: true}
+	n.resetElectionTimer()
+	n.notify()
+	if n.wonElection() {
+		return
+	}
+
+	req := &pb.RequestVoteRequest{
+		Term:         term,
+		CandidateId:  n.id,
+		LastLogIndex: n.log.lastIndex(),
+		LastLogTerm:  n.log.lastTerm(),
+		PreVote:      preVote,
+	}
+	for id := range n.voters {
+		if id == n.id {
+			continue
+		}
+		id := id
+		n.wg.Add(1)
+		go func() {
+			defer n.wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), n.config.ElectionTimeout)
+			resp, err := n.transport.RequestVote(ctx, id, req)
+			cancel()
+			if err != nil {
+				return
+			}
+			n.mu.Lock()
+			defer n.mu.Unlock()
+			n.handleVoteResponse(req, id, resp)
+		}()
+	}
+}
+
+func (n *Node) handleVoteResponse(req *pb.RequestVoteRequest, from string, resp *pb.RequestVoteResponse) {
+	if n.stopped {
+		return
+	}
+	if resp.GetTerm() > n.term && !resp.GetVoteGranted() {
+		n.becomeFollower(resp.GetTerm(), "")
+		return
+	}
+	// ignore responses to an election that is over
+	electionTerm := n.term
+	if n.preVote {
+		electionTerm++
+	}
+	if n.state != Candidate || n.preVote != req.GetPreVote() || req.GetTerm() != electionTerm {
+		return
+	}
+	if resp.GetVoteGranted() {
+		n.votes[from] = true
+		n.wonElection()
+	}
+}
+
+// wonElection moves on from a won pre-vote to the real election, or from a won election
+// to leadership, reporting whether the node has now become the leader.
+func (n *Node) wonElection() bool {
+	granted := 0
+	for id := range n.votes {
+		if n.voters[id] {
+			granted++
+		}
+	}
+	if granted <= len(n.voters)/2 {
+		return false
+	}
+	if n.preVote {
+		n.campaign(false)
+		return n.state == Leader
+	}
+	n.becomeLeader()
+	return true
+}
+
+func (n *Node) becomeLeader() {
+	log.Printf("raft %s: elected leader in term %d", n.id, n.term)
+	n.state = Leader
+	n.leader = n.id
+	n.votes = nil
+	n.leaderSince = time.Now()
+	n.peers = make(map[string]*peer)
+	for id := range n.voters {
+		if id != n.id {
+			n.startPeer(id, false)
+		}
+	}
+	// committing an entry from the new term also commits everything before it
+	index, err := n.appendEntry(pb.EntryType_ENTRY_NOOP, nil)
+	if err != nil {
+		log.Printf("raft %s: failed to append to log: %v", n.id, err)
+		n.becomeFollower(n.term, "")
+		return
+	}
+	n.noopIndex = index
+	n.notify()
+}
+
+// appendEntry appends a new entry to the leader's log and starts replicating it.
+func (n *Node) appendEntry(typ pb.EntryType, data []byte) (uint64, error) {
+	entry := &pb.Entry{
+		Index: n.log.lastIndex() + 1,
+		Term:  n.term,
+		Type:  typ,
+		Data:  data,
+	}
+	if err := n.storage.Append([]*pb.Entry{entry}); err != nil {
+		return 0, err
+	}
+	n.log.append(entry)
+	if typ == pb.EntryType_ENTRY_CONFIGURATION {
+		n.reloadConfiguration()
+	}
+	for _, p := range n.peers {
+		p.notify()
+	}
+	n.advanceCommit()
+	return entry.Index, nil
+}
+
+// reloadConfiguration adopts the latest membership in the log.
+func (n *Node) reloadConfiguration() {
+	voters, index := n.log.configuration(n.log.lastIndex(), n.baseVoters)
+	n.voters = make(map[string]bool, len(voters))
+	for _, id := range voters {
+		n.voters[id] = true
+	}
+	n.configIndex = index
+
+	if n.state != Leader {
+		return
+	}
+	for id := range n.voters {
+		if p := n.peers[id]; p != nil {
+			p.learner = false
+		} else if id != n.id {
+			n.startPeer(id, false)
+		}
+	}
+	for id, p := range n.peers {
+		if !n.voters[id] && !p.learner {
+			n.stopPeer(p)
+		}
+	}
+}
+
+// advanceCommit commits the entries replicated on a majority of voters. Only entries from
+// the leader's own term are committed by counting replicas.
+func (n *Node) advanceCommit() {
+	if n.state != Leader || len(n.voters) == 0 {
+		return
+	}
+	matches := make([]uint64, 0, len(n.voters))
+	for id := range n.voters {
+		switch {
+		case id == n.id:
+			matches = append(matches, n.log.lastIndex())
+		case n.peers[id] != nil:
+			matches = append(matches, n.peers[id].match)
+		default:
+			matches = append(matches, 0)
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i] > matches[j] })
+	index := matches[len(matches)/2]
+	if index <= n.commitIndex {
+		return
+	}
+	if term, ok := n.log.term(index); !ok || term != n.term {
+		return
+	}
+	n.commitIndex = index
+	n.wakeApplier()
+	n.notify()
+	for _, p := range n.peers {
+		p.notify()
+	}
+
+	// a leader that removed itself steps down once the change commits
+	if !n.voters[n.id] && n.commitIndex >= n.configIndex {
+		log.Printf("raft %s: removed from the group, stepping down", n.id)
+		n.becomeFollower(n.term, "")
+	}
+}
+
+func (n *Node) startPeer(id string, learner bool) *peer {
+	p := &peer{
+		id:      id,
+		next:    n.log.lastIndex() + 1,
+		contact: time.Now(),
+		learner: learner,
+		trigger: make(chan struct{}, 1),
+		stop:    make(chan struct{}),
+	}
+	n.peers[id] = p
+	n.wg.Add(1)
+	go n.replicate(p)
+	p.notify()
+	return p
+}
+
+func (n *Node) stopPeer(p *peer) {
+	close(p.stop)
+	delete(n.peers, p.id)
+}
+
+func (n *Node) stopPeers() {
+	for _, p := range n.peers {
+		n.stopPeer(p)
+	}
+	n.peers = nil
+}
+
+// replicate sends a peer the entries it is missing each time it is notified, with at most
+// one message in flight.
+func (n *Node) replicate(p *peer) {
+	defer n.wg.Done()
+	for {
+		select {
+		case <-n.done:
+			return
+		case <-p.stop:
+			return
+		case <-p.trigger:
+		}
+		for n.sendTo(p) {
+		}
+	}
+}
+
+// sendTo sends one AppendEntries (or InstallSnapshot chunk) message to a peer and handles
+// the response, reporting whether there is more to send right away.
+func (n *Node) sendTo(p *peer) bool {
+	n.mu.Lock()
+	if n.state != Leader || n.peers[p.id] != p {
+		n.mu.Unlock()
+		return false
+	}
+	if p.next <= n.log.snapshotIndex {
+		return n.sendSnapshot(p)
+	}
+
+	term, round := n.term, n.readRound
+	prev := p.next - 1
+	prevTerm, _ := n.log.term(prev)
+	last := n.log.lastIndex()
+	if limit := prev + uint64(n.config.MaxAppendEntries); last > limit {
+		last = limit
+	}
+	req := &pb.AppendEntriesRequest{
+		Term:         term,
+		LeaderId:     n.id,
+		PrevLogIndex: prev,
+		PrevLogTerm:  prevTerm,
+		Entries:      n.log.slice(p.next, last, maxAppendBytes),
+		LeaderCommit: n.commitIndex,
+	}
+	n.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), n.config.ElectionTimeout)
+	resp, err := n.transport.AppendEntries(ctx, p.id, req)
+	cancel()
+	if err != nil {
+		return false
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if !n.checkResponse(p, term, resp.GetTerm()) {
+		return false
+	}
+	if round > p.acked {
+		p.acked = round
+		n.notify()
+	}
+	if !resp.GetSuccess() {
+		// back up to where the logs may match, but never below what is known to match
+		next := resp.GetConflictIndex()
+		if next >= p.next {
+			next = p.next - 1
+		}
+		if next <= p.match {
+			next = p.match + 1
+		}
+		p.next = next
+		return true
+	}
+	if resp.GetMatchIndex() > p.match {
+		p.match = resp.GetMatchIndex()
+		n.advanceCommit()
+		n.notify()
+	}
+	p.next = p.match + 1
+	return n.state == Leader && p.next <= n.log.lastIndex()
+}
+
+// sendSnapshot sends the next chunk of the latest snapshot to a peer that needs entries
+// that were already compacted. It is called with mu held and releases it.
+func (n *Node) sendSnapshot(p *peer) bool {
+	if p.snapshot != n.snapshot {
+		p.snapshot, p.offset = n.snapshot, 0
+	}
+	snapshot, offset, term := p.snapshot, p.offset, n.term
+	data := snapshot.GetData()
+	end := offset + snapshotChunkSize
+	if end > uint64(len(data)) {
+		end = uint64(len(data))
+	}
+	req := &pb.InstallSnapshotRequest{
+		Term:     term,
+		LeaderId: n.id,
+		Metadata: snapshot.GetMetadata(),
+		Offset:   offset,
+		Data:     data[offset:end],
+		Done:     end == uint64(len(data)),
+	}
+	n.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*n.config.ElectionTimeout)
+	resp, err := n.transport.InstallSnapshot(ctx, p.id, req)
+	cancel()
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if err != nil {
+		// start over, since the follower may have discarded what it received
+		p.offset = 0
+		return false
+	}
+	if !n.checkResponse(p, term, resp.GetTerm()) || p.snapshot != snapshot {
+		return false
+	}
+	if !req.GetDone() {
+		p.offset = end
+		return true
+	}
+	p.snapshot, p.offset = nil, 0
+	if index := snapshot.GetMetadata().GetIndex(); index > p.match {
+		p.match = index
+		n.advanceCommit()
+		n.notify()
+	}
+	p.next = p.match + 1
+	return n.state == Leader && p.next <= n.log.lastIndex()
+}
+
+// checkResponse handles the term in a peer's response, stepping down if it is newer, and
+// reports whether the response should still be processed. The caller must hold mu.
+func (n *Node) checkResponse(p *peer, term, respTerm uint64) bool {
+	if respTerm > n.term {
+		if err := n.becomeFollower(respTerm, ""); err != nil {
+			log.Printf("raft %s: failed to persist term: %v", n.id, err)
+		}
+		return false
+	}
+	if n.state != Leader || n.term != term || n.peers[p.id] != p {
+		return false
+	}
+	p.contact = time.Now()
+	return true
+}
+
+// HandleRequestVote handles a vote (or pre-vote) request from a candidate.
+func (n *Node) HandleRequestVote(req *pb.RequestVoteRequest) (*pb.RequestVoteResponse, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.stopped {
+		return nil, ErrStopped
+	}
+
+	resp := &pb.RequestVoteResponse{Term: n.term}
+	// a member that has heard from a live leader does not help depose it; this also keeps
+	// servers removed from the group from disrupting it
+	if n.state == Leader || (n.leader != "" && time.Since(n.leaderContact) < n.config.ElectionTimeout) {
+		return resp, nil
+	}
+	upToDate := req.GetLastLogTerm() > n.log.lastTerm() ||
+		(req.GetLastLogTerm() == n.log.lastTerm() && req.GetLastLogIndex() >= n.log.lastIndex())
+
+	if req.GetPreVote() {
+		resp.VoteGranted = req.GetTerm() > n.term && upToDate
+		return resp, nil
+	}
+	if req.GetTerm() < n.term {
+		return resp, nil
+	}
+	if req.GetTerm() > n.term {
+		if err := n.becomeFollower(req.GetTerm(), ""); err != nil {
+			return nil, err
+		}
+	}
+	if (n.vote == "" || n.vote == req.GetCandidateId()) && upToDate {
+		if err := n.setHardState(n.term, req.GetCandidateId()); err != nil {
+			return nil, err
+		}
+		resp.VoteGranted = true
+		n.resetElectionTimer()
+	}
+	resp.Term = n.term
+	return resp, nil
+}
+
+// HandleAppendEntries handles log entries (or a heartbeat) from the leader.
+func (n *Node) HandleAppendEntries(req *pb.AppendEntriesRequest) (*pb.AppendEntriesResponse, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.stopped {
+		return nil, ErrStopped
+	}
+
+	resp := &pb.AppendEntriesResponse{Term: n.term}
+	if req.GetTerm() < n.term {
+		return resp, nil
+	}
+	if err := n.followLeader(req.GetTerm(), req.GetLeaderId()); err != nil {
+		return nil, err
+	}
+	resp.Term = n.term
+
+	prev, entries := req.GetPrevLogIndex(), req.GetEntries()
+	if prev < n.log.snapshotIndex {
+		// entries covered by the snapshot are committed, so they match the leader's
+		skip := n.log.snapshotIndex - prev
+		if skip >= uint64(len(entries)) {
+			resp.Success = true
+			resp.MatchIndex = prev + uint64(len(entries))
+			return resp, nil
+		}
+		prev, entries = n.log.snapshotIndex, entries[skip:]
+	} else if prev > n.log.lastIndex() {
+		resp.ConflictIndex = n.log.lastIndex() + 1
+		return resp, nil
+	} else if term, _ := n.log.term(prev); term != req.GetPrevLogTerm() {
+		// skip back over the whole conflicting term in one round trip
+		index := prev
+		for index > n.log.snapshotIndex+1 {
+			if t, _ := n.log.term(index - 1); t != term {
+				break
+			}
+			index--
+		}
+		resp.ConflictIndex = index
+		return resp, nil
+	}
+
+	// skip the entries the log already has, and replace the log from the first mismatch
+	for i, e := range entries {
+		if term, ok := n.log.term(e.GetIndex()); !ok || term != e.GetTerm() {
+			if err := n.storage.Append(entries[i:]); err != nil {
+				return nil, err
+			}
+			truncated := e.GetIndex() <= n.log.lastIndex()
+			n.log.append(entries[i:]...)
+			if truncated {
+				n.dropProposals(e.GetIndex())
+			}
+			n.reloadConfiguration()
+			break
+		}
+	}
+
+	lastNew := prev + uint64(len(entries))
+	if commit := req.GetLeaderCommit(); commit > n.commitIndex && lastNew > n.commitIndex {
+		if commit > lastNew {
+			commit = lastNew
+		}
+		n.commitIndex = commit
+		n.wakeApplier()
+	}
+	resp.Success = true
+	resp.MatchIndex = lastNew
+	return resp, nil
+}
+
+// HandleInstallSnapshot handles a chunk of a snapshot from the leader. Once the last chunk
+// arrives the snapshot replaces the log (apart from any entries that follow it) and the
+// state machine is restored from it.
+func (n *Node) HandleInstallSnapshot(req *pb.InstallSnapshotRequest) (*pb.InstallSnapshotResponse, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.stopped {
+		return nil, ErrStopped
+	}
+
+	resp := &pb.InstallSnapshotResponse{Term: n.term}
+	if req.GetTerm() < n.term {
+		return resp, nil
+	}
+	if err := n.followLeader(req.GetTerm(), req.GetLeaderId()); err != nil {
+		return nil, err
+	}
+	resp.Term = n.term
+
+	meta := req.GetMetadata()
+	if req.GetOffset() == 0 {
+		n.incoming = &pb.Snapshot{Metadata: meta}
+	}
+	if n.incoming == nil || !proto.Equal(n.incoming.GetMetadata(), meta) || req.GetOffset() != uint64(len(n.incoming.Data)) {
+		n.incoming = nil
+		return nil, fmt.Errorf("raft: unexpected snapshot chunk at offset %d", req.GetOffset())
+	}
+	n.incoming.Data = append(n.incoming.Data, req.GetData()...)
+	if !req.GetDone() {
+		return resp, nil
+	}
+
+	snapshot := n.incoming
+	n.incoming = nil
+	if meta.GetIndex() <= n.commitIndex {
+		return resp, nil
+	}
+	// keep the entries following the snapshot if the log agrees with it
+	var entries []*pb.Entry
+	if term, ok := n.log.term(meta.GetIndex()); ok && term == meta.GetTerm() {
+		entries = n.log.slice(meta.GetIndex()+1, n.log.lastIndex(), 0)
+	}
+	if err := n.storage.SaveSnapshot(snapshot, entries); err != nil {
+		return nil, err
+	}
+	n.snapshot = snapshot
+	n.baseVoters = meta.GetConfiguration().GetVoters()
+	n.log = raftLog{snapshotIndex: meta.GetIndex(), snapshotTerm: meta.GetTerm(), entries: entries}
+	n.commitIndex = meta.GetIndex()
+	n.pendingSnapshot = snapshot
+	n.reloadConfiguration()
+	n.dropProposals(0)
+	n.wakeApplier()
+	return resp, nil
+}
+
+// followLeader adopts the sender of an AppendEntries or InstallSnapshot message from a
+// current term as the leader.
+func (n *Node) followLeader(term uint64, leader string) error {
+	if term > n.term || n.state != Follower || n.leader != leader {
+		if err := n.becomeFollower(term, leader); err != nil {
+			return err
+		}
+	}
+	n.leaderContact = time.Now()
+	n.resetElectionTimer()
+	return nil
+}
+
+// dropProposals fails the proposals for entries from index on, which were removed from
+// the log (or, for 0, every proposal).
+func (n *Node) dropProposals(from uint64) {
+	for index, p := range n.proposals {
+		if index >= from {
+			p.done <- proposalResult{err: ErrProposalDropped}
+			delete(n.proposals, index)
+		}
+	}
+}
+
+func (n *Node) wakeApplier() {
+	select {
+	case n.applyCh <- struct{}{}:
+	default:
+	}
+}
+
+// runApplier applies committed entries to the state machine, outside of mu so that the
+// node keeps replicating meanwhile, and takes snapshots as the log grows.
+func (n *Node) runApplier() {
+	defer n.wg.Done()
+	for {
+		select {
+		case <-n.done:
+			return
+		case <-n.applyCh:
+		}
+		for n.applyCommitted() {
+		}
+	}
+}
+
+// applyCommitted applies a batch of committed entries, or restores a snapshot received from
+// the leader, reporting whether there may be more to do.
+func (n *Node) applyCommitted() bool {
+	n.mu.Lock()
+	if n.stopped {
+		n.mu.Unlock()
+		return false
+	}
+	if snapshot := n.pendingSnapshot; snapshot != nil {
+		n.pendingSnapshot = nil
+		index := snapshot.GetMetadata().GetIndex()
+		n.mu.Unlock()
+		if index <= n.appliedIndex() {
+			return true
+		}
+		if err := n.fsm.Restore(snapshot.GetData()); err != nil {
+			log.Fatalf("raft %s: failed to restore snapshot: %v", n.id, err)
+		}
+		n.mu.Lock()
+		if index > n.lastApplied {
+			n.lastApplied = index
+		}
+		n.notify()
+		n.mu.Unlock()
+		return true
+	}
+	if n.lastApplied >= n.commitIndex || n.lastApplied < n.log.snapshotIndex {
+		n.mu.Unlock()
+		return false
+	}
+	entries := n.log.slice(n.lastApplied+1, n.commitIndex, maxAppendBytes)
+	n.mu.Unlock()
+
+	results := make([]interface{}, len(entries))
+	for i, e := range entries {
+		if e.GetType() == pb.EntryType_ENTRY_NORMAL {
+			results[i] = n.fsm.Apply(e.GetData())
+		}
+	}
+
+	n.mu.Lock()
+	last := entries[len(entries)-1].GetIndex()
+	if last > n.lastApplied {
+		n.lastApplied = last
+	}
+	for i, e := range entries {
+		p := n.proposals[e.GetIndex()]
+		if p == nil {
+			continue
+		}
+		delete(n.proposals, e.GetIndex())
+		if p.term == e.GetTerm() {
+			p.done <- proposalResult{value: results[i]}
+		} else {
+			p.done <- proposalResult{err: ErrProposalDropped}
+		}
+	}
+	n.notify()
+	compact := n.lastApplied-n.log.snapshotIndex >= n.config.SnapshotThreshold
+	n.mu.Unlock()
+
+	if compact {
+		if err := n.takeSnapshot(last); err != nil {
+			log.Printf("raft %s: failed to take snapshot: %v", n.id, err)
+		}
+	}
+	return true
+}
+
+func (n *Node) appliedIndex() uint64 {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return n.lastApplied
+}
+
+// takeSnapshot snapshots the state machine, which reflects every entry up to index, and
+// compacts the log. It must be called from the applier.
+func (n *Node) takeSnapshot(index uint64) error {
+	data, err := n.fsm.Snapshot()
+	if err != nil {
+		return err
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	term, ok := n.log.term(index)
+	if !ok || index <= n.log.snapshotIndex {
+		// a snapshot from the leader replaced the log meanwhile
+		return nil
+	}
+	voters, _ := n.log.configuration(index, n.baseVoters)
+	snapshot := &pb.Snapshot{
+		Metadata: &pb.SnapshotMetadata{
+			Index:         index,
+			Term:          term,
+			Configuration: &pb.Configuration{Voters: voters},
+		},
+		Data: data,
+	}
+	if err := n.storage.SaveSnapshot(snapshot, n.log.slice(index+1, n.log.lastIndex(), 0)); err != nil {
+		return err
+	}
+	n.snapshot = snapshot
+	n.baseVoters = voters
+	n.log.compact(index, term)
+	return nil
+}
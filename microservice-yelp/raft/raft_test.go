@@ -0,0 +1,312 @@
+package raft
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testElectionTimeout = 100 * time.Millisecond
+
+// testFSM is a key-value state machine applying commands of the form key=value.
+type testFSM struct {
+	mu    sync.Mutex
+	state map[string]string
+}
+
+func newTestFSM() *testFSM {
+	return &testFSM{state: make(map[string]string)}
+}
+
+func (f *testFSM) Apply(command []byte) interface{} {
+	var kv [2]string
+	if err := json.Unmarshal(command, &kv); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.state[kv[0]] = kv[1]
+	return nil
+}
+
+func (f *testFSM) Snapshot() ([]byte, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return json.Marshal(f.state)
+}
+
+func (f *testFSM) Restore(data []byte) error {
+	state := make(map[string]string)
+	if err := json.Unmarshal(data, &state); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.state = state
+	return nil
+}
+
+func (f *testFSM) snapshot() map[string]string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	state := make(map[string]string, len(f.state))
+	for k, v := range f.state {
+		state[k] = v
+	}
+	return state
+}
+
+// testCluster is a group of nodes on a simulated network.
+type testCluster struct {
+	t       *testing.T
+	network *Network
+	config  Config
+	nodes   map[string]*Node
+	fsms    map[string]*testFSM
+}
+
+// newTestCluster starts a group of the given members.
+func newTestCluster(t *testing.T, config Config, ids ...string) *testCluster {
+	c := &testCluster{
+		t:       t,
+		network: NewNetwork(),
+		config:  config,
+		nodes:   make(map[string]*Node),
+		fsms:    make(map[string]*testFSM),
+	}
+	for _, id := range ids {
+		c.start(id, ids)
+	}
+	t.Cleanup(func() {
+		for _, node := range c.nodes {
+			node.Stop()
+		}
+	})
+	return c
+}
+
+// start starts a node with the given initial members, which are empty for a node to be
+// added with AddServer.
+func (c *testCluster) start(id string, peers []string) *Node {
+	config := c.config
+	config.ID, config.Peers = id, peers
+	if config.ElectionTimeout == 0 {
+		config.ElectionTimeout = testElectionTimeout
+	}
+	fsm := newTestFSM()
+	node, err := NewNode(config, fsm, c.network.Transport(id), NewMemoryStorage())
+	if err != nil {
+		c.t.Fatalf("NewNode(%s): %v", id, err)
+	}
+	c.network.Register(id, node)
+	c.nodes[id], c.fsms[id] = node, fsm
+	return node
+}
+
+// leader waits until exactly one of the given nodes leads, and all of them agree on it.
+func (c *testCluster) leader(ids ...string) *Node {
+	c.t.Helper()
+	var leader *Node
+	waitUntil(c.t, "a leader is elected", func() bool {
+		leader = nil
+		var known string
+		for _, id := range ids {
+			state, _, leaderID := c.nodes[id].Status()
+			if state == Leader {
+				if leader != nil {
+					return false
+				}
+				leader = c.nodes[id]
+			}
+			if known == "" {
+				known = leaderID
+			}
+			if leaderID == "" || leaderID != known {
+				return false
+			}
+		}
+		return leader != nil && leader.ID() == known
+	})
+	return leader
+}
+
+// propose applies key=value through the leader of the given nodes.
+func (c *testCluster) propose(key, value string, ids ...string) {
+	c.t.Helper()
+	command, _ := json.Marshal([2]string{key, value})
+	waitUntil(c.t, "the proposal is applied", func() bool {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*testElectionTimeout)
+		defer cancel()
+		_, err := c.leader(ids...).Propose(ctx, command)
+		return err == nil
+	})
+}
+
+// converged waits until the state machines of the given nodes all hold want.
+func (c *testCluster) converged(want map[string]string, ids ...string) {
+	c.t.Helper()
+	waitUntil(c.t, "the state machines converge", func() bool {
+		for _, id := range ids {
+			if !reflect.DeepEqual(c.fsms[id].snapshot(), want) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+func waitUntil(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(50 * testElectionTimeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting until %s", what)
+		}
+		time.Sleep(testElectionTimeout / 10)
+	}
+}
+
+func TestElection(t *testing.T) {
+	c := newTestCluster(t, Config{}, "a", "b", "c")
+	leader := c.leader("a", "b", "c")
+	_, term, _ := leader.Status()
+
+	// a leader that stays connected keeps its term
+	time.Sleep(5 * testElectionTimeout)
+	if _, now, _ := leader.Status(); now != term || c.leader("a", "b", "c") != leader {
+		t.Fatalf("leadership changed without a failure: term %d became %d", term, now)
+	}
+}
+
+func TestLeaderFailoverUnderPartition(t *testing.T) {
+	ids := []string{"a", "b", "c"}
+	c := newTestCluster(t, Config{}, ids...)
+	c.propose("x", "1", ids...)
+	old := c.leader(ids...)
+	_, oldTerm, _ := old.Status()
+
+	var rest []string
+	for _, id := range ids {
+		if id != old.ID() {
+			rest = append(rest, id)
+		}
+	}
+	c.network.Partition([]string{old.ID()}, rest)
+	leader := c.leader(rest...)
+	if _, term, _ := leader.Status(); term <= oldTerm {
+		t.Fatalf("new leader has term %d, want more than %d", term, oldTerm)
+	}
+	c.propose("y", "2", rest...)
+
+	// the old leader cannot commit on its own
+	command, _ := json.Marshal([2]string{"z", "3"})
+	ctx, cancel := context.WithTimeout(context.Background(), 3*testElectionTimeout)
+	defer cancel()
+	if _, err := old.Propose(ctx, command); err == nil {
+		t.Fatal("a partitioned leader committed a proposal")
+	}
+
+	c.network.Heal()
+	c.leader(ids...)
+	c.converged(map[string]string{"x": "1", "y": "2"}, ids...)
+}
+
+func TestLogCatchUp(t *testing.T) {
+	ids := []string{"a", "b", "c"}
+	c := newTestCluster(t, Config{}, ids...)
+	leader := c.leader(ids...)
+	var follower string
+	for _, id := range ids {
+		if id != leader.ID() {
+			follower = id
+			break
+		}
+	}
+
+	c.network.Disconnect(follower)
+	want := make(map[string]string)
+	for i := 0; i < 50; i++ {
+		key := fmt.Sprintf("k%d", i)
+		c.propose(key, "v", ids...)
+		want[key] = "v"
+	}
+	if got := c.fsms[follower].snapshot(); len(got) != 0 {
+		t.Fatalf("disconnected follower applied %d entries", len(got))
+	}
+
+	c.network.Connect(follower)
+	c.converged(want, ids...)
+}
+
+func TestSnapshotInstall(t *testing.T) {
+	ids := []string{"a", "b", "c"}
+	c := newTestCluster(t, Config{SnapshotThreshold: 10}, ids...)
+	leader := c.leader(ids...)
+	var follower string
+	for _, id := range ids {
+		if id != leader.ID() {
+			follower = id
+			break
+		}
+	}
+
+	c.network.Disconnect(follower)
+	want := make(map[string]string)
+	for i := 0; i < 40; i++ {
+		key := fmt.Sprintf("k%d", i)
+		c.propose(key, "v", ids...)
+		want[key] = "v"
+	}
+	waitUntil(t, "the leader compacts its log", func() bool {
+		return leader.Metrics()["raft_snapshot_index"] > 0
+	})
+
+	// the entries the follower is missing are gone from the leader's log, so it is sent
+	// the snapshot
+	c.network.Connect(follower)
+	c.converged(want, ids...)
+	if index := c.nodes[follower].Metrics()["raft_snapshot_index"]; index == 0 {
+		t.Fatal("follower caught up without installing a snapshot")
+	}
+}
+
+func TestAddAndRemoveServer(t *testing.T) {
+	ids := []string{"a", "b", "c"}
+	c := newTestCluster(t, Config{}, ids...)
+	c.propose("x", "1", ids...)
+
+	c.start("d", nil)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*testElectionTimeout)
+	defer cancel()
+	waitUntil(t, "d is added", func() bool {
+		return c.leader(ids...).AddServer(ctx, "d") == nil
+	})
+	all := []string{"a", "b", "c", "d"}
+	c.converged(map[string]string{"x": "1"}, all...)
+	if members := c.leader(all...).Members(); !reflect.DeepEqual(members, all) {
+		t.Fatalf("members are %v after AddServer, want %v", members, all)
+	}
+
+	// remove the leader itself, which steps down once the change commits
+	removed := c.leader(all...).ID()
+	var rest []string
+	for _, id := range all {
+		if id != removed {
+			rest = append(rest, id)
+		}
+	}
+	waitUntil(t, removed+" is removed", func() bool {
+		err := c.leader(all...).RemoveServer(ctx, removed)
+		return err == nil || err == ErrNotMember
+	})
+	leader := c.leader(rest...)
+	if members := leader.Members(); !reflect.DeepEqual(members, rest) {
+		t.Fatalf("members are %v after RemoveServer, want %v", members, rest)
+	}
+	c.propose("y", "2", rest...)
+	c.converged(map[string]string{"x": "1", "y": "2"}, rest...)
+}
@@ -0,0 +1,355 @@
+package raft
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	pb "gitlab.cs.washington.edu/syslab/cse453-welp/proto/raft"
+	"google.golang.org/protobuf/proto"
+)
+
+var ErrCorruptLog = errors.New("raft: corrupt log entry")
+
+const (
+	stateFileName    = "raft-state.json"
+	snapshotFileName = "raft-snapshot"
+	logFileName      = "raft-log"
+
+	logHeaderSize = 8 // crc32 (4 bytes) + payload length (4 bytes)
+)
+
+var logCRCTable = crc32.MakeTable(crc32.Castagnoli)
+
+// HardState is the part of a node's state that must survive restarts for elections to be safe.
+type HardState struct {
+	Term uint64 `json:"term"`
+	Vote string `json:"vote,omitempty"`
+}
+
+// Storage persists a node's hard state, log and latest snapshot. Every method must make its
+// changes durable before returning.
+type Storage interface {
+	// Load returns everything persisted so far. The snapshot is nil if none was saved, and
+	// entries are the log entries following it.
+	Load() (HardState, *pb.Snapshot, []*pb.Entry, error)
+
+	// SaveHardState replaces the persisted term and vote.
+	SaveHardState(state HardState) error
+
+	// Append adds entries to the log. If the first entry's index is not past the end of the
+	// log, the entries from that index on are replaced.
+	Append(entries []*pb.Entry) error
+
+	// SaveSnapshot replaces the persisted snapshot and replaces the log with entries, which
+	// are the entries following the snapshot.
+	SaveSnapshot(snapshot *pb.Snapshot, entries []*pb.Entry) error
+
+	// Close releases the underlying resources.
+	Close() error
+}
+
+// MemoryStorage keeps a node's persistent state in memory. It survives restarts of a node
+// within the same process, which makes it suitable for simulated clusters.
+type MemoryStorage struct {
+	mu       sync.Mutex
+	state    HardState
+	snapshot *pb.Snapshot
+	entries  []*pb.Entry
+}
+
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{}
+}
+
+func (s *MemoryStorage) Load() (HardState, *pb.Snapshot, []*pb.Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.state, s.snapshot, append([]*pb.Entry(nil), s.entries...), nil
+}
+
+func (s *MemoryStorage) SaveHardState(state HardState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.state = state
+	return nil
+}
+
+func (s *MemoryStorage) Append(entries []*pb.Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries = appendEntries(s.entries, entries)
+	return nil
+}
+
+func (s *MemoryStorage) SaveSnapshot(snapshot *pb.Snapshot, entries []*pb.Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.snapshot = snapshot
+	s.entries = append([]*pb.Entry(nil), entries...)
+	return nil
+}
+
+func (s *MemoryStorage) Close() error {
+	return nil
+}
+
+// appendEntries appends entries to log, first dropping the entries of log they replace.
+func appendEntries(log, entries []*pb.Entry) []*pb.Entry {
+	if len(entries) == 0 {
+		return log
+	}
+	first := entries[0].GetIndex()
+	for len(log) > 0 && log[len(log)-1].GetIndex() >= first {
+		log = log[:len(log)-1]
+	}
+	return append(log, entries...)
+}
+
+// FileStorage persists a node's state in a directory: the hard state as JSON, the snapshot
+// as a protobuf message, and the log as an append-only file of checksummed entries. Entries
+// replaced after a leader change are appended again rather than overwritten; loading keeps
+// the last copy of each index. The log file is rewritten whenever a snapshot is saved.
+type FileStorage struct {
+	mu  sync.Mutex
+	dir string
+	log *os.File
+}
+
+// NewFileStorage opens (creating it if needed) the storage kept in dir.
+func NewFileStorage(dir string) (*FileStorage, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(filepath.Join(dir, logFileName), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &FileStorage{dir: dir, log: f}, nil
+}
+
+func (s *FileStorage) Load() (HardState, *pb.Snapshot, []*pb.Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var state HardState
+	data, err := os.ReadFile(filepath.Join(s.dir, stateFileName))
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(data, &state); err != nil {
+			return HardState{}, nil, nil, err
+		}
+	case !os.IsNotExist(err):
+		return HardState{}, nil, nil, err
+	}
+
+	var snapshot *pb.Snapshot
+	data, err = os.ReadFile(filepath.Join(s.dir, snapshotFileName))
+	switch {
+	case err == nil:
+		snapshot = &pb.Snapshot{}
+		if err := proto.Unmarshal(data, snapshot); err != nil {
+			return HardState{}, nil, nil, err
+		}
+	case !os.IsNotExist(err):
+		return HardState{}, nil, nil, err
+	}
+
+	entries, err := s.readLog()
+	if err != nil {
+		return HardState{}, nil, nil, err
+	}
+	// the log may still hold entries covered by the snapshot if a crash interrupted
+	// SaveSnapshot between writing the snapshot and rewriting the log
+	for len(entries) > 0 && snapshot != nil && entries[0].GetIndex() <= snapshot.GetMetadata().GetIndex() {
+		entries = entries[1:]
+	}
+	return state, snapshot, entries, nil
+}
+
+// readLog reads every entry in the log file, truncating a torn or damaged tail left by a
+// crash in the middle of an append.
+func (s *FileStorage) readLog() ([]*pb.Entry, error) {
+	if _, err := s.log.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	r := bufio.NewReader(s.log)
+
+	var entries []*pb.Entry
+	var offset int64
+	for {
+		entry, n, err := readLogEntry(r)
+		if err == io.EOF {
+			break
+		}
+		if err == io.ErrUnexpectedEOF || err == ErrCorruptLog {
+			if err := s.log.Truncate(offset); err != nil {
+				return nil, err
+			}
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		entries = appendEntries(entries, []*pb.Entry{entry})
+		offset += int64(n)
+	}
+	if _, err := s.log.Seek(offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func (s *FileStorage) SaveHardState(state HardState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(s.dir, stateFileName, data)
+}
+
+func (s *FileStorage) Append(entries []*pb.Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var buf []byte
+	for _, entry := range entries {
+		frame, err := encodeLogEntry(entry)
+		if err != nil {
+			return err
+		}
+		buf = append(buf, frame...)
+	}
+	if _, err := s.log.Write(buf); err != nil {
+		return err
+	}
+	return s.log.Sync()
+}
+
+func (s *FileStorage) SaveSnapshot(snapshot *pb.Snapshot, entries []*pb.Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := proto.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(s.dir, snapshotFileName, data); err != nil {
+		return err
+	}
+
+	var buf []byte
+	for _, entry := range entries {
+		frame, err := encodeLogEntry(entry)
+		if err != nil {
+			return err
+		}
+		buf = append(buf, frame...)
+	}
+	if err := writeFileAtomic(s.dir, logFileName, buf); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(filepath.Join(s.dir, logFileName), os.O_RDWR, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Seek(0, io.SeekEnd); err != nil {
+		f.Close()
+		return err
+	}
+	s.log.Close()
+	s.log = f
+	return nil
+}
+
+func (s *FileStorage) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.log.Close()
+}
+
+// encodeLogEntry frames an entry as [crc32c][length][payload], where the payload is the
+// protobuf encoding of the entry and the checksum covers the payload.
+func encodeLogEntry(entry *pb.Entry) ([]byte, error) {
+	payload, err := proto.Marshal(entry)
+	if err != nil {
+		return nil, err
+	}
+	buf := make([]byte, logHeaderSize+len(payload))
+	binary.LittleEndian.PutUint32(buf[0:4], crc32.Checksum(payload, logCRCTable))
+	binary.LittleEndian.PutUint32(buf[4:8], uint32(len(payload)))
+	copy(buf[logHeaderSize:], payload)
+	return buf, nil
+}
+
+// readLogEntry reads the next entry from r. It returns io.EOF at a clean end of the log and
+// io.ErrUnexpectedEOF or ErrCorruptLog for a torn or damaged entry.
+func readLogEntry(r io.Reader) (*pb.Entry, int, error) {
+	var header [logHeaderSize]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, 0, err
+	}
+	checksum := binary.LittleEndian.Uint32(header[0:4])
+	length := binary.LittleEndian.Uint32(header[4:8])
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, 0, err
+	}
+	if crc32.Checksum(payload, logCRCTable) != checksum {
+		return nil, 0, ErrCorruptLog
+	}
+	entry := &pb.Entry{}
+	if err := proto.Unmarshal(payload, entry); err != nil {
+		return nil, 0, ErrCorruptLog
+	}
+	return entry, logHeaderSize + int(length), nil
+}
+
+// writeFileAtomic replaces dir/name with data by writing a temporary file, fsyncing it
+// and renaming it into place.
+func writeFileAtomic(dir, name string, data []byte) error {
+	tmp, err := os.CreateTemp(dir, name+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(dir, name)); err != nil {
+		return err
+	}
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
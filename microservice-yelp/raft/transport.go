@@ -0,0 +1,327 @@
+package raft
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+
+	pb "gitlab.cs.washington.edu/syslab/cse453-welp/proto/raft"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// LeaderTrailer is the trailer in which a member that is not the leader names the leader,
+// when it refuses a membership change.
+const LeaderTrailer = "raft-leader"
+
+var ErrUnreachable = errors.New("raft: peer unreachable")
+
+// Transport delivers RPCs from a node to the other members of its group.
+type Transport interface {
+	RequestVote(ctx context.Context, target string, req *pb.RequestVoteRequest) (*pb.RequestVoteResponse, error)
+	AppendEntries(ctx context.Context, target string, req *pb.AppendEntriesRequest) (*pb.AppendEntriesResponse, error)
+	InstallSnapshot(ctx context.Context, target string, req *pb.InstallSnapshotRequest) (*pb.InstallSnapshotResponse, error)
+}
+
+// GRPCTransport sends RPCs over gRPC, dialing each peer by its id on first use.
+type GRPCTransport struct {
+	mu      sync.Mutex
+	options []grpc.DialOption
+	clients map[string]pb.RaftServiceClient
+}
+
+func NewGRPCTransport(options ...grpc.DialOption) *GRPCTransport {
+	return &GRPCTransport{
+		options: options,
+		clients: make(map[string]pb.RaftServiceClient),
+	}
+}
+
+func (t *GRPCTransport) client(target string) (pb.RaftServiceClient, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if client, ok := t.clients[target]; ok {
+		return client, nil
+	}
+	conn, err := grpc.Dial(target, t.options...)
+	if err != nil {
+		return nil, err
+	}
+	client := pb.NewRaftServiceClient(conn)
+	t.clients[target] = client
+	return client, nil
+}
+
+func (t *GRPCTransport) RequestVote(ctx context.Context, target string, req *pb.RequestVoteRequest) (*pb.RequestVoteResponse, error) {
+	client, err := t.client(target)
+	if err != nil {
+		return nil, err
+	}
+	return client.RequestVote(ctx, req)
+}
+
+func (t *GRPCTransport) AppendEntries(ctx context.Context, target string, req *pb.AppendEntriesRequest) (*pb.AppendEntriesResponse, error) {
+	client, err := t.client(target)
+	if err != nil {
+		return nil, err
+	}
+	return client.AppendEntries(ctx, req)
+}
+
+func (t *GRPCTransport) InstallSnapshot(ctx context.Context, target string, req *pb.InstallSnapshotRequest) (*pb.InstallSnapshotResponse, error) {
+	client, err := t.client(target)
+	if err != nil {
+		return nil, err
+	}
+	return client.InstallSnapshot(ctx, req)
+}
+
+// Server serves the RaftService for a node, so that a GRPCTransport on its peers can reach it.
+type Server struct {
+	pb.UnimplementedRaftServiceServer
+	node *Node
+}
+
+func NewServer(node *Node) *Server {
+	return &Server{node: node}
+}
+
+func (s *Server) RequestVote(ctx context.Context, req *pb.RequestVoteRequest) (*pb.RequestVoteResponse, error) {
+	return s.node.HandleRequestVote(req)
+}
+
+func (s *Server) AppendEntries(ctx context.Context, req *pb.AppendEntriesRequest) (*pb.AppendEntriesResponse, error) {
+	return s.node.HandleAppendEntries(req)
+}
+
+func (s *Server) InstallSnapshot(ctx context.Context, req *pb.InstallSnapshotRequest) (*pb.InstallSnapshotResponse, error) {
+	return s.node.HandleInstallSnapshot(req)
+}
+
+func (s *Server) AddServer(ctx context.Context, req *pb.MembershipChangeRequest) (*pb.MembershipChangeResponse, error) {
+	if req.GetServerId() == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "Server id is required")
+	}
+	return s.membershipChange(ctx, s.node.AddServer(ctx, req.GetServerId()))
+}
+
+func (s *Server) RemoveServer(ctx context.Context, req *pb.MembershipChangeRequest) (*pb.MembershipChangeResponse, error) {
+	if req.GetServerId() == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "Server id is required")
+	}
+	return s.membershipChange(ctx, s.node.RemoveServer(ctx, req.GetServerId()))
+}
+
+// membershipChange converts the outcome of a membership change into a response.
+func (s *Server) membershipChange(ctx context.Context, err error) (*pb.MembershipChangeResponse, error) {
+	switch {
+	case err == nil:
+		return &pb.MembershipChangeResponse{Members: s.node.Members()}, nil
+	case errors.Is(err, ErrNotLeader):
+		_, _, leader := s.node.Status()
+		if leader != "" {
+			grpc.SetTrailer(ctx, metadata.Pairs(LeaderTrailer, leader))
+		}
+		return nil, status.Errorf(codes.FailedPrecondition, "Not the leader of the group; the leader is %q", leader)
+	case errors.Is(err, ErrConfigurationPending):
+		return nil, status.Errorf(codes.Aborted, "%v", err)
+	case errors.Is(err, ErrNotMember):
+		return nil, status.Errorf(codes.NotFound, "%v", err)
+	case errors.Is(err, ErrProposalDropped), errors.Is(err, ErrStopped):
+		return nil, status.Errorf(codes.Unavailable, "%v", err)
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, context.Canceled):
+		return nil, status.Errorf(codes.DeadlineExceeded, "Timed out waiting for the membership change: %v", err)
+	}
+	return nil, status.Errorf(codes.FailedPrecondition, "%v", err)
+}
+
+// Network simulates the network between the nodes of an in-process group. Messages can be
+// delayed, dropped at random, or cut off entirely between partitioned nodes. Requests and
+// responses are copied so that nodes never share messages.
+type Network struct {
+	mu        sync.Mutex
+	nodes     map[string]*Node
+	partition map[string]int // partition each node is in; nodes in different ones cannot talk
+	down      map[string]bool
+	dropRate  float64
+	minDelay  time.Duration
+	maxDelay  time.Duration
+	rand      *rand.Rand
+}
+
+func NewNetwork() *Network {
+	return &Network{
+		nodes:     make(map[string]*Node),
+		partition: make(map[string]int),
+		down:      make(map[string]bool),
+		rand:      rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// Register makes node reachable under id, replacing any node previously registered with it.
+func (n *Network) Register(id string, node *Node) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.nodes[id] = node
+}
+
+// Transport returns the transport for the node with the given id.
+func (n *Network) Transport(id string) Transport {
+	return &networkTransport{network: n, from: id}
+}
+
+// Partition splits the network so that only nodes within the same group can reach each
+// other. Nodes not listed in any group are isolated.
+func (n *Network) Partition(groups ...[]string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	for id := range n.partition {
+		delete(n.partition, id)
+	}
+	for i, group := range groups {
+		for _, id := range group {
+			n.partition[id] = i + 1
+		}
+	}
+	for id := range n.nodes {
+		if _, ok := n.partition[id]; !ok {
+			n.partition[id] = -1
+		}
+	}
+}
+
+// Heal removes any partition.
+func (n *Network) Heal() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	for id := range n.partition {
+		delete(n.partition, id)
+	}
+}
+
+// Disconnect cuts a single node off from all others; Connect reverses it.
+func (n *Network) Disconnect(id string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.down[id] = true
+}
+
+func (n *Network) Connect(id string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	delete(n.down, id)
+}
+
+// SetUnreliable drops each message with probability dropRate and delays delivered ones by a
+// random duration between minDelay and maxDelay.
+func (n *Network) SetUnreliable(dropRate float64, minDelay, maxDelay time.Duration) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.dropRate, n.minDelay, n.maxDelay = dropRate, minDelay, maxDelay
+}
+
+// route returns the node a message from one node to another should be delivered to, the
+// delay before delivery, and whether it should be delivered at all.
+func (n *Network) route(from, to string) (*Node, time.Duration, bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	node := n.nodes[to]
+	if node == nil || n.down[from] || n.down[to] || n.partition[from] != n.partition[to] {
+		return nil, 0, false
+	}
+	if n.dropRate > 0 && n.rand.Float64() < n.dropRate {
+		return nil, 0, false
+	}
+	delay := n.minDelay
+	if n.maxDelay > n.minDelay {
+		delay += time.Duration(n.rand.Int63n(int64(n.maxDelay - n.minDelay)))
+	}
+	return node, delay, true
+}
+
+// deliver sends a request across the network, and its response back, subject to the
+// network's faults.
+func (n *Network) deliver(ctx context.Context, from, to string, req proto.Message, handle func(*Node, proto.Message) (proto.Message, error)) (proto.Message, error) {
+	node, delay, ok := n.route(from, to)
+	if !ok {
+		return nil, ErrUnreachable
+	}
+	if err := sleepContext(ctx, delay); err != nil {
+		return nil, err
+	}
+	resp, err := handle(node, proto.Clone(req))
+	if err != nil {
+		return nil, err
+	}
+
+	if _, delay, ok = n.route(to, from); !ok {
+		return nil, ErrUnreachable
+	}
+	if err := sleepContext(ctx, delay); err != nil {
+		return nil, err
+	}
+	return proto.Clone(resp), nil
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// networkTransport is the Transport of a single node attached to a Network.
+type networkTransport struct {
+	network *Network
+	from    string
+}
+
+func (t *networkTransport) RequestVote(ctx context.Context, target string, req *pb.RequestVoteRequest) (*pb.RequestVoteResponse, error) {
+	resp, err := t.network.deliver(ctx, t.from, target, req, func(node *Node, req proto.Message) (proto.Message, error) {
+		return node.HandleRequestVote(req.(*pb.RequestVoteRequest))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.(*pb.RequestVoteResponse), nil
+}
+
+func (t *networkTransport) AppendEntries(ctx context.Context, target string, req *pb.AppendEntriesRequest) (*pb.AppendEntriesResponse, error) {
+	resp, err := t.network.deliver(ctx, t.from, target, req, func(node *Node, req proto.Message) (proto.Message, error) {
+		return node.HandleAppendEntries(req.(*pb.AppendEntriesRequest))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.(*pb.AppendEntriesResponse), nil
+}
+
+func (t *networkTransport) InstallSnapshot(ctx context.Context, target string, req *pb.InstallSnapshotRequest) (*pb.InstallSnapshotResponse, error) {
+	resp, err := t.network.deliver(ctx, t.from, target, req, func(node *Node, req proto.Message) (proto.Message, error) {
+		return node.HandleInstallSnapshot(req.(*pb.InstallSnapshotRequest))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.(*pb.InstallSnapshotResponse), nil
+}
@@ -5,17 +5,37 @@ import requests
 import sys
 import os
 
+# Restaurants are keyed by the IDs the server assigns them when they are first posted;
+# the IDs of the restaurants named by the samples are looked up by name once, and kept here
+restaurant_ids = {}
+
+def restaurant_id(restaurant_name):
+    if restaurant_name not in restaurant_ids:
+        url = f"http://10.96.88.88:8080/get-detail?restaurant_name={restaurant_name}"
+        response = requests.post(url)
+        if response.status_code != 200:
+            print("Error! Looking up", restaurant_name, "failed with status code:", response.status_code)
+            print("Post the detail samples first")
+            sys.exit(1)
+        restaurant_ids[restaurant_name] = response.json()["restaurant_id"]
+    return restaurant_ids[restaurant_name]
+
 def execute_post_detail(restaurant_name, location, style, capacity):
     url = f"http://10.96.88.88:8080/post-detail?restaurant_name={restaurant_name}&location={location}&style={style}&capacity={capacity}"
+    # posting a restaurant again updates it rather than adding another of the same name
+    response = requests.post(f"http://10.96.88.88:8080/get-detail?restaurant_name={restaurant_name}")
+    if response.status_code == 200:
+        url += f"&restaurant_id={response.json()['restaurant_id']}"
     print(url)
     response = requests.post(url)
     if response.status_code != 200:
         print("Error! PostReview failed with status code:", response.status_code)
         print(url)
         sys.exit(1)
+    restaurant_ids[restaurant_name] = response.json()["restaurant_id"]
 
 def execute_get_detail(restaurant_name):
-    url = f"http://10.96.88.88:8080/get-detail?restaurant_name={restaurant_name}"
+    url = f"http://10.96.88.88:8080/get-detail?restaurant_id={restaurant_id(restaurant_name)}"
     response = requests.post(url)
     if response.status_code != 200:
         print(url)
@@ -26,7 +46,7 @@ def execute_get_detail(restaurant_name):
 
 # Post reviews using sample data
 def execute_post_review(user_name, restaurant_name, review, rating):
-    url = f"http://10.96.88.88:8080/post-review?user_name={user_name}&restaurant_name={restaurant_name}&review={review}&rating={rating}"
+    url = f"http://10.96.88.88:8080/post-review?user_name={user_name}&restaurant_id={restaurant_id(restaurant_name)}&restaurant_name={restaurant_name}&review={review}&rating={rating}"
     print(url)
     response = requests.post(url)
     if response.status_code != 200:
@@ -34,7 +54,7 @@ def execute_post_review(user_name, restaurant_name, review, rating):
         sys.exit(1)
 
 def execute_get_review(restaurant_name):
-    url = f"http://10.96.88.88:8080/get-review?restaurant_name={restaurant_name}"
+    url = f"http://10.96.88.88:8080/get-review?restaurant_id={restaurant_id(restaurant_name)}"
     response = requests.post(url)
     if response.status_code != 200:
         print("Error! GetReview failed with status code:", response.status_code)
@@ -44,7 +64,7 @@ def execute_get_review(restaurant_name):
 
 # Make reservations using sample data
 def execute_make_reservation(user_name, restaurant_name, year, month, day):
-    url = f"http://10.96.88.88:8080/make-reservation?user_name={user_name}&restaurant_name={restaurant_name}&year={year}&month={month}&day={day}"
+    url = f"http://10.96.88.88:8080/make-reservation?user_name={user_name}&restaurant_id={restaurant_id(restaurant_name)}&restaurant_name={restaurant_name}&year={year}&month={month}&day={day}"
     print(url)
     response = requests.post(url)
     if response.status_code != 200:
@@ -136,9 +156,10 @@ if __name__ == '__main__':
     elif service == 'detail':
         post_details(detail_samples, service_op)
     elif service == 'all':
+        # the restaurants are posted first, so that reviews and reservations can name them by ID
+        post_details(detail_samples, service_op)
         post_reviews(review_samples, service_op)
         make_reservations(reservation_samples, service_op)
-        post_details(detail_samples, service_op)
     else:
         print(service)
         print("usage: python generate-samples.py <service_name> <samples_dir> <PUT|GET>")
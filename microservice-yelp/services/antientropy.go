@@ -0,0 +1,499 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+	"time"
+
+	apps "gitlab.cs.washington.edu/syslab/cse453-welp/applications"
+	"gitlab.cs.washington.edu/syslab/cse453-welp/proto/mydatabase"
+	"gitlab.cs.washington.edu/syslab/cse453-welp/raft"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	merkleDepth        = 10               // the anti-entropy Merkle tree has 1024 leaves
+	merkleTreeTTL      = 10 * time.Second // how long a built tree answers peers before it is rebuilt
+	syncBatchLeaves    = 64               // differing leaves synced per SyncRecords call
+	syncScanBatch      = 1000             // records SyncRecords compares per scan of its storage
+	antiEntropyTimeout = time.Minute      // how long a round may spend on a single peer
+
+	defaultAntiEntropyInterval = time.Minute
+)
+
+// AntiEntropyOptions configures the background process that repairs a database's records
+// from other databases holding the same data.
+type AntiEntropyOptions struct {
+	// Peers are the addresses of the other databases holding the same records, e.g. the
+	// other replicas written by a quorum client. Leave them empty to disable anti-entropy.
+	Peers []string
+
+	// Interval is the time between rounds, each comparing the database with every peer.
+	Interval time.Duration
+}
+
+// antiEntropyStats counts the work of a round.
+type antiEntropyStats struct {
+	nodes    int // tree nodes compared
+	leaves   int // leaves whose hashes differed
+	records  int // records in those leaves that differed
+	repaired int // records overwritten or deleted with a peer's version
+}
+
+func (s *antiEntropyStats) add(other antiEntropyStats) {
+	s.nodes += other.nodes
+	s.leaves += other.leaves
+	s.records += other.records
+	s.repaired += other.repaired
+}
+
+// antiEntropy periodically compares a database's Merkle tree with each of its peers',
+// descending only into the subtrees whose hashes differ, then pulls the records in the
+// differing leaves that the peer holds another version of. Each database only repairs
+// itself; its peers repair themselves from it in their own rounds.
+//
+// Records written by quorum clients are repaired by merging their siblings. Other records
+// are repaired last writer wins by commit timestamp, deletions included as long as the
+// storage app retains them; commit timestamps come from each database's own clock.
+type antiEntropy struct {
+	db       *MyDatabase
+	peers    []string
+	clients  []mydatabase.DatabaseServiceClient
+	interval time.Duration
+
+	mu            sync.Mutex
+	peersDone     int              // peers compared so far in the current round
+	current       antiEntropyStats // of the current round
+	last          antiEntropyStats // of the last completed round
+	lastDuration  time.Duration
+	rounds        uint64
+	peerFailures  uint64
+	totalRepaired uint64
+	conflicts     uint64 // repairs skipped because a client wrote the record meanwhile
+}
+
+func newAntiEntropy(db *MyDatabase, options AntiEntropyOptions) *antiEntropy {
+	a := &antiEntropy{
+		db:       db,
+		peers:    options.Peers,
+		interval: options.Interval,
+	}
+	if a.interval <= 0 {
+		a.interval = defaultAntiEntropyInterval
+	}
+	for _, peer := range a.peers {
+		a.clients = append(a.clients, mydatabase.NewDatabaseServiceClient(dial(peer)))
+	}
+	a.peersDone = len(a.peers)
+	go a.run()
+	return a
+}
+
+func (a *antiEntropy) run() {
+	ticker := time.NewTicker(a.interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		a.round()
+	}
+}
+
+// round compares the database with every peer in turn. In a replicated group only the
+// leader repairs records, through the group.
+func (a *antiEntropy) round() {
+	if replica := a.db.replica; replica != nil {
+		if state, _, _ := replica.Node().Status(); state != raft.Leader {
+			return
+		}
+	}
+	start := time.Now()
+	a.mu.Lock()
+	a.peersDone = 0
+	a.current = antiEntropyStats{}
+	a.mu.Unlock()
+
+	tree, err := a.db.merkleTree(merkleDepth, true)
+	if err != nil {
+		log.Printf("anti-entropy: failed to build merkle tree: %v", err)
+		return
+	}
+
+	// find the leaves that differ from each peer first, so that the digests of the records
+	// in all of them are read in a single scan
+	stats := make([]antiEntropyStats, len(a.clients))
+	diffs := make([][]uint32, len(a.clients))
+	errs := make([]error, len(a.clients))
+	differing := make(map[uint32]bool)
+	for i, peer := range a.clients {
+		ctx, cancel := context.WithTimeout(context.Background(), antiEntropyTimeout)
+		diffs[i], errs[i] = a.diffPeer(ctx, peer, tree, &stats[i])
+		cancel()
+		for _, leaf := range diffs[i] {
+			differing[leaf] = true
+		}
+	}
+	digests, err := a.leafDigests(tree.Depth(), differing)
+	if err != nil {
+		log.Printf("anti-entropy: failed to read record digests: %v", err)
+		return
+	}
+
+	for i, peer := range a.clients {
+		if errs[i] == nil {
+			ctx, cancel := context.WithTimeout(context.Background(), antiEntropyTimeout)
+			errs[i] = a.syncPeer(ctx, peer, tree.Depth(), diffs[i], digests, &stats[i])
+			cancel()
+		}
+
+		a.mu.Lock()
+		a.peersDone++
+		a.current.add(stats[i])
+		a.totalRepaired += uint64(stats[i].repaired)
+		if errs[i] != nil {
+			a.peerFailures++
+		}
+		a.mu.Unlock()
+		if errs[i] != nil {
+			log.Printf("anti-entropy: sync with %s failed: %v", a.peers[i], errs[i])
+		} else if stats[i].leaves > 0 {
+			log.Printf("anti-entropy: %d leaves and %d records differed from %s, %d repaired", stats[i].leaves, stats[i].records, a.peers[i], stats[i].repaired)
+		}
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.rounds++
+	a.last = a.current
+	a.lastDuration = time.Since(start)
+}
+
+// diffPeer returns the leaves of tree whose hashes differ from the peer's, found level by level.
+func (a *antiEntropy) diffPeer(ctx context.Context, peer mydatabase.DatabaseServiceClient, tree *apps.MerkleTree, stats *antiEntropyStats) ([]uint32, error) {
+	var leaves []uint32
+	frontier := []uint32{0}
+	for level := 0; level <= tree.Depth() && len(frontier) > 0; level++ {
+		resp, err := peer.GetMerkleNodes(ctx, &mydatabase.GetMerkleNodesRequest{
+			Depth:   uint32(tree.Depth()),
+			Level:   uint32(level),
+			Indices: frontier,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if len(resp.GetHashes()) != len(frontier) {
+			return nil, fmt.Errorf("peer returned %d hashes for %d nodes", len(resp.GetHashes()), len(frontier))
+		}
+		stats.nodes += len(frontier)
+
+		var next []uint32
+		for i, index := range frontier {
+			if hash, _ := tree.Node(level, int(index)); hash == resp.GetHashes()[i] {
+				continue
+			}
+			if level == tree.Depth() {
+				leaves = append(leaves, index)
+			} else {
+				next = append(next, 2*index, 2*index+1)
+			}
+		}
+		frontier = next
+	}
+	stats.leaves = len(leaves)
+	return leaves, nil
+}
+
+// leafDigests returns the digests of the database's records in each of leaves.
+func (a *antiEntropy) leafDigests(depth int, leaves map[uint32]bool) (map[uint32][]*mydatabase.RecordDigest, error) {
+	digests := make(map[uint32][]*mydatabase.RecordDigest, len(leaves))
+	if len(leaves) == 0 {
+		return digests, nil
+	}
+	err := a.db.app.Scan(apps.ScanOptions{}, func(record *mydatabase.DatabaseRecord) bool {
+		if leaf := uint32(apps.MerkleLeaf(record.Key, depth)); leaves[leaf] {
+			digests[leaf] = append(digests[leaf], &mydatabase.RecordDigest{Key: record.Key, Digest: apps.RecordDigest(record.Key, record.Value)})
+		}
+		return true
+	})
+	return digests, err
+}
+
+// syncPeer repairs the records in the leaves that differ from the peer's, a batch of
+// leaves at a time.
+func (a *antiEntropy) syncPeer(ctx context.Context, peer mydatabase.DatabaseServiceClient, depth int, leaves []uint32, digests map[uint32][]*mydatabase.RecordDigest, stats *antiEntropyStats) error {
+	for start := 0; start < len(leaves); start += syncBatchLeaves {
+		end := start + syncBatchLeaves
+		if end > len(leaves) {
+			end = len(leaves)
+		}
+		if err := a.syncLeaves(ctx, peer, depth, leaves[start:end], digests, stats); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// syncLeaves sends the peer the digests of the database's records in leaves and repairs
+// the records it streams back.
+func (a *antiEntropy) syncLeaves(ctx context.Context, peer mydatabase.DatabaseServiceClient, depth int, leaves []uint32, digests map[uint32][]*mydatabase.RecordDigest, stats *antiEntropyStats) error {
+	req := &mydatabase.SyncRecordsRequest{Depth: uint32(depth), Leaves: leaves}
+	for _, leaf := range leaves {
+		req.Digests = append(req.Digests, digests[leaf]...)
+	}
+
+	stream, err := peer.SyncRecords(ctx, req)
+	if err != nil {
+		return err
+	}
+	for {
+		msg, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		for _, record := range msg.GetRecords() {
+			stats.records++
+			if err := a.repair(record, false, stats); err != nil {
+				return err
+			}
+		}
+		for _, deletion := range msg.GetDeletions() {
+			stats.records++
+			if err := a.repair(deletion.GetRecord(), true, stats); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// repair brings the database's copy of a record in line with a peer's version of it. It
+// runs in a transaction, so that a client writing the record meanwhile wins.
+func (a *antiEntropy) repair(peer *mydatabase.DatabaseRecord, deleted bool, stats *antiEntropyStats) error {
+	txns := a.db.txns
+	id := txns.Begin()
+	local, ok, err := txns.Get(id, peer.GetKey())
+	if err != nil {
+		txns.Abort(id)
+		return err
+	}
+	mutation, needed, err := a.resolve(local, ok, peer, deleted)
+	if err != nil || !needed {
+		txns.Abort(id)
+		return err
+	}
+	if err := txns.Write(id, mutation); err != nil {
+		txns.Abort(id)
+		return err
+	}
+	if err := txns.Commit(id); err != nil {
+		if errors.Is(err, apps.ErrTransactionConflict) {
+			a.mu.Lock()
+			a.conflicts++
+			a.mu.Unlock()
+			return nil
+		}
+		return err
+	}
+	stats.repaired++
+	return nil
+}
+
+// resolve returns the write that brings the local copy of a record, if ok, in line with
+// the peer's version, or false if the local copy should be kept.
+func (a *antiEntropy) resolve(local *mydatabase.DatabaseRecord, ok bool, peer *mydatabase.DatabaseRecord, deleted bool) (apps.Mutation, bool, error) {
+	key := peer.GetKey()
+	if deleted {
+		// quorum clients delete by writing tombstone siblings, never by deleting records
+		if ok && !isSiblingSet(local.GetValue()) && peer.GetTimestamp() > local.GetTimestamp() {
+			return apps.Mutation{Key: key, Delete: true}, true, nil
+		}
+		return apps.Mutation{}, false, nil
+	}
+
+	if isSiblingSet(peer.GetValue()) || ok && isSiblingSet(local.GetValue()) {
+		theirs, err := decodeSiblings(peer.GetValue())
+		if err != nil {
+			return apps.Mutation{}, false, fmt.Errorf("failed to decode siblings of %s: %v", key, err)
+		}
+		var ours []*mydatabase.Sibling
+		if ok {
+			if ours, err = decodeSiblings(local.GetValue()); err != nil {
+				return apps.Mutation{}, false, fmt.Errorf("failed to decode siblings of %s: %v", key, err)
+			}
+		}
+		merged := encodeSiblings(mergeSiblings(ours, theirs))
+		return apps.Mutation{Key: key, Value: merged}, !ok || !bytes.Equal(merged, local.GetValue()), nil
+	}
+
+	if ok {
+		return apps.Mutation{Key: key, Value: peer.GetValue()}, peer.GetTimestamp() > local.GetTimestamp(), nil
+	}
+	// the record is missing locally: keep it deleted if it was deleted after the peer wrote it
+	var deletedAt int64
+	err := a.db.app.History(key, func(v apps.Version) bool {
+		if v.Deleted {
+			deletedAt = v.Timestamp
+		}
+		return false
+	})
+	if err != nil {
+		return apps.Mutation{}, false, err
+	}
+	return apps.Mutation{Key: key, Value: peer.GetValue()}, deletedAt <= peer.GetTimestamp(), nil
+}
+
+// Metrics reports the progress of the current round and how far the database diverged
+// from its peers in the last completed one.
+func (a *antiEntropy) Metrics() map[string]float64 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return map[string]float64{
+		"anti_entropy_peers":              float64(len(a.peers)),
+		"anti_entropy_progress":           float64(a.peersDone) / float64(len(a.peers)),
+		"anti_entropy_rounds":             float64(a.rounds),
+		"anti_entropy_peer_failures":      float64(a.peerFailures),
+		"anti_entropy_last_round_seconds": a.lastDuration.Seconds(),
+		"anti_entropy_nodes_compared":     float64(a.last.nodes),
+		"anti_entropy_divergent_leaves":   float64(a.last.leaves),
+		"anti_entropy_divergent_records":  float64(a.last.records),
+		"anti_entropy_repaired_records":   float64(a.totalRepaired),
+		"anti_entropy_conflicts":          float64(a.conflicts),
+	}
+}
+
+// merkleTree returns a tree of the given depth over the database's records, reusing the
+// one built last unless it is older than merkleTreeTTL or fresh is set.
+func (s *MyDatabase) merkleTree(depth int, fresh bool) (*apps.MerkleTree, error) {
+	s.treeMu.Lock()
+	defer s.treeMu.Unlock()
+
+	if fresh || s.tree == nil || s.tree.Depth() != depth || time.Since(s.treeBuilt) > merkleTreeTTL {
+		tree, err := apps.BuildMerkleTree(s.app, depth)
+		if err != nil {
+			return nil, err
+		}
+		s.tree, s.treeBuilt = tree, time.Now()
+	}
+	return s.tree, nil
+}
+
+// GetMerkleNodes returns hashes of nodes of the Merkle tree over the database's records.
+func (s *MyDatabase) GetMerkleNodes(ctx context.Context, req *mydatabase.GetMerkleNodesRequest) (*mydatabase.GetMerkleNodesResponse, error) {
+	msg := &mydatabase.GetMerkleNodesResponse{}
+	depth := int(req.GetDepth())
+	if depth > apps.MaxMerkleDepth {
+		return msg, status.Errorf(codes.InvalidArgument, "Merkle tree depth %d exceeds %d", depth, apps.MaxMerkleDepth)
+	}
+	tree, err := s.merkleTree(depth, false)
+	if err != nil {
+		return msg, status.Errorf(codes.Internal, "Failed to build merkle tree: %v", err)
+	}
+	for _, index := range req.GetIndices() {
+		hash, ok := tree.Node(int(req.GetLevel()), int(index))
+		if !ok {
+			return msg, status.Errorf(codes.InvalidArgument, "No node %d at level %d of the merkle tree", index, req.GetLevel())
+		}
+		msg.Hashes = append(msg.Hashes, hash)
+	}
+	return msg, nil
+}
+
+// SyncRecords streams the records in the requested Merkle tree leaves that the caller lacks
+// or holds a different value of, and the retained deletions of records the caller holds
+// but the database does not.
+func (s *MyDatabase) SyncRecords(req *mydatabase.SyncRecordsRequest, stream mydatabase.DatabaseService_SyncRecordsServer) error {
+	depth := int(req.GetDepth())
+	if depth > apps.MaxMerkleDepth {
+		return status.Errorf(codes.InvalidArgument, "Merkle tree depth %d exceeds %d", depth, apps.MaxMerkleDepth)
+	}
+	leaves := make(map[int]bool, len(req.GetLeaves()))
+	for _, leaf := range req.GetLeaves() {
+		leaves[int(leaf)] = true
+	}
+	theirs := make(map[string]uint64, len(req.GetDigests()))
+	for _, digest := range req.GetDigests() {
+		theirs[digest.GetKey()] = digest.GetDigest()
+	}
+
+	// records are compared a batch at a time and the differing ones sent as they are
+	// found, rather than held until the scan completes
+	var records []*mydatabase.DatabaseRecord
+	send := func(min int) error {
+		for len(records) >= min && len(records) > 0 {
+			n := len(records)
+			if n > scanBatchSize {
+				n = scanBatchSize
+			}
+			if err := stream.Send(&mydatabase.SyncRecordsResponse{Records: records[:n]}); err != nil {
+				return err
+			}
+			records = records[n:]
+		}
+		return nil
+	}
+	options := apps.ScanOptions{}
+	for {
+		visited := 0
+		last := ""
+		err := s.app.Scan(options, func(record *mydatabase.DatabaseRecord) bool {
+			visited++
+			last = record.Key
+			if leaves[apps.MerkleLeaf(record.Key, depth)] {
+				digest, ok := theirs[record.Key]
+				delete(theirs, record.Key)
+				if !ok || digest != apps.RecordDigest(record.Key, record.Value) {
+					records = append(records, record)
+				}
+			}
+			return visited < syncScanBatch
+		})
+		if err != nil {
+			return status.Errorf(codes.Internal, "Failed to scan storage: %v", err)
+		}
+		if visited < syncScanBatch {
+			break
+		}
+		if err := send(scanBatchSize); err != nil {
+			return err
+		}
+		options.Start = last + "\x00"
+	}
+	if err := send(1); err != nil {
+		return err
+	}
+
+	// the caller's records left over are missing here
+	var deletions []*mydatabase.RecordVersion
+	for key := range theirs {
+		err := s.app.History(key, func(v apps.Version) bool {
+			if v.Deleted {
+				deletions = append(deletions, &mydatabase.RecordVersion{
+					Record:  &mydatabase.DatabaseRecord{Key: key, Timestamp: v.Timestamp},
+					Deleted: true,
+				})
+			}
+			return false
+		})
+		if err != nil {
+			return status.Errorf(codes.Internal, "Failed to read history of %s: %v", key, err)
+		}
+		if len(deletions) == scanBatchSize {
+			if err := stream.Send(&mydatabase.SyncRecordsResponse{Deletions: deletions}); err != nil {
+				return err
+			}
+			deletions = nil
+		}
+	}
+	if len(deletions) > 0 {
+		return stream.Send(&mydatabase.SyncRecordsResponse{Deletions: deletions})
+	}
+	return nil
+}
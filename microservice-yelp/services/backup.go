@@ -0,0 +1,380 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	apps "gitlab.cs.washington.edu/syslab/cse453-welp/applications"
+	"gitlab.cs.washington.edu/syslab/cse453-welp/proto/mydatabase"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+const (
+	backupChunkSize  = 1 << 20 // bytes of backup file per stream message
+	restoreBatchSize = 1000    // mutations per Apply while restoring a backup
+)
+
+// CreateBackup streams a backup of the database as of the moment the request arrives.
+// Records are read from the snapshot at that commit timestamp in batches, so the database
+// keeps serving writes meanwhile; the snapshot is pinned for the backup's duration, so it
+// stays readable however long the backup takes.
+func (s *MyDatabase) CreateBackup(req *mydatabase.CreateBackupRequest, stream mydatabase.DatabaseService_CreateBackupServer) error {
+	manifest := &mydatabase.BackupManifest{
+		Database:  s.name,
+		Backend:   s.app.Stats().GetBackend(),
+		CreatedAt: time.Now().UnixNano(),
+	}
+	ts := s.app.Timestamp()
+	release := s.app.PinSnapshot(ts)
+	defer release()
+
+	w := &backupChunkWriter{stream: stream}
+	if err := apps.WriteBackup(w, s.app, ts, manifest); err != nil {
+		if w.err != nil {
+			return w.err
+		}
+		return status.Errorf(codes.Internal, "Failed to write backup: %v", err)
+	}
+	return w.finish(manifest)
+}
+
+// backupChunkWriter sends a backup file down a stream in chunks of backupChunkSize.
+type backupChunkWriter struct {
+	stream mydatabase.DatabaseService_CreateBackupServer
+	buf    []byte
+	err    error // from the stream
+}
+
+func (w *backupChunkWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for len(w.buf) >= backupChunkSize && w.err == nil {
+		w.err = w.stream.Send(&mydatabase.BackupChunk{Data: w.buf[:backupChunkSize]})
+		w.buf = append([]byte(nil), w.buf[backupChunkSize:]...)
+	}
+	return len(p), w.err
+}
+
+// finish sends the rest of the file along with its manifest.
+func (w *backupChunkWriter) finish(manifest *mydatabase.BackupManifest) error {
+	return w.stream.Send(&mydatabase.BackupChunk{Data: w.buf, Manifest: manifest})
+}
+
+// RestoreBackup restores the database from a streamed backup file. The file is spooled to
+// disk and checked against its manifest before anything is written. Records are restored
+// in batches, so readers may observe a partially restored database, and receive new commit
+// timestamps. Followers forward the stream to the leader of their group.
+func (s *MyDatabase) RestoreBackup(stream mydatabase.DatabaseService_RestoreBackupServer) error {
+	leader, ctx, err := s.leader(stream.Context())
+	if err != nil {
+		return err
+	}
+	if leader != nil {
+		return forwardRestore(ctx, leader, stream)
+	}
+
+	f, err := os.CreateTemp("", "mydatabase-restore-")
+	if err != nil {
+		return status.Errorf(codes.Internal, "Failed to spool backup: %v", err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	var mode mydatabase.RestoreMode
+	for first := true; ; first = false {
+		msg, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if first {
+			mode = msg.GetMode()
+		}
+		if _, err := f.Write(msg.GetData()); err != nil {
+			return status.Errorf(codes.Internal, "Failed to spool backup: %v", err)
+		}
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return status.Errorf(codes.Internal, "Failed to read spooled backup: %v", err)
+	}
+	if _, err := apps.ReadBackup(f, nil); err != nil {
+		return status.Errorf(codes.InvalidArgument, "Invalid backup: %v", err)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return status.Errorf(codes.Internal, "Failed to read spooled backup: %v", err)
+	}
+
+	msg := &mydatabase.RestoreBackupResponse{}
+	restored := make(map[string]struct{})
+	var batch []apps.Mutation
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		err := s.txns.Apply(batch)
+		batch = batch[:0]
+		return err
+	}
+	msg.Manifest, err = apps.ReadBackup(f, func(record *mydatabase.DatabaseRecord) error {
+		if mode == mydatabase.RestoreMode_RESTORE_REPLACE {
+			restored[record.GetKey()] = struct{}{}
+		}
+		batch = append(batch, apps.Mutation{Key: record.GetKey(), Value: record.GetValue()})
+		msg.RecordsRestored++
+		if len(batch) < restoreBatchSize {
+			return nil
+		}
+		return flush()
+	})
+	if err == nil {
+		err = flush()
+	}
+	if err == nil && mode == mydatabase.RestoreMode_RESTORE_REPLACE {
+		err = s.app.Scan(apps.ScanOptions{}, func(record *mydatabase.DatabaseRecord) bool {
+			if _, ok := restored[record.Key]; !ok {
+				batch = append(batch, apps.Mutation{Key: record.Key, Delete: true})
+			}
+			return true
+		})
+		deleted := int64(len(batch))
+		for err == nil && len(batch) > 0 {
+			n := len(batch)
+			if n > restoreBatchSize {
+				n = restoreBatchSize
+			}
+			err = s.txns.Apply(batch[:n])
+			batch = batch[n:]
+		}
+		msg.RecordsDeleted = deleted
+	}
+	if err != nil {
+		if err := replicationError(err); err != nil {
+			return err
+		}
+		if err := quotaError(err); err != nil {
+			return err
+		}
+		return status.Errorf(codes.Internal, "Failed to restore backup: %v", err)
+	}
+	msg.Success = true
+	return stream.SendAndClose(msg)
+}
+
+// forwardRestore relays a restore to the leader and its response back.
+func forwardRestore(ctx context.Context, leader mydatabase.DatabaseServiceClient, stream mydatabase.DatabaseService_RestoreBackupServer) error {
+	restore, err := leader.RestoreBackup(ctx)
+	if err != nil {
+		return err
+	}
+	for {
+		msg, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		// the leader ended the stream early; CloseAndRecv returns why
+		if err := restore.Send(msg); err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+	}
+	resp, err := restore.CloseAndRecv()
+	if err != nil {
+		return err
+	}
+	return stream.SendAndClose(resp)
+}
+
+// BackupDatabase writes a backup of the database at addr to path. The file only appears
+// once the whole backup has been received.
+func BackupDatabase(addr, path string) (*mydatabase.BackupManifest, error) {
+	return receiveBackup(addr, path, func(r io.Reader, w io.Writer) error {
+		_, err := io.Copy(w, r)
+		return err
+	})
+}
+
+// ExportDatabase writes the records of a backup of the database at addr to path as
+// newline delimited JSON, one DatabaseRecord per line with its value base64 encoded.
+func ExportDatabase(addr, path string) (*mydatabase.BackupManifest, error) {
+	return receiveBackup(addr, path, func(r io.Reader, w io.Writer) error {
+		_, err := apps.ReadBackup(r, func(record *mydatabase.DatabaseRecord) error {
+			line, err := protojson.Marshal(record)
+			if err != nil {
+				return err
+			}
+			_, err = w.Write(append(line, '\n'))
+			return err
+		})
+		return err
+	})
+}
+
+// receiveBackup streams a backup of the database at addr through convert into path.
+func receiveBackup(addr, path string, convert func(r io.Reader, w io.Writer) error) (*mydatabase.BackupManifest, error) {
+	client := mydatabase.NewDatabaseServiceClient(dial(addr))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream, err := client.CreateBackup(ctx, &mydatabase.CreateBackupRequest{})
+	if err != nil {
+		return nil, err
+	}
+
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp)
+	defer f.Close()
+
+	pr, pw := io.Pipe()
+	converted := make(chan error, 1)
+	go func() {
+		w := bufio.NewWriter(f)
+		err := convert(pr, w)
+		if err == nil {
+			err = w.Flush()
+		}
+		pr.CloseWithError(err)
+		converted <- err
+	}()
+
+	var manifest *mydatabase.BackupManifest
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			pw.CloseWithError(err)
+			<-converted
+			return nil, err
+		}
+		if _, err := pw.Write(chunk.GetData()); err != nil {
+			return nil, <-converted
+		}
+		if chunk.GetManifest() != nil {
+			manifest = chunk.GetManifest()
+		}
+	}
+	pw.Close()
+	if err := <-converted; err != nil {
+		return nil, err
+	}
+	if manifest == nil {
+		return nil, errors.New("backup stream ended without a manifest")
+	}
+	if err := f.Sync(); err != nil {
+		return nil, err
+	}
+	if err := f.Close(); err != nil {
+		return nil, err
+	}
+	return manifest, os.Rename(tmp, path)
+}
+
+// RestoreDatabase restores the database at addr from the backup file at path, after
+// checking the file against its manifest.
+func RestoreDatabase(addr, path string, mode mydatabase.RestoreMode) (*mydatabase.RestoreBackupResponse, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if _, err := apps.ReadBackup(f, nil); err != nil {
+		return nil, err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	return sendBackup(addr, f, mode)
+}
+
+// ImportDatabase restores the database at addr from newline delimited JSON records at
+// path, as written by ExportDatabase.
+func ImportDatabase(addr, path string, mode mydatabase.RestoreMode) (*mydatabase.RestoreBackupResponse, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	// convert the records into a backup file as it is sent
+	pr, pw := io.Pipe()
+	go func() {
+		b, err := apps.NewBackupWriter(pw)
+		if err != nil {
+			pw.CloseWithError(err)
+			return
+		}
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(nil, 64<<20)
+		for line := 1; scanner.Scan(); line++ {
+			if len(scanner.Bytes()) == 0 {
+				continue
+			}
+			var record mydatabase.DatabaseRecord
+			if err := protojson.Unmarshal(scanner.Bytes(), &record); err != nil {
+				pw.CloseWithError(fmt.Errorf("%s:%d: %v", path, line, err))
+				return
+			}
+			if err := b.Write(&record); err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			pw.CloseWithError(err)
+			return
+		}
+		pw.CloseWithError(b.Close(&mydatabase.BackupManifest{Database: path, Backend: "ndjson", CreatedAt: time.Now().UnixNano()}))
+	}()
+	resp, err := sendBackup(addr, pr, mode)
+	pr.CloseWithError(io.ErrClosedPipe)
+	return resp, err
+}
+
+// sendBackup streams a backup file to the database at addr.
+func sendBackup(addr string, r io.Reader, mode mydatabase.RestoreMode) (*mydatabase.RestoreBackupResponse, error) {
+	client := mydatabase.NewDatabaseServiceClient(dial(addr))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream, err := client.RestoreBackup(ctx)
+	if err != nil {
+		return nil, err
+	}
+	buf := make([]byte, backupChunkSize)
+	for first := true; ; first = false {
+		n, err := io.ReadFull(r, buf)
+		if err == io.EOF {
+			break
+		}
+		if err != nil && err != io.ErrUnexpectedEOF {
+			// cancel rather than close the stream, so the database restores nothing
+			return nil, err
+		}
+		req := &mydatabase.RestoreBackupRequest{Data: buf[:n]}
+		if first {
+			req.Mode = mode
+		}
+		// the database ended the stream early; CloseAndRecv returns why
+		if err := stream.Send(req); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+	}
+	return stream.CloseAndRecv()
+}
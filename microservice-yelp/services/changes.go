@@ -0,0 +1,67 @@
+package services
+
+import (
+	"errors"
+	"strings"
+
+	apps "gitlab.cs.washington.edu/syslab/cse453-welp/applications"
+	"gitlab.cs.washington.edu/syslab/cse453-welp/proto/mydatabase"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// changeStreamBatch is the number of buffered changes read at a time for a subscriber.
+const changeStreamBatch = 100
+
+// StreamChanges streams the changes applied to a namespace in the order they were
+// applied, starting at the requested sequence number, until the client cancels. Every
+// member of a replicated group streams the writes it applies, numbered by that member. A
+// subscriber that falls behind the buffered history is ended with OutOfRange, and must
+// resume from a fresh scan of the database. Changes are only recorded by databases started
+// with the change log enabled.
+func (s *MyDatabase) StreamChanges(req *mydatabase.StreamChangesRequest, stream mydatabase.DatabaseService_StreamChangesServer) error {
+	if s.changes == nil {
+		return status.Errorf(codes.FailedPrecondition, "The database does not record changes; start it with -changelog")
+	}
+	if _, err := storedKey(req.GetNamespace(), ""); err != nil {
+		return err
+	}
+	next := req.GetStartSequence()
+	if next == 0 {
+		// fix the starting point now, so no change is missed while the stream is set up
+		next = s.changes.Next()
+	}
+	for {
+		events, changed, err := s.changes.Read(next, changeStreamBatch)
+		if errors.Is(err, apps.ErrChangesUnavailable) {
+			return status.Errorf(codes.OutOfRange, "Cannot stream changes from sequence %d: %v", next, err)
+		}
+		if err != nil {
+			return status.Errorf(codes.Internal, "Failed to read changes: %v", err)
+		}
+		if changed != nil {
+			select {
+			case <-changed:
+				continue
+			case <-stream.Context().Done():
+				return stream.Context().Err()
+			}
+		}
+		for _, e := range events {
+			next = e.GetSequence() + 1
+			namespace, key := apps.SplitNamespaceKey(e.GetKey())
+			if namespace != req.GetNamespace() || !strings.HasPrefix(key, req.GetPrefix()) {
+				continue
+			}
+			if key != e.GetKey() {
+				// events are shared between subscribers, so send a copy keyed within the namespace
+				e = proto.Clone(e).(*mydatabase.ChangeEvent)
+				e.Key = key
+			}
+			if err := stream.Send(e); err != nil {
+				return err
+			}
+		}
+	}
+}
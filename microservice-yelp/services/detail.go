@@ -2,10 +2,15 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net"
+	"sync"
 
+	"github.com/google/uuid"
+
+	apps "gitlab.cs.washington.edu/syslab/cse453-welp/applications"
 	"gitlab.cs.washington.edu/syslab/cse453-welp/proto/detail"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
@@ -16,6 +21,59 @@ import (
 	"gitlab.cs.washington.edu/syslab/cse453-welp/proto/mydatabase"
 )
 
+// Names of the secondary indexes the detail databases keep over restaurant details.
+// Capacities are indexed with apps.EncodeIndexInt, so they can be queried by range, and
+// coordinates by geohash, so the restaurants in a cell can be queried by prefix. Names are
+// indexed normalized, and by each of their trigrams, to suggest restaurants by similar
+// names when one is not found.
+const (
+	DetailLocationIndex = "location"
+	DetailStyleIndex    = "style"
+	DetailCapacityIndex = "capacity"
+	DetailGeohashIndex  = "geohash"
+	DetailNameIndex     = "name"
+	DetailTrigramIndex  = "name_trigram"
+)
+
+// DetailIndexes returns the secondary indexes to keep over the detail records of a
+// database, which are kept in namespace.
+func DetailIndexes(namespace string) []apps.Index {
+	return []apps.Index{
+		{Namespace: namespace, Name: DetailLocationIndex, Extract: detailIndexExtractor(func(d *detail.GetDetailResponse) string {
+			return d.GetLocation()
+		})},
+		{Namespace: namespace, Name: DetailStyleIndex, Extract: detailIndexExtractor(func(d *detail.GetDetailResponse) string {
+			return d.GetStyle()
+		})},
+		{Namespace: namespace, Name: DetailCapacityIndex, Extract: detailIndexExtractor(func(d *detail.GetDetailResponse) string {
+			return apps.EncodeIndexInt(int64(d.GetCapacity()))
+		})},
+		{Namespace: namespace, Name: DetailGeohashIndex, Extract: nonEmpty(detailIndexExtractor(detailGeohash))},
+		{Namespace: namespace, Name: DetailNameIndex, Extract: nonEmpty(detailIndexExtractor(normalizedName))},
+		{Namespace: namespace, Name: DetailTrigramIndex, Extract: nameTrigrams(detailIndexExtractor(normalizedName))},
+	}
+}
+
+// detailIndexExtractor indexes detail records by a field of the details they hold. Records
+// written through a quorum client are indexed under the field of each of their siblings.
+func detailIndexExtractor(field func(d *detail.GetDetailResponse) string) func(key string, value []byte) []string {
+	return func(key string, value []byte) []string {
+		siblings, err := decodeSiblings(value)
+		if err != nil {
+			return nil
+		}
+		var values []string
+		for _, sibling := range siblings {
+			var d detail.GetDetailResponse
+			if sibling.GetDeleted() || proto.Unmarshal(sibling.GetValue(), &d) != nil {
+				continue
+			}
+			values = append(values, field(&d))
+		}
+		return values
+	}
+}
+
 // Detail implements the detail service
 type Detail struct {
 	name string
@@ -25,21 +83,56 @@ type Detail struct {
 	// mu sync.Mutex
 	// dataStore map[string][]byte
 
-	detailCacheClient    mycache.CacheServiceClient       // Add detail grpc cache client for communicating with detail cache server
-	detailDatabaseClient mydatabase.DatabaseServiceClient // Add detail grpc storage client for communicating with detail storage server
+	detailCacheClient     mycache.CacheServiceClient       // Add detail grpc cache client for communicating with detail cache server
+	detailDatabaseClient  mydatabase.DatabaseServiceClient // Add detail grpc storage client for communicating with detail storage server
+	photoDatabaseClient   mydatabase.DatabaseServiceClient // the same storage, in the namespace photos are kept in
+	historyDatabaseClient mydatabase.DatabaseServiceClient // the same storage, in the namespace the history of details is kept in
+
+	// edits of a restaurant's details are serialized by the lock its ID hashes to, and
+	// made in database transactions unless the database is replicated by quorum, which
+	// does not support them
+	editLocks    [detailEditLocks]sync.Mutex
+	transactions bool
 
 	CACHE_FLAG bool
 }
 
-// NewDetail returns a new server
-func NewDetail(name string, detailPort int, detailCacheAddr string, detailDatabaseAddr string) *Detail {
+const (
+	detailEditLocks   = 64 // locks serializing the edits made through a detail server
+	detailEditRetries = 3  // retries of an edit whose transaction a concurrent write aborted
+)
+
+// errRatingRecorded aborts recording a rating older than the one a restaurant's details hold.
+var errRatingRecorded = errors.New("rating already recorded")
+
+// NewDetail returns a new server keeping its records in namespace of its database. If quorum
+// lists replicas, records are replicated across them with quorum reads and writes instead
+// of being kept in detailDatabaseAddr alone.
+func NewDetail(name string, detailPort int, detailCacheAddr string, detailDatabaseAddr string, namespace string, quorum QuorumOptions) *Detail {
+	var databaseClient, photoClient, historyClient mydatabase.DatabaseServiceClient
+	if len(quorum.Replicas) > 0 {
+		photoQuorum, historyQuorum := quorum, quorum
+		quorum.Namespace, photoQuorum.Namespace, historyQuorum.Namespace = namespace, PhotoNamespace(namespace), HistoryNamespace(namespace)
+		databaseClient = NewQuorumClient(name, quorum)
+		photoClient = NewQuorumClient(name+"-photos", photoQuorum)
+		historyClient = NewQuorumClient(name+"-history", historyQuorum)
+	} else {
+		conn := mydatabase.NewDatabaseServiceClient(dial(detailDatabaseAddr)) // Initialize and establish cxn using specified address
+		databaseClient = NewNamespacedClient(conn, namespace)
+		photoClient = NewNamespacedClient(conn, PhotoNamespace(namespace))
+		historyClient = NewNamespacedClient(conn, HistoryNamespace(namespace))
+	}
+
 	return &Detail{
 		name: name,
 		port: detailPort,
 		// dataStore: make(map[string][]byte),
-		detailCacheClient:    mycache.NewCacheServiceClient(dial(detailCacheAddr)),          // Initialize and establish cxn using specified address
-		detailDatabaseClient: mydatabase.NewDatabaseServiceClient(dial(detailDatabaseAddr)), // Initialize and establish cxn using specified address
-		CACHE_FLAG:           true,
+		detailCacheClient:     mycache.NewCacheServiceClient(dial(detailCacheAddr)), // Initialize and establish cxn using specified address
+		detailDatabaseClient:  databaseClient,
+		photoDatabaseClient:   photoClient,
+		historyDatabaseClient: historyClient,
+		transactions:          len(quorum.Replicas) == 0,
+		CACHE_FLAG:            true,
 	}
 }
 
@@ -47,7 +140,7 @@ func NewDetail(name string, detailPort int, detailCacheAddr string, detailDataba
 // It returns an error if the server fails to start or encounters an error.
 func (s *Detail) Run() error {
 	// Create a new gRPC server instance.
-	srv := grpc.NewServer()
+	srv := grpc.NewServer(grpc.UnaryInterceptor(validateUnary), grpc.StreamInterceptor(validateStream))
 
 	// Register the Detail server implementation with the gRPC server.
 	detail.RegisterDetailServiceServer(srv, s)
@@ -70,14 +163,15 @@ func (s *Detail) Run() error {
 // If not, it retrieves the data from mydb and stores it in mycache for future use.
 // It returns an error if the requested restaurant does not exist.
 func (s *Detail) GetDetail(ctx context.Context, req *detail.GetDetailRequest) (*detail.GetDetailResponse, error) {
-	// Get the name of the requested restaurant
-	restaurantName := req.GetRestaurantName()
+	// Get the ID of the requested restaurant, looking it up by name if the request has none
 	detailResponse := &detail.GetDetailResponse{}
-
-	var err error
+	restaurantID, err := s.restaurantID(ctx, req.GetRestaurantId(), req.GetRestaurantName())
+	if err != nil {
+		return detailResponse, err
+	}
 
 	getCacheItemMsg := &mycache.GetItemRequest{
-		Key: restaurantName,
+		Key: restaurantID,
 	}
 
 	// Check if the details are stored in the cache
@@ -91,12 +185,12 @@ func (s *Detail) GetDetail(ctx context.Context, req *detail.GetDetailRequest) (*
 		if err != nil {
 			err = status.Errorf(codes.Internal, "Failed to deserialize data")
 		} else {
-			err = status.Errorf(codes.OK, "Found value with Key: %s", restaurantName)
+			err = status.Errorf(codes.OK, "Found value with Key: %s", restaurantID)
 		}
 	} else {
 		// Make a call to the storage layer due to a cache-miss
 		getRecordMsg := &mydatabase.GetRecordRequest{
-			Key: restaurantName,
+			Key: restaurantID,
 		}
 
 		if getRecordResponse, errGetRecord := s.detailDatabaseClient.GetRecord(ctx, getRecordMsg); errGetRecord == nil {
@@ -107,47 +201,413 @@ func (s *Detail) GetDetail(ctx context.Context, req *detail.GetDetailRequest) (*
 			} else {
 				// If we found the item in the storage layer, then update the cache to hold this element
 				if s.CACHE_FLAG {
-					updateCache(ctx, s.detailCacheClient, restaurantName, rawValue)
+					updateCache(ctx, s.detailCacheClient, restaurantID, rawValue)
 				}
 
-				err = status.Errorf(codes.OK, "Found value with Key: %s", restaurantName)
+				err = status.Errorf(codes.OK, "Found value with Key: %s", restaurantID)
 			}
 		} else {
-			return detailResponse, status.Errorf(codes.NotFound, "Item with Key: %s does not exist", restaurantName)
+			return detailResponse, status.Errorf(codes.NotFound, "Item with Key: %s does not exist", restaurantID)
 		}
 	}
+	if detailResponse.RestaurantId == "" {
+		// details written before restaurants had IDs are keyed by their name
+		detailResponse.RestaurantId = restaurantID
+	}
 	return detailResponse, err
 }
 
-// PostDetail adds or updates the details of a restaurant in the in-memory dataStore.
+// PostDetail adds or updates the details of a restaurant, overwriting every field. A
+// request without a restaurant ID adds a new restaurant, under a new ID.
 func (s *Detail) PostDetail(ctx context.Context, req *detail.PostDetailRequest) (*detail.PostDetailResponse, error) {
-	// Extract fields from the request.
-	restaurantName := req.GetRestaurantName()
-	location := req.GetLocation()
-	capacity := req.GetCapacity()
-	style := req.GetStyle()
+	restaurantID := req.GetRestaurantId()
+	if restaurantID == "" {
+		restaurantID = NewRestaurantID()
+	}
 
 	// Create a new GetDetailResponse object with the details to save.
 	msg := &detail.GetDetailResponse{
-		RestaurantName: restaurantName,
-		Location:       location,
-		Style:          style,
-		Capacity:       capacity,
+		RestaurantName: req.GetRestaurantName(),
+		Location:       req.GetLocation(),
+		Style:          req.GetStyle(),
+		Capacity:       req.GetCapacity(),
+		Coordinates:    req.GetCoordinates(),
+		Hours:          req.GetHours(),
+		PriceTier:      req.GetPriceTier(),
+		Tags:           req.GetTags(),
+		Contact:        req.GetContact(),
+		Menu:           req.GetMenu(),
 	}
 
 	// Initialize an empty response object.
 	detailResponse := &detail.PostDetailResponse{Status: false}
+	if msg.Coordinates != nil {
+		if err := checkCoordinates(msg.Coordinates); err != nil {
+			return detailResponse, err
+		}
+	}
+	if err := checkProfile(msg); err != nil {
+		return detailResponse, err
+	}
+
+	written, err := s.editDetail(ctx, restaurantID, &detailEdit{author: req.GetAuthor()}, func(current *detail.GetDetailResponse) (*detail.GetDetailResponse, error) {
+		// the rating comes from the restaurant's reviews, and the photos from their uploads,
+		// not from whoever posts its details
+		if current != nil {
+			msg.Rating, msg.ReviewCount, msg.RatingVersion = current.GetRating(), current.GetReviewCount(), current.GetRatingVersion()
+			msg.Photos = current.GetPhotos()
+		}
+		return msg, nil
+	})
+	if err != nil {
+		return detailResponse, err
+	}
+	detailResponse.Status, detailResponse.Etag, detailResponse.RestaurantId = true, written.GetEtag(), restaurantID
+	return detailResponse, status.Errorf(codes.OK, "Updated data storage with key: %s", restaurantID)
+}
 
-	// Serialize the Go object into protobuf format.
-	data, err := proto.Marshal(msg)
+// DeleteDetail removes the details of a restaurant, if they are still at the version
+// named by the request's etag.
+func (s *Detail) DeleteDetail(ctx context.Context, req *detail.DeleteDetailRequest) (*detail.DeleteDetailResponse, error) {
+	detailResponse := &detail.DeleteDetailResponse{Status: false}
+	restaurantID, err := s.restaurantID(ctx, req.GetRestaurantId(), req.GetRestaurantName())
 	if err != nil {
-		// If serialization fails, return an internal error.
-		return detailResponse, status.Errorf(codes.Internal, "Failed to serialize data")
+		return detailResponse, err
 	}
 
-	// add detail to DB (updates cache as well)
-	detailResponse.Status, err = updateDB(ctx, s.detailCacheClient, s.detailDatabaseClient, restaurantName, data, s.CACHE_FLAG)
+	_, err = s.editDetail(ctx, restaurantID, &detailEdit{author: req.GetAuthor()}, func(current *detail.GetDetailResponse) (*detail.GetDetailResponse, error) {
+		if current == nil {
+			return nil, status.Errorf(codes.NotFound, "Item with Key: %s does not exist", restaurantID)
+		}
+		if err := checkEtag(current, req.GetEtag()); err != nil {
+			return nil, err
+		}
+		return nil, nil
+	})
+	if err != nil {
+		return detailResponse, err
+	}
+	detailResponse.Status = true
+	return detailResponse, nil
+}
 
-	// Return the response object and any error.
-	return detailResponse, err
+// PatchDetail updates the fields of a restaurant's details named by the request's update
+// mask, if the details are still at the version named by its etag.
+func (s *Detail) PatchDetail(ctx context.Context, req *detail.PatchDetailRequest) (*detail.PatchDetailResponse, error) {
+	detailResponse := &detail.PatchDetailResponse{}
+
+	patch := req.GetDetail()
+	paths := req.GetUpdateMask().GetPaths()
+	if len(paths) == 0 {
+		if patch.GetLocation() != "" {
+			paths = append(paths, "location")
+		}
+		if patch.GetStyle() != "" {
+			paths = append(paths, "style")
+		}
+		if patch.GetCapacity() != 0 {
+			paths = append(paths, "capacity")
+		}
+		if patch.GetCoordinates() != nil {
+			paths = append(paths, "coordinates")
+		}
+		if patch.GetHours() != nil {
+			paths = append(paths, "hours")
+		}
+		if patch.GetPriceTier() != detail.PriceTier_PRICE_TIER_UNSPECIFIED {
+			paths = append(paths, "price_tier")
+		}
+		if len(patch.GetTags()) > 0 {
+			paths = append(paths, "tags")
+		}
+		if patch.GetContact() != nil {
+			paths = append(paths, "contact")
+		}
+		if patch.GetMenu() != nil {
+			paths = append(paths, "menu")
+		}
+	}
+	if len(paths) == 0 {
+		return detailResponse, status.Errorf(codes.InvalidArgument, "No fields to update")
+	}
+	var tags []string
+	for _, path := range paths {
+		switch path {
+		case "location", "style", "capacity":
+		case "restaurant_name":
+			if patch.GetRestaurantName() == "" {
+				return detailResponse, status.Errorf(codes.InvalidArgument, "Cannot rename a restaurant to an empty name")
+			}
+		case "coordinates":
+			if patch.GetCoordinates() != nil {
+				if err := checkCoordinates(patch.GetCoordinates()); err != nil {
+					return detailResponse, err
+				}
+			}
+		case "hours":
+			if err := checkOpeningHours(patch.GetHours()); err != nil {
+				return detailResponse, err
+			}
+		case "price_tier":
+			if err := checkPriceTier(patch.GetPriceTier()); err != nil {
+				return detailResponse, err
+			}
+		case "tags":
+			var err error
+			if tags, err = normalizeTags(patch.GetTags()); err != nil {
+				return detailResponse, err
+			}
+		case "contact":
+			if err := checkContact(patch.GetContact()); err != nil {
+				return detailResponse, err
+			}
+		case "menu":
+			if err := checkMenu(patch.GetMenu()); err != nil {
+				return detailResponse, err
+			}
+		default:
+			return detailResponse, status.Errorf(codes.InvalidArgument, "Cannot update field %q: must be restaurant_name, location, style, capacity, coordinates, hours, price_tier, tags, contact or menu", path)
+		}
+	}
+	restaurantID, err := s.restaurantID(ctx, req.GetRestaurantId(), req.GetRestaurantName())
+	if err != nil {
+		return detailResponse, err
+	}
+
+	written, err := s.editDetail(ctx, restaurantID, &detailEdit{author: req.GetAuthor()}, func(current *detail.GetDetailResponse) (*detail.GetDetailResponse, error) {
+		if current == nil {
+			return nil, status.Errorf(codes.NotFound, "Item with Key: %s does not exist", restaurantID)
+		}
+		if err := checkEtag(current, req.GetEtag()); err != nil {
+			return nil, err
+		}
+		next := proto.Clone(current).(*detail.GetDetailResponse)
+		for _, path := range paths {
+			switch path {
+			case "restaurant_name":
+				// reviews and reservations name the restaurant by its ID, so they follow it
+				next.RestaurantName = patch.GetRestaurantName()
+			case "location":
+				next.Location = patch.GetLocation()
+			case "style":
+				next.Style = patch.GetStyle()
+			case "capacity":
+				next.Capacity = patch.GetCapacity()
+			case "coordinates":
+				// clearing the coordinates takes the restaurant out of nearby searches
+				next.Coordinates = patch.GetCoordinates()
+			case "hours":
+				next.Hours = patch.GetHours()
+			case "price_tier":
+				next.PriceTier = patch.GetPriceTier()
+			case "tags":
+				next.Tags = tags
+			case "contact":
+				next.Contact = patch.GetContact()
+			case "menu":
+				next.Menu = patch.GetMenu()
+			}
+		}
+		return next, nil
+	})
+	if err != nil {
+		return detailResponse, err
+	}
+	detailResponse.Detail = written
+	return detailResponse, nil
+}
+
+// UpdateRating records the average rating of a restaurant's reviews in its details, unless
+// they hold one taken from stats at least as recent. Only the review service's ratings are
+// recorded, so this is an edit nobody is credited with and that makes no revision.
+func (s *Detail) UpdateRating(ctx context.Context, req *detail.UpdateRatingRequest) (*detail.UpdateRatingResponse, error) {
+	ratingResponse := &detail.UpdateRatingResponse{}
+	restaurantID := req.GetRestaurantId()
+	_, err := s.editDetail(ctx, restaurantID, &detailEdit{unversioned: true}, func(current *detail.GetDetailResponse) (*detail.GetDetailResponse, error) {
+		if current == nil {
+			return nil, status.Errorf(codes.NotFound, "Item with Key: %s does not exist", restaurantID)
+		}
+		if current.GetRatingVersion() >= req.GetRatingVersion() {
+			return nil, errRatingRecorded
+		}
+		next := proto.Clone(current).(*detail.GetDetailResponse)
+		next.Rating, next.ReviewCount, next.RatingVersion = req.GetRating(), req.GetReviewCount(), req.GetRatingVersion()
+		return next, nil
+	})
+	if err == errRatingRecorded {
+		return ratingResponse, nil
+	}
+	if err != nil {
+		return ratingResponse, err
+	}
+	ratingResponse.Updated = true
+	return ratingResponse, nil
+}
+
+// restaurantID returns the ID of the restaurant a request names: restaurantID if it is set,
+// or else the ID of the only restaurant called restaurantName. If there is none, the
+// NotFound error suggests restaurants with similar names.
+func (s *Detail) restaurantID(ctx context.Context, restaurantID, restaurantName string) (string, error) {
+	if restaurantID != "" {
+		return restaurantID, nil
+	}
+	if restaurantName == "" {
+		return "", status.Errorf(codes.InvalidArgument, "Missing restaurant id or name")
+	}
+	ids, err := s.restaurantsNamed(ctx, restaurantName)
+	if err != nil {
+		return "", err
+	}
+	switch len(ids) {
+	case 0:
+		return "", s.detailNotFound(ctx, restaurantName)
+	case 1:
+		return ids[0], nil
+	}
+	return "", status.Errorf(codes.FailedPrecondition, "%d restaurants are named %s: look them up by restaurant id", len(ids), restaurantName)
+}
+
+// restaurantsNamed returns the IDs of the restaurants called restaurantName, found by the
+// index of normalized names.
+func (s *Detail) restaurantsNamed(ctx context.Context, restaurantName string) ([]string, error) {
+	var ids []string
+	token := ""
+	for {
+		msg, err := s.detailDatabaseClient.QueryIndex(ctx, &mydatabase.QueryIndexRequest{
+			Conditions:        []*mydatabase.IndexCondition{{Index: DetailNameIndex, Value: normalizeName(restaurantName)}},
+			ContinuationToken: token,
+		})
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "Failed to query data storage: %v", err)
+		}
+		details, err := s.getDetails(ctx, msg.GetKeys())
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range details {
+			// the index only narrows the search to names that normalize the same
+			if d.GetRestaurantName() == restaurantName {
+				ids = append(ids, d.GetRestaurantId())
+			}
+		}
+		if token = msg.GetContinuationToken(); token == "" {
+			return ids, nil
+		}
+	}
+}
+
+// checkEtag returns FailedPrecondition if etag is set and the details are no longer at
+// that version.
+func checkEtag(current *detail.GetDetailResponse, etag string) error {
+	if etag != "" && etag != current.GetEtag() {
+		return status.Errorf(codes.FailedPrecondition, "Details of %s changed since version %s; they are now at version %s",
+			current.GetRestaurantName(), etag, current.GetEtag())
+	}
+	return nil
+}
+
+// editDetail reads the details of a restaurant, passes them to edit, or nil if there are
+// none, and then writes the details edit returns under a new etag, or deletes them if it
+// returns nil. The revision the edit makes is recorded in their history as e describes
+// it; e may be nil for edits nobody is credited with. The cache is refreshed to match.
+// Concurrent edits through this server wait for each other; an edit racing one made
+// elsewhere aborts its transaction and runs again, so edit sees the details the other
+// edit wrote.
+func (s *Detail) editDetail(ctx context.Context, restaurantID string, e *detailEdit, edit func(current *detail.GetDetailResponse) (*detail.GetDetailResponse, error)) (*detail.GetDetailResponse, error) {
+	mu := &s.editLocks[hash(restaurantID)%detailEditLocks]
+	mu.Lock()
+	defer mu.Unlock()
+
+	var next *detail.GetDetailResponse
+	var data []byte
+	var err error
+	for attempt := 0; ; attempt++ {
+		next, data, err = s.tryEditDetail(ctx, restaurantID, e, edit)
+		if status.Code(err) != codes.Aborted || attempt == detailEditRetries {
+			break
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if next == nil {
+		s.detailCacheClient.DeleteItem(ctx, &mycache.DeleteItemRequest{Key: restaurantID})
+	} else if s.CACHE_FLAG {
+		updateCache(ctx, s.detailCacheClient, restaurantID, data)
+	}
+	return next, nil
+}
+
+// tryEditDetail makes one attempt at an edit, in a transaction if the database supports
+// them. It returns the details written and their encoding.
+func (s *Detail) tryEditDetail(ctx context.Context, restaurantID string, e *detailEdit, edit func(current *detail.GetDetailResponse) (*detail.GetDetailResponse, error)) (*detail.GetDetailResponse, []byte, error) {
+	if !s.transactions {
+		return s.writeDetail(ctx, restaurantID, 0, e, edit)
+	}
+	begin, err := s.detailDatabaseClient.BeginTransaction(ctx, &mydatabase.BeginTransactionRequest{})
+	if err != nil {
+		return nil, nil, status.Errorf(codes.Internal, "Failed to start transaction: %v", err)
+	}
+	txn := begin.GetTransactionId()
+	next, data, err := s.writeDetail(ctx, restaurantID, txn, e, edit)
+	if err != nil {
+		s.detailDatabaseClient.AbortTransaction(ctx, &mydatabase.AbortTransactionRequest{TransactionId: txn})
+		return nil, nil, err
+	}
+	if _, err := s.detailDatabaseClient.CommitTransaction(ctx, &mydatabase.CommitTransactionRequest{TransactionId: txn}); err != nil {
+		if status.Code(err) == codes.Aborted {
+			return nil, nil, err
+		}
+		return nil, nil, status.Errorf(codes.Internal, "Failed to commit transaction: %v", err)
+	}
+	return next, data, nil
+}
+
+// writeDetail reads the details of a restaurant, in transaction txn if it is set, and
+// writes the details edit returns in their place, along with the revision they make. An
+// unversioned edit, which must return details, keeps their etag and makes no revision.
+func (s *Detail) writeDetail(ctx context.Context, restaurantID string, txn uint64, e *detailEdit, edit func(current *detail.GetDetailResponse) (*detail.GetDetailResponse, error)) (*detail.GetDetailResponse, []byte, error) {
+	var current *detail.GetDetailResponse
+	getRecordResponse, err := s.detailDatabaseClient.GetRecord(ctx, &mydatabase.GetRecordRequest{Key: restaurantID, TransactionId: txn})
+	switch {
+	case status.Code(err) == codes.NotFound:
+	case err != nil:
+		return nil, nil, status.Errorf(codes.Internal, "Failed to read data storage: %v", err)
+	default:
+		current = &detail.GetDetailResponse{}
+		if err := proto.Unmarshal(getRecordResponse.GetRecord().GetValue(), current); err != nil {
+			return nil, nil, status.Errorf(codes.Internal, "Failed to deserialize data")
+		}
+	}
+
+	next, err := edit(current)
+	if err != nil {
+		return nil, nil, err
+	}
+	if next != nil {
+		next = proto.Clone(next).(*detail.GetDetailResponse)
+		next.RestaurantId, next.Etag = restaurantID, uuid.New().String()
+	}
+	if e != nil && e.unversioned {
+		next.Etag, next.Revision = current.GetEtag(), current.GetRevision()
+	} else if err := s.recordRevision(ctx, restaurantID, txn, e, current, next); err != nil {
+		return nil, nil, err
+	}
+	var data []byte
+	if next == nil {
+		_, err = s.detailDatabaseClient.DeleteRecord(ctx, &mydatabase.DeleteRecordRequest{Key: restaurantID, TransactionId: txn})
+	} else {
+		if data, err = proto.Marshal(next); err != nil {
+			return nil, nil, status.Errorf(codes.Internal, "Failed to serialize data")
+		}
+		_, err = s.detailDatabaseClient.SetRecord(ctx, &mydatabase.SetRecordRequest{
+			Record:        &mydatabase.DatabaseRecord{Key: restaurantID, Value: data},
+			TransactionId: txn,
+		})
+	}
+	if err != nil {
+		return nil, nil, status.Errorf(codes.Internal, "Error in updating data storage: %v", err)
+	}
+	return next, data, nil
 }
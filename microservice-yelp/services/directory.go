@@ -0,0 +1,101 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"gitlab.cs.washington.edu/syslab/cse453-welp/proto/detail"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// restaurantDirectory looks up restaurants by name across the detail replicas, each of
+// which holds the details of some of them, for the services that name restaurants by ID.
+type restaurantDirectory []detail.DetailServiceClient
+
+// newRestaurantDirectory returns a directory of the restaurants held by the detail replicas
+// at addrs. With no replicas, restaurants can only be named by ID.
+func newRestaurantDirectory(addrs []string) restaurantDirectory {
+	var clients restaurantDirectory
+	for _, addr := range addrs {
+		clients = append(clients, detail.NewDetailServiceClient(dial(addr)))
+	}
+	return clients
+}
+
+// restaurantID returns the ID of the restaurant a request names: restaurantID if it is set,
+// or else the ID of the only restaurant called restaurantName.
+func (clients restaurantDirectory) restaurantID(ctx context.Context, restaurantID, restaurantName string) (string, error) {
+	if restaurantID != "" {
+		return restaurantID, nil
+	}
+	if restaurantName == "" || len(clients) == 0 {
+		return "", status.Errorf(codes.InvalidArgument, "Missing restaurant id")
+	}
+	d, err := clients.getDetailByName(ctx, &detail.GetDetailRequest{RestaurantName: restaurantName})
+	if err != nil {
+		return "", err
+	}
+	return d.GetRestaurantId(), nil
+}
+
+// getDetailByName asks every detail replica for the restaurant with the name req gives,
+// returning its details if exactly one restaurant has it. Otherwise it fails with
+// FailedPrecondition, or with NotFound carrying the suggestions of every replica.
+func (clients restaurantDirectory) getDetailByName(ctx context.Context, req *detail.GetDetailRequest) (*detail.GetDetailResponse, error) {
+	replies := make([]*detail.GetDetailResponse, len(clients))
+	errs := make([]error, len(clients))
+	var wg sync.WaitGroup
+	for i, client := range clients {
+		wg.Add(1)
+		go func(i int, client detail.DetailServiceClient) {
+			defer wg.Done()
+			replies[i], errs[i] = client.GetDetail(ctx, req)
+		}(i, client)
+	}
+	wg.Wait()
+
+	found := make(map[string]*detail.GetDetailResponse)
+	seen := make(map[string]bool)
+	var suggestions []*detail.RestaurantSuggestion
+	for i, err := range errs {
+		switch status.Code(err) {
+		case codes.OK:
+			found[replies[i].GetRestaurantId()] = replies[i]
+		case codes.NotFound:
+			for _, d := range status.Convert(err).Details() {
+				if msg, ok := d.(*detail.RestaurantSuggestions); ok {
+					for _, suggestion := range msg.GetSuggestions() {
+						if !seen[suggestion.GetRestaurantId()] {
+							seen[suggestion.GetRestaurantId()] = true
+							suggestions = append(suggestions, suggestion)
+						}
+					}
+				}
+			}
+		default:
+			return nil, err
+		}
+	}
+	switch len(found) {
+	case 1:
+		for _, reply := range found {
+			return reply, nil
+		}
+	case 0:
+		sort.SliceStable(suggestions, func(i, j int) bool {
+			return suggestions[i].GetSimilarity() > suggestions[j].GetSimilarity()
+		})
+		if len(suggestions) > detailSuggestions {
+			suggestions = suggestions[:detailSuggestions]
+		}
+		st := status.New(codes.NotFound, fmt.Sprintf("Item with Key: %s does not exist", req.GetRestaurantName()))
+		if withDetails, err := st.WithDetails(&detail.RestaurantSuggestions{Suggestions: suggestions}); err == nil && len(suggestions) > 0 {
+			st = withDetails
+		}
+		return nil, st.Err()
+	}
+	return nil, status.Errorf(codes.FailedPrecondition, "%d restaurants are named %s: look them up by restaurant id", len(found), req.GetRestaurantName())
+}
@@ -1,18 +1,26 @@
 package services
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"hash/fnv"
+	"io"
 	"log"
 	"net/http"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	"gitlab.cs.washington.edu/syslab/cse453-welp/proto/detail"
 	"gitlab.cs.washington.edu/syslab/cse453-welp/proto/reservation"
 	"gitlab.cs.washington.edu/syslab/cse453-welp/proto/review"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 // Frontend implements a service that acts as an interface to interact with different microservices.
@@ -21,6 +29,7 @@ type Frontend struct {
 	detailClient1 detail.DetailServiceClient
 	detailClient2 detail.DetailServiceClient
 	detailClient3 detail.DetailServiceClient
+	restaurants   restaurantDirectory
 
 	reviewClient1 review.ReviewServiceClient
 	reviewClient2 review.ReviewServiceClient
@@ -61,6 +70,7 @@ func NewFrontend(port int, detailaddr1 string, detailaddr2 string, detailaddr3 s
 		reservationClient: reservation.NewReservationServiceClient(dial(reservationaddr)),
 		User:              "None",
 	}
+	f.restaurants = restaurantDirectory{f.detailClient1, f.detailClient2, f.detailClient3}
 	return f
 }
 
@@ -69,6 +79,13 @@ func (s *Frontend) Run() error {
 	http.Handle("/", http.FileServer(http.Dir("./static")))
 	http.HandleFunc("/get-detail", s.getDetailHandler)
 	http.HandleFunc("/post-detail", s.postDetailHandler)
+	http.HandleFunc("/restaurants", s.listRestaurantsHandler)
+	http.HandleFunc("/nearby", s.nearbyRestaurantsHandler)
+	http.HandleFunc("/is-open", s.isOpenHandler)
+	http.HandleFunc("/upload-photo", s.uploadPhotoHandler)
+	http.HandleFunc("/download-photo", s.downloadPhotoHandler)
+	http.HandleFunc("/detail-history", s.detailHistoryHandler)
+	http.HandleFunc("/revert-detail", s.revertDetailHandler)
 	http.HandleFunc("/get-review", s.getReviewHandler)
 	http.HandleFunc("/post-review", s.postReviewHandler)
 	http.HandleFunc("/search-reviews", s.searchReviewsHandler)
@@ -86,28 +103,32 @@ func (s *Frontend) getDetailHandler(w http.ResponseWriter, r *http.Request) {
 
 	ctx := r.Context()
 
+	restaurant_id := r.URL.Query().Get("restaurant_id")
 	restaurant_name := r.URL.Query().Get("restaurant_name")
-	if restaurant_name == "" {
+	if restaurant_id == "" && restaurant_name == "" {
 		http.Error(w, "Malformed request to `/get-detail` endpoint!", http.StatusBadRequest)
 		return
 	}
 
 	replicaNum := 1
 	if s.LOAD_BALANCING_ALG == "hash" {
-		hashCode := int(hash(restaurant_name))
+		hashCode := int(hash(restaurant_id))
 		replicaNum = hashCode%3 + 1
 	} else if s.LOAD_BALANCING_ALG == "none" {
 		replicaNum = 1
 	} else {
-		replicaNum = determineReplicaNext(s, "detail", restaurant_name)
+		replicaNum = determineReplicaNext(s, "detail", restaurant_id)
 	}
 
-	req := &detail.GetDetailRequest{RestaurantName: restaurant_name}
+	req := &detail.GetDetailRequest{RestaurantName: restaurant_name, RestaurantId: restaurant_id}
 
 	var reply *detail.GetDetailResponse
 	var err error
 
-	if replicaNum == 1 {
+	if restaurant_id == "" {
+		// without its ID, the replica holding the restaurant is not known
+		reply, err = s.restaurants.getDetailByName(ctx, req)
+	} else if replicaNum == 1 {
 		reply, err = s.detailClient1.GetDetail(ctx, req)
 	} else if replicaNum == 2 {
 		reply, err = s.detailClient2.GetDetail(ctx, req)
@@ -115,6 +136,19 @@ func (s *Frontend) getDetailHandler(w http.ResponseWriter, r *http.Request) {
 		reply, err = s.detailClient3.GetDetail(ctx, req)
 	}
 
+	if status.Code(err) == codes.NotFound {
+		writeDetailNotFound(w, err)
+		return
+	}
+	if status.Code(err) == codes.FailedPrecondition {
+		// more than one restaurant has the name
+		http.Error(w, status.Convert(err).Message(), http.StatusConflict)
+		return
+	}
+	if status.Code(err) == codes.InvalidArgument {
+		writeInvalidArgument(w, err)
+		return
+	}
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -136,36 +170,123 @@ func (s *Frontend) getDetailHandler(w http.ResponseWriter, r *http.Request) {
 	err = json.NewEncoder(w).Encode(reply)
 }
 
+// detailNotFoundReply is the body of a `/get-detail` response for a restaurant that does
+// not exist.
+type detailNotFoundReply struct {
+	Error       string                         `json:"error"`
+	Suggestions []*detail.RestaurantSuggestion `json:"suggestions"`
+}
+
+// writeDetailNotFound responds with the NotFound error of GetDetail as JSON, listing the
+// restaurants with similar names it suggests.
+func writeDetailNotFound(w http.ResponseWriter, err error) {
+	st := status.Convert(err)
+	reply := detailNotFoundReply{Error: st.Message(), Suggestions: []*detail.RestaurantSuggestion{}}
+	for _, d := range st.Details() {
+		if suggestions, ok := d.(*detail.RestaurantSuggestions); ok {
+			reply.Suggestions = append(reply.Suggestions, suggestions.GetSuggestions()...)
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusNotFound)
+	json.NewEncoder(w).Encode(reply)
+}
+
+// writeLookupError responds with the error of looking up a restaurant by name: NotFound
+// with the restaurants with similar names, or Conflict if several have the name.
+func writeLookupError(w http.ResponseWriter, err error) {
+	switch status.Code(err) {
+	case codes.NotFound:
+		writeDetailNotFound(w, err)
+	case codes.FailedPrecondition:
+		http.Error(w, status.Convert(err).Message(), http.StatusConflict)
+	case codes.InvalidArgument:
+		writeInvalidArgument(w, err)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// invalidArgumentReply is the body of a response to a request a service rejected as
+// invalid.
+type invalidArgumentReply struct {
+	Error  string                                  `json:"error"`
+	Fields []*errdetails.BadRequest_FieldViolation `json:"fields"`
+}
+
+// writeInvalidArgument responds with an InvalidArgument error as JSON, listing each field
+// of the request that the error names and what is wrong with it.
+func writeInvalidArgument(w http.ResponseWriter, err error) {
+	st := status.Convert(err)
+	reply := invalidArgumentReply{Error: st.Message(), Fields: []*errdetails.BadRequest_FieldViolation{}}
+	for _, d := range st.Details() {
+		if badRequest, ok := d.(*errdetails.BadRequest); ok {
+			reply.Fields = append(reply.Fields, badRequest.GetFieldViolations()...)
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(reply)
+}
+
 // postDetailHandler handles requests for posting restaurant details.
 func (s *Frontend) postDetailHandler(w http.ResponseWriter, r *http.Request) {
 	start := time.Now()
 
 	ctx := r.Context()
-	restaurant_name := r.URL.Query().Get("restaurant_name")
-	location := r.URL.Query().Get("location")
-	style := r.URL.Query().Get("style")
-	capacity, errCap := strconv.Atoi(r.URL.Query().Get("capacity"))
 
-	if restaurant_name == "" || location == "" || style == "" || errCap != nil {
+	// the rest of a restaurant's profile, such as its opening hours and menu, is posted as
+	// a JSON PostDetailRequest in the body, whose fields the query parameters override
+	req := &detail.PostDetailRequest{}
+	errBody := error(nil)
+	profile := strings.HasPrefix(r.Header.Get("Content-Type"), "application/json")
+	if profile {
+		var body []byte
+		if body, errBody = io.ReadAll(r.Body); errBody == nil {
+			errBody = protojson.Unmarshal(body, req)
+		}
+	}
+
+	query := r.URL.Query()
+	for param, field := range map[string]*string{"restaurant_id": &req.RestaurantId, "restaurant_name": &req.RestaurantName, "location": &req.Location, "style": &req.Style, "author": &req.Author} {
+		if v := query.Get(param); v != "" {
+			*field = v
+		}
+	}
+	errCap := error(nil)
+	if v := query.Get("capacity"); v != "" || !profile {
+		var capacity int
+		capacity, errCap = strconv.Atoi(v)
+		req.Capacity = int32(capacity)
+	}
+
+	// coordinates are optional, but latitude and longitude come together
+	coordinates, errCoords := parseLatLng(query.Get("lat"), query.Get("lng"))
+	if coordinates != nil {
+		req.Coordinates = coordinates
+	}
+
+	if req.RestaurantName == "" || req.Location == "" || req.Style == "" || errBody != nil || errCap != nil || errCoords != nil {
 		http.Error(w, "Malformed request to `/post-detail` endpoint!", http.StatusBadRequest)
 		return
 	}
+	restaurant_id := req.RestaurantId
+
+	// a new restaurant gets its ID here rather than from PostDetail, so that it can be
+	// routed to the replica every later request for it goes to
+	if restaurant_id == "" {
+		restaurant_id = NewRestaurantID()
+		req.RestaurantId = restaurant_id
+	}
 
 	replicaNum := 1
 	if s.LOAD_BALANCING_ALG == "hash" {
-		hashCode := int(hash(restaurant_name))
+		hashCode := int(hash(restaurant_id))
 		replicaNum = hashCode%3 + 1
 	} else if s.LOAD_BALANCING_ALG == "none" {
 		replicaNum = 1
 	} else {
-		replicaNum = determineReplicaNext(s, "detail", restaurant_name)
-	}
-
-	req := &detail.PostDetailRequest{
-		RestaurantName: restaurant_name,
-		Location:       location,
-		Style:          style,
-		Capacity:       int32(capacity),
+		replicaNum = determineReplicaNext(s, "detail", restaurant_id)
 	}
 
 	var reply *detail.PostDetailResponse
@@ -179,6 +300,10 @@ func (s *Frontend) postDetailHandler(w http.ResponseWriter, r *http.Request) {
 		reply, err = s.detailClient3.PostDetail(ctx, req)
 	}
 
+	if status.Code(err) == codes.InvalidArgument {
+		writeInvalidArgument(w, err)
+		return
+	}
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -199,36 +324,572 @@ func (s *Frontend) postDetailHandler(w http.ResponseWriter, r *http.Request) {
 	err = json.NewEncoder(w).Encode(reply)
 }
 
+// listRestaurantsHandler handles requests for browsing restaurants. Every detail replica is
+// asked for a page of the restaurants it holds, and the pages are merged into one.
+func (s *Frontend) listRestaurantsHandler(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	req := &detail.ListRestaurantsRequest{
+		Location:   query.Get("location"),
+		Style:      query.Get("style"),
+		Descending: query.Get("order") == "desc",
+		PageToken:  query.Get("page_token"),
+	}
+	malformed := query.Get("order") != "" && query.Get("order") != "asc" && query.Get("order") != "desc"
+	for param, field := range map[string]*int32{"min_capacity": &req.MinCapacity, "max_capacity": &req.MaxCapacity, "page_size": &req.PageSize} {
+		if v := query.Get(param); v != "" {
+			n, err := strconv.Atoi(v)
+			malformed = malformed || err != nil
+			*field = int32(n)
+		}
+	}
+	switch query.Get("sort_by") {
+	case "", "name":
+		req.OrderBy = detail.RestaurantOrder_ORDER_BY_NAME
+	case "capacity":
+		req.OrderBy = detail.RestaurantOrder_ORDER_BY_CAPACITY
+	case "rating":
+		req.OrderBy = detail.RestaurantOrder_ORDER_BY_RATING
+	default:
+		malformed = true
+	}
+	if _, _, err := checkListRestaurants(req); malformed || err != nil {
+		http.Error(w, "Malformed request to `/restaurants` endpoint!", http.StatusBadRequest)
+		return
+	}
+
+	clients := []detail.DetailServiceClient{s.detailClient1, s.detailClient2, s.detailClient3}
+	pages := make([]*detail.ListRestaurantsResponse, len(clients))
+	errs := make([]error, len(clients))
+	var wg sync.WaitGroup
+	for i, client := range clients {
+		wg.Add(1)
+		go func(i int, client detail.DetailServiceClient) {
+			defer wg.Done()
+			pages[i], errs[i] = client.ListRestaurants(ctx, req)
+		}(i, client)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if status.Code(err) == codes.InvalidArgument {
+			writeInvalidArgument(w, err)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	reply := mergeRestaurantPages(req, pages)
+
+	// Calculate the duration in microseconds
+	duration := int64(time.Since(start).Microseconds())
+	in, _ := json.Marshal(req)
+	inStr, outStr := string(in), "{}"
+	logMsg("frontend.listRestaurantsHandler", inStr, outStr, "<nil>", duration)
+
+	json.NewEncoder(w).Encode(reply)
+}
+
+// nearbyRestaurantsHandler handles requests for finding restaurants near a point. Every
+// detail replica is searched, and the restaurants they find are merged, nearest first.
+func (s *Frontend) nearbyRestaurantsHandler(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	center, err := parseLatLng(query.Get("lat"), query.Get("lng"))
+	radius, errRadius := strconv.ParseFloat(query.Get("radius_km"), 64)
+	req := &detail.NearbyRestaurantsRequest{
+		Center:   center,
+		RadiusKm: radius,
+		Style:    query.Get("style"),
+	}
+	malformed := err != nil || errRadius != nil
+	if v := query.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		malformed = malformed || err != nil
+		req.Limit = int32(n)
+	}
+	if _, err := checkNearbyRestaurants(req); malformed || err != nil {
+		http.Error(w, "Malformed request to `/nearby` endpoint!", http.StatusBadRequest)
+		return
+	}
+
+	clients := []detail.DetailServiceClient{s.detailClient1, s.detailClient2, s.detailClient3}
+	found := make([]*detail.NearbyRestaurantsResponse, len(clients))
+	errs := make([]error, len(clients))
+	var wg sync.WaitGroup
+	for i, client := range clients {
+		wg.Add(1)
+		go func(i int, client detail.DetailServiceClient) {
+			defer wg.Done()
+			found[i], errs[i] = client.NearbyRestaurants(ctx, req)
+		}(i, client)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if status.Code(err) == codes.InvalidArgument {
+			writeInvalidArgument(w, err)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	reply := mergeNearbyRestaurants(req, found)
+
+	// Calculate the duration in microseconds
+	duration := int64(time.Since(start).Microseconds())
+	in, _ := json.Marshal(req)
+	inStr, outStr := string(in), "{}"
+	logMsg("frontend.nearbyRestaurantsHandler", inStr, outStr, "<nil>", duration)
+
+	json.NewEncoder(w).Encode(reply)
+}
+
+// parseLatLng parses a point from its latitude and longitude query parameters. It returns
+// nil if both are empty.
+func parseLatLng(lat, lng string) (*detail.LatLng, error) {
+	if lat == "" && lng == "" {
+		return nil, nil
+	}
+	latitude, err := strconv.ParseFloat(lat, 64)
+	if err != nil {
+		return nil, err
+	}
+	longitude, err := strconv.ParseFloat(lng, 64)
+	if err != nil {
+		return nil, err
+	}
+	p := &detail.LatLng{Latitude: latitude, Longitude: longitude}
+	return p, checkCoordinates(p)
+}
+
+// detailReplica returns the client of the detail replica holding a restaurant.
+func (s *Frontend) detailReplica(restaurant_id string) detail.DetailServiceClient {
+	replicaNum := 1
+	if s.LOAD_BALANCING_ALG == "hash" {
+		hashCode := int(hash(restaurant_id))
+		replicaNum = hashCode%3 + 1
+	} else if s.LOAD_BALANCING_ALG == "none" {
+		replicaNum = 1
+	} else {
+		replicaNum = determineReplicaNext(s, "detail", restaurant_id)
+	}
+	return []detail.DetailServiceClient{s.detailClient1, s.detailClient2, s.detailClient3}[replicaNum-1]
+}
+
+// isOpenHandler handles requests for checking whether a restaurant is open at a time, given
+// in RFC 3339 format, or on a date; if neither is given, now.
+func (s *Frontend) isOpenHandler(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	req := &detail.IsOpenRequest{
+		RestaurantId:   query.Get("restaurant_id"),
+		RestaurantName: query.Get("restaurant_name"),
+	}
+	malformed := req.RestaurantId == "" && req.RestaurantName == ""
+	if v := query.Get("time"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		malformed = malformed || err != nil
+		req.Time = timestamppb.New(t)
+	}
+	if query.Get("year") != "" || query.Get("month") != "" || query.Get("day") != "" {
+		year, year_err := strconv.Atoi(query.Get("year"))
+		month, month_err := strconv.Atoi(query.Get("month"))
+		day, day_err := strconv.Atoi(query.Get("day"))
+		malformed = malformed || year_err != nil || month_err != nil || day_err != nil || req.Time != nil
+		req.Date = &detail.Date{Year: int32(year), Month: int32(month), Day: int32(day)}
+	}
+	if malformed {
+		http.Error(w, "Malformed request to `/is-open` endpoint!", http.StatusBadRequest)
+		return
+	}
+
+	var reply *detail.IsOpenResponse
+	var err error
+	if req.RestaurantId == "" {
+		// without its ID, the replica holding the restaurant is not known
+		var d *detail.GetDetailResponse
+		if d, err = s.restaurants.getDetailByName(ctx, &detail.GetDetailRequest{RestaurantName: req.RestaurantName}); err == nil {
+			req.RestaurantId = d.GetRestaurantId()
+		}
+	}
+	if err == nil {
+		reply, err = s.detailReplica(req.RestaurantId).IsOpen(ctx, req)
+	}
+
+	switch status.Code(err) {
+	case codes.OK:
+	case codes.NotFound:
+		writeDetailNotFound(w, err)
+		return
+	case codes.FailedPrecondition:
+		// more than one restaurant has the name
+		http.Error(w, status.Convert(err).Message(), http.StatusConflict)
+		return
+	case codes.InvalidArgument:
+		writeInvalidArgument(w, err)
+		return
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Calculate the duration in microseconds
+	duration := int64(time.Since(start).Microseconds())
+	in, _ := json.Marshal(req)
+	inStr, outStr := string(in), "{}"
+	logMsg("frontend.isOpenHandler", inStr, outStr, "<nil>", duration)
+
+	json.NewEncoder(w).Encode(reply)
+}
+
+// uploadPhotoHandler handles multipart/form-data requests uploading a photo of a restaurant
+// in their photo field, along with the restaurant_id or restaurant_name of the restaurant
+// and an optional caption.
+func (s *Frontend) uploadPhotoHandler(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	ctx := r.Context()
+	if r.Method != http.MethodPost {
+		http.Error(w, "Photos are uploaded with POST requests to `/upload-photo`!", http.StatusMethodNotAllowed)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxPhotoSize+photoFormOverhead)
+	if err := r.ParseMultipartForm(photoFormMemory); err != nil {
+		http.Error(w, fmt.Sprintf("Malformed request to `/upload-photo` endpoint: %v", err), http.StatusBadRequest)
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+	file, _, errFile := r.FormFile("photo")
+	restaurant_id := r.FormValue("restaurant_id")
+	restaurant_name := r.FormValue("restaurant_name")
+	if errFile != nil || (restaurant_id == "" && restaurant_name == "") {
+		http.Error(w, "Malformed request to `/upload-photo` endpoint!", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	var reply *detail.UploadPhotoResponse
+	var err error
+	if restaurant_id == "" {
+		// without its ID, the replica holding the restaurant is not known
+		var d *detail.GetDetailResponse
+		if d, err = s.restaurants.getDetailByName(ctx, &detail.GetDetailRequest{RestaurantName: restaurant_name}); err == nil {
+			restaurant_id = d.GetRestaurantId()
+		}
+	}
+	if err == nil {
+		reply, err = s.uploadPhoto(ctx, restaurant_id, r.FormValue("caption"), file)
+	}
+
+	switch status.Code(err) {
+	case codes.OK:
+	case codes.NotFound:
+		writeDetailNotFound(w, err)
+		return
+	case codes.InvalidArgument:
+		writeInvalidArgument(w, err)
+		return
+	case codes.FailedPrecondition, codes.ResourceExhausted:
+		http.Error(w, status.Convert(err).Message(), http.StatusConflict)
+		return
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Calculate the duration in microseconds
+	duration := int64(time.Since(start).Microseconds())
+	in, _ := json.Marshal(map[string]string{"restaurant_id": restaurant_id, "restaurant_name": restaurant_name})
+	inStr, outStr := string(in), "{}"
+	logMsg("frontend.uploadPhotoHandler", inStr, outStr, "<nil>", duration)
+
+	json.NewEncoder(w).Encode(reply)
+}
+
+// uploadPhoto streams a photo to the detail replica holding its restaurant.
+func (s *Frontend) uploadPhoto(ctx context.Context, restaurant_id, caption string, photo io.Reader) (*detail.UploadPhotoResponse, error) {
+	stream, err := s.detailReplica(restaurant_id).UploadPhoto(ctx)
+	if err != nil {
+		return nil, err
+	}
+	buf := make([]byte, photoChunkSize)
+	for first := true; ; first = false {
+		n, err := io.ReadFull(photo, buf)
+		if err == io.EOF && !first {
+			break
+		}
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return nil, status.Errorf(codes.InvalidArgument, "Failed to read photo: %v", err)
+		}
+		msg := &detail.UploadPhotoRequest{Data: buf[:n]}
+		if first {
+			msg.RestaurantId, msg.Caption = restaurant_id, caption
+		}
+		if err := stream.Send(msg); err == io.EOF {
+			// the replica gave up on the photo; CloseAndRecv returns why
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		if n < len(buf) {
+			break
+		}
+	}
+	return stream.CloseAndRecv()
+}
+
+// downloadPhotoHandler handles requests for downloading a photo of a restaurant, which
+// is kept by the detail replica of the restaurant it was uploaded for.
+func (s *Frontend) downloadPhotoHandler(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	ctx := r.Context()
+	restaurant_id := r.URL.Query().Get("restaurant_id")
+	photo_id := r.URL.Query().Get("photo_id")
+	if restaurant_id == "" || photo_id == "" {
+		http.Error(w, "Malformed request to `/download-photo` endpoint!", http.StatusBadRequest)
+		return
+	}
+
+	// photos are named by the hash of their content, which therefore never changes
+	etag := `"` + photo_id + `"`
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	req := &detail.DownloadPhotoRequest{PhotoId: photo_id}
+	stream, err := s.detailReplica(restaurant_id).DownloadPhoto(ctx, req)
+	var msg *detail.DownloadPhotoResponse
+	if err == nil {
+		msg, err = stream.Recv()
+	}
+	switch status.Code(err) {
+	case codes.OK:
+	case codes.NotFound:
+		http.Error(w, status.Convert(err).Message(), http.StatusNotFound)
+		return
+	case codes.InvalidArgument:
+		writeInvalidArgument(w, err)
+		return
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	metadata := msg.GetMetadata()
+	w.Header().Set("Content-Type", metadata.GetContentType())
+	w.Header().Set("Content-Length", strconv.FormatInt(metadata.GetSize(), 10))
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	for {
+		if _, err := w.Write(msg.GetData()); err != nil {
+			return
+		}
+		if msg, err = stream.Recv(); err == io.EOF {
+			break
+		} else if err != nil {
+			// the response has started, so the client only sees it cut short
+			log.Printf("failed to download photo %s: %v", photo_id, err)
+			return
+		}
+	}
+
+	// Calculate the duration in microseconds
+	duration := int64(time.Since(start).Microseconds())
+	in, _ := json.Marshal(req)
+	inStr, outStr := string(in), "{}"
+	logMsg("frontend.downloadPhotoHandler", inStr, outStr, "<nil>", duration)
+}
+
+// detailHistoryHandler handles requests for listing the revisions of a restaurant's
+// details, newest first, a page of at most limit at a time, starting before the revision
+// before_revision if it is given.
+func (s *Frontend) detailHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	req := &detail.GetDetailHistoryRequest{
+		RestaurantId:   query.Get("restaurant_id"),
+		RestaurantName: query.Get("restaurant_name"),
+	}
+	malformed := req.RestaurantId == "" && req.RestaurantName == ""
+	if v := query.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		malformed = malformed || err != nil
+		req.Limit = int32(n)
+	}
+	if v := query.Get("before_revision"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		malformed = malformed || err != nil
+		req.BeforeRevision = n
+	}
+	if malformed {
+		http.Error(w, "Malformed request to `/detail-history` endpoint!", http.StatusBadRequest)
+		return
+	}
+
+	var reply *detail.GetDetailHistoryResponse
+	var err error
+	if req.RestaurantId == "" {
+		// without its ID, the replica holding the restaurant is not known
+		var d *detail.GetDetailResponse
+		if d, err = s.restaurants.getDetailByName(ctx, &detail.GetDetailRequest{RestaurantName: req.RestaurantName}); err == nil {
+			req.RestaurantId = d.GetRestaurantId()
+		}
+	}
+	if err == nil {
+		reply, err = s.detailReplica(req.RestaurantId).GetDetailHistory(ctx, req)
+	}
+
+	switch status.Code(err) {
+	case codes.OK:
+	case codes.NotFound:
+		writeDetailNotFound(w, err)
+		return
+	case codes.FailedPrecondition:
+		// more than one restaurant has the name
+		http.Error(w, status.Convert(err).Message(), http.StatusConflict)
+		return
+	case codes.InvalidArgument:
+		writeInvalidArgument(w, err)
+		return
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Calculate the duration in microseconds
+	duration := int64(time.Since(start).Microseconds())
+	in, _ := json.Marshal(req)
+	inStr, outStr := string(in), "{}"
+	logMsg("frontend.detailHistoryHandler", inStr, outStr, "<nil>", duration)
+
+	json.NewEncoder(w).Encode(reply)
+}
+
+// revertDetailHandler handles requests for restoring the details of a restaurant to those
+// of an earlier revision, credited to author, if they are still at the version etag names
+// when it is given.
+func (s *Frontend) revertDetailHandler(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	revision, errRevision := strconv.ParseInt(query.Get("revision"), 10, 64)
+	req := &detail.RevertDetailRequest{
+		RestaurantId:   query.Get("restaurant_id"),
+		RestaurantName: query.Get("restaurant_name"),
+		Revision:       revision,
+		Etag:           query.Get("etag"),
+		Author:         query.Get("author"),
+	}
+	if (req.RestaurantId == "" && req.RestaurantName == "") || errRevision != nil {
+		http.Error(w, "Malformed request to `/revert-detail` endpoint!", http.StatusBadRequest)
+		return
+	}
+
+	var reply *detail.RevertDetailResponse
+	var err error
+	if req.RestaurantId == "" {
+		// without its ID, the replica holding the restaurant is not known
+		var d *detail.GetDetailResponse
+		if d, err = s.restaurants.getDetailByName(ctx, &detail.GetDetailRequest{RestaurantName: req.RestaurantName}); err == nil {
+			req.RestaurantId = d.GetRestaurantId()
+		}
+	}
+	if err == nil {
+		reply, err = s.detailReplica(req.RestaurantId).RevertDetail(ctx, req)
+	}
+
+	switch status.Code(err) {
+	case codes.OK:
+	case codes.NotFound:
+		writeDetailNotFound(w, err)
+		return
+	case codes.FailedPrecondition:
+		// more than one restaurant has the name, or the details changed since etag
+		http.Error(w, status.Convert(err).Message(), http.StatusConflict)
+		return
+	case codes.InvalidArgument:
+		writeInvalidArgument(w, err)
+		return
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Calculate the duration in microseconds
+	duration := int64(time.Since(start).Microseconds())
+	in, _ := json.Marshal(req)
+	inStr, outStr := string(in), "{}"
+	logMsg("frontend.revertDetailHandler", inStr, outStr, "<nil>", duration)
+
+	json.NewEncoder(w).Encode(reply)
+}
+
+// updateRating copies the average rating of a restaurant's reviews, as of a review just
+// posted, to the restaurant's details, unless a review posted since has already updated
+// it. Restaurants without details have nowhere to keep it.
+func (s *Frontend) updateRating(ctx context.Context, restaurant_id string, posted *review.PostReviewResponse) {
+	client := s.detailReplica(restaurant_id)
+	req := &detail.UpdateRatingRequest{
+		RestaurantId:  restaurant_id,
+		Rating:        posted.GetAverageRating(),
+		ReviewCount:   posted.GetReviewCount(),
+		RatingVersion: posted.GetRatingVersion(),
+	}
+	if _, err := client.UpdateRating(ctx, req); err != nil && status.Code(err) != codes.NotFound {
+		log.Printf("failed to update the rating of %s: %v", restaurant_id, err)
+	}
+}
+
 // getReviewHandler handles requests for retrieving reviews.
 func (s *Frontend) getReviewHandler(w http.ResponseWriter, r *http.Request) {
 	start := time.Now()
 
 	ctx := r.Context()
+	restaurant_id := r.URL.Query().Get("restaurant_id")
 	restaurant_name := r.URL.Query().Get("restaurant_name")
 	user_name := r.URL.Query().Get("user_name")
 
-	if restaurant_name == "" || user_name == "" {
+	if (restaurant_id == "" && restaurant_name == "") || user_name == "" {
 		http.Error(w, "Malformed request to `/get-review` endpoint!", http.StatusBadRequest)
 		return
 	}
+	// without its ID, the replica holding the restaurant's reviews is not known
+	restaurant_id, err := s.restaurants.restaurantID(ctx, restaurant_id, restaurant_name)
+	if err != nil {
+		writeLookupError(w, err)
+		return
+	}
 
 	replicaNum := 1
 	if s.LOAD_BALANCING_ALG == "hash" {
-		hashCode := int(hash(restaurant_name))
+		hashCode := int(hash(restaurant_id))
 		replicaNum = hashCode%3 + 1
 	} else if s.LOAD_BALANCING_ALG == "none" {
 		replicaNum = 1
 	} else {
-		replicaNum = determineReplicaNext(s, "review", restaurant_name)
+		replicaNum = determineReplicaNext(s, "review", restaurant_id)
 	}
 
 	req := &review.GetReviewRequest{
 		RestaurantName: restaurant_name,
+		RestaurantId:   restaurant_id,
 		UserName:       user_name,
 	}
 
 	var reply *review.GetReviewResponse
-	var err error
 
 	if replicaNum == 1 {
 		reply, err = s.reviewClient1.GetReview(ctx, req)
@@ -238,6 +899,10 @@ func (s *Frontend) getReviewHandler(w http.ResponseWriter, r *http.Request) {
 		reply, err = s.reviewClient3.GetReview(ctx, req)
 	}
 
+	if status.Code(err) == codes.InvalidArgument {
+		writeInvalidArgument(w, err)
+		return
+	}
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -263,34 +928,41 @@ func (s *Frontend) postReviewHandler(w http.ResponseWriter, r *http.Request) {
 
 	ctx := r.Context()
 	user_name := r.URL.Query().Get("user_name")
+	restaurant_id := r.URL.Query().Get("restaurant_id")
 	restaurant_name := r.URL.Query().Get("restaurant_name")
 	restaurant_review := r.URL.Query().Get("review")
 	restaurant_rating, errRating := strconv.Atoi(r.URL.Query().Get("rating"))
 
-	if restaurant_name == "" || user_name == "" || restaurant_review == "" || errRating != nil {
+	if (restaurant_id == "" && restaurant_name == "") || user_name == "" || restaurant_review == "" || errRating != nil {
 		http.Error(w, "Malformed request to `/post-review` endpoint!", http.StatusBadRequest)
 		return
 	}
+	// without its ID, the replica holding the restaurant's reviews is not known
+	restaurant_id, err := s.restaurants.restaurantID(ctx, restaurant_id, restaurant_name)
+	if err != nil {
+		writeLookupError(w, err)
+		return
+	}
 
 	replicaNum := 1
 	if s.LOAD_BALANCING_ALG == "hash" {
-		hashCode := int(hash(restaurant_name))
+		hashCode := int(hash(restaurant_id))
 		replicaNum = hashCode%3 + 1
 	} else if s.LOAD_BALANCING_ALG == "none" {
 		replicaNum = 1
 	} else {
-		replicaNum = determineReplicaNext(s, "review", restaurant_name)
+		replicaNum = determineReplicaNext(s, "review", restaurant_id)
 	}
 
 	req := &review.PostReviewRequest{
 		UserName:       user_name,
 		RestaurantName: restaurant_name,
+		RestaurantId:   restaurant_id,
 		Review:         restaurant_review,
 		Rating:         int32(restaurant_rating),
 	}
 
 	var reply *review.PostReviewResponse
-	var err error
 
 	if replicaNum == 1 {
 		reply, err = s.reviewClient1.PostReview(ctx, req)
@@ -300,10 +972,15 @@ func (s *Frontend) postReviewHandler(w http.ResponseWriter, r *http.Request) {
 		reply, err = s.reviewClient3.PostReview(ctx, req)
 	}
 
+	if status.Code(err) == codes.InvalidArgument {
+		writeInvalidArgument(w, err)
+		return
+	}
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	s.updateRating(ctx, restaurant_id, reply)
 
 	// Calculate the duration in microseconds
 	duration := int64(time.Since(start).Microseconds())
@@ -323,28 +1000,35 @@ func (s *Frontend) postReviewHandler(w http.ResponseWriter, r *http.Request) {
 func (s *Frontend) searchReviewsHandler(w http.ResponseWriter, r *http.Request) {
 	start := time.Now()
 	ctx := r.Context()
+	restaurant_id := r.URL.Query().Get("restaurant_id")
 	restaurant_name := r.URL.Query().Get("restaurant_name")
-	if restaurant_name == "" {
+	if restaurant_id == "" && restaurant_name == "" {
 		http.Error(w, "Malformed request to `/search-reviews` endpoint!", http.StatusBadRequest)
 		return
 	}
+	// without its ID, the replica holding the restaurant's reviews is not known
+	restaurant_id, err := s.restaurants.restaurantID(ctx, restaurant_id, restaurant_name)
+	if err != nil {
+		writeLookupError(w, err)
+		return
+	}
 
 	replicaNum := 1
 	if s.LOAD_BALANCING_ALG == "hash" {
-		hashCode := int(hash(restaurant_name))
+		hashCode := int(hash(restaurant_id))
 		replicaNum = hashCode%3 + 1
 	} else if s.LOAD_BALANCING_ALG == "none" {
 		replicaNum = 1
 	} else {
-		replicaNum = determineReplicaNext(s, "review", restaurant_name)
+		replicaNum = determineReplicaNext(s, "review", restaurant_id)
 	}
 
 	req := &review.SearchReviewsRequest{
 		RestaurantName: restaurant_name,
+		RestaurantId:   restaurant_id,
 	}
 
 	var reply *review.SearchReviewsResponse
-	var err error
 
 	if replicaNum == 1 {
 		reply, err = s.reviewClient1.SearchReviews(ctx, req)
@@ -354,6 +1038,10 @@ func (s *Frontend) searchReviewsHandler(w http.ResponseWriter, r *http.Request)
 		reply, err = s.reviewClient3.SearchReviews(ctx, req)
 	}
 
+	if status.Code(err) == codes.InvalidArgument {
+		writeInvalidArgument(w, err)
+		return
+	}
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -388,6 +1076,10 @@ func (s *Frontend) getReservationHandler(w http.ResponseWriter, r *http.Request)
 	req := &reservation.GetReservationRequest{UserName: user_name}
 	reply, err := s.reservationClient.GetReservation(ctx, req)
 
+	if status.Code(err) == codes.InvalidArgument {
+		writeInvalidArgument(w, err)
+		return
+	}
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -412,23 +1104,56 @@ func (s *Frontend) makeReservationHandler(w http.ResponseWriter, r *http.Request
 	start := time.Now()
 	ctx := r.Context()
 	user_name := r.URL.Query().Get("user_name")
+	restaurant_id := r.URL.Query().Get("restaurant_id")
 	restaurant_name := r.URL.Query().Get("restaurant_name")
 	year, year_err := strconv.Atoi(r.URL.Query().Get("year"))
 	month, month_err := strconv.Atoi(r.URL.Query().Get("month"))
 	day, day_err := strconv.Atoi(r.URL.Query().Get("day"))
 
-	if restaurant_name == "" || user_name == "" || year_err != nil || month_err != nil || day_err != nil {
+	if (restaurant_id == "" && restaurant_name == "") || user_name == "" || year_err != nil || month_err != nil || day_err != nil {
 		http.Error(w, "Malformed request to `/make-reservation` endpoint!", http.StatusBadRequest)
 		return
 	}
+	restaurant_id, err := s.restaurants.restaurantID(ctx, restaurant_id, restaurant_name)
+	if err != nil {
+		writeLookupError(w, err)
+		return
+	}
 
 	req := &reservation.MakeReservationRequest{
 		UserName:       user_name,
 		RestaurantName: restaurant_name,
+		RestaurantId:   restaurant_id,
 		Time:           &reservation.Date{Year: int32(year), Month: int32(month), Day: int32(day)},
 	}
+
+	// reservations are only taken for days the restaurant is open, as far as its details
+	// say; restaurants without details are not checked
+	open, err := s.detailReplica(restaurant_id).IsOpen(ctx, &detail.IsOpenRequest{
+		RestaurantId: restaurant_id,
+		Date:         &detail.Date{Year: int32(year), Month: int32(month), Day: int32(day)},
+	})
+	switch status.Code(err) {
+	case codes.OK:
+		if !open.GetOpen() {
+			http.Error(w, fmt.Sprintf("The restaurant is closed on %04d-%02d-%02d", year, month, day), http.StatusConflict)
+			return
+		}
+	case codes.NotFound:
+	case codes.InvalidArgument:
+		writeInvalidArgument(w, err)
+		return
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
 	reply, err := s.reservationClient.MakeReservation(ctx, req)
 
+	if status.Code(err) == codes.InvalidArgument {
+		writeInvalidArgument(w, err)
+		return
+	}
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -464,6 +1189,10 @@ func (s *Frontend) mostPopularHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	reply, err := s.reservationClient.MostPopular(ctx, req)
 
+	if status.Code(err) == codes.InvalidArgument {
+		writeInvalidArgument(w, err)
+		return
+	}
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -489,11 +1218,11 @@ func hash(s string) uint32 {
 	return h.Sum32()
 }
 
-func determineReplicaNext(s *Frontend, service string, restaurant_name string) int {
+func determineReplicaNext(s *Frontend, service string, restaurant_id string) int {
 	s.mu.Lock()
 
 	if service == "detail" {
-		currReplicaNum, exists := s.sequentialKeyToReplicaDetail[restaurant_name]
+		currReplicaNum, exists := s.sequentialKeyToReplicaDetail[restaurant_id]
 
 		if exists {
 			// if yes: send to that replica
@@ -507,12 +1236,12 @@ func determineReplicaNext(s *Frontend, service string, restaurant_name string) i
 				s.sequentialNextReplicaDetail = 1
 			}
 
-			s.sequentialKeyToReplicaDetail[restaurant_name] = replicaNum
+			s.sequentialKeyToReplicaDetail[restaurant_id] = replicaNum
 			s.mu.Unlock()
 			return replicaNum
 		}
 	} else if service == "review" {
-		currReplicaNum, exists := s.sequentialKeyToReplicaReview[restaurant_name]
+		currReplicaNum, exists := s.sequentialKeyToReplicaReview[restaurant_id]
 
 		if exists {
 			// if yes: send to that replica
@@ -526,7 +1255,7 @@ func determineReplicaNext(s *Frontend, service string, restaurant_name string) i
 				s.sequentialNextReplicaReview = 1
 			}
 
-			s.sequentialKeyToReplicaReview[restaurant_name] = replicaNum
+			s.sequentialKeyToReplicaReview[restaurant_id] = replicaNum
 			s.mu.Unlock()
 			return replicaNum
 		}
@@ -0,0 +1,301 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gitlab.cs.washington.edu/syslab/cse453-welp/proto/detail"
+	"gitlab.cs.washington.edu/syslab/cse453-welp/proto/mydatabase"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	maxDetailRevisions  = 100 // revisions kept of a restaurant's details
+	defaultHistoryLimit = 20  // revisions GetDetailHistory returns by default
+	maxHistoryLimit     = 50  // revisions GetDetailHistory returns at most
+	historyScanLimit    = 10  // revisions read per ScanRecords call, to keep its messages small
+)
+
+// errHistoryScanned stops a scan of a restaurant's history once it has read the revisions
+// it needs.
+var errHistoryScanned = errors.New("history scanned")
+
+// unversionedDetailFields are the fields of restaurant details that follow their reviews,
+// photo uploads and writes rather than the edits people make, and so are not part of
+// their history.
+var unversionedDetailFields = map[protoreflect.Name]bool{
+	"restaurant_id":  true,
+	"etag":           true,
+	"rating":         true,
+	"review_count":   true,
+	"rating_version": true,
+	"photos":         true,
+	"revision":       true,
+}
+
+// HistoryNamespace returns the namespace the history of the details kept in namespace is
+// kept in, apart from the details, so that scans and indexes of the details only see
+// details.
+func HistoryNamespace(namespace string) string {
+	if namespace == "" {
+		return "history"
+	}
+	return namespace + "-history"
+}
+
+// revisionKey returns the key of a revision of a restaurant's details. The revisions of a
+// restaurant sort in order, under a prefix of their own.
+func revisionKey(restaurantID string, revision int64) string {
+	return fmt.Sprintf("%s/%010d", restaurantID, revision)
+}
+
+// detailEdit describes an edit of a restaurant's details for the revision it makes.
+type detailEdit struct {
+	author     string
+	revertedTo int64
+	// the edit only changes fields that are not part of the history, such as the rating,
+	// and so makes no revision and leaves the etag as it is
+	unversioned bool
+	// the revision the edit made, once it is written; nil if it changed nothing that is
+	// part of the history
+	revision *detail.DetailRevision
+}
+
+// versionedFields returns the fields of a restaurant's details that are set, in JSON, by
+// field name. d may be nil.
+func versionedFields(d *detail.GetDetailResponse) (map[string]string, error) {
+	fields := make(map[string]string)
+	if d == nil {
+		return fields, nil
+	}
+	data, err := protojson.MarshalOptions{UseProtoNames: true}.Marshal(d)
+	if err != nil {
+		return nil, err
+	}
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	for name, value := range raw {
+		// protojson does not promise stable spacing
+		var compact bytes.Buffer
+		if err := json.Compact(&compact, value); err != nil {
+			return nil, err
+		}
+		fields[name] = compact.String()
+	}
+	return fields, nil
+}
+
+// detailChanges returns the changes between two versions of a restaurant's details, either
+// of which may be nil, to the fields that are part of their history, in the order the
+// fields are declared.
+func detailChanges(old, next *detail.GetDetailResponse) ([]*detail.FieldChange, error) {
+	oldFields, err := versionedFields(old)
+	if err != nil {
+		return nil, err
+	}
+	nextFields, err := versionedFields(next)
+	if err != nil {
+		return nil, err
+	}
+	var changes []*detail.FieldChange
+	fields := (&detail.GetDetailResponse{}).ProtoReflect().Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		name := fields.Get(i).Name()
+		if unversionedDetailFields[name] {
+			continue
+		}
+		if o, n := oldFields[string(name)], nextFields[string(name)]; o != n {
+			changes = append(changes, &detail.FieldChange{Field: string(name), OldValue: o, NewValue: n})
+		}
+	}
+	return changes, nil
+}
+
+// recordRevision writes the revision an edit of a restaurant's details makes, in
+// transaction txn if it is set, numbering next, the details the edit writes, with it. An
+// edit that changes nothing in the history makes no revision, and leaves next at the
+// revision of current. Only the newest maxDetailRevisions revisions are kept.
+func (s *Detail) recordRevision(ctx context.Context, restaurantID string, txn uint64, e *detailEdit, current, next *detail.GetDetailResponse) error {
+	if e == nil {
+		e = &detailEdit{}
+	}
+	e.revision = nil
+	changes, err := detailChanges(current, next)
+	if err != nil {
+		return status.Errorf(codes.Internal, "Failed to compare details: %v", err)
+	}
+	latest := current.GetRevision()
+	if current == nil {
+		// the details may have been deleted, with their history kept
+		if latest, err = s.latestRevision(ctx, restaurantID); err != nil {
+			return err
+		}
+	}
+	if len(changes) == 0 {
+		if next != nil {
+			next.Revision = latest
+		}
+		return nil
+	}
+
+	revision := &detail.DetailRevision{
+		Revision:   latest + 1,
+		Author:     e.author,
+		EditedAt:   timestamppb.Now(),
+		Changes:    changes,
+		Detail:     next,
+		Deleted:    next == nil,
+		RevertedTo: e.revertedTo,
+	}
+	if next != nil {
+		next.Revision = revision.Revision
+	}
+	data, err := proto.Marshal(revision)
+	if err != nil {
+		return status.Errorf(codes.Internal, "Failed to serialize data")
+	}
+	_, err = s.historyDatabaseClient.SetRecord(ctx, &mydatabase.SetRecordRequest{
+		Record:        &mydatabase.DatabaseRecord{Key: revisionKey(restaurantID, revision.Revision), Value: data},
+		TransactionId: txn,
+	})
+	if err != nil {
+		return status.Errorf(codes.Internal, "Error in updating data storage: %v", err)
+	}
+	if expired := revision.Revision - maxDetailRevisions; expired > 0 {
+		_, err = s.historyDatabaseClient.DeleteRecord(ctx, &mydatabase.DeleteRecordRequest{Key: revisionKey(restaurantID, expired), TransactionId: txn})
+		if err != nil {
+			return status.Errorf(codes.Internal, "Error in updating data storage: %v", err)
+		}
+	}
+	e.revision = revision
+	return nil
+}
+
+// latestRevision returns the number of the newest revision of a restaurant's details, or
+// 0 if they have no history.
+func (s *Detail) latestRevision(ctx context.Context, restaurantID string) (int64, error) {
+	prefix := restaurantID + "/"
+	var latest int64
+	err := scanRecords(ctx, s.historyDatabaseClient, &mydatabase.ScanRecordsRequest{Prefix: prefix, Limit: 1, Reverse: true}, func(record *mydatabase.DatabaseRecord) error {
+		revision, err := strconv.ParseInt(strings.TrimPrefix(record.GetKey(), prefix), 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid revision key %s", record.GetKey())
+		}
+		latest = revision
+		return errHistoryScanned
+	})
+	if err != nil && err != errHistoryScanned {
+		return 0, status.Errorf(codes.Internal, "Failed to read history of %s: %v", restaurantID, err)
+	}
+	return latest, nil
+}
+
+// getRevision returns a revision of a restaurant's details.
+func (s *Detail) getRevision(ctx context.Context, restaurantID string, revision int64) (*detail.DetailRevision, error) {
+	getRecordResponse, err := s.historyDatabaseClient.GetRecord(ctx, &mydatabase.GetRecordRequest{Key: revisionKey(restaurantID, revision)})
+	if status.Code(err) == codes.NotFound {
+		return nil, status.Errorf(codes.NotFound, "Revision %d of %s does not exist", revision, restaurantID)
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Failed to read data storage: %v", err)
+	}
+	msg := &detail.DetailRevision{}
+	if err := proto.Unmarshal(getRecordResponse.GetRecord().GetValue(), msg); err != nil {
+		return nil, status.Errorf(codes.Internal, "Failed to deserialize data")
+	}
+	return msg, nil
+}
+
+// GetDetailHistory lists the revisions of a restaurant's details, newest first.
+func (s *Detail) GetDetailHistory(ctx context.Context, req *detail.GetDetailHistoryRequest) (*detail.GetDetailHistoryResponse, error) {
+	historyResponse := &detail.GetDetailHistoryResponse{}
+	limit := int(req.GetLimit())
+	if limit < 0 {
+		return historyResponse, status.Errorf(codes.InvalidArgument, "Invalid limit: %d", limit)
+	}
+	if limit == 0 {
+		limit = defaultHistoryLimit
+	}
+	if limit > maxHistoryLimit {
+		limit = maxHistoryLimit
+	}
+	if req.GetBeforeRevision() < 0 {
+		return historyResponse, status.Errorf(codes.InvalidArgument, "Invalid revision: %d", req.GetBeforeRevision())
+	}
+	restaurantID, err := s.restaurantID(ctx, req.GetRestaurantId(), req.GetRestaurantName())
+	if err != nil {
+		return historyResponse, err
+	}
+
+	scan := &mydatabase.ScanRecordsRequest{Prefix: restaurantID + "/", Limit: historyScanLimit, Reverse: true}
+	if before := req.GetBeforeRevision(); before > 0 {
+		scan.EndKey = revisionKey(restaurantID, before)
+	}
+	err = scanRecords(ctx, s.historyDatabaseClient, scan, func(record *mydatabase.DatabaseRecord) error {
+		if len(historyResponse.Revisions) == limit {
+			historyResponse.NextBeforeRevision = historyResponse.Revisions[limit-1].GetRevision()
+			return errHistoryScanned
+		}
+		revision := &detail.DetailRevision{}
+		if err := proto.Unmarshal(record.GetValue(), revision); err != nil {
+			return fmt.Errorf("failed to deserialize revision %s: %v", record.GetKey(), err)
+		}
+		historyResponse.Revisions = append(historyResponse.Revisions, revision)
+		return nil
+	})
+	if err != nil && err != errHistoryScanned {
+		return historyResponse, status.Errorf(codes.Internal, "Failed to read history of %s: %v", restaurantID, err)
+	}
+	if len(historyResponse.Revisions) == 0 && req.GetBeforeRevision() == 0 {
+		return historyResponse, status.Errorf(codes.NotFound, "Details of %s have no history", restaurantID)
+	}
+	return historyResponse, nil
+}
+
+// RevertDetail restores the details of a restaurant to those of an earlier revision, or
+// deletes them if that revision did, keeping their rating and photos as they are.
+func (s *Detail) RevertDetail(ctx context.Context, req *detail.RevertDetailRequest) (*detail.RevertDetailResponse, error) {
+	revertResponse := &detail.RevertDetailResponse{}
+	if req.GetRevision() <= 0 {
+		return revertResponse, status.Errorf(codes.InvalidArgument, "Invalid revision: %d", req.GetRevision())
+	}
+	restaurantID, err := s.restaurantID(ctx, req.GetRestaurantId(), req.GetRestaurantName())
+	if err != nil {
+		return revertResponse, err
+	}
+	target, err := s.getRevision(ctx, restaurantID, req.GetRevision())
+	if err != nil {
+		return revertResponse, err
+	}
+
+	e := &detailEdit{author: req.GetAuthor(), revertedTo: req.GetRevision()}
+	written, err := s.editDetail(ctx, restaurantID, e, func(current *detail.GetDetailResponse) (*detail.GetDetailResponse, error) {
+		if err := checkEtag(current, req.GetEtag()); err != nil {
+			return nil, err
+		}
+		if target.GetDeleted() {
+			return nil, nil
+		}
+		next := proto.Clone(target.GetDetail()).(*detail.GetDetailResponse)
+		next.Rating, next.ReviewCount, next.RatingVersion = current.GetRating(), current.GetReviewCount(), current.GetRatingVersion()
+		next.Photos = current.GetPhotos()
+		return next, nil
+	})
+	if err != nil {
+		return revertResponse, err
+	}
+	revertResponse.Detail, revertResponse.Revision = written, e.revision
+	return revertResponse, nil
+}
@@ -0,0 +1,236 @@
+package services
+
+import (
+	"context"
+	"sort"
+	"time"
+	// restaurants name their time zones, which the images the services run in may not have
+	_ "time/tzdata"
+
+	"gitlab.cs.washington.edu/syslab/cse453-welp/proto/detail"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	minutesPerDay      = 24 * 60
+	maxOpeningPeriods  = 100 // weekly periods of a restaurant's opening hours
+	maxSpecialHours    = 400 // dates with special hours of a restaurant
+	maxRangesPerDay    = 10  // time ranges of a single date with special hours
+	openingHoursWindow = 7   // days ahead IsOpen looks for the next time a restaurant opens or closes
+	specialHoursLayout = "2006-01-02"
+)
+
+// interval is a span of time during which a restaurant is open, from start up to end.
+type interval struct {
+	start, end time.Time
+}
+
+// checkTimeRange checks that a time range opens within the day and closes at another
+// minute of it, or at its end.
+func checkTimeRange(r *detail.TimeRange) error {
+	if r.GetOpenMinute() < 0 || r.GetOpenMinute() >= minutesPerDay {
+		return status.Errorf(codes.InvalidArgument, "Invalid opening minute %d: must be at least 0 and less than %d", r.GetOpenMinute(), minutesPerDay)
+	}
+	if r.GetCloseMinute() < 0 || r.GetCloseMinute() > minutesPerDay {
+		return status.Errorf(codes.InvalidArgument, "Invalid closing minute %d: must be between 0 and %d", r.GetCloseMinute(), minutesPerDay)
+	}
+	if r.GetOpenMinute() == r.GetCloseMinute() {
+		return status.Errorf(codes.InvalidArgument, "Invalid time range: opens and closes at minute %d", r.GetOpenMinute())
+	}
+	return nil
+}
+
+// checkOpeningHours checks the opening hours of a restaurant.
+func checkOpeningHours(h *detail.OpeningHours) error {
+	if _, err := time.LoadLocation(h.GetTimeZone()); err != nil {
+		return status.Errorf(codes.InvalidArgument, "Invalid time zone %q", h.GetTimeZone())
+	}
+	if len(h.GetWeekly()) > maxOpeningPeriods {
+		return status.Errorf(codes.InvalidArgument, "Too many opening periods: at most %d are allowed", maxOpeningPeriods)
+	}
+	if len(h.GetExceptions()) > maxSpecialHours {
+		return status.Errorf(codes.InvalidArgument, "Too many dates with special hours: at most %d are allowed", maxSpecialHours)
+	}
+	if len(h.GetWeekly()) == 0 && len(h.GetExceptions()) > 0 {
+		return status.Errorf(codes.InvalidArgument, "Special hours need weekly hours to make exceptions to")
+	}
+	for _, period := range h.GetWeekly() {
+		if period.GetDay() < detail.DayOfWeek_MONDAY || period.GetDay() > detail.DayOfWeek_SUNDAY {
+			return status.Errorf(codes.InvalidArgument, "Invalid day of the week %v", period.GetDay())
+		}
+		if err := checkTimeRange(period.GetHours()); err != nil {
+			return err
+		}
+	}
+	dates := make(map[string]bool)
+	for _, special := range h.GetExceptions() {
+		if _, err := time.Parse(specialHoursLayout, special.GetDate()); err != nil {
+			return status.Errorf(codes.InvalidArgument, "Invalid date %q of special hours: must be YYYY-MM-DD", special.GetDate())
+		}
+		if dates[special.GetDate()] {
+			return status.Errorf(codes.InvalidArgument, "Special hours for %s given more than once", special.GetDate())
+		}
+		dates[special.GetDate()] = true
+		if len(special.GetHours()) > maxRangesPerDay {
+			return status.Errorf(codes.InvalidArgument, "Too many time ranges on %s: at most %d are allowed", special.GetDate(), maxRangesPerDay)
+		}
+		for _, r := range special.GetHours() {
+			if err := checkTimeRange(r); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// dayOfWeek returns the day of the week of a date.
+func dayOfWeek(date time.Time) detail.DayOfWeek {
+	if date.Weekday() == time.Sunday {
+		return detail.DayOfWeek_SUNDAY
+	}
+	return detail.DayOfWeek(date.Weekday())
+}
+
+// hoursOn returns the time ranges a restaurant opens in on a date: its special hours, if
+// the date has any, or else its weekly hours for that day of the week.
+func hoursOn(h *detail.OpeningHours, date time.Time) []*detail.TimeRange {
+	for _, special := range h.GetExceptions() {
+		if special.GetDate() == date.Format(specialHoursLayout) {
+			return special.GetHours()
+		}
+	}
+	var ranges []*detail.TimeRange
+	for _, period := range h.GetWeekly() {
+		if period.GetDay() == dayOfWeek(date) {
+			ranges = append(ranges, period.GetHours())
+		}
+	}
+	return ranges
+}
+
+// openIntervals returns the intervals during which a restaurant is open that start on the
+// given number of days from a date, which must be midnight in loc, merged so that none of
+// them overlap or touch.
+func openIntervals(h *detail.OpeningHours, loc *time.Location, date time.Time, days int) []interval {
+	var intervals []interval
+	for i := 0; i < days; i++ {
+		y, m, d := date.AddDate(0, 0, i).Date()
+		for _, r := range hoursOn(h, time.Date(y, m, d, 0, 0, 0, 0, loc)) {
+			// time.Date normalizes minutes past the hour, and past the end of the day
+			start := time.Date(y, m, d, 0, int(r.GetOpenMinute()), 0, 0, loc)
+			end := time.Date(y, m, d, 0, int(r.GetCloseMinute()), 0, 0, loc)
+			if r.GetCloseMinute() <= r.GetOpenMinute() {
+				end = time.Date(y, m, d+1, 0, int(r.GetCloseMinute()), 0, 0, loc)
+			}
+			intervals = append(intervals, interval{start: start, end: end})
+		}
+	}
+	sort.Slice(intervals, func(i, j int) bool {
+		return intervals[i].start.Before(intervals[j].start)
+	})
+	var merged []interval
+	for _, iv := range intervals {
+		if n := len(merged); n > 0 && !iv.start.After(merged[n-1].end) {
+			if iv.end.After(merged[n-1].end) {
+				merged[n-1].end = iv.end
+			}
+			continue
+		}
+		merged = append(merged, iv)
+	}
+	return merged
+}
+
+// openAt reports whether a restaurant is open at a moment, and when that next changes. The
+// next change is zero if it is not within openingHoursWindow days.
+func openAt(h *detail.OpeningHours, loc *time.Location, t time.Time) (bool, time.Time) {
+	y, m, d := t.In(loc).Date()
+	// periods of the day before may run past midnight into this one
+	from := time.Date(y, m, d-1, 0, 0, 0, 0, loc)
+	intervals := openIntervals(h, loc, from, openingHoursWindow+2)
+	horizon := time.Date(y, m, d+openingHoursWindow+1, 0, 0, 0, 0, loc)
+	for _, iv := range intervals {
+		switch {
+		case t.Before(iv.start):
+			return false, iv.start
+		case t.Before(iv.end):
+			if !iv.end.Before(horizon) {
+				// open around the clock for as far ahead as the hours are looked at
+				return true, time.Time{}
+			}
+			return true, iv.end
+		}
+	}
+	return false, time.Time{}
+}
+
+// openOn reports whether a restaurant is open at any time on a date in loc.
+func openOn(h *detail.OpeningHours, loc *time.Location, y int, m time.Month, d int) bool {
+	start, end := time.Date(y, m, d, 0, 0, 0, 0, loc), time.Date(y, m, d+1, 0, 0, 0, 0, loc)
+	for _, iv := range openIntervals(h, loc, time.Date(y, m, d-1, 0, 0, 0, 0, loc), 2) {
+		if iv.start.Before(end) && iv.end.After(start) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsOpen reports whether a restaurant is open at a moment, or on a date, according to its
+// opening hours. Restaurants whose weekly hours are not known count as always open.
+func (s *Detail) IsOpen(ctx context.Context, req *detail.IsOpenRequest) (*detail.IsOpenResponse, error) {
+	isOpenResponse := &detail.IsOpenResponse{}
+	if req.GetTime() != nil && req.GetDate() != nil {
+		return isOpenResponse, status.Errorf(codes.InvalidArgument, "Set either a time or a date to check, not both")
+	}
+	if req.GetTime() != nil {
+		if err := req.GetTime().CheckValid(); err != nil {
+			return isOpenResponse, status.Errorf(codes.InvalidArgument, "Invalid time: %v", err)
+		}
+	}
+	date := req.GetDate()
+	if date != nil {
+		parsed := time.Date(int(date.GetYear()), time.Month(date.GetMonth()), int(date.GetDay()), 0, 0, 0, 0, time.UTC)
+		if y, m, d := parsed.Date(); y != int(date.GetYear()) || int(m) != int(date.GetMonth()) || d != int(date.GetDay()) {
+			return isOpenResponse, status.Errorf(codes.InvalidArgument, "Invalid date %d-%d-%d", date.GetYear(), date.GetMonth(), date.GetDay())
+		}
+	}
+
+	d, err := s.GetDetail(ctx, &detail.GetDetailRequest{RestaurantId: req.GetRestaurantId(), RestaurantName: req.GetRestaurantName()})
+	if err != nil {
+		return isOpenResponse, err
+	}
+	hours := d.GetHours()
+	loc, err := time.LoadLocation(hours.GetTimeZone())
+	if err != nil {
+		return isOpenResponse, status.Errorf(codes.Internal, "Failed to load time zone %q: %v", hours.GetTimeZone(), err)
+	}
+	isOpenResponse.TimeZone = loc.String()
+
+	if date != nil {
+		y, m, day := int(date.GetYear()), time.Month(date.GetMonth()), int(date.GetDay())
+		isOpenResponse.Hours = hoursOn(hours, time.Date(y, m, day, 0, 0, 0, 0, loc))
+		isOpenResponse.HoursKnown = len(hours.GetWeekly()) > 0
+		isOpenResponse.Open = !isOpenResponse.HoursKnown || openOn(hours, loc, y, m, day)
+		return isOpenResponse, nil
+	}
+
+	t := time.Now()
+	if req.GetTime() != nil {
+		t = req.GetTime().AsTime()
+	}
+	y, m, day := t.In(loc).Date()
+	isOpenResponse.Hours = hoursOn(hours, time.Date(y, m, day, 0, 0, 0, 0, loc))
+	isOpenResponse.HoursKnown = len(hours.GetWeekly()) > 0
+	if !isOpenResponse.HoursKnown {
+		isOpenResponse.Open = true
+		return isOpenResponse, nil
+	}
+	open, next := openAt(hours, loc, t)
+	isOpenResponse.Open = open
+	if !next.IsZero() {
+		isOpenResponse.NextChange = timestamppb.New(next)
+	}
+	return isOpenResponse, nil
+}
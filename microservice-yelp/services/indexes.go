@@ -0,0 +1,75 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	apps "gitlab.cs.washington.edu/syslab/cse453-welp/applications"
+	"gitlab.cs.washington.edu/syslab/cse453-welp/proto/mydatabase"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// QueryIndex returns the keys of the records of a namespace matching every condition of the
+// request on the namespace's secondary indexes. At most limit keys are returned; if more
+// remain, the response carries a continuation token that resumes the query just after the
+// last key.
+func (s *MyDatabase) QueryIndex(ctx context.Context, req *mydatabase.QueryIndexRequest) (*mydatabase.QueryIndexResponse, error) {
+	if _, err := storedKey(req.GetNamespace(), ""); err != nil {
+		return &mydatabase.QueryIndexResponse{}, err
+	}
+	limit := int(req.GetLimit())
+	if limit < 0 {
+		return &mydatabase.QueryIndexResponse{}, status.Errorf(codes.InvalidArgument, "Invalid query limit: %d", limit)
+	}
+	if limit == 0 {
+		limit = defaultScanLimit
+	}
+	if limit > maxScanLimit {
+		limit = maxScanLimit
+	}
+	if req.GetConsistency() == mydatabase.ReadConsistency_READ_LINEARIZABLE {
+		leader, ctx, err := s.leader(ctx)
+		if err != nil {
+			return &mydatabase.QueryIndexResponse{}, err
+		}
+		if leader != nil {
+			return leader.QueryIndex(ctx, req)
+		}
+		if err := s.readIndex(ctx); err != nil {
+			return &mydatabase.QueryIndexResponse{}, err
+		}
+	}
+
+	conditions := make([]apps.IndexCondition, len(req.GetConditions()))
+	for i, c := range req.GetConditions() {
+		conditions[i] = apps.IndexCondition{Index: c.GetIndex(), Value: c.GetValue()}
+		if r := c.GetRange(); r != nil {
+			conditions[i].Range = true
+			conditions[i].Start, conditions[i].End, conditions[i].Prefix = r.GetStart(), r.GetEnd(), r.GetPrefix()
+		}
+	}
+	var after string
+	if token := req.GetContinuationToken(); token != "" {
+		var err error
+		if after, err = decodeScanToken(token, false); err != nil {
+			return &mydatabase.QueryIndexResponse{}, status.Errorf(codes.InvalidArgument, "Invalid continuation token: %v", err)
+		}
+	}
+
+	keys, entries, more, err := s.indexes.Query(req.GetNamespace(), conditions, after, limit)
+	switch {
+	case errors.Is(err, apps.ErrUnknownIndex), errors.Is(err, apps.ErrEmptyQuery):
+		return &mydatabase.QueryIndexResponse{}, status.Errorf(codes.InvalidArgument, "Invalid query: %v", err)
+	case err != nil:
+		return &mydatabase.QueryIndexResponse{}, status.Errorf(codes.Internal, "Failed to query indexes: %v", err)
+	}
+	msg := &mydatabase.QueryIndexResponse{Keys: keys}
+	for _, entry := range entries {
+		msg.Tokens = append(msg.Tokens, encodeScanToken(entry, false))
+	}
+	if more {
+		msg.ContinuationToken = msg.Tokens[len(msg.Tokens)-1]
+	}
+	return msg, nil
+}
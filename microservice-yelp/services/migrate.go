@@ -0,0 +1,222 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"gitlab.cs.washington.edu/syslab/cse453-welp/proto/detail"
+	"gitlab.cs.washington.edu/syslab/cse453-welp/proto/mydatabase"
+	"gitlab.cs.washington.edu/syslab/cse453-welp/proto/reservation"
+	"gitlab.cs.washington.edu/syslab/cse453-welp/proto/review"
+	"google.golang.org/protobuf/proto"
+)
+
+// The migrations below re-key the records stored before restaurants had IDs, when details
+// and reviews were keyed by restaurant name, to the ID RestaurantIDFromName derives from
+// that name. They are meant to be run once, with the services stopped and their caches
+// restarted afterwards, and are safe to run again if interrupted: records that already
+// carry a restaurant ID are left alone.
+//
+// Details and reviews are spread across shards by the frontend, which routes a restaurant
+// to the shard its key hashes to; re-keyed records are moved to the shard their ID hashes
+// to, assuming the frontend's default hash routing. Pass a single shard when the records
+// are replicated with quorum reads and writes.
+
+// MigrationShards connects to the shards of a service's records for a migration: one per
+// database address, or a single quorum client over all of them if quorum.Replicas is set.
+func MigrationShards(name string, addrs []string, namespace string, quorum QuorumOptions) []mydatabase.DatabaseServiceClient {
+	if len(quorum.Replicas) > 0 {
+		quorum.Namespace = namespace
+		return []mydatabase.DatabaseServiceClient{NewQuorumClient(name, quorum)}
+	}
+	shards := make([]mydatabase.DatabaseServiceClient, len(addrs))
+	for i, addr := range addrs {
+		shards[i] = NewNamespacedClient(mydatabase.NewDatabaseServiceClient(dial(addr)), namespace)
+	}
+	return shards
+}
+
+// MigrateDetails re-keys the detail records keyed by restaurant name to restaurant IDs,
+// filling in the restaurant_id of each. It returns the number of records re-keyed.
+func MigrateDetails(ctx context.Context, shards []mydatabase.DatabaseServiceClient) (int, error) {
+	return rekeyRecords(ctx, shards, func(record *mydatabase.DatabaseRecord) (*mydatabase.DatabaseRecord, error) {
+		d := &detail.GetDetailResponse{}
+		if err := proto.Unmarshal(record.GetValue(), d); err != nil {
+			return nil, fmt.Errorf("failed to deserialize details of %s: %v", record.GetKey(), err)
+		}
+		if d.GetRestaurantId() != "" {
+			return nil, nil
+		}
+		if d.GetRestaurantName() == "" {
+			d.RestaurantName = record.GetKey()
+		}
+		d.RestaurantId = RestaurantIDFromName(record.GetKey())
+		return encodeRekeyed(d.RestaurantId, d)
+	})
+}
+
+// MigrateReviews re-keys the review records keyed by restaurant name to restaurant IDs,
+// filling in the restaurant_id of each of their reviews. It returns the number of records
+// re-keyed. The shards are those of the namespace reviews were kept in before they had
+// records of their own, so every record holds all the reviews of a restaurant.
+func MigrateReviews(ctx context.Context, shards []mydatabase.DatabaseServiceClient) (int, error) {
+	return rekeyRecords(ctx, shards, func(record *mydatabase.DatabaseRecord) (*mydatabase.DatabaseRecord, error) {
+		reviews, err := restaurantReviews(record)
+		if err != nil {
+			return nil, err
+		}
+		legacy := false
+		for _, r := range reviews.GetReviewsMap() {
+			legacy = legacy || r.GetRestaurantId() == ""
+		}
+		if !legacy {
+			return nil, nil
+		}
+		restaurantID := RestaurantIDFromName(record.GetKey())
+		for _, r := range reviews.GetReviewsMap() {
+			r.RestaurantId = restaurantID
+			if r.GetRestaurantName() == "" {
+				r.RestaurantName = record.GetKey()
+			}
+		}
+		return encodeRekeyed(restaurantID, reviews)
+	})
+}
+
+// restaurantReviews returns the reviews of a restaurant a record of the namespace reviews
+// were kept in before held.
+func restaurantReviews(record *mydatabase.DatabaseRecord) (*review.SearchReviewsResponse, error) {
+	reviews := &review.SearchReviewsResponse{}
+	if err := proto.Unmarshal(record.GetValue(), reviews); err != nil {
+		return nil, fmt.Errorf("failed to deserialize reviews of %s: %v", record.GetKey(), err)
+	}
+	return reviews, nil
+}
+
+// SplitReviews moves the reviews of each restaurant, kept in one record of shards under
+// the restaurant's ID, to records of their own in the shard of recordShards matching
+// their shard, indexed in the shard of indexShards along with the restaurant's stats, and
+// returns the number of records split. It is meant to be run after MigrateReviews, once the
+// records are keyed by restaurant ID, and is safe to run again if interrupted: the reviews
+// and their index are written before the record holding them is deleted.
+func SplitReviews(ctx context.Context, shards, recordShards, indexShards []mydatabase.DatabaseServiceClient) (int, error) {
+	n := 0
+	for i, shard := range shards {
+		var records []*mydatabase.DatabaseRecord
+		err := scanRecords(ctx, shard, &mydatabase.ScanRecordsRequest{Limit: maxScanLimit}, func(record *mydatabase.DatabaseRecord) error {
+			records = append(records, record)
+			return nil
+		})
+		if err != nil {
+			return n, err
+		}
+
+		for _, record := range records {
+			restaurantID := record.GetKey()
+			reviews, err := restaurantReviews(record)
+			if err != nil {
+				return n, err
+			}
+			stats := &review.ReviewStats{Version: 1}
+			for userName, r := range reviews.GetReviewsMap() {
+				key, err := reviewKey(restaurantID, userName)
+				if err != nil {
+					return n, err
+				}
+				r.RestaurantId = restaurantID
+				split, err := encodeRekeyed(key, r)
+				if err != nil {
+					return n, err
+				}
+				if _, err := recordShards[i].SetRecord(ctx, &mydatabase.SetRecordRequest{Record: split}); err != nil {
+					return n, fmt.Errorf("failed to write %s: %v", key, err)
+				}
+				entry, err := encodeRekeyed(reviewIndexPrefix(restaurantID)+key, &review.ReviewStats{ReviewCount: 1, RatingTotal: int64(r.GetRating())})
+				if err != nil {
+					return n, err
+				}
+				if _, err := indexShards[i].SetRecord(ctx, &mydatabase.SetRecordRequest{Record: entry}); err != nil {
+					return n, fmt.Errorf("failed to write %s: %v", entry.GetKey(), err)
+				}
+				stats.ReviewCount++
+				stats.RatingTotal += int64(r.GetRating())
+			}
+			total, err := encodeRekeyed(restaurantID, stats)
+			if err != nil {
+				return n, err
+			}
+			if _, err := indexShards[i].SetRecord(ctx, &mydatabase.SetRecordRequest{Record: total}); err != nil {
+				return n, fmt.Errorf("failed to write stats of %s: %v", restaurantID, err)
+			}
+			if _, err := shard.DeleteRecord(ctx, &mydatabase.DeleteRecordRequest{Key: restaurantID}); err != nil {
+				return n, fmt.Errorf("failed to delete %s: %v", restaurantID, err)
+			}
+			n++
+		}
+	}
+	return n, nil
+}
+
+// MigrateReservations fills in the restaurant_id of the reservation records, which stay
+// keyed by user name. It returns the number of records updated.
+func MigrateReservations(ctx context.Context, db mydatabase.DatabaseServiceClient) (int, error) {
+	return rekeyRecords(ctx, []mydatabase.DatabaseServiceClient{db}, func(record *mydatabase.DatabaseRecord) (*mydatabase.DatabaseRecord, error) {
+		r := &reservation.GetReservationResponse{}
+		if err := proto.Unmarshal(record.GetValue(), r); err != nil {
+			return nil, fmt.Errorf("failed to deserialize reservation of %s: %v", record.GetKey(), err)
+		}
+		if r.GetRestaurantId() != "" || r.GetRestaurantName() == "" {
+			return nil, nil
+		}
+		r.RestaurantId = RestaurantIDFromName(r.GetRestaurantName())
+		return encodeRekeyed(record.GetKey(), r)
+	})
+}
+
+// encodeRekeyed returns the record holding a message under its new key.
+func encodeRekeyed(key string, m proto.Message) (*mydatabase.DatabaseRecord, error) {
+	data, err := proto.Marshal(m)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize %s: %v", key, err)
+	}
+	return &mydatabase.DatabaseRecord{Key: key, Value: data}, nil
+}
+
+// rekeyRecords rewrites every record of the shards that rekey returns a new record for,
+// and returns how many it rewrote. The new record is written to the shard its key hashes
+// to before the old one is deleted, so an interrupted run loses nothing. All the shards
+// are scanned before anything is written, so that no record is visited twice.
+func rekeyRecords(ctx context.Context, shards []mydatabase.DatabaseServiceClient, rekey func(record *mydatabase.DatabaseRecord) (*mydatabase.DatabaseRecord, error)) (int, error) {
+	type move struct {
+		shard  int
+		oldKey string
+		record *mydatabase.DatabaseRecord
+	}
+	var moves []move
+	for i, shard := range shards {
+		err := scanRecords(ctx, shard, &mydatabase.ScanRecordsRequest{Limit: maxScanLimit}, func(record *mydatabase.DatabaseRecord) error {
+			rekeyed, err := rekey(record)
+			if rekeyed != nil {
+				moves = append(moves, move{shard: i, oldKey: record.GetKey(), record: rekeyed})
+			}
+			return err
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	for n, m := range moves {
+		target := int(hash(m.record.GetKey()) % uint32(len(shards)))
+		if _, err := shards[target].SetRecord(ctx, &mydatabase.SetRecordRequest{Record: m.record}); err != nil {
+			return n, fmt.Errorf("failed to write %s: %v", m.record.GetKey(), err)
+		}
+		if target == m.shard && m.oldKey == m.record.GetKey() {
+			continue
+		}
+		if _, err := shards[m.shard].DeleteRecord(ctx, &mydatabase.DeleteRecordRequest{Key: m.oldKey}); err != nil {
+			return n, fmt.Errorf("failed to delete %s: %v", m.oldKey, err)
+		}
+	}
+	return len(moves), nil
+}
@@ -1,51 +1,176 @@
 package services
 
 import (
+	"bytes"
 	"context"
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net"
+	"path/filepath"
+	"sync"
+	"time"
 
 	apps "gitlab.cs.washington.edu/syslab/cse453-welp/applications"
 	"gitlab.cs.washington.edu/syslab/cse453-welp/proto/mydatabase"
+	raftpb "gitlab.cs.washington.edu/syslab/cse453-welp/proto/raft"
+	"gitlab.cs.washington.edu/syslab/cse453-welp/raft"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 )
 
+const (
+	defaultScanLimit = 1000  // records returned by ScanRecords when the request sets no limit
+	maxScanLimit     = 10000 // upper bound on the records returned by a single ScanRecords call
+	scanBatchSize    = 100   // records per ScanRecords stream message
+
+	transactionTimeout = 30 * time.Second // lifetime after which an open transaction is aborted
+
+	// forwardedKey is the metadata key marking requests forwarded by a follower to its
+	// leader, which are never forwarded again
+	forwardedKey = "mydatabase-forwarded-by"
+)
+
+// ReplicationOptions configures a database server as a member of a Raft group that
+// replicates its storage. Leave ID empty to run a standalone server.
+type ReplicationOptions struct {
+	// ID is the address the other members reach this server at, e.g. mydatabase-detail-1-a:27017.
+	ID string
+
+	// Peers are the addresses of the group's initial members, including ID. Leave them
+	// empty for a server that will be added to an existing group.
+	Peers []string
+
+	// ElectionTimeout is how long followers wait to hear from a leader before electing a new one.
+	ElectionTimeout time.Duration
+
+	// SnapshotThreshold is the number of writes after which the Raft log is compacted.
+	SnapshotThreshold uint64
+}
+
 // MyDatabase represents a gRPC service for interacting with a database.
 type MyDatabase struct {
 	name string
 	port int
 	mydatabase.DatabaseServiceServer
-	app *apps.EmulatedStorageApp
+	app  apps.Storage
+	txns *apps.TransactionManager
+
+	// changes records the writes applied to the server's storage app for StreamChanges, if
+	// enabled, indexes maintains the secondary indexes over them for QueryIndex, and namespaces
+	// accounts for the usage of each namespace, whose quotas are enforced by quotas
+	changes    *apps.ChangeLog
+	indexes    *apps.IndexedStorage
+	namespaces *apps.NamespacedStorage
+	quotas     *apps.QuotaStorage
+
+	// scheduler orders the requests waiting to be served when too many arrive at once
+	scheduler *scheduler
+
+	// mergeMu serializes writes that merge siblings and conditional deletes, which read the
+	// stored record first
+	mergeMu sync.Mutex
+
+	// antiEntropy repairs records from peers holding the same data, if any are configured.
+	// The Merkle tree answering peers' comparisons is cached in tree.
+	antiEntropy *antiEntropy
+	treeMu      sync.Mutex
+	tree        *apps.MerkleTree
+	treeBuilt   time.Time
+
+	// replica is set if the server is a member of a replicated group. Followers forward
+	// writes, transactions and linearizable reads to the leader.
+	replica   *apps.ReplicatedStorage
+	leadersMu sync.Mutex
+	leaders   map[string]mydatabase.DatabaseServiceClient
 }
 
 // NewMyDatabase creates a new instance of MyDatabase.
 // serverName: The name of the database server.
 // databasePort: The port on which the server should listen.
-// deviceType: The type of storage device to use. (ssd, disk, or cloud)
-func NewMyDatabase(serverName string, databasePort int, deviceType string) *MyDatabase {
+// options: The storage backend configuration. Persistent backends keep their files in
+// a subdirectory of options.DataDir named after the server.
+// replication: The Raft group the server is a member of, if any. The Raft log is kept in
+// the server's data directory.
+// antiEntropy: The databases holding the same records, if any, to repair records from.
+// changes: The bounds of the change history buffered for StreamChanges subscribers.
+// scheduling: The number of requests served at once, beyond which they are queued.
+// quotas: The limits on the records and bytes each namespace may hold.
+// indexes: The secondary indexes kept over the records for QueryIndex.
+func NewMyDatabase(serverName string, databasePort int, options apps.StorageOptions, replication ReplicationOptions, antiEntropy AntiEntropyOptions, changes apps.ChangeLogOptions, scheduling SchedulerOptions, quotas map[string]apps.NamespaceQuota, indexes ...apps.Index) *MyDatabase {
 	// Initialize and return a new MyDatabase instance.
-	app, err := apps.NewEmulatedStorageApp(deviceType)
+	options.DataDir = filepath.Join(options.DataDir, serverName)
+	// transactions pin the snapshots they read, so any retention serves them
+	app, err := apps.NewStorageApp(options)
 	if err != nil {
 		log.Fatalf("failed to initialize application: %v", err)
 	}
-	return &MyDatabase{
-		name: serverName,
-		port: databasePort,
-		app:  app,
+	// changes are recorded, indexed and accounted for below replication, so followers stream
+	// the writes they apply, answer index queries and report namespace usage too; quotas
+	// are enforced above it, by the leader, so that every member applies the same writes
+	s := &MyDatabase{
+		name:      serverName,
+		port:      databasePort,
+		scheduler: newScheduler(scheduling),
+		leaders:   make(map[string]mydatabase.DatabaseServiceClient),
+	}
+	if changes.Enabled {
+		s.changes = apps.NewChangeLog(app, changes)
+		app = s.changes
+	}
+	if s.indexes, err = apps.NewIndexedStorage(app, indexes...); err != nil {
+		log.Fatalf("failed to build indexes: %v", err)
+	}
+	if s.namespaces, err = apps.NewNamespacedStorage(s.indexes); err != nil {
+		log.Fatalf("failed to count namespace usage: %v", err)
+	}
+	s.app = s.namespaces
+	if replication.ID != "" {
+		raftStorage, err := raft.NewFileStorage(filepath.Join(options.DataDir, "raft"))
+		if err != nil {
+			log.Fatalf("failed to open raft log: %v", err)
+		}
+		config := raft.Config{
+			ID:                replication.ID,
+			Peers:             replication.Peers,
+			ElectionTimeout:   replication.ElectionTimeout,
+			SnapshotThreshold: replication.SnapshotThreshold,
+		}
+		transport := raft.NewGRPCTransport(grpc.WithInsecure())
+		if s.replica, err = apps.NewReplicatedStorage(s.namespaces, config, transport, raftStorage); err != nil {
+			log.Fatalf("failed to start raft node: %v", err)
+		}
+		s.app = s.replica
 	}
+	s.quotas = apps.NewQuotaStorage(s.app, s.namespaces, quotas)
+	s.app = s.quotas
+	if options.GroupCommitSize > 1 {
+		// grouping above every other layer makes a group one raft proposal and one write to
+		// the storage app, instead of being serialized again by the layers' own locks
+		s.app = apps.NewGroupCommitStorage(s.app, options.GroupCommitWindow, options.GroupCommitSize)
+	}
+	s.txns = apps.NewTransactionManager(s.app, transactionTimeout)
+	if len(antiEntropy.Peers) > 0 {
+		s.antiEntropy = newAntiEntropy(s, antiEntropy)
+	}
+	return s
 }
 
 // Run starts the MyDatabase gRPC server and listens for incoming requests.
 // It returns an error if the server fails to start or encounters an error.
 func (s *MyDatabase) Run() error {
-	// Create a new gRPC server instance.
-	srv := grpc.NewServer()
+	// Create a new gRPC server instance, scheduling requests as they arrive.
+	srv := grpc.NewServer(grpc.UnaryInterceptor(s.scheduler.unaryInterceptor))
 
 	// Register the Database server implementation with the gRPC server.
 	mydatabase.RegisterDatabaseServiceServer(srv, s)
+	if s.replica != nil {
+		raftpb.RegisterRaftServiceServer(srv, raft.NewServer(s.replica.Node()))
+	}
 
 	// Create a TCP listener that listens for incoming requests on the specified port.
 	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", s.port))
@@ -61,14 +186,48 @@ func (s *MyDatabase) Run() error {
 // GetRecord retrieves a record from the database.
 func (s *MyDatabase) GetRecord(ctx context.Context, req *mydatabase.GetRecordRequest) (*mydatabase.GetRecordResponse, error) {
 	// Get the name of the requested item
-	key := req.GetKey()
+	key, err := storedKey(req.GetNamespace(), req.GetKey())
+	if err != nil {
+		return &mydatabase.GetRecordResponse{}, err
+	}
+
+	// Transactions live on the leader of a replicated group, and linearizable reads are served by it
+	if req.GetTransactionId() != 0 || req.GetConsistency() == mydatabase.ReadConsistency_READ_LINEARIZABLE {
+		leader, ctx, err := s.leader(ctx)
+		if err != nil {
+			return &mydatabase.GetRecordResponse{}, err
+		}
+		if leader != nil {
+			return leader.GetRecord(ctx, req)
+		}
+		if req.GetTransactionId() == 0 {
+			if err := s.readIndex(ctx); err != nil {
+				return &mydatabase.GetRecordResponse{}, err
+			}
+		}
+	}
 
-	// Retrieve record from the database application
-	record, ok := s.app.Get(key)
+	// Retrieve record from the database application, from the transaction's snapshot, or
+	// as of the requested time
+	var record *mydatabase.DatabaseRecord
+	var ok bool
+	id, ts := req.GetTransactionId(), req.GetReadTimestamp()
+	switch {
+	case id != 0 && ts != 0:
+		return &mydatabase.GetRecordResponse{}, status.Errorf(codes.InvalidArgument, "A read timestamp cannot be used within a transaction")
+	case id != 0:
+		var err error
+		if record, ok, err = s.txns.Get(id, key); err != nil {
+			return &mydatabase.GetRecordResponse{}, transactionError(err)
+		}
+	case ts != 0:
+		record, ok = s.app.GetAt(key, ts)
+	default:
+		record, ok = s.app.Get(key)
+	}
 	msg := &mydatabase.GetRecordResponse{
-		Record: record, // will be nil if an error occurs
+		Record: namespaceRecord(record), // will be nil if an error occurs
 	}
-	var err error
 	if !ok {
 		err = status.Errorf(codes.NotFound, "Record not found in storage!")
 	} else {
@@ -77,25 +236,476 @@ func (s *MyDatabase) GetRecord(ctx context.Context, req *mydatabase.GetRecordReq
 	return msg, err
 }
 
+// GetHistory lists the retained versions of a record, newest first, including deletions.
+func (s *MyDatabase) GetHistory(ctx context.Context, req *mydatabase.GetHistoryRequest) (*mydatabase.GetHistoryResponse, error) {
+	key := req.GetKey()
+	stored, err := storedKey(req.GetNamespace(), key)
+	if err != nil {
+		return &mydatabase.GetHistoryResponse{}, err
+	}
+	limit := int(req.GetLimit())
+	if limit < 0 {
+		return &mydatabase.GetHistoryResponse{}, status.Errorf(codes.InvalidArgument, "Invalid history limit: %d", limit)
+	}
+	if req.GetConsistency() == mydatabase.ReadConsistency_READ_LINEARIZABLE {
+		leader, ctx, err := s.leader(ctx)
+		if err != nil {
+			return &mydatabase.GetHistoryResponse{}, err
+		}
+		if leader != nil {
+			return leader.GetHistory(ctx, req)
+		}
+		if err := s.readIndex(ctx); err != nil {
+			return &mydatabase.GetHistoryResponse{}, err
+		}
+	}
+
+	msg := &mydatabase.GetHistoryResponse{}
+	err = s.app.History(stored, func(v apps.Version) bool {
+		msg.Versions = append(msg.Versions, &mydatabase.RecordVersion{
+			Record:  &mydatabase.DatabaseRecord{Key: key, Value: v.Value, Timestamp: v.Timestamp},
+			Deleted: v.Deleted,
+		})
+		return limit == 0 || len(msg.Versions) < limit
+	})
+	if err != nil {
+		return msg, status.Errorf(codes.Internal, "Failed to read record history: %v", err)
+	}
+	if len(msg.Versions) == 0 {
+		return msg, status.Errorf(codes.NotFound, "No versions of record in storage!")
+	}
+	return msg, nil
+}
+
 // SetRecord sets a record in the database.
 func (s *MyDatabase) SetRecord(ctx context.Context, req *mydatabase.SetRecordRequest) (*mydatabase.SetRecordResponse, error) {
+	leader, ctx, err := s.leader(ctx)
+	if err != nil {
+		return &mydatabase.SetRecordResponse{}, err
+	}
+	if leader != nil {
+		return leader.SetRecord(ctx, req)
+	}
 	record := req.GetRecord()
+	key, err := storedKey(req.GetNamespace(), record.GetKey())
+	if err != nil {
+		return &mydatabase.SetRecordResponse{}, err
+	}
 
 	msg := &mydatabase.SetRecordResponse{
 		Success: true,
 	}
-	s.app.Set(record)
+	mutation := apps.Mutation{Key: key, Value: record.GetValue()}
+	if req.GetMergeSiblings() {
+		if req.GetTransactionId() != 0 {
+			msg.Success = false
+			return msg, status.Errorf(codes.InvalidArgument, "Siblings cannot be merged within a transaction")
+		}
+		s.mergeMu.Lock()
+		defer s.mergeMu.Unlock()
+
+		incoming, err := decodeSiblings(mutation.Value)
+		if err != nil {
+			msg.Success = false
+			return msg, status.Errorf(codes.InvalidArgument, "Failed to decode siblings: %v", err)
+		}
+		if current, ok := s.app.Get(mutation.Key); ok {
+			stored, err := decodeSiblings(current.GetValue())
+			if err != nil {
+				msg.Success = false
+				return msg, status.Errorf(codes.Internal, "Failed to decode stored siblings: %v", err)
+			}
+			incoming = mergeSiblings(stored, incoming)
+		}
+		mutation.Value = encodeSiblings(incoming)
+	}
+	if id := req.GetTransactionId(); id != 0 {
+		if err := s.txns.Write(id, mutation); err != nil {
+			msg.Success = false
+			return msg, transactionError(err)
+		}
+		return msg, status.Error(codes.OK, "Record buffered in transaction!")
+	}
+	if err := s.txns.Apply([]apps.Mutation{mutation}); err != nil {
+		msg.Success = false
+		if err := replicationError(err); err != nil {
+			return msg, err
+		}
+		if err := quotaError(err); err != nil {
+			return msg, err
+		}
+		return msg, status.Errorf(codes.Internal, "Failed to place record in storage: %v", err)
+	}
 	return msg, status.Error(codes.OK, "Record placed in storage!")
 }
 
 // DeleteRecord deletes a record from the database.
 func (s *MyDatabase) DeleteRecord(ctx context.Context, req *mydatabase.DeleteRecordRequest) (*mydatabase.DeleteRecordResponse, error) {
-	key := req.GetKey()
-	log.Printf("DeleteKey: %s", key)
+	leader, ctx, err := s.leader(ctx)
+	if err != nil {
+		return &mydatabase.DeleteRecordResponse{}, err
+	}
+	if leader != nil {
+		return leader.DeleteRecord(ctx, req)
+	}
+	log.Printf("DeleteKey: %s", req.GetKey())
+	key, err := storedKey(req.GetNamespace(), req.GetKey())
+	if err != nil {
+		return &mydatabase.DeleteRecordResponse{}, err
+	}
 	msg := &mydatabase.DeleteRecordResponse{
 		Success: true,
 	}
 
-	s.app.Delete(key)
+	mutation := apps.Mutation{Key: key, Delete: true}
+	if expected := req.GetExpectedValue(); expected != nil {
+		if req.GetTransactionId() != 0 {
+			msg.Success = false
+			return msg, status.Errorf(codes.InvalidArgument, "Conditional deletes cannot be made within a transaction")
+		}
+		s.mergeMu.Lock()
+		defer s.mergeMu.Unlock()
+
+		if current, ok := s.app.Get(key); !ok || !bytes.Equal(current.GetValue(), expected) {
+			msg.Success = false
+			return msg, status.Errorf(codes.FailedPrecondition, "Item with Key: %s no longer holds the expected value", req.GetKey())
+		}
+	}
+	if id := req.GetTransactionId(); id != 0 {
+		if err := s.txns.Write(id, mutation); err != nil {
+			msg.Success = false
+			return msg, transactionError(err)
+		}
+		return msg, status.Error(codes.OK, "Delete buffered in transaction!")
+	}
+	if err := s.txns.Apply([]apps.Mutation{mutation}); err != nil {
+		msg.Success = false
+		if err := replicationError(err); err != nil {
+			return msg, err
+		}
+		return msg, status.Errorf(codes.Internal, "Failed to delete record from database: %v", err)
+	}
 	return msg, status.Error(codes.OK, "Record deleted from database!")
 }
+
+// GetStats reports statistics about the storage backend.
+func (s *MyDatabase) GetStats(ctx context.Context, req *mydatabase.GetStatsRequest) (*mydatabase.GetStatsResponse, error) {
+	stats := s.app.Stats()
+	if stats.Metrics == nil {
+		stats.Metrics = make(map[string]float64)
+	}
+	for name, value := range s.txns.Metrics() {
+		stats.Metrics[name] = value
+	}
+	for name, value := range s.scheduler.Metrics() {
+		stats.Metrics[name] = value
+	}
+	if s.antiEntropy != nil {
+		for name, value := range s.antiEntropy.Metrics() {
+			stats.Metrics[name] = value
+		}
+	}
+	msg := &mydatabase.GetStatsResponse{
+		Stats:      stats,
+		Namespaces: s.namespaceStats(),
+	}
+	return msg, nil
+}
+
+// BeginTransaction starts a transaction that reads from a snapshot of the database taken now.
+// Pass the returned id in GetRecord, SetRecord and DeleteRecord requests to use it.
+func (s *MyDatabase) BeginTransaction(ctx context.Context, req *mydatabase.BeginTransactionRequest) (*mydatabase.BeginTransactionResponse, error) {
+	leader, ctx, err := s.leader(ctx)
+	if err != nil {
+		return &mydatabase.BeginTransactionResponse{}, err
+	}
+	if leader != nil {
+		return leader.BeginTransaction(ctx, req)
+	}
+	// the snapshot must include every write acknowledged so far
+	if err := s.readIndex(ctx); err != nil {
+		return &mydatabase.BeginTransactionResponse{}, err
+	}
+	msg := &mydatabase.BeginTransactionResponse{
+		TransactionId: s.txns.Begin(),
+	}
+	return msg, nil
+}
+
+// CommitTransaction atomically applies the transaction's writes. If another write to one of
+// the same keys committed first, the transaction is aborted and the client should retry it.
+func (s *MyDatabase) CommitTransaction(ctx context.Context, req *mydatabase.CommitTransactionRequest) (*mydatabase.CommitTransactionResponse, error) {
+	leader, ctx, err := s.leader(ctx)
+	if err != nil {
+		return &mydatabase.CommitTransactionResponse{}, err
+	}
+	if leader != nil {
+		return leader.CommitTransaction(ctx, req)
+	}
+	msg := &mydatabase.CommitTransactionResponse{
+		Success: true,
+	}
+	if err := s.txns.Commit(req.GetTransactionId()); err != nil {
+		msg.Success = false
+		return msg, transactionError(err)
+	}
+	return msg, status.Error(codes.OK, "Transaction committed!")
+}
+
+// AbortTransaction discards the transaction's writes.
+func (s *MyDatabase) AbortTransaction(ctx context.Context, req *mydatabase.AbortTransactionRequest) (*mydatabase.AbortTransactionResponse, error) {
+	leader, ctx, err := s.leader(ctx)
+	if err != nil {
+		return &mydatabase.AbortTransactionResponse{}, err
+	}
+	if leader != nil {
+		return leader.AbortTransaction(ctx, req)
+	}
+	msg := &mydatabase.AbortTransactionResponse{
+		Success: true,
+	}
+	if err := s.txns.Abort(req.GetTransactionId()); err != nil {
+		msg.Success = false
+		return msg, transactionError(err)
+	}
+	return msg, status.Error(codes.OK, "Transaction aborted!")
+}
+
+// transactionError converts an error from the transaction manager into a gRPC status.
+// Conflicts and unknown (e.g. expired) transactions are reported as Aborted, telling the
+// client to retry the whole transaction.
+func transactionError(err error) error {
+	if err := replicationError(err); err != nil {
+		return err
+	}
+	switch {
+	case errors.Is(err, apps.ErrTransactionConflict):
+		return status.Errorf(codes.Aborted, "Transaction aborted due to a write-write conflict: %v", err)
+	case errors.Is(err, apps.ErrTransactionNotFound):
+		return status.Errorf(codes.Aborted, "Transaction is no longer active: %v", err)
+	}
+	if err := quotaError(err); err != nil {
+		return err
+	}
+	return status.Errorf(codes.Internal, "Failed to commit transaction: %v", err)
+}
+
+// ScanRecords streams the records of a namespace in the requested range in key order. At most limit
+// records are returned; if more remain, the last message carries a continuation token
+// that resumes the scan just after the last record sent.
+func (s *MyDatabase) ScanRecords(req *mydatabase.ScanRecordsRequest, stream mydatabase.DatabaseService_ScanRecordsServer) error {
+	options := apps.ScanOptions{
+		Start:   req.GetStartKey(),
+		End:     req.GetEndKey(),
+		Prefix:  req.GetPrefix(),
+		Reverse: req.GetReverse(),
+	}
+	if _, err := storedKey(req.GetNamespace(), ""); err != nil {
+		return err
+	}
+	limit := int(req.GetLimit())
+	if limit < 0 {
+		return status.Errorf(codes.InvalidArgument, "Invalid scan limit: %d", limit)
+	}
+	if limit == 0 {
+		limit = defaultScanLimit
+	}
+	if limit > maxScanLimit {
+		limit = maxScanLimit
+	}
+	if req.GetConsistency() == mydatabase.ReadConsistency_READ_LINEARIZABLE {
+		leader, ctx, err := s.leader(stream.Context())
+		if err != nil {
+			return err
+		}
+		if leader != nil {
+			return forwardScan(ctx, leader, req, stream)
+		}
+		if err := s.readIndex(ctx); err != nil {
+			return err
+		}
+	}
+	if token := req.GetContinuationToken(); token != "" {
+		lastKey, err := decodeScanToken(token, options.Reverse)
+		if err != nil {
+			return status.Errorf(codes.InvalidArgument, "Invalid continuation token: %v", err)
+		}
+		if options.Reverse {
+			options.End = lastKey
+		} else {
+			options.Start = lastKey + "\x00"
+		}
+	}
+
+	// fetch one record past the limit to learn whether a continuation token is needed
+	records := make([]*mydatabase.DatabaseRecord, 0, limit+1)
+	err := s.app.Scan(apps.NamespaceScan(req.GetNamespace(), options), func(record *mydatabase.DatabaseRecord) bool {
+		records = append(records, namespaceRecord(record))
+		return len(records) <= limit
+	})
+	if err != nil {
+		return status.Errorf(codes.Internal, "Failed to scan storage: %v", err)
+	}
+
+	var token string
+	if len(records) > limit {
+		records = records[:limit]
+		token = encodeScanToken(records[limit-1].GetKey(), options.Reverse)
+	}
+	// always send at least one message so an empty scan still ends with a response
+	for start := 0; start < len(records) || start == 0; start += scanBatchSize {
+		end := start + scanBatchSize
+		if end > len(records) {
+			end = len(records)
+		}
+		msg := &mydatabase.ScanRecordsResponse{
+			Records: records[start:end],
+		}
+		if end == len(records) {
+			msg.ContinuationToken = token
+		}
+		if err := stream.Send(msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// forwardScan relays a scan to the leader and streams its responses back.
+func forwardScan(ctx context.Context, leader mydatabase.DatabaseServiceClient, req *mydatabase.ScanRecordsRequest, stream mydatabase.DatabaseService_ScanRecordsServer) error {
+	scan, err := leader.ScanRecords(ctx, req)
+	if err != nil {
+		return err
+	}
+	for {
+		msg, err := scan.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := stream.Send(msg); err != nil {
+			return err
+		}
+	}
+}
+
+// leader returns a client for the leader of the server's replicated group, and the context
+// to forward a request with, if this server is a follower. It returns a nil client if the
+// server should handle the request itself: it is standalone or the leader.
+func (s *MyDatabase) leader(ctx context.Context) (mydatabase.DatabaseServiceClient, context.Context, error) {
+	if s.replica == nil {
+		return nil, ctx, nil
+	}
+	node := s.replica.Node()
+	state, _, leader := node.Status()
+	if state == raft.Leader {
+		return nil, ctx, nil
+	}
+	// a forwarded request reaching a follower means leadership moved in the meantime
+	if md, ok := metadata.FromIncomingContext(ctx); ok && len(md.Get(forwardedKey)) > 0 {
+		return nil, ctx, status.Errorf(codes.Unavailable, "Not the leader of the database group; retry the request")
+	}
+	if leader == "" {
+		return nil, ctx, status.Errorf(codes.Unavailable, "Database group has no leader; retry the request")
+	}
+
+	s.leadersMu.Lock()
+	client, ok := s.leaders[leader]
+	if !ok {
+		client = mydatabase.NewDatabaseServiceClient(dial(leader))
+		s.leaders[leader] = client
+	}
+	s.leadersMu.Unlock()
+	ctx = metadata.AppendToOutgoingContext(ctx, forwardedKey, node.ID())
+	// the leader schedules the request in the class it was sent in
+	if _, class, err := requestPriority(ctx); err == nil && class != PriorityNormal {
+		ctx = WithPriority(ctx, class)
+	}
+	return client, ctx, nil
+}
+
+// readIndex waits until reads on this server, the leader of its replicated group, observe
+// every write acknowledged before the call. It returns immediately on a standalone server.
+func (s *MyDatabase) readIndex(ctx context.Context) error {
+	if s.replica == nil {
+		return nil
+	}
+	if err := s.replica.Node().ReadIndex(ctx); err != nil {
+		if err := replicationError(err); err != nil {
+			return err
+		}
+		return status.Errorf(codes.Internal, "Failed to confirm leadership: %v", err)
+	}
+	return nil
+}
+
+// replicationError converts an error from the server's Raft group into a gRPC status, or
+// returns nil if err did not come from replication. Writes that failed this way may or may
+// not have been applied; SetRecord and DeleteRecord are safe to retry.
+func replicationError(err error) error {
+	switch {
+	case errors.Is(err, raft.ErrNotLeader):
+		return status.Errorf(codes.Unavailable, "Leadership of the database group changed; retry the request: %v", err)
+	case errors.Is(err, raft.ErrProposalDropped), errors.Is(err, raft.ErrStopped):
+		return status.Errorf(codes.Unavailable, "Database group could not complete the request: %v", err)
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, context.Canceled):
+		return status.Errorf(codes.Unavailable, "Timed out waiting for the database group: %v", err)
+	}
+	return nil
+}
+
+// ChangeMembership adds the server with the given id to, or removes it from, the Raft group
+// of the database server at addr, and returns the group's members after the change. A
+// member that is not the leader names it, and the change is retried there once. A server
+// to be added is started with -raft_id set and no -raft_peers, and joins once it has caught
+// up with the leader's log.
+func ChangeMembership(ctx context.Context, addr, id string, add bool) ([]string, error) {
+	for attempt := 0; ; attempt++ {
+		client := raftpb.NewRaftServiceClient(dial(addr))
+		change := client.RemoveServer
+		if add {
+			change = client.AddServer
+		}
+		var trailer metadata.MD
+		resp, err := change(ctx, &raftpb.MembershipChangeRequest{ServerId: id}, grpc.Trailer(&trailer))
+		if status.Code(err) == codes.FailedPrecondition && attempt == 0 {
+			if leader := trailer.Get(raft.LeaderTrailer); len(leader) > 0 && leader[0] != addr {
+				addr = leader[0]
+				continue
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		return resp.GetMembers(), nil
+	}
+}
+
+// encodeScanToken builds an opaque continuation token from the scan direction and the
+// last key returned.
+func encodeScanToken(lastKey string, reverse bool) string {
+	direction := byte('f')
+	if reverse {
+		direction = 'r'
+	}
+	return base64.RawURLEncoding.EncodeToString(append([]byte{direction}, lastKey...))
+}
+
+// decodeScanToken returns the last key recorded in a continuation token, checking that the
+// token was issued for a scan in the same direction.
+func decodeScanToken(token string, reverse bool) (string, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return "", err
+	}
+	direction := byte('f')
+	if reverse {
+		direction = 'r'
+	}
+	if len(data) == 0 || data[0] != direction {
+		return "", fmt.Errorf("token does not match scan direction")
+	}
+	return string(data[1:]), nil
+}
@@ -0,0 +1,127 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	apps "gitlab.cs.washington.edu/syslab/cse453-welp/applications"
+	"gitlab.cs.washington.edu/syslab/cse453-welp/proto/mydatabase"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// storedKey returns the key a record of namespace is stored under, or an InvalidArgument
+// status if the namespace or key is not valid.
+func storedKey(namespace, key string) (string, error) {
+	if err := apps.CheckNamespaceKey(namespace, key); err != nil {
+		return "", status.Errorf(codes.InvalidArgument, "Invalid record key: %v", err)
+	}
+	return apps.NamespaceKey(namespace, key), nil
+}
+
+// namespaceRecord returns a copy of a stored record carrying the key it has within its
+// namespace.
+func namespaceRecord(record *mydatabase.DatabaseRecord) *mydatabase.DatabaseRecord {
+	if record == nil {
+		return nil
+	}
+	_, key := apps.SplitNamespaceKey(record.GetKey())
+	if key == record.GetKey() {
+		return record
+	}
+	record = proto.Clone(record).(*mydatabase.DatabaseRecord)
+	record.Key = key
+	return record
+}
+
+// quotaError converts an error from a write that would exceed a namespace quota into a
+// gRPC status, or returns nil if the write failed for another reason.
+func quotaError(err error) error {
+	if errors.Is(err, apps.ErrQuotaExceeded) {
+		return status.Errorf(codes.ResourceExhausted, "Namespace quota exceeded: %v", err)
+	}
+	return nil
+}
+
+// namespaceStats reports the usage of every namespace holding records and the quota of
+// every namespace that has one.
+func (s *MyDatabase) namespaceStats() map[string]*mydatabase.NamespaceStats {
+	stats := make(map[string]*mydatabase.NamespaceStats)
+	for namespace, u := range s.namespaces.Usage() {
+		stats[namespace] = &mydatabase.NamespaceStats{RecordCount: u.Records, SizeBytes: u.Bytes}
+	}
+	for namespace, q := range s.quotas.Quotas() {
+		if stats[namespace] == nil {
+			stats[namespace] = &mydatabase.NamespaceStats{}
+		}
+		stats[namespace].MaxRecords, stats[namespace].MaxBytes = q.MaxRecords, q.MaxBytes
+	}
+	return stats
+}
+
+// namespacedClient keeps the records read and written through a database client in a
+// namespace, so that several services can share one database.
+type namespacedClient struct {
+	mydatabase.DatabaseServiceClient
+	namespace string
+}
+
+// NewNamespacedClient returns a client that sets namespace on every request for records
+// sent through client. The default namespace needs no wrapping, so client is returned
+// as is for it.
+func NewNamespacedClient(client mydatabase.DatabaseServiceClient, namespace string) mydatabase.DatabaseServiceClient {
+	if namespace == "" {
+		return client
+	}
+	return &namespacedClient{DatabaseServiceClient: client, namespace: namespace}
+}
+
+func (c *namespacedClient) GetRecord(ctx context.Context, req *mydatabase.GetRecordRequest, opts ...grpc.CallOption) (*mydatabase.GetRecordResponse, error) {
+	req = proto.Clone(req).(*mydatabase.GetRecordRequest)
+	req.Namespace = c.namespace
+	return c.DatabaseServiceClient.GetRecord(ctx, req, opts...)
+}
+
+func (c *namespacedClient) GetHistory(ctx context.Context, req *mydatabase.GetHistoryRequest, opts ...grpc.CallOption) (*mydatabase.GetHistoryResponse, error) {
+	req = proto.Clone(req).(*mydatabase.GetHistoryRequest)
+	req.Namespace = c.namespace
+	return c.DatabaseServiceClient.GetHistory(ctx, req, opts...)
+}
+
+func (c *namespacedClient) SetRecord(ctx context.Context, req *mydatabase.SetRecordRequest, opts ...grpc.CallOption) (*mydatabase.SetRecordResponse, error) {
+	req = proto.Clone(req).(*mydatabase.SetRecordRequest)
+	req.Namespace = c.namespace
+	return c.DatabaseServiceClient.SetRecord(ctx, req, opts...)
+}
+
+func (c *namespacedClient) UpdateRecord(ctx context.Context, req *mydatabase.UpdateRecordRequest, opts ...grpc.CallOption) (*mydatabase.UpdateRecordResponse, error) {
+	req = proto.Clone(req).(*mydatabase.UpdateRecordRequest)
+	req.Namespace = c.namespace
+	return c.DatabaseServiceClient.UpdateRecord(ctx, req, opts...)
+}
+
+func (c *namespacedClient) DeleteRecord(ctx context.Context, req *mydatabase.DeleteRecordRequest, opts ...grpc.CallOption) (*mydatabase.DeleteRecordResponse, error) {
+	req = proto.Clone(req).(*mydatabase.DeleteRecordRequest)
+	req.Namespace = c.namespace
+	return c.DatabaseServiceClient.DeleteRecord(ctx, req, opts...)
+}
+
+func (c *namespacedClient) ScanRecords(ctx context.Context, req *mydatabase.ScanRecordsRequest, opts ...grpc.CallOption) (mydatabase.DatabaseService_ScanRecordsClient, error) {
+	req = proto.Clone(req).(*mydatabase.ScanRecordsRequest)
+	req.Namespace = c.namespace
+	return c.DatabaseServiceClient.ScanRecords(ctx, req, opts...)
+}
+
+func (c *namespacedClient) QueryIndex(ctx context.Context, req *mydatabase.QueryIndexRequest, opts ...grpc.CallOption) (*mydatabase.QueryIndexResponse, error) {
+	req = proto.Clone(req).(*mydatabase.QueryIndexRequest)
+	req.Namespace = c.namespace
+	return c.DatabaseServiceClient.QueryIndex(ctx, req, opts...)
+}
+
+func (c *namespacedClient) StreamChanges(ctx context.Context, req *mydatabase.StreamChangesRequest, opts ...grpc.CallOption) (mydatabase.DatabaseService_StreamChangesClient, error) {
+	req = proto.Clone(req).(*mydatabase.StreamChangesRequest)
+	req.Namespace = c.namespace
+	return c.DatabaseServiceClient.StreamChanges(ctx, req, opts...)
+}
@@ -0,0 +1,276 @@
+package services
+
+import (
+	"context"
+	"math"
+	"sort"
+
+	"gitlab.cs.washington.edu/syslab/cse453-welp/proto/detail"
+	"gitlab.cs.washington.edu/syslab/cse453-welp/proto/mydatabase"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultNearbyLimit = 20  // restaurants found by NearbyRestaurants when the request sets no limit
+	maxNearbyLimit     = 100 // upper bound on the restaurants found by a single NearbyRestaurants call
+
+	earthRadiusKm   = 6371.0088               // mean radius of the Earth
+	maxNearbyRadius = math.Pi * earthRadiusKm // half the Earth's circumference; every point is within it
+
+	geohashPrecision = 9 // characters of the geohashes restaurants are indexed under, cells of about 5m
+	geohashAlphabet  = "0123456789bcdefghjkmnpqrstuvwxyz"
+)
+
+// geohash returns the geohash cell of the given precision holding a point: the bits of
+// its longitude and latitude, interleaved starting with longitude, in base 32.
+func geohash(latitude, longitude float64, precision int) string {
+	lat := [2]float64{-90, 90}
+	lng := [2]float64{-180, 180}
+	hash := make([]byte, 0, precision)
+	bits, ch := 0, 0
+	for even := true; len(hash) < precision; even = !even {
+		interval, value := &lat, latitude
+		if even {
+			interval, value = &lng, longitude
+		}
+		mid := (interval[0] + interval[1]) / 2
+		ch <<= 1
+		if value >= mid {
+			ch |= 1
+			interval[0] = mid
+		} else {
+			interval[1] = mid
+		}
+		if bits++; bits == 5 {
+			hash = append(hash, geohashAlphabet[ch])
+			bits, ch = 0, 0
+		}
+	}
+	return string(hash)
+}
+
+// geohashCellSize returns the height and width, in degrees, of the geohash cells of a
+// precision.
+func geohashCellSize(precision int) (float64, float64) {
+	bits := 5 * precision
+	lngBits := (bits + 1) / 2
+	return 180 / math.Pow(2, float64(bits-lngBits)), 360 / math.Pow(2, float64(lngBits))
+}
+
+// geohashCover returns the geohash cells, of a single precision, that together hold every
+// point within radiusKm of a point: the cell of the point and its eight neighbours, with
+// cells large enough that the circle cannot reach past them. It returns nil if the circle
+// reaches a pole or is too large for even the largest cells, so the cells cannot cover it.
+func geohashCover(latitude, longitude, radiusKm float64) []string {
+	angle := radiusKm / earthRadiusKm
+	dLat := angle * 180 / math.Pi
+	if latitude+dLat >= 90 || latitude-dLat <= -90 {
+		return nil
+	}
+	// the widest the circle gets in longitude, at the latitude whose meridians it touches
+	dLng := math.Asin(math.Sin(angle)/math.Cos(latitude*math.Pi/180)) * 180 / math.Pi
+
+	precision := 0
+	for p := geohashPrecision; p >= 1; p-- {
+		if height, width := geohashCellSize(p); height >= dLat && width >= dLng {
+			precision = p
+			break
+		}
+	}
+	if precision == 0 {
+		return nil
+	}
+
+	height, width := geohashCellSize(precision)
+	// step from the middle of the point's cell, so neighbours land in the middle of theirs
+	centerLat := (math.Floor((latitude+90)/height)+0.5)*height - 90
+	centerLng := (math.Floor((longitude+180)/width)+0.5)*width - 180
+	seen := make(map[string]bool)
+	var cells []string
+	for i := -1; i <= 1; i++ {
+		lat := centerLat + float64(i)*height
+		if lat <= -90 || lat >= 90 {
+			continue
+		}
+		for j := -1; j <= 1; j++ {
+			// wrap around the antimeridian
+			lng := math.Mod(centerLng+float64(j)*width+540, 360) - 180
+			if cell := geohash(lat, lng, precision); !seen[cell] {
+				seen[cell] = true
+				cells = append(cells, cell)
+			}
+		}
+	}
+	return cells
+}
+
+// distanceKm returns the great-circle distance between two points, by the haversine
+// formula.
+func distanceKm(a, b *detail.LatLng) float64 {
+	lat1, lat2 := a.GetLatitude()*math.Pi/180, b.GetLatitude()*math.Pi/180
+	dLat := lat2 - lat1
+	dLng := (b.GetLongitude() - a.GetLongitude()) * math.Pi / 180
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// checkCoordinates returns InvalidArgument unless a point is on the Earth.
+func checkCoordinates(p *detail.LatLng) error {
+	lat, lng := p.GetLatitude(), p.GetLongitude()
+	if math.IsNaN(lat) || lat < -90 || lat > 90 || math.IsNaN(lng) || lng < -180 || lng > 180 {
+		return status.Errorf(codes.InvalidArgument, "Invalid coordinates (%v, %v): latitude must be in [-90, 90] and longitude in [-180, 180]", lat, lng)
+	}
+	return nil
+}
+
+// checkNearbyRestaurants validates a nearby search, returning the number of restaurants
+// it finds at most.
+func checkNearbyRestaurants(req *detail.NearbyRestaurantsRequest) (int, error) {
+	if req.GetCenter() == nil {
+		return 0, status.Errorf(codes.InvalidArgument, "Missing search center")
+	}
+	if err := checkCoordinates(req.GetCenter()); err != nil {
+		return 0, err
+	}
+	if radius := req.GetRadiusKm(); !(radius > 0 && radius <= maxNearbyRadius) {
+		return 0, status.Errorf(codes.InvalidArgument, "Invalid search radius: %v km, must be positive and at most %.0f km", radius, maxNearbyRadius)
+	}
+	limit := int(req.GetLimit())
+	if limit < 0 {
+		return 0, status.Errorf(codes.InvalidArgument, "Invalid limit: %d", limit)
+	}
+	if limit == 0 {
+		limit = defaultNearbyLimit
+	}
+	if limit > maxNearbyLimit {
+		limit = maxNearbyLimit
+	}
+	return limit, nil
+}
+
+// nearbyResults sorts the restaurants found by a nearby search, nearest first, then by
+// name and ID, and returns the first limit of them.
+func nearbyResults(restaurants []*detail.NearbyRestaurant, limit int) *detail.NearbyRestaurantsResponse {
+	sort.Slice(restaurants, func(i, j int) bool {
+		a, b := restaurants[i], restaurants[j]
+		if a.GetDistanceKm() != b.GetDistanceKm() {
+			return a.GetDistanceKm() < b.GetDistanceKm()
+		}
+		if a.GetDetail().GetRestaurantName() != b.GetDetail().GetRestaurantName() {
+			return a.GetDetail().GetRestaurantName() < b.GetDetail().GetRestaurantName()
+		}
+		return a.GetDetail().GetRestaurantId() < b.GetDetail().GetRestaurantId()
+	})
+	if len(restaurants) > limit {
+		restaurants = restaurants[:limit]
+	}
+	return &detail.NearbyRestaurantsResponse{Restaurants: restaurants}
+}
+
+// mergeNearbyRestaurants merges the restaurants found by several detail servers for the
+// same search. A restaurant found by more than one of them is listed once.
+func mergeNearbyRestaurants(req *detail.NearbyRestaurantsRequest, found []*detail.NearbyRestaurantsResponse) *detail.NearbyRestaurantsResponse {
+	limit, _ := checkNearbyRestaurants(req)
+	seen := make(map[string]bool)
+	var restaurants []*detail.NearbyRestaurant
+	for _, msg := range found {
+		for _, r := range msg.GetRestaurants() {
+			if id := r.GetDetail().GetRestaurantId(); !seen[id] {
+				seen[id] = true
+				restaurants = append(restaurants, r)
+			}
+		}
+	}
+	return nearbyResults(restaurants, limit)
+}
+
+// NearbyRestaurants finds the restaurants within a great-circle distance of a point, nearest
+// first. The geohash index narrows the search to the cells around the point, unless the
+// search reaches a pole or spans most of the Earth, in which case every restaurant is
+// checked.
+func (s *Detail) NearbyRestaurants(ctx context.Context, req *detail.NearbyRestaurantsRequest) (*detail.NearbyRestaurantsResponse, error) {
+	limit, err := checkNearbyRestaurants(req)
+	if err != nil {
+		return &detail.NearbyRestaurantsResponse{}, err
+	}
+
+	var restaurants []*detail.NearbyRestaurant
+	found := func(d *detail.GetDetailResponse) {
+		if d.GetCoordinates() == nil || (req.GetStyle() != "" && d.GetStyle() != req.GetStyle()) {
+			return
+		}
+		if distance := distanceKm(req.GetCenter(), d.GetCoordinates()); distance <= req.GetRadiusKm() {
+			restaurants = append(restaurants, &detail.NearbyRestaurant{Detail: d, DistanceKm: distance})
+		}
+	}
+
+	cells := geohashCover(req.GetCenter().GetLatitude(), req.GetCenter().GetLongitude(), req.GetRadiusKm())
+	if cells == nil {
+		err = s.scanDetails(ctx, found)
+	} else {
+		err = s.searchCells(ctx, cells, req.GetStyle(), found)
+	}
+	if err != nil {
+		return &detail.NearbyRestaurantsResponse{}, err
+	}
+	return nearbyResults(restaurants, limit), nil
+}
+
+// searchCells calls fn with the details of every restaurant indexed under one of the geohash cells, and of style if it is set.
+func (s *Detail) searchCells(ctx context.Context, cells []string, style string, fn func(d *detail.GetDetailResponse)) error {
+	var keys []string
+	for _, cell := range cells {
+		conditions := []*mydatabase.IndexCondition{{Index: DetailGeohashIndex, Range: &mydatabase.IndexRange{Prefix: cell}}}
+		if style != "" {
+			conditions = append(conditions, &mydatabase.IndexCondition{Index: DetailStyleIndex, Value: style})
+		}
+		token := ""
+		for {
+			msg, err := s.detailDatabaseClient.QueryIndex(ctx, &mydatabase.QueryIndexRequest{
+				Conditions:        conditions,
+				Limit:             maxScanLimit,
+				ContinuationToken: token,
+			})
+			if err != nil {
+				return status.Errorf(codes.Internal, "Failed to query data storage: %v", err)
+			}
+			keys = append(keys, msg.GetKeys()...)
+			if token = msg.GetContinuationToken(); token == "" {
+				break
+			}
+		}
+	}
+
+	details, err := s.getDetails(ctx, keys)
+	if err != nil {
+		return err
+	}
+	for _, d := range details {
+		fn(d)
+	}
+	return nil
+}
+
+// detailGeohash returns the geohash restaurant details are indexed under, or the empty
+// string if they have no coordinates.
+func detailGeohash(d *detail.GetDetailResponse) string {
+	if d.GetCoordinates() == nil {
+		return ""
+	}
+	return geohash(d.GetCoordinates().GetLatitude(), d.GetCoordinates().GetLongitude(), geohashPrecision)
+}
+
+// nonEmpty drops the empty values of an index extractor, so that records without a value
+// are not indexed.
+func nonEmpty(extract func(key string, value []byte) []string) func(key string, value []byte) []string {
+	return func(key string, value []byte) []string {
+		var values []string
+		for _, v := range extract(key, value) {
+			if v != "" {
+				values = append(values, v)
+			}
+		}
+		return values
+	}
+}
@@ -0,0 +1,140 @@
+package services
+
+import (
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"gitlab.cs.washington.edu/syslab/cse453-welp/proto/detail"
+)
+
+// kmPerDegree is the length of a degree of a great circle.
+const kmPerDegree = math.Pi * earthRadiusKm / 180
+
+func TestGeohash(t *testing.T) {
+	for _, tc := range []struct {
+		lat, lng  float64
+		precision int
+		want      string
+	}{
+		{57.64911, 10.40744, 9, "u4pruydqq"},
+		{0, 0, 5, "s0000"},
+		{-90, -180, 3, "000"},
+		{89.99999, 179.99999, 3, "zzz"},
+	} {
+		if got := geohash(tc.lat, tc.lng, tc.precision); got != tc.want {
+			t.Errorf("geohash(%v, %v, %d) = %s, want %s", tc.lat, tc.lng, tc.precision, got, tc.want)
+		}
+	}
+}
+
+func TestDistanceKm(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		a, b *detail.LatLng
+		want float64
+	}{
+		{"same point", &detail.LatLng{Latitude: 47.6, Longitude: -122.3}, &detail.LatLng{Latitude: 47.6, Longitude: -122.3}, 0},
+		{"along the equator", &detail.LatLng{Latitude: 0, Longitude: 10}, &detail.LatLng{Latitude: 0, Longitude: 11}, kmPerDegree},
+		{"across the antimeridian", &detail.LatLng{Latitude: 0, Longitude: 179.5}, &detail.LatLng{Latitude: 0, Longitude: -179.5}, kmPerDegree},
+		{"along a meridian", &detail.LatLng{Latitude: 10, Longitude: 30}, &detail.LatLng{Latitude: 12, Longitude: 30}, 2 * kmPerDegree},
+		{"over the pole", &detail.LatLng{Latitude: 89.9, Longitude: 0}, &detail.LatLng{Latitude: 89.9, Longitude: 180}, 0.2 * kmPerDegree},
+		{"pole to pole", &detail.LatLng{Latitude: 90}, &detail.LatLng{Latitude: -90}, 180 * kmPerDegree},
+		{"antipodes", &detail.LatLng{Latitude: 30, Longitude: 100}, &detail.LatLng{Latitude: -30, Longitude: -80}, 180 * kmPerDegree},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := distanceKm(tc.a, tc.b); math.Abs(got-tc.want) > 1e-6*math.Max(1, tc.want) {
+				t.Fatalf("distanceKm = %v, want %v", got, tc.want)
+			}
+			if got, back := distanceKm(tc.a, tc.b), distanceKm(tc.b, tc.a); math.Abs(got-back) > 1e-9 {
+				t.Fatalf("distanceKm is not symmetric: %v and %v", got, back)
+			}
+		})
+	}
+}
+
+// destination returns the point distanceKm from a point along a great circle starting at
+// bearing, in radians clockwise from north.
+func destination(latitude, longitude, distanceKm, bearing float64) *detail.LatLng {
+	lat1, lng1 := latitude*math.Pi/180, longitude*math.Pi/180
+	angle := distanceKm / earthRadiusKm
+	lat2 := math.Asin(math.Sin(lat1)*math.Cos(angle) + math.Cos(lat1)*math.Sin(angle)*math.Cos(bearing))
+	lng2 := lng1 + math.Atan2(math.Sin(bearing)*math.Sin(angle)*math.Cos(lat1), math.Cos(angle)-math.Sin(lat1)*math.Sin(lat2))
+	lng := math.Mod(lng2*180/math.Pi+540, 360) - 180
+	return &detail.LatLng{Latitude: lat2 * 180 / math.Pi, Longitude: lng}
+}
+
+func TestGeohashCoverMissesNoPointInRadius(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		lat, lng float64
+		radiusKm float64
+		wantScan bool // the circle cannot be covered by cells, so every restaurant is checked
+	}{
+		{"within a cell", 47.6062, -122.3321, 0.002, false},
+		{"a radius larger than a cell", 47.6062, -122.3321, 0.5, false},
+		{"a radius of many cells", 47.6062, -122.3321, 40, false},
+		{"a radius of a continent", 10, 20, 1500, false},
+		{"east of the antimeridian", 0.01, 179.999, 5, false},
+		{"west of the antimeridian", -17.7, -179.99, 50, false},
+		{"on the antimeridian", 65, 180, 200, false},
+		{"far north", 85, 45, 100, false},
+		{"near the north pole", 89, -30, 20, false},
+		{"near the south pole", -88.5, 170, 50, false},
+		{"near the pole, wider than any cell", 89.9, -30, 10, true},
+		{"reaching the north pole", 89.9, 0, 50, true},
+		{"reaching the south pole", -89, 0, 200, true},
+		{"most of the Earth", 0, 0, 15000, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cells := geohashCover(tc.lat, tc.lng, tc.radiusKm)
+			if cells == nil {
+				if !tc.wantScan {
+					t.Fatal("geohashCover = nil, want cells")
+				}
+				return
+			}
+			if tc.wantScan {
+				t.Fatalf("geohashCover = %v, want nil", cells)
+			}
+			if len(cells) > 9 {
+				t.Fatalf("geohashCover returned %d cells, want at most 9", len(cells))
+			}
+			for _, cell := range cells {
+				if len(cell) != len(cells[0]) {
+					t.Fatalf("geohashCover returned cells of several precisions: %v", cells)
+				}
+			}
+
+			covered := func(p *detail.LatLng) bool {
+				hash := geohash(p.GetLatitude(), p.GetLongitude(), geohashPrecision)
+				for _, cell := range cells {
+					if strings.HasPrefix(hash, cell) {
+						return true
+					}
+				}
+				return false
+			}
+			center := &detail.LatLng{Latitude: tc.lat, Longitude: tc.lng}
+			if !covered(center) {
+				t.Fatalf("the center is outside the cells %v", cells)
+			}
+			r := rand.New(rand.NewSource(1))
+			for n := 0; n < 2000; n++ {
+				distance := tc.radiusKm * math.Sqrt(r.Float64())
+				if n%4 == 0 {
+					// the edge of the circle is where a cover is most likely to fall short
+					distance = tc.radiusKm * (1 - 1e-9)
+				}
+				p := destination(tc.lat, tc.lng, distance, r.Float64()*2*math.Pi)
+				if d := distanceKm(center, p); d > tc.radiusKm {
+					continue
+				}
+				if !covered(p) {
+					t.Fatalf("(%v, %v), %.3f km away, is outside the cells %v", p.GetLatitude(), p.GetLongitude(), distanceKm(center, p), cells)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,233 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	// register the formats photos can be in with image.DecodeConfig
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"net/http"
+
+	"gitlab.cs.washington.edu/syslab/cse453-welp/proto/detail"
+	"gitlab.cs.washington.edu/syslab/cse453-welp/proto/mydatabase"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	maxPhotoSize        = 10 << 20  // bytes of a photo
+	maxRestaurantPhotos = 100       // photos of a restaurant
+	photoChunkSize      = 256 << 10 // bytes of a photo per database record, and per DownloadPhoto message
+	photoScanLimit      = 8         // chunks of a photo read per ScanRecords call, to keep its messages small
+	photoSniffLength    = 512       // bytes of a photo its content type is detected from
+	photoFormMemory     = 1 << 20   // bytes of an uploaded form the frontend keeps in memory, spooling the rest to disk
+	photoFormOverhead   = 64 << 10  // bytes of an uploaded form besides its photo
+)
+
+// errPhotoUploaded aborts adding a photo to a restaurant that already has it.
+var errPhotoUploaded = errors.New("photo already uploaded")
+
+// PhotoNamespace returns the namespace the photos of the restaurants whose details are kept
+// in namespace are kept in. Photos are kept apart from the details, so that scans and
+// indexes of the details only see details.
+func PhotoNamespace(namespace string) string {
+	if namespace == "" {
+		return "photos"
+	}
+	return namespace + "-photos"
+}
+
+// photoChunkKey returns the key of a chunk of a photo. The chunks of a photo sort in order,
+// under a prefix of their own.
+func photoChunkKey(photoID string, i int) string {
+	return fmt.Sprintf("%s/%06d", photoID, i)
+}
+
+// checkPhotoType checks that the start of a photo is that of an image in a format photos
+// can be in.
+func checkPhotoType(data []byte) error {
+	switch contentType := http.DetectContentType(data); contentType {
+	case "image/gif", "image/jpeg", "image/png":
+		return nil
+	default:
+		return status.Errorf(codes.InvalidArgument, "Unsupported content type %s: photos must be GIF, JPEG or PNG images", contentType)
+	}
+}
+
+// photoMetadata returns the metadata of a photo's content, which must be an image in one
+// of the formats photos can be in.
+func photoMetadata(data []byte) (*detail.PhotoMetadata, error) {
+	if len(data) == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "Missing photo")
+	}
+	if err := checkPhotoType(data); err != nil {
+		return nil, err
+	}
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "Invalid image: %v", err)
+	}
+	sum := sha256.Sum256(data)
+	return &detail.PhotoMetadata{
+		PhotoId:     hex.EncodeToString(sum[:]),
+		ContentType: "image/" + format,
+		Width:       int32(config.Width),
+		Height:      int32(config.Height),
+		Size:        int64(len(data)),
+	}, nil
+}
+
+// UploadPhoto adds a photo to a restaurant. The photo is read from the stream in full, and
+// rejected if it is too large or not an image, before any of it is stored.
+func (s *Detail) UploadPhoto(stream detail.DetailService_UploadPhotoServer) error {
+	ctx := stream.Context()
+	var first *detail.UploadPhotoRequest
+	var data []byte
+	for {
+		msg, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if first == nil {
+			first = msg
+		}
+		if len(data)+len(msg.GetData()) > maxPhotoSize {
+			return status.Errorf(codes.InvalidArgument, "Photo is larger than the %d byte limit", maxPhotoSize)
+		}
+		// reject what is not an image without waiting for the rest of it
+		if len(data) < photoSniffLength && len(data)+len(msg.GetData()) >= photoSniffLength {
+			if err := checkPhotoType(append(data, msg.GetData()...)); err != nil {
+				return err
+			}
+		}
+		data = append(data, msg.GetData()...)
+	}
+	if first == nil {
+		return status.Errorf(codes.InvalidArgument, "Missing photo")
+	}
+
+	photo, err := photoMetadata(data)
+	if err != nil {
+		return err
+	}
+	restaurantID, err := s.restaurantID(ctx, first.GetRestaurantId(), first.GetRestaurantName())
+	if err != nil {
+		return err
+	}
+	// a photo is only stored for a restaurant that exists
+	if _, err := s.GetDetail(ctx, &detail.GetDetailRequest{RestaurantId: restaurantID}); err != nil {
+		return err
+	}
+	if err := s.storePhoto(ctx, photo, data); err != nil {
+		return err
+	}
+
+	photo.Caption, photo.UploadedAt = first.GetCaption(), timestamppb.Now()
+	_, err = s.editDetail(ctx, restaurantID, nil, func(current *detail.GetDetailResponse) (*detail.GetDetailResponse, error) {
+		if current == nil {
+			return nil, status.Errorf(codes.NotFound, "Item with Key: %s does not exist", restaurantID)
+		}
+		for _, p := range current.GetPhotos() {
+			if p.GetPhotoId() == photo.GetPhotoId() {
+				// keep the caption and time it was first uploaded with
+				photo = p
+				return nil, errPhotoUploaded
+			}
+		}
+		if len(current.GetPhotos()) >= maxRestaurantPhotos {
+			return nil, status.Errorf(codes.ResourceExhausted, "Restaurant %s has the most photos allowed, %d", restaurantID, maxRestaurantPhotos)
+		}
+		next := proto.Clone(current).(*detail.GetDetailResponse)
+		next.Photos = append(next.Photos, photo)
+		return next, nil
+	})
+	if err != nil && err != errPhotoUploaded {
+		return err
+	}
+	return stream.SendAndClose(&detail.UploadPhotoResponse{Photo: photo})
+}
+
+// storePhoto writes the content of a photo in chunks, followed by its metadata, unless a
+// photo with the same content is stored already. Since the metadata is written last, a
+// photo whose metadata can be read is complete.
+func (s *Detail) storePhoto(ctx context.Context, photo *detail.PhotoMetadata, data []byte) error {
+	_, err := s.photoDatabaseClient.GetRecord(ctx, &mydatabase.GetRecordRequest{Key: photo.GetPhotoId()})
+	switch {
+	case err == nil:
+		return nil
+	case status.Code(err) != codes.NotFound:
+		return status.Errorf(codes.Internal, "Failed to read data storage: %v", err)
+	}
+
+	write := func(key string, value []byte) error {
+		_, err := s.photoDatabaseClient.SetRecord(ctx, &mydatabase.SetRecordRequest{Record: &mydatabase.DatabaseRecord{Key: key, Value: value}})
+		if status.Code(err) == codes.ResourceExhausted {
+			return err
+		}
+		if err != nil {
+			return status.Errorf(codes.Internal, "Failed to write data storage: %v", err)
+		}
+		return nil
+	}
+	for i := 0; i*photoChunkSize < len(data); i++ {
+		end := (i + 1) * photoChunkSize
+		if end > len(data) {
+			end = len(data)
+		}
+		if err := write(photoChunkKey(photo.GetPhotoId(), i), data[i*photoChunkSize:end]); err != nil {
+			return err
+		}
+	}
+	metadata, err := proto.Marshal(photo)
+	if err != nil {
+		return status.Errorf(codes.Internal, "Failed to serialize data")
+	}
+	return write(photo.GetPhotoId(), metadata)
+}
+
+// DownloadPhoto streams a photo: its metadata, then its content a chunk at a time.
+func (s *Detail) DownloadPhoto(req *detail.DownloadPhotoRequest, stream detail.DetailService_DownloadPhotoServer) error {
+	ctx := stream.Context()
+	if req.GetPhotoId() == "" {
+		return status.Errorf(codes.InvalidArgument, "Missing photo id")
+	}
+	getRecordResponse, err := s.photoDatabaseClient.GetRecord(ctx, &mydatabase.GetRecordRequest{Key: req.GetPhotoId()})
+	if status.Code(err) == codes.NotFound {
+		return status.Errorf(codes.NotFound, "Photo %s does not exist", req.GetPhotoId())
+	}
+	if err != nil {
+		return status.Errorf(codes.Internal, "Failed to read data storage: %v", err)
+	}
+	photo := &detail.PhotoMetadata{}
+	if err := proto.Unmarshal(getRecordResponse.GetRecord().GetValue(), photo); err != nil {
+		return status.Errorf(codes.Internal, "Failed to deserialize data")
+	}
+
+	var sent int64
+	metadata := photo
+	err = scanRecords(ctx, s.photoDatabaseClient, &mydatabase.ScanRecordsRequest{Prefix: photo.GetPhotoId() + "/", Limit: photoScanLimit}, func(record *mydatabase.DatabaseRecord) error {
+		sent += int64(len(record.GetValue()))
+		msg := &detail.DownloadPhotoResponse{Data: record.GetValue(), Metadata: metadata}
+		metadata = nil
+		return stream.Send(msg)
+	})
+	if err != nil {
+		return status.Errorf(codes.Internal, "Failed to read photo %s: %v", photo.GetPhotoId(), err)
+	}
+	if sent != photo.GetSize() {
+		return status.Errorf(codes.DataLoss, "Photo %s is %d bytes, but %d were found", photo.GetPhotoId(), photo.GetSize(), sent)
+	}
+	return nil
+}
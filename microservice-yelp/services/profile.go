@@ -0,0 +1,153 @@
+package services
+
+import (
+	"net/mail"
+	"net/url"
+	"strings"
+
+	"gitlab.cs.washington.edu/syslab/cse453-welp/proto/detail"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	maxTags          = 20  // tags of a restaurant
+	maxTagLength     = 40  // characters of a tag
+	maxMenuSections  = 50  // sections of a restaurant's menu
+	maxMenuItems     = 500 // items of a restaurant's menu, across its sections
+	minPhoneDigits   = 7
+	maxPhoneDigits   = 15 // the most an international number has, per E.164
+	maxContactLength = 256
+)
+
+// normalizeTags returns the tags of a restaurant trimmed and in lower case, without
+// duplicates, in the order they were first given.
+func normalizeTags(tags []string) ([]string, error) {
+	seen := make(map[string]bool)
+	var normalized []string
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" {
+			return nil, status.Errorf(codes.InvalidArgument, "Invalid tag: must not be empty")
+		}
+		if len([]rune(tag)) > maxTagLength {
+			return nil, status.Errorf(codes.InvalidArgument, "Invalid tag %q: must be at most %d characters", tag, maxTagLength)
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			normalized = append(normalized, tag)
+		}
+	}
+	if len(normalized) > maxTags {
+		return nil, status.Errorf(codes.InvalidArgument, "Too many tags: at most %d are allowed", maxTags)
+	}
+	return normalized, nil
+}
+
+// checkPriceTier checks that a price tier is one of those defined.
+func checkPriceTier(tier detail.PriceTier) error {
+	if _, ok := detail.PriceTier_name[int32(tier)]; !ok {
+		return status.Errorf(codes.InvalidArgument, "Invalid price tier %d", tier)
+	}
+	return nil
+}
+
+// checkContact checks that the contact info of a restaurant holds a phone number, an email
+// address and a web site, where it holds any.
+func checkContact(c *detail.ContactInfo) error {
+	for _, field := range []string{c.GetPhone(), c.GetEmail(), c.GetWebsite()} {
+		if len(field) > maxContactLength {
+			return status.Errorf(codes.InvalidArgument, "Invalid contact info %q: must be at most %d bytes", field, maxContactLength)
+		}
+	}
+	if phone := c.GetPhone(); phone != "" {
+		digits := 0
+		for i, r := range phone {
+			switch {
+			case r >= '0' && r <= '9':
+				digits++
+			case r == '+' && i == 0:
+			case r == ' ' || r == '-' || r == '.' || r == '(' || r == ')':
+			default:
+				return status.Errorf(codes.InvalidArgument, "Invalid phone number %q", phone)
+			}
+		}
+		if digits < minPhoneDigits || digits > maxPhoneDigits {
+			return status.Errorf(codes.InvalidArgument, "Invalid phone number %q: must have %d to %d digits", phone, minPhoneDigits, maxPhoneDigits)
+		}
+	}
+	if email := c.GetEmail(); email != "" {
+		if addr, err := mail.ParseAddress(email); err != nil || addr.Address != email {
+			return status.Errorf(codes.InvalidArgument, "Invalid email address %q", email)
+		}
+	}
+	if website := c.GetWebsite(); website != "" {
+		if u, err := url.Parse(website); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return status.Errorf(codes.InvalidArgument, "Invalid website %q: must be an http or https URL", website)
+		}
+	}
+	return nil
+}
+
+// checkMenu checks that every section and item of a menu is named, and that its items have
+// prices in a currency.
+func checkMenu(m *detail.Menu) error {
+	if len(m.GetSections()) > maxMenuSections {
+		return status.Errorf(codes.InvalidArgument, "Too many menu sections: at most %d are allowed", maxMenuSections)
+	}
+	items := 0
+	for _, section := range m.GetSections() {
+		if strings.TrimSpace(section.GetName()) == "" {
+			return status.Errorf(codes.InvalidArgument, "Invalid menu section: must be named")
+		}
+		for _, item := range section.GetItems() {
+			if strings.TrimSpace(item.GetName()) == "" {
+				return status.Errorf(codes.InvalidArgument, "Invalid item in menu section %q: must be named", section.GetName())
+			}
+			if item.GetPrice() < 0 {
+				return status.Errorf(codes.InvalidArgument, "Invalid price %d of %q: must not be negative", item.GetPrice(), item.GetName())
+			}
+		}
+		items += len(section.GetItems())
+	}
+	if items > maxMenuItems {
+		return status.Errorf(codes.InvalidArgument, "Too many menu items: at most %d are allowed", maxMenuItems)
+	}
+	if items > 0 && !isCurrencyCode(m.GetCurrencyCode()) {
+		return status.Errorf(codes.InvalidArgument, "Invalid currency code %q: must be three capital letters, such as USD", m.GetCurrencyCode())
+	}
+	return nil
+}
+
+// isCurrencyCode reports whether code has the form of an ISO 4217 currency code.
+func isCurrencyCode(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+	for _, r := range code {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// checkProfile checks the profile of a restaurant beyond its name, location, style and
+// capacity, normalizing its tags.
+func checkProfile(d *detail.GetDetailResponse) error {
+	tags, err := normalizeTags(d.GetTags())
+	if err != nil {
+		return err
+	}
+	d.Tags = tags
+	if err := checkOpeningHours(d.GetHours()); err != nil {
+		return err
+	}
+	if err := checkPriceTier(d.GetPriceTier()); err != nil {
+		return err
+	}
+	if err := checkContact(d.GetContact()); err != nil {
+		return err
+	}
+	return checkMenu(d.GetMenu())
+}
@@ -0,0 +1,1032 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"gitlab.cs.washington.edu/syslab/cse453-welp/proto/mydatabase"
+)
+
+const (
+	quorumTimeout         = 2 * time.Second // how long replicas that miss a quorum get to finish in the background
+	hintedHandoffInterval = time.Second     // how often writes missed by a replica are retried
+
+	// tombstoneGracePeriod is how old a deletion must be before the tombstone every replica
+	// holds for it is removed. Hints older than this are dropped rather than handed off, so
+	// that a version the tombstone superseded cannot come back once it is gone; a replica
+	// down for longer is brought up to date by read repair and anti-entropy instead.
+	tombstoneGracePeriod = time.Hour
+
+	// siblingSetMagic prefixes stored SiblingSets. Protobuf encodings never start with a
+	// zero byte, so records written without a quorum client cannot be mistaken for one.
+	siblingSetMagic = "\x00siblings"
+)
+
+// QuorumOptions configures a QuorumClient.
+type QuorumOptions struct {
+	// Replicas are the addresses of the databases every record is written to. Leave them
+	// empty to use a single database directly.
+	Replicas []string
+
+	// W and R are how many replicas must acknowledge a write, or answer a read, for it to
+	// succeed. Reads see the latest successful write when W + R exceeds the number of
+	// replicas. Zero means a majority.
+	W, R int
+
+	// Siblings makes reads return every version of a record written concurrently by
+	// different clients, instead of only the one written last.
+	Siblings bool
+
+	// Namespace is the namespace of the replicas the records are kept in.
+	Namespace string
+}
+
+// HintNamespace returns the namespace the writes replicas missed are kept in until they are
+// handed off, for records kept in namespace.
+func HintNamespace(namespace string) string {
+	if namespace == "" {
+		return "hints"
+	}
+	return namespace + "-hints"
+}
+
+// QuorumClient replicates records across several databases, Dynamo style. Every write is
+// sent to all replicas and succeeds once W of them acknowledge it; every read asks all
+// replicas and returns once R of them answer.
+//
+// Records are stored as a set of siblings, versions tagged with a vector clock whose
+// entries are hybrid logical clock timestamps. A write supersedes the versions its read
+// returned; replicas merge each write into what they hold, keeping the versions no other
+// version supersedes, so writes from different clients that did not see each other are
+// kept side by side until a later write resolves them. Reads merge the replicas' siblings,
+// pick the one written last unless Siblings is set, and repair the replicas that missed a
+// version. A write a replica misses is stored as a hint on a replica that took it, in the
+// HintNamespace, and handed off by any client of the replicas once the replica is back;
+// hints do not count towards W. Tombstones every replica holds are removed once they are
+// older than tombstoneGracePeriod.
+//
+// ScanRecords and QueryIndex gather the records and keys of every replica, failing unless R
+// of them answer. GetHistory, UpdateRecord and transactions are refused, as they do not
+// understand the stored siblings. The other RPCs are served by the first replica as is.
+type QuorumClient struct {
+	mydatabase.DatabaseServiceClient
+
+	name       string // identifies the client's writes in vector clocks
+	addrs      []string
+	replicas   []mydatabase.DatabaseServiceClient
+	hintStores []mydatabase.DatabaseServiceClient // the replicas, in the namespace hints are kept in
+	w, r       int
+	siblings   bool
+	clock      hybridClock
+
+	stop chan struct{}
+}
+
+// NewQuorumClient connects to the replicas and starts handing off missed writes. name must
+// be unique among the clients writing to the same replicas.
+func NewQuorumClient(name string, options QuorumOptions) *QuorumClient {
+	n := len(options.Replicas)
+	c := &QuorumClient{
+		name:     name,
+		addrs:    options.Replicas,
+		w:        options.W,
+		r:        options.R,
+		siblings: options.Siblings,
+		stop:     make(chan struct{}),
+	}
+	if c.w == 0 {
+		c.w = n/2 + 1
+	}
+	if c.r == 0 {
+		c.r = n/2 + 1
+	}
+	if n == 0 || c.w < 1 || c.w > n || c.r < 1 || c.r > n {
+		log.Fatalf("invalid quorum: W=%d and R=%d must be between 1 and the %d replicas", c.w, c.r, n)
+	}
+	for _, addr := range options.Replicas {
+		conn := mydatabase.NewDatabaseServiceClient(dial(addr))
+		c.replicas = append(c.replicas, NewNamespacedClient(conn, options.Namespace))
+		c.hintStores = append(c.hintStores, NewNamespacedClient(conn, HintNamespace(options.Namespace)))
+	}
+	c.DatabaseServiceClient = c.replicas[0]
+	go c.handoff()
+	return c
+}
+
+// Close stops handing off missed writes. Hints not delivered yet are kept by the replicas
+// for other clients, or this one once it is restarted, to deliver.
+func (c *QuorumClient) Close() {
+	close(c.stop)
+}
+
+// GetRecord reads the record from R replicas. Transactions and read timestamps are not supported.
+func (c *QuorumClient) GetRecord(ctx context.Context, req *mydatabase.GetRecordRequest, opts ...grpc.CallOption) (*mydatabase.GetRecordResponse, error) {
+	msg := &mydatabase.GetRecordResponse{}
+	if req.GetTransactionId() != 0 || req.GetReadTimestamp() != 0 {
+		return msg, status.Errorf(codes.InvalidArgument, "Quorum reads do not support transactions or read timestamps")
+	}
+	key := req.GetKey()
+	siblings, err := c.read(ctx, key)
+	if err != nil {
+		return msg, err
+	}
+	if msg.CausalContext, err = proto.Marshal(causalContext(siblings)); err != nil {
+		return msg, status.Errorf(codes.Internal, "Failed to encode causal context: %v", err)
+	}
+
+	var live []*mydatabase.Sibling
+	for _, sibling := range c.resolve(siblings) {
+		if !sibling.GetDeleted() {
+			live = append(live, sibling)
+		}
+	}
+	if len(live) == 0 {
+		return msg, status.Errorf(codes.NotFound, "Item with Key: %s does not exist", key)
+	}
+	msg.Record = &mydatabase.DatabaseRecord{Key: key, Value: lastWriter(live).GetValue()}
+	if len(live) > 1 {
+		for _, sibling := range live {
+			msg.Siblings = append(msg.Siblings, &mydatabase.DatabaseRecord{Key: key, Value: sibling.GetValue()})
+		}
+	}
+	return msg, nil
+}
+
+// SetRecord writes the record to W replicas. Transactions are not supported.
+func (c *QuorumClient) SetRecord(ctx context.Context, req *mydatabase.SetRecordRequest, opts ...grpc.CallOption) (*mydatabase.SetRecordResponse, error) {
+	msg := &mydatabase.SetRecordResponse{}
+	if req.GetTransactionId() != 0 || req.GetMergeSiblings() {
+		return msg, status.Errorf(codes.InvalidArgument, "Quorum writes do not support transactions or merging siblings")
+	}
+	record := req.GetRecord()
+	if err := c.write(ctx, record.GetKey(), record.GetValue(), false, req.GetCausalContext()); err != nil {
+		return msg, err
+	}
+	msg.Success = true
+	return msg, nil
+}
+
+// DeleteRecord writes a tombstone for the record to W replicas. Transactions and
+// conditional deletes are not supported.
+func (c *QuorumClient) DeleteRecord(ctx context.Context, req *mydatabase.DeleteRecordRequest, opts ...grpc.CallOption) (*mydatabase.DeleteRecordResponse, error) {
+	msg := &mydatabase.DeleteRecordResponse{}
+	if req.GetTransactionId() != 0 || req.GetExpectedValue() != nil {
+		return msg, status.Errorf(codes.InvalidArgument, "Quorum writes do not support transactions or conditional deletes")
+	}
+	if err := c.write(ctx, req.GetKey(), nil, true, req.GetCausalContext()); err != nil {
+		return msg, err
+	}
+	msg.Success = true
+	return msg, nil
+}
+
+func (c *QuorumClient) GetHistory(ctx context.Context, req *mydatabase.GetHistoryRequest, opts ...grpc.CallOption) (*mydatabase.GetHistoryResponse, error) {
+	return &mydatabase.GetHistoryResponse{}, status.Errorf(codes.Unimplemented, "Quorum reads do not support record history")
+}
+
+func (c *QuorumClient) UpdateRecord(ctx context.Context, req *mydatabase.UpdateRecordRequest, opts ...grpc.CallOption) (*mydatabase.UpdateRecordResponse, error) {
+	return &mydatabase.UpdateRecordResponse{}, status.Errorf(codes.Unimplemented, "Quorum writes do not support UpdateRecord; use SetRecord")
+}
+
+func (c *QuorumClient) BeginTransaction(ctx context.Context, req *mydatabase.BeginTransactionRequest, opts ...grpc.CallOption) (*mydatabase.BeginTransactionResponse, error) {
+	return &mydatabase.BeginTransactionResponse{}, status.Errorf(codes.Unimplemented, "Quorum clients do not support transactions")
+}
+
+func (c *QuorumClient) CommitTransaction(ctx context.Context, req *mydatabase.CommitTransactionRequest, opts ...grpc.CallOption) (*mydatabase.CommitTransactionResponse, error) {
+	return &mydatabase.CommitTransactionResponse{}, status.Errorf(codes.Unimplemented, "Quorum clients do not support transactions")
+}
+
+func (c *QuorumClient) AbortTransaction(ctx context.Context, req *mydatabase.AbortTransactionRequest, opts ...grpc.CallOption) (*mydatabase.AbortTransactionResponse, error) {
+	return &mydatabase.AbortTransactionResponse{}, status.Errorf(codes.Unimplemented, "Quorum clients do not support transactions")
+}
+
+// ScanRecords scans every replica, merging the siblings each holds for a key and returning
+// the version written last. The scan fails once fewer than R replicas are still answering.
+// Deleted records are skipped and do not count towards the limit; the tombstones found on
+// every replica are removed once they are old enough.
+func (c *QuorumClient) ScanRecords(ctx context.Context, req *mydatabase.ScanRecordsRequest, opts ...grpc.CallOption) (mydatabase.DatabaseService_ScanRecordsClient, error) {
+	limit := int(req.GetLimit())
+	if limit < 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "Invalid scan limit: %d", limit)
+	}
+	if limit == 0 {
+		limit = defaultScanLimit
+	}
+	if limit > maxScanLimit {
+		limit = maxScanLimit
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	scan := &quorumScan{client: c, ctx: ctx, cancel: cancel, reverse: req.GetReverse(), limit: limit}
+	for _, replica := range c.replicas {
+		scan.cursors = append(scan.cursors, &scanCursor{
+			client: replica,
+			req:    proto.Clone(req).(*mydatabase.ScanRecordsRequest),
+			opts:   opts,
+		})
+	}
+	return scan, nil
+}
+
+// QueryIndex queries every replica's indexes and merges the keys of the first R to answer
+// in index order. Replicas index the siblings they hold, so a key may be returned for a
+// version a quorum read no longer returns; callers check the records they read.
+func (c *QuorumClient) QueryIndex(ctx context.Context, req *mydatabase.QueryIndexRequest, opts ...grpc.CallOption) (*mydatabase.QueryIndexResponse, error) {
+	limit := int(req.GetLimit())
+	if limit == 0 {
+		limit = defaultScanLimit
+	}
+	if limit > maxScanLimit {
+		limit = maxScanLimit
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type queryReply struct {
+		msg *mydatabase.QueryIndexResponse
+		err error
+	}
+	pending := make(chan queryReply, len(c.replicas))
+	for _, replica := range c.replicas {
+		replica := replica
+		go func() {
+			msg, err := replica.QueryIndex(ctx, req, opts...)
+			if err == nil && len(msg.GetTokens()) != len(msg.GetKeys()) {
+				err = status.Errorf(codes.Internal, "Replica did not return the index positions of its keys")
+			}
+			pending <- queryReply{msg, err}
+		}()
+	}
+
+	var replies []*mydatabase.QueryIndexResponse
+	var lastErr error
+	for failed := 0; len(replies) < c.r && failed <= len(c.replicas)-c.r; {
+		select {
+		case reply := <-pending:
+			if reply.err != nil {
+				// a query the replicas reject fails on all of them
+				if code := status.Code(reply.err); code == codes.InvalidArgument {
+					return &mydatabase.QueryIndexResponse{}, reply.err
+				}
+				failed++
+				lastErr = reply.err
+				continue
+			}
+			replies = append(replies, reply.msg)
+		case <-ctx.Done():
+			return &mydatabase.QueryIndexResponse{}, status.FromContextError(ctx.Err()).Err()
+		}
+	}
+	if len(replies) < c.r {
+		return &mydatabase.QueryIndexResponse{}, status.Errorf(codes.Unavailable, "Query reached %d of the %d replicas required: %v", len(replies), c.r, lastErr)
+	}
+	return mergeQueryReplies(replies, limit)
+}
+
+// mergeQueryReplies merges the keys replicas returned for a query in index order. A replica
+// with more keys to return has not been heard from past its last one, so no key beyond it
+// is returned.
+func mergeQueryReplies(replies []*mydatabase.QueryIndexResponse, limit int) (*mydatabase.QueryIndexResponse, error) {
+	type position struct {
+		entry, key, token string
+	}
+	var positions []position
+	bound, bounded := "", false
+	for _, reply := range replies {
+		for i, key := range reply.GetKeys() {
+			token := reply.GetTokens()[i]
+			entry, err := decodeScanToken(token, false)
+			if err != nil {
+				return &mydatabase.QueryIndexResponse{}, status.Errorf(codes.Internal, "Replica returned an invalid index position: %v", err)
+			}
+			positions = append(positions, position{entry, key, token})
+		}
+		if reply.GetContinuationToken() != "" && len(reply.GetKeys()) > 0 {
+			last := positions[len(positions)-1].entry
+			if !bounded || last < bound {
+				bound, bounded = last, true
+			}
+		}
+	}
+	sort.SliceStable(positions, func(i, j int) bool {
+		return positions[i].entry < positions[j].entry
+	})
+
+	msg := &mydatabase.QueryIndexResponse{}
+	seen := make(map[string]bool)
+	more := bounded
+	for _, p := range positions {
+		if bounded && p.entry > bound {
+			break
+		}
+		if seen[p.key] {
+			continue
+		}
+		if len(msg.Keys) == limit {
+			more = true
+			break
+		}
+		seen[p.key] = true
+		msg.Keys = append(msg.Keys, p.key)
+		msg.Tokens = append(msg.Tokens, p.token)
+	}
+	if more && len(msg.Tokens) > 0 {
+		msg.ContinuationToken = msg.Tokens[len(msg.Tokens)-1]
+	}
+	return msg, nil
+}
+
+// quorumReply is a replica's answer to a quorum read.
+type quorumReply struct {
+	replica  int
+	value    []byte // as stored, or nil if the replica holds no record
+	siblings []*mydatabase.Sibling
+	err      error
+}
+
+// read returns the merged siblings of the record from at least R replicas. Replicas that
+// answer late, or hold out-of-date siblings, are repaired in the background.
+func (c *QuorumClient) read(ctx context.Context, key string) ([]*mydatabase.Sibling, error) {
+	pending := make(chan quorumReply, len(c.replicas))
+	for i, replica := range c.replicas {
+		i, replica := i, replica
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), quorumTimeout)
+			defer cancel()
+
+			reply := quorumReply{replica: i}
+			resp, err := replica.GetRecord(ctx, &mydatabase.GetRecordRequest{Key: key})
+			switch {
+			case status.Code(err) == codes.NotFound:
+			case err != nil:
+				reply.err = err
+			default:
+				reply.value = resp.GetRecord().GetValue()
+				reply.siblings, reply.err = decodeSiblings(reply.value)
+			}
+			pending <- reply
+		}()
+	}
+
+	var replies []quorumReply
+	var sets [][]*mydatabase.Sibling
+	var lastErr error
+	for failed := 0; len(sets) < c.r && failed <= len(c.replicas)-c.r; {
+		select {
+		case reply := <-pending:
+			replies = append(replies, reply)
+			if reply.err != nil {
+				failed++
+				lastErr = reply.err
+				continue
+			}
+			sets = append(sets, reply.siblings)
+		case <-ctx.Done():
+			return nil, status.FromContextError(ctx.Err()).Err()
+		}
+	}
+	if len(sets) < c.r {
+		return nil, status.Errorf(codes.Unavailable, "Read reached %d of the %d replicas required: %v", len(sets), c.r, lastErr)
+	}
+
+	go c.repair(key, replies, pending)
+	return mergeSiblings(sets...), nil
+}
+
+// repair waits for the replicas that had not answered a read, then writes the merged
+// siblings back to every replica that answered without one of them. A record every replica
+// holds only an old tombstone for is removed instead.
+func (c *QuorumClient) repair(key string, replies []quorumReply, pending <-chan quorumReply) {
+	for len(replies) < len(c.replicas) {
+		replies = append(replies, <-pending)
+	}
+
+	values := make(map[int][]byte)
+	var sets [][]*mydatabase.Sibling
+	for _, reply := range replies {
+		if reply.err == nil {
+			sets = append(sets, reply.siblings)
+			if reply.value != nil {
+				values[reply.replica] = reply.value
+			}
+		}
+	}
+	if len(sets) == len(c.replicas) && collectable(sets) {
+		c.collect(key, values)
+		return
+	}
+	merged := c.resolve(mergeSiblings(sets...))
+	if len(merged) == 0 {
+		return
+	}
+	req := &mydatabase.SetRecordRequest{
+		Record:        &mydatabase.DatabaseRecord{Key: key, Value: encodeSiblings(merged)},
+		MergeSiblings: true,
+	}
+	for _, reply := range replies {
+		if reply.err != nil || containsSiblings(reply.siblings, merged) {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), quorumTimeout)
+		if _, err := c.replicas[reply.replica].SetRecord(ctx, req); err != nil {
+			log.Printf("quorum: read repair of %s on replica %d failed: %v", key, reply.replica, err)
+		}
+		cancel()
+	}
+}
+
+// collectable reports whether the sibling sets every replica holds for a record are all
+// tombstones older than tombstoneGracePeriod, and at least one replica holds one.
+func collectable(sets [][]*mydatabase.Sibling) bool {
+	cutoff := uint64(time.Now().Add(-tombstoneGracePeriod).UnixNano())
+	found := false
+	for _, set := range sets {
+		for _, sibling := range set {
+			if !sibling.GetDeleted() || sibling.GetTimestamp() >= cutoff {
+				return false
+			}
+			found = true
+		}
+	}
+	return found
+}
+
+// collect removes a record from the replicas holding only a tombstone for it, the stored
+// value of which is given for each. A replica written to since keeps the record.
+func (c *QuorumClient) collect(key string, values map[int][]byte) {
+	for i, value := range values {
+		ctx, cancel := context.WithTimeout(context.Background(), quorumTimeout)
+		_, err := c.replicas[i].DeleteRecord(ctx, &mydatabase.DeleteRecordRequest{Key: key, ExpectedValue: value})
+		cancel()
+		if err != nil && status.Code(err) != codes.FailedPrecondition {
+			log.Printf("quorum: removing the tombstone of %s from replica %d failed: %v", key, i, err)
+		}
+	}
+}
+
+// write stores a new version of the record, superseding the versions in causal, or the
+// ones a quorum read returns if causal is empty, on at least W replicas. Replicas the write
+// fails on are handed it later.
+func (c *QuorumClient) write(ctx context.Context, key string, value []byte, deleted bool, causal []byte) error {
+	seen := &mydatabase.VectorClock{}
+	if len(causal) > 0 {
+		if err := proto.Unmarshal(causal, seen); err != nil {
+			return status.Errorf(codes.InvalidArgument, "Invalid causal context: %v", err)
+		}
+	} else {
+		siblings, err := c.read(ctx, key)
+		if err != nil {
+			return err
+		}
+		seen = causalContext(siblings)
+	}
+
+	// the new version must be timestamped after every version it supersedes
+	entries := make(map[string]uint64, len(seen.GetEntries())+1)
+	for name, ts := range seen.GetEntries() {
+		c.clock.observe(ts)
+		entries[name] = ts
+	}
+	ts := c.clock.now()
+	entries[c.name] = ts
+	sibling := &mydatabase.Sibling{
+		Value:     value,
+		Deleted:   deleted,
+		Clock:     &mydatabase.VectorClock{Entries: entries},
+		Timestamp: ts,
+	}
+	req := &mydatabase.SetRecordRequest{
+		Record:        &mydatabase.DatabaseRecord{Key: key, Value: encodeSiblings([]*mydatabase.Sibling{sibling})},
+		MergeSiblings: true,
+	}
+
+	results := make([]error, len(c.replicas))
+	pending := make(chan error, len(c.replicas))
+	var wg sync.WaitGroup
+	for i, replica := range c.replicas {
+		i, replica := i, replica
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), quorumTimeout)
+			defer cancel()
+
+			_, err := replica.SetRecord(ctx, req)
+			results[i] = err
+			pending <- err
+		}()
+	}
+	go func() {
+		wg.Wait()
+		c.hint(key, req.Record.Value, results)
+	}()
+
+	acks := 0
+	var lastErr error
+	for failed := 0; acks < c.w && failed <= len(c.replicas)-c.w; {
+		select {
+		case err := <-pending:
+			if err != nil {
+				failed++
+				lastErr = err
+				continue
+			}
+			acks++
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		}
+	}
+	if acks < c.w {
+		return status.Errorf(codes.Unavailable, "Write reached %d of the %d replicas required: %v", acks, c.w, lastErr)
+	}
+	return nil
+}
+
+// resolve keeps only the sibling written last, unless the client returns siblings. The
+// kept version's clock covers all the others, so that it supersedes them on the replicas.
+func (c *QuorumClient) resolve(siblings []*mydatabase.Sibling) []*mydatabase.Sibling {
+	if c.siblings || len(siblings) < 2 {
+		return siblings
+	}
+	winner := proto.Clone(lastWriter(siblings)).(*mydatabase.Sibling)
+	winner.Clock = causalContext(siblings)
+	return []*mydatabase.Sibling{winner}
+}
+
+// hintKey returns the key a hint for the record with key, missed by the replica at target,
+// is kept under. Addresses hold no slash.
+func hintKey(target, key string) string {
+	return target + "/" + key
+}
+
+// hint stores the encoded siblings of a write on one of the replicas that took it, for each
+// replica results says missed it. A write no replica took needs no hint.
+func (c *QuorumClient) hint(key string, value []byte, results []error) {
+	for i, err := range results {
+		if err == nil {
+			continue
+		}
+		stored := false
+		for j := 1; j < len(c.replicas) && !stored; j++ {
+			holder := (i + j) % len(c.replicas)
+			if results[holder] != nil {
+				continue
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), quorumTimeout)
+			_, err := c.hintStores[holder].SetRecord(ctx, &mydatabase.SetRecordRequest{
+				Record:        &mydatabase.DatabaseRecord{Key: hintKey(c.addrs[i], key), Value: value},
+				MergeSiblings: true,
+			})
+			cancel()
+			if err != nil {
+				log.Printf("quorum: storing the hint for %s on replica %d failed: %v", key, holder, err)
+				continue
+			}
+			stored = true
+		}
+		if !stored && len(results) > 1 {
+			log.Printf("quorum: no replica took the hint for %s missed by replica %d", key, i)
+		}
+	}
+}
+
+// handoff periodically delivers the hints the replicas hold to the replicas that missed them.
+func (c *QuorumClient) handoff() {
+	ticker := time.NewTicker(hintedHandoffInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-ticker.C:
+			down := make(map[string]bool)
+			for j := range c.hintStores {
+				c.deliverHints(j, down)
+			}
+		}
+	}
+}
+
+// deliverHints sends the hints replica j holds to the replicas that missed them, removing
+// each once delivered. Replicas a delivery finds down, recorded in down, are skipped.
+func (c *QuorumClient) deliverHints(j int, down map[string]bool) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	targets := make(map[string]int, len(c.addrs))
+	for i, addr := range c.addrs {
+		targets[addr] = i
+	}
+	cutoff := uint64(time.Now().Add(-tombstoneGracePeriod).UnixNano())
+	delivered := 0
+	err := scanRecords(ctx, c.hintStores[j], &mydatabase.ScanRecordsRequest{Limit: maxScanLimit}, func(hint *mydatabase.DatabaseRecord) error {
+		slash := strings.IndexByte(hint.GetKey(), '/')
+		if slash < 0 {
+			return nil
+		}
+		target, key := hint.GetKey()[:slash], hint.GetKey()[slash+1:]
+		i, ok := targets[target]
+		if !ok || down[target] {
+			return nil
+		}
+		siblings, err := decodeSiblings(hint.GetValue())
+		if err != nil {
+			log.Printf("quorum: dropping the undecodable hint for %s on replica %d: %v", key, i, err)
+			siblings = nil
+		}
+		var fresh []*mydatabase.Sibling
+		for _, sibling := range siblings {
+			if sibling.GetTimestamp() >= cutoff {
+				fresh = append(fresh, sibling)
+			}
+		}
+		if len(fresh) > 0 {
+			ctx, cancel := context.WithTimeout(ctx, quorumTimeout)
+			_, err := c.replicas[i].SetRecord(ctx, &mydatabase.SetRecordRequest{
+				Record:        &mydatabase.DatabaseRecord{Key: key, Value: encodeSiblings(fresh)},
+				MergeSiblings: true,
+			})
+			cancel()
+			if code := status.Code(err); code == codes.Unavailable || code == codes.DeadlineExceeded {
+				down[target] = true
+				return nil
+			} else if err != nil {
+				log.Printf("quorum: dropping the hint for %s on replica %d: %v", key, i, err)
+			} else {
+				delivered++
+			}
+		}
+
+		// a hint merged into since is left for the next round
+		ctx, cancel := context.WithTimeout(ctx, quorumTimeout)
+		defer cancel()
+		_, err = c.hintStores[j].DeleteRecord(ctx, &mydatabase.DeleteRecordRequest{Key: hint.GetKey(), ExpectedValue: hint.GetValue()})
+		if err != nil && status.Code(err) != codes.FailedPrecondition {
+			log.Printf("quorum: removing the delivered hint for %s from replica %d failed: %v", key, j, err)
+		}
+		return nil
+	})
+	if err != nil && !down[c.addrs[j]] {
+		log.Printf("quorum: reading the hints held by replica %d failed: %v", j, err)
+	}
+	if delivered > 0 {
+		log.Printf("quorum: handed off %d missed writes held by replica %d", delivered, j)
+	}
+}
+
+// quorumScan merges the scans of every replica in key order.
+type quorumScan struct {
+	client  *QuorumClient
+	ctx     context.Context
+	cancel  context.CancelFunc
+	cursors []*scanCursor
+	reverse bool
+	limit   int
+
+	returned int    // live records returned so far
+	lastKey  string // key of the last record merged, live or not
+	done     bool
+}
+
+// scanCursor reads the records of one replica's scan, continuing it until the replica has
+// no more.
+type scanCursor struct {
+	client mydatabase.DatabaseServiceClient
+	req    *mydatabase.ScanRecordsRequest
+	opts   []grpc.CallOption
+	stream mydatabase.DatabaseService_ScanRecordsClient
+	token  string // continuation token of the open stream, once it sends one
+	buf    []*mydatabase.DatabaseRecord
+	done   bool
+	err    error
+}
+
+// peek returns the next record of the replica's scan, or nil if there are no more.
+func (cur *scanCursor) peek(ctx context.Context) (*mydatabase.DatabaseRecord, error) {
+	for len(cur.buf) == 0 && !cur.done && cur.err == nil {
+		if cur.stream == nil {
+			if cur.stream, cur.err = cur.client.ScanRecords(ctx, cur.req, cur.opts...); cur.err != nil {
+				break
+			}
+			cur.token = ""
+		}
+		msg, err := cur.stream.Recv()
+		if err == io.EOF {
+			cur.stream = nil
+			if cur.token == "" {
+				cur.done = true
+			}
+			cur.req.ContinuationToken = cur.token
+			continue
+		}
+		if err != nil {
+			cur.err = err
+			break
+		}
+		cur.buf = msg.GetRecords()
+		if token := msg.GetContinuationToken(); token != "" {
+			cur.token = token
+		}
+	}
+	if cur.err != nil || len(cur.buf) == 0 {
+		return nil, cur.err
+	}
+	return cur.buf[0], nil
+}
+
+func (s *quorumScan) Recv() (*mydatabase.ScanRecordsResponse, error) {
+	if s.done {
+		return nil, io.EOF
+	}
+	msg := &mydatabase.ScanRecordsResponse{}
+	for len(msg.Records) < scanBatchSize {
+		if s.returned == s.limit {
+			records, err := s.heads()
+			if err != nil {
+				return nil, s.fail(err)
+			}
+			if len(records) > 0 {
+				msg.ContinuationToken = encodeScanToken(s.lastKey, s.reverse)
+			}
+			s.finish()
+			return msg, nil
+		}
+		key, records, err := s.next()
+		if err != nil {
+			return nil, s.fail(err)
+		}
+		if records == nil {
+			s.finish()
+			if len(msg.Records) == 0 {
+				return nil, io.EOF
+			}
+			return msg, nil
+		}
+		s.lastKey = key
+		if record, err := s.merge(key, records); err != nil {
+			return nil, s.fail(err)
+		} else if record != nil {
+			msg.Records = append(msg.Records, record)
+			s.returned++
+		}
+	}
+	return msg, nil
+}
+
+func (s *quorumScan) finish() {
+	s.done = true
+	s.cancel()
+}
+
+func (s *quorumScan) fail(err error) error {
+	s.finish()
+	return err
+}
+
+// heads returns the next record of each replica still answering, by replica, failing if
+// fewer than R are.
+func (s *quorumScan) heads() (map[int]*mydatabase.DatabaseRecord, error) {
+	records := make(map[int]*mydatabase.DatabaseRecord)
+	failed := 0
+	var lastErr error
+	for i, cur := range s.cursors {
+		record, err := cur.peek(s.ctx)
+		if err != nil {
+			failed++
+			lastErr = err
+			continue
+		}
+		if record != nil {
+			records[i] = record
+		}
+	}
+	if len(s.cursors)-failed < s.client.r {
+		if s.ctx.Err() != nil {
+			return nil, status.FromContextError(s.ctx.Err()).Err()
+		}
+		return nil, status.Errorf(codes.Unavailable, "Scan reached %d of the %d replicas required: %v", len(s.cursors)-failed, s.client.r, lastErr)
+	}
+	return records, nil
+}
+
+// next consumes the next key of the merged scans, returning the record each replica holds
+// for it, or nil records once every scan is done.
+func (s *quorumScan) next() (string, map[int]*mydatabase.DatabaseRecord, error) {
+	heads, err := s.heads()
+	if err != nil || len(heads) == 0 {
+		return "", nil, err
+	}
+	first := true
+	var key string
+	for _, record := range heads {
+		if k := record.GetKey(); first || !s.reverse && k < key || s.reverse && k > key {
+			key, first = k, false
+		}
+	}
+	records := make(map[int]*mydatabase.DatabaseRecord)
+	for i, record := range heads {
+		if record.GetKey() == key {
+			records[i] = record
+			s.cursors[i].buf = s.cursors[i].buf[1:]
+		}
+	}
+	return key, records, nil
+}
+
+// merge returns the version written last of the siblings the replicas hold for key, or nil
+// if it is deleted. A record every replica holds an old tombstone for is removed.
+func (s *quorumScan) merge(key string, records map[int]*mydatabase.DatabaseRecord) (*mydatabase.DatabaseRecord, error) {
+	var sets [][]*mydatabase.Sibling
+	values := make(map[int][]byte, len(records))
+	var first *mydatabase.DatabaseRecord
+	for i, record := range records {
+		siblings, err := decodeSiblings(record.GetValue())
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "Failed to decode siblings of %s: %v", key, err)
+		}
+		sets = append(sets, siblings)
+		values[i] = record.GetValue()
+		if first == nil {
+			first = record
+		}
+	}
+	winner := lastWriter(mergeSiblings(sets...))
+	if winner == nil || winner.GetDeleted() {
+		healthy := true
+		for _, cur := range s.cursors {
+			healthy = healthy && cur.err == nil
+		}
+		// replicas not holding the record were scanned past it without finding it
+		if healthy && collectable(sets) {
+			go s.client.collect(key, values)
+		}
+		return nil, nil
+	}
+	record := proto.Clone(first).(*mydatabase.DatabaseRecord)
+	record.Value = winner.GetValue()
+	return record, nil
+}
+
+func (s *quorumScan) Header() (metadata.MD, error) { return nil, nil }
+func (s *quorumScan) Trailer() metadata.MD         { return nil }
+func (s *quorumScan) CloseSend() error             { return nil }
+func (s *quorumScan) Context() context.Context     { return s.ctx }
+
+func (s *quorumScan) SendMsg(m interface{}) error {
+	return status.Errorf(codes.Unimplemented, "Quorum scans take no further requests")
+}
+
+func (s *quorumScan) RecvMsg(m interface{}) error {
+	return status.Errorf(codes.Unimplemented, "Quorum scans are read with Recv")
+}
+
+// hybridClock is a hybrid logical clock. It follows physical time in nanoseconds but never
+// goes backwards and always moves past the timestamps it observes, so that a version is
+// timestamped after every version it supersedes, even if the clocks of the clients that
+// wrote them are skewed.
+type hybridClock struct {
+	mu   sync.Mutex
+	last uint64
+}
+
+func (c *hybridClock) now() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if pt := uint64(time.Now().UnixNano()); pt > c.last {
+		c.last = pt
+	} else {
+		c.last++
+	}
+	return c.last
+}
+
+func (c *hybridClock) observe(ts uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if ts > c.last {
+		c.last = ts
+	}
+}
+
+// encodeSiblings encodes a SiblingSet deterministically, so that replicas holding the same
+// siblings store the same bytes.
+func encodeSiblings(siblings []*mydatabase.Sibling) []byte {
+	data, _ := proto.MarshalOptions{Deterministic: true}.Marshal(&mydatabase.SiblingSet{Siblings: siblings})
+	return append([]byte(siblingSetMagic), data...)
+}
+
+// isSiblingSet reports whether a stored value was written by a quorum client.
+func isSiblingSet(value []byte) bool {
+	return bytes.HasPrefix(value, []byte(siblingSetMagic))
+}
+
+// decodeSiblings decodes a stored SiblingSet. A value that is not one, written without a
+// quorum client, is a single version that every quorum write supersedes.
+func decodeSiblings(value []byte) ([]*mydatabase.Sibling, error) {
+	if !isSiblingSet(value) {
+		return []*mydatabase.Sibling{{Value: value}}, nil
+	}
+	var set mydatabase.SiblingSet
+	if err := proto.Unmarshal(value[len(siblingSetMagic):], &set); err != nil {
+		return nil, err
+	}
+	return set.GetSiblings(), nil
+}
+
+// descends reports whether the version with clock a has seen every write the version with
+// clock b has.
+func descends(a, b *mydatabase.VectorClock) bool {
+	for name, ts := range b.GetEntries() {
+		if a.GetEntries()[name] < ts {
+			return false
+		}
+	}
+	return true
+}
+
+// mergeSiblings combines sets of siblings, keeping each version that no other supersedes once.
+func mergeSiblings(sets ...[]*mydatabase.Sibling) []*mydatabase.Sibling {
+	var all []*mydatabase.Sibling
+	for _, set := range sets {
+		all = append(all, set...)
+	}
+
+	var merged []*mydatabase.Sibling
+	for i, sibling := range all {
+		superseded := false
+		for j, other := range all {
+			if i == j || !descends(other.GetClock(), sibling.GetClock()) {
+				continue
+			}
+			// of identical versions, keep the first
+			if !descends(sibling.GetClock(), other.GetClock()) || j < i {
+				superseded = true
+				break
+			}
+		}
+		if !superseded {
+			merged = append(merged, sibling)
+		}
+	}
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].GetTimestamp() < merged[j].GetTimestamp()
+	})
+	return merged
+}
+
+// containsSiblings reports whether set holds every version in siblings.
+func containsSiblings(set, siblings []*mydatabase.Sibling) bool {
+	for _, sibling := range siblings {
+		found := false
+		for _, other := range set {
+			if descends(other.GetClock(), sibling.GetClock()) && descends(sibling.GetClock(), other.GetClock()) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// causalContext returns the clock of a version that supersedes all of siblings.
+func causalContext(siblings []*mydatabase.Sibling) *mydatabase.VectorClock {
+	entries := make(map[string]uint64)
+	for _, sibling := range siblings {
+		for name, ts := range sibling.GetClock().GetEntries() {
+			if ts > entries[name] {
+				entries[name] = ts
+			}
+		}
+	}
+	return &mydatabase.VectorClock{Entries: entries}
+}
+
+// lastWriter returns the sibling with the latest timestamp, breaking ties by value so that
+// every client picks the same one.
+func lastWriter(siblings []*mydatabase.Sibling) *mydatabase.Sibling {
+	var winner *mydatabase.Sibling
+	for _, sibling := range siblings {
+		if winner == nil || sibling.GetTimestamp() > winner.GetTimestamp() ||
+			sibling.GetTimestamp() == winner.GetTimestamp() && bytes.Compare(sibling.GetValue(), winner.GetValue()) > 0 {
+			winner = sibling
+		}
+	}
+	return winner
+}
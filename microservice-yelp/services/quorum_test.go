@@ -0,0 +1,350 @@
+package services
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"gitlab.cs.washington.edu/syslab/cse453-welp/proto/mydatabase"
+)
+
+// sibling returns a version of a record written at ts with the given vector clock.
+func sibling(value string, ts uint64, clock map[string]uint64) *mydatabase.Sibling {
+	return &mydatabase.Sibling{Value: []byte(value), Timestamp: ts, Clock: &mydatabase.VectorClock{Entries: clock}}
+}
+
+// siblingValues returns the values of siblings, in order.
+func siblingValues(siblings []*mydatabase.Sibling) []string {
+	values := []string{}
+	for _, s := range siblings {
+		values = append(values, string(s.GetValue()))
+	}
+	return values
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestDescends(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		a, b map[string]uint64
+		want bool
+	}{
+		{"equal clocks", map[string]uint64{"x": 1, "y": 2}, map[string]uint64{"x": 1, "y": 2}, true},
+		{"a dominates b", map[string]uint64{"x": 2, "y": 2}, map[string]uint64{"x": 1, "y": 2}, true},
+		{"a has seen a writer b has not", map[string]uint64{"x": 1, "y": 1}, map[string]uint64{"x": 1}, true},
+		{"b dominates a", map[string]uint64{"x": 1}, map[string]uint64{"x": 2}, false},
+		{"b has seen a writer a has not", map[string]uint64{"x": 1}, map[string]uint64{"x": 1, "y": 1}, false},
+		{"concurrent", map[string]uint64{"x": 2, "y": 1}, map[string]uint64{"x": 1, "y": 2}, false},
+		{"concurrent writers", map[string]uint64{"x": 1}, map[string]uint64{"y": 1}, false},
+		{"every clock descends the empty clock", map[string]uint64{"x": 1}, nil, true},
+		{"the empty clock descends only itself", nil, map[string]uint64{"x": 1}, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			a, b := &mydatabase.VectorClock{Entries: tc.a}, &mydatabase.VectorClock{Entries: tc.b}
+			if got := descends(a, b); got != tc.want {
+				t.Fatalf("descends(%v, %v) = %v, want %v", tc.a, tc.b, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestMergeSiblings(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		sets [][]*mydatabase.Sibling
+		want []string // values of the merged siblings, oldest first
+	}{
+		{
+			name: "a dominated version is dropped",
+			sets: [][]*mydatabase.Sibling{{sibling("old", 1, map[string]uint64{"x": 1})}, {sibling("new", 2, map[string]uint64{"x": 2})}},
+			want: []string{"new"},
+		},
+		{
+			name: "concurrent versions are kept, oldest first",
+			sets: [][]*mydatabase.Sibling{{sibling("b", 2, map[string]uint64{"y": 2})}, {sibling("a", 1, map[string]uint64{"x": 1})}},
+			want: []string{"a", "b"},
+		},
+		{
+			name: "a version held by several replicas is kept once",
+			sets: [][]*mydatabase.Sibling{{sibling("a", 1, map[string]uint64{"x": 1})}, {sibling("a", 1, map[string]uint64{"x": 1})}},
+			want: []string{"a"},
+		},
+		{
+			name: "a write that saw concurrent versions supersedes them all",
+			sets: [][]*mydatabase.Sibling{
+				{sibling("a", 1, map[string]uint64{"x": 1}), sibling("b", 2, map[string]uint64{"y": 2})},
+				{sibling("c", 3, map[string]uint64{"x": 1, "y": 2, "z": 3})},
+			},
+			want: []string{"c"},
+		},
+		{
+			name: "a version concurrent with one of several is kept beside the other's successor",
+			sets: [][]*mydatabase.Sibling{
+				{sibling("a", 1, map[string]uint64{"x": 1}), sibling("b", 2, map[string]uint64{"y": 2})},
+				{sibling("c", 3, map[string]uint64{"x": 1, "z": 3})},
+			},
+			want: []string{"b", "c"},
+		},
+		{
+			name: "a value written without a quorum client is superseded by any quorum write",
+			sets: [][]*mydatabase.Sibling{{{Value: []byte("plain")}}, {sibling("quorum", 1, map[string]uint64{"x": 1})}},
+			want: []string{"quorum"},
+		},
+		{
+			name: "no versions",
+			sets: [][]*mydatabase.Sibling{nil, nil},
+			want: []string{},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := siblingValues(mergeSiblings(tc.sets...)); !equalStrings(got, tc.want) {
+				t.Fatalf("mergeSiblings = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestResolveSiblings(t *testing.T) {
+	concurrent := []*mydatabase.Sibling{
+		sibling("a", 1, map[string]uint64{"x": 1}),
+		sibling("c", 3, map[string]uint64{"z": 3}),
+		sibling("b", 2, map[string]uint64{"y": 2}),
+	}
+
+	c := &QuorumClient{siblings: true}
+	if got := siblingValues(c.resolve(concurrent)); !equalStrings(got, []string{"a", "c", "b"}) {
+		t.Fatalf("resolve with siblings = %v, want every sibling", got)
+	}
+
+	c.siblings = false
+	resolved := c.resolve(concurrent)
+	if got := siblingValues(resolved); !equalStrings(got, []string{"c"}) {
+		t.Fatalf("resolve = %v, want the sibling written last", got)
+	}
+	// the kept version supersedes the others wherever it is merged
+	for _, s := range concurrent {
+		if !descends(resolved[0].GetClock(), s.GetClock()) {
+			t.Fatalf("resolved clock %v does not descend %v", resolved[0].GetClock().GetEntries(), s.GetClock().GetEntries())
+		}
+	}
+	if got := siblingValues(mergeSiblings(concurrent, resolved)); !equalStrings(got, []string{"c"}) {
+		t.Fatalf("merging the resolved version = %v, want only it", got)
+	}
+	if concurrent[1].GetClock().GetEntries()["x"] != 0 {
+		t.Fatal("resolve changed the clock of the sibling it kept")
+	}
+}
+
+func TestLastWriterBreaksTiesByValue(t *testing.T) {
+	siblings := []*mydatabase.Sibling{
+		sibling("a", 5, map[string]uint64{"x": 5}),
+		sibling("b", 5, map[string]uint64{"y": 5}),
+		sibling("c", 4, map[string]uint64{"z": 4}),
+	}
+	for i := 0; i < 2; i++ {
+		if got := string(lastWriter(siblings).GetValue()); got != "b" {
+			t.Fatalf("lastWriter = %s, want b", got)
+		}
+		siblings[0], siblings[1] = siblings[1], siblings[0]
+	}
+	if lastWriter(nil) != nil {
+		t.Fatal("lastWriter of no siblings is not nil")
+	}
+}
+
+// fakeReplica is a database holding sibling sets in memory, merging the siblings written
+// to it as a database does for quorum clients.
+type fakeReplica struct {
+	mydatabase.DatabaseServiceClient
+
+	mu      sync.Mutex
+	records map[string][]byte
+	writes  int
+}
+
+func newFakeReplica() *fakeReplica {
+	return &fakeReplica{records: make(map[string][]byte)}
+}
+
+func (r *fakeReplica) GetRecord(ctx context.Context, req *mydatabase.GetRecordRequest, opts ...grpc.CallOption) (*mydatabase.GetRecordResponse, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	value, ok := r.records[req.GetKey()]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "Item with Key: %s does not exist", req.GetKey())
+	}
+	return &mydatabase.GetRecordResponse{Record: &mydatabase.DatabaseRecord{Key: req.GetKey(), Value: value}}, nil
+}
+
+func (r *fakeReplica) SetRecord(ctx context.Context, req *mydatabase.SetRecordRequest, opts ...grpc.CallOption) (*mydatabase.SetRecordResponse, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key, value := req.GetRecord().GetKey(), req.GetRecord().GetValue()
+	if old, ok := r.records[key]; ok && req.GetMergeSiblings() {
+		held, _ := decodeSiblings(old)
+		written, _ := decodeSiblings(value)
+		value = encodeSiblings(mergeSiblings(held, written))
+	}
+	r.records[key] = value
+	r.writes++
+	return &mydatabase.SetRecordResponse{Success: true}, nil
+}
+
+// store sets the siblings the replica holds for key, as if written earlier.
+func (r *fakeReplica) store(key string, siblings ...*mydatabase.Sibling) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.records[key] = encodeSiblings(siblings)
+}
+
+// held returns the values of the siblings the replica holds for key, and the number of
+// writes it took.
+func (r *fakeReplica) held(key string) ([]string, int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	value, ok := r.records[key]
+	if !ok {
+		return []string{}, r.writes
+	}
+	siblings, _ := decodeSiblings(value)
+	values := siblingValues(siblings)
+	sort.Strings(values)
+	return values, r.writes
+}
+
+// newFakeQuorumClient returns a quorum client of the replicas reading from and writing to
+// a majority of them, without handing off hints.
+func newFakeQuorumClient(siblings bool, replicas ...*fakeReplica) *QuorumClient {
+	c := &QuorumClient{name: "test", w: len(replicas)/2 + 1, r: len(replicas)/2 + 1, siblings: siblings, stop: make(chan struct{})}
+	for _, r := range replicas {
+		c.addrs = append(c.addrs, "fake")
+		c.replicas = append(c.replicas, r)
+		c.hintStores = append(c.hintStores, r)
+	}
+	c.DatabaseServiceClient = c.replicas[0]
+	return c
+}
+
+// waitForReplica waits for the replica to hold the siblings with the given values for key.
+func waitForReplica(t *testing.T, r *fakeReplica, key string, want ...string) {
+	t.Helper()
+	sort.Strings(want)
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		got, _ := r.held(key)
+		if equalStrings(got, want) {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("replica holds %v for %s, want %v", got, key, want)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestQuorumReadRepairsStaleReplicas(t *testing.T) {
+	old := sibling("old", 1, map[string]uint64{"x": 1})
+	current := sibling("new", 2, map[string]uint64{"x": 2})
+	for _, tc := range []struct {
+		name  string
+		stale func(r *fakeReplica)
+	}{
+		{"replica holding an old version", func(r *fakeReplica) { r.store("k", old) }},
+		{"replica missing the record", func(r *fakeReplica) {}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			replicas := []*fakeReplica{newFakeReplica(), newFakeReplica(), newFakeReplica()}
+			replicas[0].store("k", current)
+			replicas[1].store("k", current)
+			tc.stale(replicas[2])
+			c := newFakeQuorumClient(false, replicas...)
+
+			resp, err := c.GetRecord(context.Background(), &mydatabase.GetRecordRequest{Key: "k"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := string(resp.GetRecord().GetValue()); got != "new" {
+				t.Fatalf("GetRecord = %s, want new", got)
+			}
+			waitForReplica(t, replicas[2], "k", "new")
+			for i, r := range replicas[:2] {
+				if _, writes := r.held("k"); writes != 0 {
+					t.Fatalf("up-to-date replica %d was written %d times", i, writes)
+				}
+			}
+		})
+	}
+}
+
+func TestQuorumReadRepairsConcurrentSiblings(t *testing.T) {
+	a := sibling("a", 1, map[string]uint64{"x": 1})
+	b := sibling("b", 2, map[string]uint64{"y": 2})
+
+	t.Run("siblings returned", func(t *testing.T) {
+		replicas := []*fakeReplica{newFakeReplica(), newFakeReplica(), newFakeReplica()}
+		replicas[0].store("k", a)
+		replicas[1].store("k", b)
+		c := newFakeQuorumClient(true, replicas...)
+		c.r = len(replicas) // a majority may not include both versions
+
+		resp, err := c.GetRecord(context.Background(), &mydatabase.GetRecordRequest{Key: "k"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		values := []string{}
+		for _, s := range resp.GetSiblings() {
+			values = append(values, string(s.GetValue()))
+		}
+		sort.Strings(values)
+		if !equalStrings(values, []string{"a", "b"}) {
+			t.Fatalf("GetRecord siblings = %v, want a and b", values)
+		}
+		if got := string(resp.GetRecord().GetValue()); got != "b" {
+			t.Fatalf("GetRecord = %s, want b, written last", got)
+		}
+		// every replica ends up holding both versions
+		for _, r := range replicas {
+			waitForReplica(t, r, "k", "a", "b")
+		}
+	})
+
+	t.Run("siblings resolved", func(t *testing.T) {
+		replicas := []*fakeReplica{newFakeReplica(), newFakeReplica(), newFakeReplica()}
+		replicas[0].store("k", a)
+		replicas[1].store("k", b)
+		c := newFakeQuorumClient(false, replicas...)
+		c.r = len(replicas)
+
+		resp, err := c.GetRecord(context.Background(), &mydatabase.GetRecordRequest{Key: "k"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := string(resp.GetRecord().GetValue()); got != "b" || len(resp.GetSiblings()) != 0 {
+			t.Fatalf("GetRecord = %s with %d siblings, want b alone", got, len(resp.GetSiblings()))
+		}
+		// the version written last replaces the other on every replica
+		for _, r := range replicas {
+			waitForReplica(t, r, "k", "b")
+		}
+	})
+}
@@ -26,23 +26,29 @@ type Reservation struct {
 
 	mu sync.Mutex
 	// reservationStore map[string][]byte
-	reservationPopularity map[string]int
+	reservationPopularity map[string]int    // reservations made by restaurant id
+	restaurantNames       map[string]string // name of each restaurant in reservationPopularity, as last reserved
 
 	reservationCacheClient    mycache.CacheServiceClient       // Add reservation grpc cache client for communicating with reservation cache server
 	reservationDatabaseClient mydatabase.DatabaseServiceClient // Add reservation grpc storage client for communicating with reservation storage server
+	restaurants               restaurantDirectory              // finds the restaurants requests name without their ID
 
 	CACHE_FLAG bool
 }
 
-// NewReservation returns a new server
-func NewReservation(name string, reservationPort int, reservationCacheAddr string, reservationDatabaseAddr string) *Reservation {
+// NewReservation returns a new server keeping its records in namespace of its database.
+// Restaurants named without their ID are looked up by name in the detail services at
+// detailAddrs.
+func NewReservation(name string, reservationPort int, reservationCacheAddr string, reservationDatabaseAddr string, namespace string, detailAddrs []string) *Reservation {
 	return &Reservation{
 		name: name,
 		port: reservationPort,
 		// reservationStore: make(map[string][]byte),
 		reservationPopularity:     make(map[string]int),
-		reservationCacheClient:    mycache.NewCacheServiceClient(dial(reservationCacheAddr)),          // Initialize and establish cxn using specified address
-		reservationDatabaseClient: mydatabase.NewDatabaseServiceClient(dial(reservationDatabaseAddr)), // Initialize and establish cxn using specified address
+		restaurantNames:           make(map[string]string),
+		reservationCacheClient:    mycache.NewCacheServiceClient(dial(reservationCacheAddr)),                                          // Initialize and establish cxn using specified address
+		reservationDatabaseClient: NewNamespacedClient(mydatabase.NewDatabaseServiceClient(dial(reservationDatabaseAddr)), namespace), // Initialize and establish cxn using specified address
+		restaurants:               newRestaurantDirectory(detailAddrs),
 		CACHE_FLAG:                true,
 	}
 }
@@ -51,7 +57,7 @@ func NewReservation(name string, reservationPort int, reservationCacheAddr strin
 // It returns an error if the server fails to start or encounters an error.
 func (s *Reservation) Run() error {
 	// Create a new gRPC server instance.
-	srv := grpc.NewServer()
+	srv := grpc.NewServer(grpc.UnaryInterceptor(validateUnary))
 
 	// Register the Reservation server implementation with the gRPC server.
 	reservation.RegisterReservationServiceServer(srv, s)
@@ -123,16 +129,21 @@ func (s *Reservation) MakeReservation(ctx context.Context, req *reservation.Make
 	restaurant_name := req.GetRestaurantName()
 	time := req.GetTime()
 
+	// Initialize an empty response object.
+	reservationResponse := &reservation.MakeReservationResponse{Status: false}
+	restaurant_id, err := s.restaurants.restaurantID(ctx, req.GetRestaurantId(), restaurant_name)
+	if err != nil {
+		return reservationResponse, err
+	}
+
 	// Create a new GetReservationResponse object with the reservation to save.
 	msg := &reservation.GetReservationResponse{
 		UserName:       username,
 		RestaurantName: restaurant_name,
+		RestaurantId:   restaurant_id,
 		Time:           time,
 	}
 
-	// Initialize an empty response object.
-	reservationResponse := &reservation.MakeReservationResponse{Status: false}
-
 	// Serialize the Go object into protobuf format.
 	data, err := proto.Marshal(msg)
 	if err != nil {
@@ -143,10 +154,11 @@ func (s *Reservation) MakeReservation(ctx context.Context, req *reservation.Make
 	// Use locking when updating the local data structure to prevent concurrency issues
 	s.mu.Lock()
 	count := 0
-	if val, exists := s.reservationPopularity[restaurant_name]; exists {
+	if val, exists := s.reservationPopularity[restaurant_id]; exists {
 		count = val
 	}
-	s.reservationPopularity[restaurant_name] = count + 1
+	s.reservationPopularity[restaurant_id] = count + 1
+	s.restaurantNames[restaurant_id] = restaurant_name
 	s.mu.Unlock()
 
 	reservationResponse.Status, err = updateDB(ctx, s.reservationCacheClient, s.reservationDatabaseClient, username, data, s.CACHE_FLAG)
@@ -170,17 +182,22 @@ func (s *Reservation) MostPopular(ctx context.Context, req *reservation.MostPopu
 
 	var numElements int32 = 0
 	topKRestaurants := make([]string, 0)
+	topKRestaurantIds := make([]string, 0)
 
 	for numElements < topK {
 		if pq.Len() > 0 {
-			topKRestaurants = append(topKRestaurants, heap.Pop(&pq).(*Item).value)
+			id := heap.Pop(&pq).(*Item).value
+			topKRestaurantIds = append(topKRestaurantIds, id)
+			s.mu.Lock()
+			topKRestaurants = append(topKRestaurants, s.restaurantNames[id])
+			s.mu.Unlock()
 		} else {
 			return &reservation.MostPopularResponse{}, status.Errorf(codes.Internal, "Less than K restaurants in WELP")
 		}
 		numElements = numElements + 1
 	}
 
-	mostPopularResponse := &reservation.MostPopularResponse{TopKRestaurants: topKRestaurants}
+	mostPopularResponse := &reservation.MostPopularResponse{TopKRestaurants: topKRestaurants, TopKRestaurantIds: topKRestaurantIds}
 	return mostPopularResponse, nil
 }
 
@@ -0,0 +1,386 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+
+	apps "gitlab.cs.washington.edu/syslab/cse453-welp/applications"
+	"gitlab.cs.washington.edu/syslab/cse453-welp/proto/detail"
+	"gitlab.cs.washington.edu/syslab/cse453-welp/proto/mydatabase"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	defaultRestaurantPageSize = 20  // restaurants listed by ListRestaurants when the request sets no page size
+	maxRestaurantPageSize     = 100 // upper bound on the restaurants listed by a single ListRestaurants call
+	detailFetches             = 16  // details read from the database at once when fetching several
+)
+
+// restaurantPosition is the position of a restaurant in a listing: the value it is ordered
+// by, then its name, then its ID. Page tokens hold the position of the last restaurant of a page, so
+// that the next page can be found on any detail server, or merged from all of them.
+type restaurantPosition struct {
+	Order      detail.RestaurantOrder `json:"o"`
+	Descending bool                   `json:"d,omitempty"`
+	Capacity   int32                  `json:"c,omitempty"`
+	Rating     float64                `json:"r,omitempty"`
+	Name       string                 `json:"n"`
+	ID         string                 `json:"i,omitempty"`
+}
+
+// positionOf returns the position of a restaurant in the listing req asks for.
+func positionOf(req *detail.ListRestaurantsRequest, d *detail.GetDetailResponse) restaurantPosition {
+	p := restaurantPosition{Order: req.GetOrderBy(), Descending: req.GetDescending(), Name: d.GetRestaurantName(), ID: d.GetRestaurantId()}
+	switch p.Order {
+	case detail.RestaurantOrder_ORDER_BY_CAPACITY:
+		p.Capacity = d.GetCapacity()
+	case detail.RestaurantOrder_ORDER_BY_RATING:
+		p.Rating = d.GetRating()
+	}
+	return p
+}
+
+// before reports whether a restaurant at position p is listed before one at position o.
+func (p restaurantPosition) before(o restaurantPosition) bool {
+	var less, greater bool
+	switch p.Order {
+	case detail.RestaurantOrder_ORDER_BY_CAPACITY:
+		less, greater = p.Capacity < o.Capacity, p.Capacity > o.Capacity
+	case detail.RestaurantOrder_ORDER_BY_RATING:
+		less, greater = p.Rating < o.Rating, p.Rating > o.Rating
+	}
+	if !less && !greater {
+		less, greater = p.Name < o.Name, p.Name > o.Name
+	}
+	if !less && !greater {
+		less, greater = p.ID < o.ID, p.ID > o.ID
+	}
+	if p.Descending {
+		return greater
+	}
+	return less
+}
+
+// encodeRestaurantToken builds an opaque page token from the position of the last
+// restaurant of a page.
+func encodeRestaurantToken(p restaurantPosition) string {
+	data, _ := json.Marshal(p)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeRestaurantToken returns the position recorded in a page token, checking that the
+// token was issued for a listing in the same order.
+func decodeRestaurantToken(token string, req *detail.ListRestaurantsRequest) (restaurantPosition, error) {
+	var p restaurantPosition
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return p, err
+	}
+	if err := json.Unmarshal(data, &p); err != nil {
+		return p, err
+	}
+	if p.Order != req.GetOrderBy() || p.Descending != req.GetDescending() {
+		return p, fmt.Errorf("token does not match listing order")
+	}
+	return p, nil
+}
+
+// checkListRestaurants validates a listing request, returning its page size and the
+// position its page starts after, if it has a page token.
+func checkListRestaurants(req *detail.ListRestaurantsRequest) (int, *restaurantPosition, error) {
+	switch {
+	case req.GetMinCapacity() < 0 || req.GetMaxCapacity() < 0:
+		return 0, nil, status.Errorf(codes.InvalidArgument, "Invalid capacity range: capacities must not be negative")
+	case req.GetMaxCapacity() > 0 && req.GetMaxCapacity() < req.GetMinCapacity():
+		return 0, nil, status.Errorf(codes.InvalidArgument, "Invalid capacity range: %d to %d", req.GetMinCapacity(), req.GetMaxCapacity())
+	case detail.RestaurantOrder_name[int32(req.GetOrderBy())] == "":
+		return 0, nil, status.Errorf(codes.InvalidArgument, "Invalid listing order: %v", req.GetOrderBy())
+	}
+	size := int(req.GetPageSize())
+	if size < 0 {
+		return 0, nil, status.Errorf(codes.InvalidArgument, "Invalid page size: %d", size)
+	}
+	if size == 0 {
+		size = defaultRestaurantPageSize
+	}
+	if size > maxRestaurantPageSize {
+		size = maxRestaurantPageSize
+	}
+	if req.GetPageToken() == "" {
+		return size, nil, nil
+	}
+	after, err := decodeRestaurantToken(req.GetPageToken(), req)
+	if err != nil {
+		return 0, nil, status.Errorf(codes.InvalidArgument, "Invalid page token: %v", err)
+	}
+	return size, &after, nil
+}
+
+// restaurantMatches reports whether a restaurant passes the filters of a listing request.
+func restaurantMatches(req *detail.ListRestaurantsRequest, d *detail.GetDetailResponse) bool {
+	return (req.GetLocation() == "" || d.GetLocation() == req.GetLocation()) &&
+		(req.GetStyle() == "" || d.GetStyle() == req.GetStyle()) &&
+		d.GetCapacity() >= req.GetMinCapacity() &&
+		(req.GetMaxCapacity() == 0 || d.GetCapacity() <= req.GetMaxCapacity())
+}
+
+// restaurantPage sorts restaurants in the order req asks for and returns the first size
+// of them, with a token for the next page if there are more, or more was set.
+func restaurantPage(req *detail.ListRestaurantsRequest, restaurants []*detail.GetDetailResponse, size int, more bool) *detail.ListRestaurantsResponse {
+	sort.Slice(restaurants, func(i, j int) bool {
+		return positionOf(req, restaurants[i]).before(positionOf(req, restaurants[j]))
+	})
+	msg := &detail.ListRestaurantsResponse{Restaurants: restaurants}
+	if len(restaurants) > size {
+		msg.Restaurants, more = restaurants[:size], true
+	}
+	if more && len(msg.Restaurants) > 0 {
+		msg.NextPageToken = encodeRestaurantToken(positionOf(req, msg.Restaurants[len(msg.Restaurants)-1]))
+	}
+	return msg
+}
+
+// mergeRestaurantPages merges the pages listed by several detail servers for the same
+// request into a single page. A restaurant listed by more than one of them, as when they
+// share their databases, is listed once.
+func mergeRestaurantPages(req *detail.ListRestaurantsRequest, pages []*detail.ListRestaurantsResponse) *detail.ListRestaurantsResponse {
+	size, _, _ := checkListRestaurants(req)
+	seen := make(map[string]bool)
+	var restaurants []*detail.GetDetailResponse
+	more := false
+	for _, page := range pages {
+		more = more || page.GetNextPageToken() != ""
+		for _, d := range page.GetRestaurants() {
+			if !seen[d.GetRestaurantId()] {
+				seen[d.GetRestaurantId()] = true
+				restaurants = append(restaurants, d)
+			}
+		}
+	}
+	return restaurantPage(req, restaurants, size, more)
+}
+
+// restaurantSelection keeps the first n restaurants of a listing among those added to it,
+// so that a page can be listed without holding every restaurant that matches.
+type restaurantSelection struct {
+	req         *detail.ListRestaurantsRequest
+	n           int
+	restaurants []*detail.GetDetailResponse
+}
+
+func (s *restaurantSelection) add(d *detail.GetDetailResponse) {
+	p := positionOf(s.req, d)
+	i := sort.Search(len(s.restaurants), func(i int) bool {
+		return p.before(positionOf(s.req, s.restaurants[i]))
+	})
+	if i >= s.n {
+		return
+	}
+	s.restaurants = append(s.restaurants, nil)
+	copy(s.restaurants[i+1:], s.restaurants[i:])
+	s.restaurants[i] = d
+	if len(s.restaurants) > s.n {
+		s.restaurants = s.restaurants[:s.n]
+	}
+}
+
+// full reports whether no restaurant listed after the last one kept can be added.
+func (s *restaurantSelection) full() bool {
+	return len(s.restaurants) == s.n
+}
+
+// ListRestaurants lists the restaurants whose details match the request's filters, in the
+// requested order, a page at a time. Restaurants are found through the detail indexes
+// when the request filters on location, style or capacity, or lists by ascending
+// capacity, and by scanning every restaurant otherwise.
+func (s *Detail) ListRestaurants(ctx context.Context, req *detail.ListRestaurantsRequest) (*detail.ListRestaurantsResponse, error) {
+	size, after, err := checkListRestaurants(req)
+	if err != nil {
+		return &detail.ListRestaurantsResponse{}, err
+	}
+
+	page := &restaurantSelection{req: req, n: size + 1}
+	add := func(d *detail.GetDetailResponse) {
+		if restaurantMatches(req, d) && (after == nil || after.before(positionOf(req, d))) {
+			page.add(d)
+		}
+	}
+	if conditions, ordered := listingConditions(req, after); conditions != nil {
+		err = s.queryListing(ctx, conditions, ordered, page, add)
+	} else {
+		err = s.scanDetails(ctx, add)
+	}
+	if err != nil {
+		return &detail.ListRestaurantsResponse{}, err
+	}
+	return restaurantPage(req, page.restaurants, size, false), nil
+}
+
+// listingConditions returns the index conditions that find the restaurants a listing
+// request can match, or nil if it must scan them all. It also reports whether the
+// conditions return restaurants in listing order, which they do when listing by
+// ascending capacity: the capacity index is then queried first, from the capacity of the
+// page token on.
+func listingConditions(req *detail.ListRestaurantsRequest, after *restaurantPosition) ([]*mydatabase.IndexCondition, bool) {
+	var conditions []*mydatabase.IndexCondition
+	if req.GetLocation() != "" {
+		conditions = append(conditions, &mydatabase.IndexCondition{Index: DetailLocationIndex, Value: req.GetLocation()})
+	}
+	if req.GetStyle() != "" {
+		conditions = append(conditions, &mydatabase.IndexCondition{Index: DetailStyleIndex, Value: req.GetStyle()})
+	}
+
+	ordered := req.GetOrderBy() == detail.RestaurantOrder_ORDER_BY_CAPACITY && !req.GetDescending()
+	if !ordered && req.GetMinCapacity() == 0 && req.GetMaxCapacity() == 0 {
+		return conditions, false
+	}
+	min := req.GetMinCapacity()
+	if ordered && after != nil && after.Capacity > min {
+		min = after.Capacity
+	}
+	capacity := &mydatabase.IndexRange{Start: apps.EncodeIndexInt(int64(min))}
+	if req.GetMaxCapacity() > 0 {
+		capacity.End = apps.EncodeIndexInt(int64(req.GetMaxCapacity()) + 1)
+	}
+	condition := &mydatabase.IndexCondition{Index: DetailCapacityIndex, Range: capacity}
+	if ordered {
+		return append([]*mydatabase.IndexCondition{condition}, conditions...), true
+	}
+	return append(conditions, condition), false
+}
+
+// queryListing calls add with the details of the restaurants indexed under conditions,
+// reading the index a batch of keys at a time. When the keys come in listing order, it
+// stops once no restaurant further on can be added to page.
+func (s *Detail) queryListing(ctx context.Context, conditions []*mydatabase.IndexCondition, ordered bool, page *restaurantSelection, add func(d *detail.GetDetailResponse)) error {
+	limit := maxScanLimit
+	if ordered {
+		limit = scanBatchSize
+	}
+	token := ""
+	for {
+		msg, err := s.detailDatabaseClient.QueryIndex(ctx, &mydatabase.QueryIndexRequest{
+			Conditions:        conditions,
+			Limit:             int32(limit),
+			ContinuationToken: token,
+		})
+		if err != nil {
+			return status.Errorf(codes.Internal, "Failed to query data storage: %v", err)
+		}
+		details, err := s.getDetails(ctx, msg.GetKeys())
+		if err != nil {
+			return err
+		}
+		for _, d := range details {
+			add(d)
+		}
+		// restaurants of the same capacity are listed by name, so the index is read past
+		// every one sharing the capacity of the last restaurant kept
+		if ordered && page.full() && len(details) > 0 &&
+			details[len(details)-1].GetCapacity() > page.restaurants[page.n-1].GetCapacity() {
+			return nil
+		}
+		if token = msg.GetContinuationToken(); token == "" {
+			return nil
+		}
+	}
+}
+
+// scanDetails calls fn with the details of every restaurant in the database.
+func (s *Detail) scanDetails(ctx context.Context, fn func(d *detail.GetDetailResponse)) error {
+	token := ""
+	for {
+		stream, err := s.detailDatabaseClient.ScanRecords(ctx, &mydatabase.ScanRecordsRequest{
+			Limit:             maxScanLimit,
+			ContinuationToken: token,
+		})
+		if err != nil {
+			return status.Errorf(codes.Internal, "Failed to scan data storage: %v", err)
+		}
+		token = ""
+		for {
+			msg, err := stream.Recv()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return status.Errorf(codes.Internal, "Failed to scan data storage: %v", err)
+			}
+			for _, record := range msg.GetRecords() {
+				d, err := decodeDetail(record)
+				if err != nil {
+					return err
+				}
+				fn(d)
+			}
+			token = msg.GetContinuationToken()
+		}
+		if token == "" {
+			return nil
+		}
+	}
+}
+
+// getDetails returns the details of the restaurants with the given IDs, reading them from
+// the database several at a time. Restaurants deleted since their IDs were found are
+// skipped.
+func (s *Detail) getDetails(ctx context.Context, ids []string) ([]*detail.GetDetailResponse, error) {
+	details := make([]*detail.GetDetailResponse, len(ids))
+	errs := make([]error, len(ids))
+	var wg sync.WaitGroup
+	fetches := make(chan struct{}, detailFetches)
+	for i, id := range ids {
+		i, id := i, id
+		wg.Add(1)
+		fetches <- struct{}{}
+		go func() {
+			defer func() {
+				<-fetches
+				wg.Done()
+			}()
+			msg, err := s.detailDatabaseClient.GetRecord(ctx, &mydatabase.GetRecordRequest{Key: id})
+			switch {
+			case status.Code(err) == codes.NotFound:
+			case err != nil:
+				errs[i] = status.Errorf(codes.Internal, "Failed to read data storage: %v", err)
+			default:
+				details[i], errs[i] = decodeDetail(msg.GetRecord())
+			}
+		}()
+	}
+	wg.Wait()
+
+	found := details[:0]
+	for i, d := range details {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+		if d != nil {
+			found = append(found, d)
+		}
+	}
+	return found, nil
+}
+
+// decodeDetail returns the details a database record holds. Details written before
+// restaurants had IDs are keyed by the restaurant's name, which stands in for its ID.
+func decodeDetail(record *mydatabase.DatabaseRecord) (*detail.GetDetailResponse, error) {
+	d := &detail.GetDetailResponse{}
+	if err := proto.Unmarshal(record.GetValue(), d); err != nil {
+		return nil, status.Errorf(codes.Internal, "Failed to deserialize data")
+	}
+	if d.RestaurantName == "" {
+		d.RestaurantName = record.GetKey()
+	}
+	if d.RestaurantId == "" {
+		d.RestaurantId = record.GetKey()
+	}
+	return d, nil
+}
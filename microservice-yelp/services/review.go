@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"log"
 	"net"
+	"strings"
+	"sync"
 
 	"gitlab.cs.washington.edu/syslab/cse453-welp/proto/review"
 	"google.golang.org/grpc"
@@ -16,6 +18,16 @@ import (
 	"gitlab.cs.washington.edu/syslab/cse453-welp/proto/mydatabase"
 )
 
+// Each review is kept in a record of its own, under the key reviewKey derives from the
+// restaurant and user, so that a review is read and written without touching the others.
+// The records are kept in the namespace ReviewRecordNamespace returns, apart from those
+// written before, which held all the reviews of a restaurant and are moved to it by
+// SplitReviews. The reviews of a restaurant are listed by an index kept in the namespace
+// ReviewIndexNamespace returns: an entry per review, under the restaurant's ID, holding the
+// ReviewStats of that review alone. The restaurant's own stats are kept there too, under its
+// ID, and updated in the transaction that posts a review, so that they are read without
+// going through every review.
+
 // Review implements the review service
 type Review struct {
 	name string
@@ -25,27 +37,95 @@ type Review struct {
 	// reviewTracker map[string][]byte
 	reviewCacheClient    mycache.CacheServiceClient       // Add review grpc cache client for communicating with review cache server
 	reviewDatabaseClient mydatabase.DatabaseServiceClient // Add review grpc storage client for communicating with review storage server
+	reviewIndexClient    mydatabase.DatabaseServiceClient // the same storage, in the namespace the index of reviews is kept in
+	restaurants          restaurantDirectory              // finds the restaurants requests name without their ID
+
+	// posts of reviews are made in database transactions unless the database is
+	// replicated by quorum, which does not support them
+	transactions bool
+	postLocks    [reviewPostLocks]sync.Mutex
 
 	CACHE_FLAG bool
 }
 
-// NewReview returns a new server
-func NewReview(name string, reviewPort int, reviewCacheAddr string, reviewDatabaseAddr string) *Review {
+const (
+	reviewPostLocks   = 64 // locks serializing the reviews posted through a review server
+	reviewPostRetries = 3  // retries of a post whose transaction a concurrent write aborted
+	reviewFetches     = 16 // reviews read from the database at once when searching them
+)
+
+// NewReview returns a new server keeping its records in namespace of its database. If quorum
+// lists replicas, records are replicated across them with quorum reads and writes instead
+// of being kept in reviewDatabaseAddr alone. Restaurants named without their ID are looked
+// up by name in the detail services at detailAddrs.
+func NewReview(name string, reviewPort int, reviewCacheAddr string, reviewDatabaseAddr string, namespace string, quorum QuorumOptions, detailAddrs []string) *Review {
+	var databaseClient, indexClient mydatabase.DatabaseServiceClient
+	if len(quorum.Replicas) > 0 {
+		indexQuorum := quorum
+		quorum.Namespace, indexQuorum.Namespace = ReviewRecordNamespace(namespace), ReviewIndexNamespace(namespace)
+		// stats updated by posts through different servers at once are recounted
+		indexQuorum.Siblings = true
+		databaseClient = NewQuorumClient(name, quorum)
+		indexClient = NewQuorumClient(name+"-index", indexQuorum)
+	} else {
+		conn := mydatabase.NewDatabaseServiceClient(dial(reviewDatabaseAddr)) // Initialize and establish cxn using specified address
+		databaseClient = NewNamespacedClient(conn, ReviewRecordNamespace(namespace))
+		indexClient = NewNamespacedClient(conn, ReviewIndexNamespace(namespace))
+	}
+
 	return &Review{
 		name: name,
 		port: reviewPort,
 
-		reviewCacheClient:    mycache.NewCacheServiceClient(dial(reviewCacheAddr)),          // Initialize and establish cxn using specified address
-		reviewDatabaseClient: mydatabase.NewDatabaseServiceClient(dial(reviewDatabaseAddr)), // Initialize and establish cxn using specified address
+		reviewCacheClient:    mycache.NewCacheServiceClient(dial(reviewCacheAddr)), // Initialize and establish cxn using specified address
+		reviewDatabaseClient: databaseClient,
+		reviewIndexClient:    indexClient,
+		restaurants:          newRestaurantDirectory(detailAddrs),
+		transactions:         len(quorum.Replicas) == 0,
 		CACHE_FLAG:           true,
 	}
 }
 
+// ReviewRecordNamespace returns the namespace the reviews of namespace are kept in, a
+// record per review, so that they are never mistaken for the records holding all the
+// reviews of a restaurant kept in namespace itself before.
+func ReviewRecordNamespace(namespace string) string {
+	if namespace == "" {
+		return "review-records"
+	}
+	return namespace + "-records"
+}
+
+// ReviewIndexNamespace returns the namespace the index of the reviews kept in namespace is
+// kept in, apart from the reviews, so that scans of the reviews only see reviews.
+func ReviewIndexNamespace(namespace string) string {
+	if namespace == "" {
+		return "review-index"
+	}
+	return namespace + "-index"
+}
+
+// reviewKey returns the key of a user's review of a restaurant. Restaurant IDs hold no
+// slash, so no two pairs of restaurant and user give the same key.
+func reviewKey(restaurantID, userName string) (string, error) {
+	key, err := GetQueryUUID(restaurantID+"/", userName)
+	if err != nil {
+		return "", status.Errorf(codes.Internal, "Failed to derive review key: %v", err)
+	}
+	return key, nil
+}
+
+// reviewIndexPrefix returns the prefix of the keys of the index entries of a restaurant's
+// reviews.
+func reviewIndexPrefix(restaurantID string) string {
+	return restaurantID + "/"
+}
+
 // Run starts the Review gRPC server and listens for incoming requests.
 // It returns an error if the server fails to start or encounters an error.
 func (s *Review) Run() error {
 	// Create a new gRPC server instance.
-	srv := grpc.NewServer()
+	srv := grpc.NewServer(grpc.UnaryInterceptor(validateUnary))
 
 	// Register the Review server implementation with the gRPC server.
 	review.RegisterReviewServiceServer(srv, s)
@@ -65,172 +145,340 @@ func (s *Review) Run() error {
 
 func (s *Review) GetReview(ctx context.Context, req *review.GetReviewRequest) (*review.GetReviewResponse, error) {
 	username := req.GetUserName()
-	restaurant_name := req.GetRestaurantName()
 
 	reviewResponse := &review.GetReviewResponse{}
-	searchResponse := &review.SearchReviewsResponse{}
-
-	var err error
-
-	getCacheItemMsg := &mycache.GetItemRequest{
-		Key: restaurant_name,
+	restaurant_id, err := s.restaurants.restaurantID(ctx, req.GetRestaurantId(), req.GetRestaurantName())
+	if err != nil {
+		return reviewResponse, err
+	}
+	key, err := reviewKey(restaurant_id, username)
+	if err != nil {
+		return reviewResponse, err
 	}
-
-	var getItemResponse *mycache.GetItemResponse
-	var errGetItem error
 
 	// Check the cache for the review
-	getItemResponse, errGetItem = s.reviewCacheClient.GetItem(ctx, getCacheItemMsg)
-	if errGetItem == nil {
-		rawValue := getItemResponse.Item.Value
-		err = proto.Unmarshal(rawValue, searchResponse)
-		if err != nil {
-			err = status.Errorf(codes.Internal, "Failed to deserialize data")
-		} else {
-			err = status.Errorf(codes.OK, "Found value with Key: %s", restaurant_name)
-		}
-	} else {
-		// Make a call to the storage layer due to a cache-miss
-		getRecordMsg := &mydatabase.GetRecordRequest{
-			Key: restaurant_name,
+	if getItemResponse, errGetItem := s.reviewCacheClient.GetItem(ctx, &mycache.GetItemRequest{Key: key}); errGetItem == nil {
+		if err := proto.Unmarshal(getItemResponse.Item.Value, reviewResponse); err != nil {
+			return reviewResponse, status.Errorf(codes.Internal, "Failed to deserialize data")
 		}
+		return reviewResponse, nil
+	}
 
-		if getRecordResponse, errGetRecord := s.reviewDatabaseClient.GetRecord(ctx, getRecordMsg); errGetRecord == nil {
-			rawValue := getRecordResponse.Record.Value
-			err = proto.Unmarshal(rawValue, searchResponse)
-			if err != nil {
-				err = status.Errorf(codes.Internal, "Failed to deserialize data")
-			} else {
-				// If we found the item in the storage layer, then update the cache to hold this element
-				if s.CACHE_FLAG {
-					updateCache(ctx, s.reviewCacheClient, restaurant_name, rawValue)
-				}
-				err = status.Errorf(codes.OK, "Found value with Key: %s", restaurant_name)
-			}
-		} else {
-			return reviewResponse, status.Errorf(codes.NotFound, "Item with Key: %s does not exist", restaurant_name)
-		}
+	// Make a call to the storage layer due to a cache-miss
+	reviewResponse, rawValue, err := s.readReview(ctx, key, 0)
+	if status.Code(err) == codes.NotFound {
+		return &review.GetReviewResponse{}, status.Errorf(codes.NotFound, "Review of %s by %s does not exist", restaurant_id, username)
+	}
+	if err != nil {
+		return &review.GetReviewResponse{}, err
+	}
+	// If we found the item in the storage layer, then update the cache to hold this element
+	if s.CACHE_FLAG {
+		updateCache(ctx, s.reviewCacheClient, key, rawValue)
+	}
+	return reviewResponse, nil
+}
+
+// readReview reads the review kept under key, in transaction txn if it is set, and returns
+// it with its encoding.
+func (s *Review) readReview(ctx context.Context, key string, txn uint64) (*review.GetReviewResponse, []byte, error) {
+	getRecordResponse, err := s.reviewDatabaseClient.GetRecord(ctx, &mydatabase.GetRecordRequest{Key: key, TransactionId: txn})
+	if status.Code(err) == codes.NotFound {
+		return nil, nil, status.Errorf(codes.NotFound, "Item with Key: %s does not exist", key)
+	}
+	if err != nil {
+		return nil, nil, status.Errorf(codes.Internal, "Failed to read data storage: %v", err)
 	}
-	reviewResponse = searchResponse.ReviewsMap[username]
-	return reviewResponse, err
+	rawValue := getRecordResponse.GetRecord().GetValue()
+	msg := &review.GetReviewResponse{}
+	if err := proto.Unmarshal(rawValue, msg); err != nil {
+		return nil, nil, status.Errorf(codes.Internal, "Failed to deserialize data")
+	}
+	return msg, rawValue, nil
 }
 
 func (s *Review) SearchReviews(ctx context.Context, req *review.SearchReviewsRequest) (*review.SearchReviewsResponse, error) {
-	restaurant_name := req.GetRestaurantName()
 	searchResponse := &review.SearchReviewsResponse{}
-
-	var err error
-
-	getCacheItemMsg := &mycache.GetItemRequest{
-		Key: restaurant_name,
+	restaurant_id, err := s.restaurants.restaurantID(ctx, req.GetRestaurantId(), req.GetRestaurantName())
+	if err != nil {
+		return searchResponse, err
 	}
 
-	var getItemResponse *mycache.GetItemResponse
-	var errGetItem error
-
 	// Check the cache for the restaurant
-	getItemResponse, errGetItem = s.reviewCacheClient.GetItem(ctx, getCacheItemMsg)
-	if errGetItem == nil {
-		rawValue := getItemResponse.Item.Value
-		err = proto.Unmarshal(rawValue, searchResponse)
-		if err != nil {
-			err = status.Errorf(codes.Internal, "Failed to deserialize data")
-		} else {
-			err = status.Errorf(codes.OK, "Found value with Key: %s", restaurant_name)
+	if getItemResponse, errGetItem := s.reviewCacheClient.GetItem(ctx, &mycache.GetItemRequest{Key: restaurant_id}); errGetItem == nil {
+		if err := proto.Unmarshal(getItemResponse.Item.Value, searchResponse); err != nil {
+			return searchResponse, status.Errorf(codes.Internal, "Failed to deserialize data")
 		}
-	} else {
-		// Make a call to the storage layer due to a cache-miss
-		getRecordMsg := &mydatabase.GetRecordRequest{
-			Key: restaurant_name,
+		return searchResponse, nil
+	}
+
+	// Make calls to the storage layer due to a cache-miss, reading the reviews the
+	// restaurant's index lists several at a time
+	var keys []string
+	prefix := reviewIndexPrefix(restaurant_id)
+	err = scanRecords(ctx, s.reviewIndexClient, &mydatabase.ScanRecordsRequest{Prefix: prefix}, func(record *mydatabase.DatabaseRecord) error {
+		keys = append(keys, strings.TrimPrefix(record.GetKey(), prefix))
+		return nil
+	})
+	if err != nil {
+		return &review.SearchReviewsResponse{}, status.Errorf(codes.Internal, "Failed to read reviews of %s: %v", restaurant_id, err)
+	}
+	reviews, err := s.getReviews(ctx, keys)
+	if err != nil {
+		return &review.SearchReviewsResponse{}, err
+	}
+	searchResponse.ReviewsMap = make(map[string]*review.GetReviewResponse, len(reviews))
+	for _, r := range reviews {
+		searchResponse.ReviewsMap[r.GetUserName()] = r
+	}
+	if len(searchResponse.ReviewsMap) == 0 {
+		return searchResponse, status.Errorf(codes.NotFound, "Item with Key: %s does not exist", restaurant_id)
+	}
+
+	// Cache the reviews found until another is posted
+	if s.CACHE_FLAG {
+		if rawValue, err := proto.Marshal(searchResponse); err == nil {
+			updateCache(ctx, s.reviewCacheClient, restaurant_id, rawValue)
 		}
+	}
+	return searchResponse, nil
+}
 
-		if getRecordResponse, errGetRecord := s.reviewDatabaseClient.GetRecord(ctx, getRecordMsg); errGetRecord == nil {
-			rawValue := getRecordResponse.Record.Value
-			err = proto.Unmarshal(rawValue, searchResponse)
-			if err != nil {
-				err = status.Errorf(codes.Internal, "Failed to deserialize data")
-			} else {
-				// If we found the item in the storage layer, then update the cache to hold this element
-				if s.CACHE_FLAG {
-					updateCache(ctx, s.reviewCacheClient, restaurant_name, rawValue)
-				}
-				err = status.Errorf(codes.OK, "Found value with Key: %s", restaurant_name)
+// getReviews returns the reviews kept under the given keys, reading them from the database
+// several at a time. Reviews indexed before they were written, by a post not made in a
+// transaction, are skipped.
+func (s *Review) getReviews(ctx context.Context, keys []string) ([]*review.GetReviewResponse, error) {
+	reviews := make([]*review.GetReviewResponse, len(keys))
+	errs := make([]error, len(keys))
+	var wg sync.WaitGroup
+	fetches := make(chan struct{}, reviewFetches)
+	for i, key := range keys {
+		i, key := i, key
+		wg.Add(1)
+		fetches <- struct{}{}
+		go func() {
+			defer func() {
+				<-fetches
+				wg.Done()
+			}()
+			r, _, err := s.readReview(ctx, key, 0)
+			if status.Code(err) != codes.NotFound {
+				reviews[i], errs[i] = r, err
 			}
-		} else {
-			return searchResponse, status.Errorf(codes.NotFound, "Item with Key: %s does not exist", restaurant_name)
+		}()
+	}
+	wg.Wait()
+
+	found := reviews[:0]
+	for i, r := range reviews {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+		if r != nil {
+			found = append(found, r)
 		}
 	}
-	return searchResponse, err
+	return found, nil
 }
 
 func (s *Review) PostReview(ctx context.Context, req *review.PostReviewRequest) (*review.PostReviewResponse, error) {
 	username := req.GetUserName()
-	restaurant_name := req.GetRestaurantName()
 	userReview := req.GetReview()
 	rating := req.GetRating()
 
 	reviewResponse := &review.PostReviewResponse{Status: false}
-
-	var err error
+	restaurant_id, err := s.restaurants.restaurantID(ctx, req.GetRestaurantId(), req.GetRestaurantName())
+	if err != nil {
+		return reviewResponse, err
+	}
+	key, err := reviewKey(restaurant_id, username)
+	if err != nil {
+		return reviewResponse, err
+	}
 
 	// Create a new GetReviewResponse object with the details to save.
 	msg := &review.GetReviewResponse{
 		UserName:       username,
-		RestaurantName: restaurant_name,
+		RestaurantName: req.GetRestaurantName(),
+		RestaurantId:   restaurant_id,
 		Review:         userReview,
 		Rating:         rating,
 	}
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		// If serialization fails, return an internal error.
+		return reviewResponse, status.Errorf(codes.Internal, "Failed to serialize data")
+	}
 
-	getCacheItemMsg := &mycache.GetItemRequest{
-		Key: restaurant_name,
+	// Posts of reviews of the same restaurant through this server wait for each other
+	// rather than abort each other's updates of its stats
+	mu := &s.postLocks[hash(restaurant_id)%reviewPostLocks]
+	mu.Lock()
+	var stats *review.ReviewStats
+	for attempt := 0; ; attempt++ {
+		stats, err = s.tryPostReview(ctx, key, msg, data)
+		if status.Code(err) != codes.Aborted || attempt == reviewPostRetries {
+			break
+		}
+	}
+	mu.Unlock()
+	if err != nil {
+		return reviewResponse, err
 	}
 
-	searchResponse := &review.SearchReviewsResponse{}
-	username_reviews := make(map[string]*review.GetReviewResponse)
+	if s.CACHE_FLAG {
+		updateCache(ctx, s.reviewCacheClient, key, data)
+	}
+	// The reviews of the restaurant cached by SearchReviews no longer include them all
+	s.reviewCacheClient.DeleteItem(ctx, &mycache.DeleteItemRequest{Key: restaurant_id})
 
-	var getItemResponse *mycache.GetItemResponse
-	var errGetItem error
+	reviewResponse.Status = true
+	reviewResponse.AverageRating, reviewResponse.ReviewCount = averageRating(stats)
+	reviewResponse.RatingVersion = stats.GetVersion()
+	return reviewResponse, nil
+}
+
+// tryPostReview makes one attempt at posting a review, in a transaction if the database
+// supports them, and returns the restaurant's stats with the review.
+func (s *Review) tryPostReview(ctx context.Context, key string, msg *review.GetReviewResponse, data []byte) (*review.ReviewStats, error) {
+	if !s.transactions {
+		return s.writeReview(ctx, key, 0, msg, data)
+	}
+	begin, err := s.reviewDatabaseClient.BeginTransaction(ctx, &mydatabase.BeginTransactionRequest{})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Failed to start transaction: %v", err)
+	}
+	txn := begin.GetTransactionId()
+	stats, err := s.writeReview(ctx, key, txn, msg, data)
+	if err != nil {
+		s.reviewDatabaseClient.AbortTransaction(ctx, &mydatabase.AbortTransactionRequest{TransactionId: txn})
+		return nil, err
+	}
+	if _, err := s.reviewDatabaseClient.CommitTransaction(ctx, &mydatabase.CommitTransactionRequest{TransactionId: txn}); err != nil {
+		if status.Code(err) == codes.Aborted {
+			return nil, err
+		}
+		return nil, status.Errorf(codes.Internal, "Failed to commit transaction: %v", err)
+	}
+	return stats, nil
+}
 
-	// Check the cache to see if restaurant already exists
-	getItemResponse, errGetItem = s.reviewCacheClient.GetItem(ctx, getCacheItemMsg)
-	if errGetItem == nil {
-		rawValue := getItemResponse.Item.Value
-		err = proto.Unmarshal(rawValue, searchResponse)
+// writeReview writes a review under key, in transaction txn if it is set, along with its
+// entry in the restaurant's index, which holds the review's contribution to the
+// restaurant's stats, and the restaurant's stats, updated to count the review in place of
+// the one it replaces, if any. It returns the stats written. The review is written before
+// its index entry, so that without a transaction an entry may only lead to a review not
+// yet written; a post failing partway then leaves the stats without it until they are
+// next recounted.
+func (s *Review) writeReview(ctx context.Context, key string, txn uint64, msg *review.GetReviewResponse, data []byte) (*review.ReviewStats, error) {
+	restaurantID := msg.GetRestaurantId()
+	stats, causal, err := s.readStats(ctx, restaurantID, txn)
+	if err != nil {
+		return nil, err
+	}
+	previous, _, err := s.readReview(ctx, key, txn)
+	switch {
+	case status.Code(err) == codes.NotFound:
+		stats.ReviewCount++
+	case err != nil:
+		return nil, err
+	default:
+		stats.RatingTotal -= int64(previous.GetRating())
+	}
+	stats.RatingTotal += int64(msg.GetRating())
+	stats.Version++
+
+	entry, err := proto.Marshal(&review.ReviewStats{ReviewCount: 1, RatingTotal: int64(msg.GetRating())})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Failed to serialize data")
+	}
+	total, err := proto.Marshal(stats)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Failed to serialize data")
+	}
+
+	writes := []struct {
+		db     mydatabase.DatabaseServiceClient
+		record *mydatabase.DatabaseRecord
+		causal []byte
+	}{
+		{s.reviewDatabaseClient, &mydatabase.DatabaseRecord{Key: key, Value: data}, nil},
+		{s.reviewIndexClient, &mydatabase.DatabaseRecord{Key: reviewIndexPrefix(restaurantID) + key, Value: entry}, nil},
+		{s.reviewIndexClient, &mydatabase.DatabaseRecord{Key: restaurantID, Value: total}, causal},
+	}
+	for _, w := range writes {
+		setRecordResponse, err := w.db.SetRecord(ctx, &mydatabase.SetRecordRequest{Record: w.record, TransactionId: txn, CausalContext: w.causal})
 		if err != nil {
-			err = status.Errorf(codes.Internal, "Failed to deserialize data")
-			return reviewResponse, err
-		} else {
-			err = status.Errorf(codes.OK, "Found value with Key: %s", restaurant_name)
-			// Store the map so we can add the new review
-			username_reviews = searchResponse.ReviewsMap
+			if status.Code(err) == codes.Aborted {
+				return nil, err
+			}
+			return nil, status.Errorf(codes.Internal, "Error in updating data storage: %v", err)
 		}
-	} else {
-		getRecordMsg := &mydatabase.GetRecordRequest{
-			Key: restaurant_name,
+		if !setRecordResponse.GetSuccess() {
+			return nil, status.Errorf(codes.Internal, "Failed to update data storage")
+		}
+	}
+	return stats, nil
+}
+
+// readStats reads the stats of a restaurant, kept in its index of reviews under its ID, in
+// transaction txn if it is set, along with the causal context to write them back with when
+// the index is replicated by quorum. A restaurant without reviews has empty stats. Stats
+// that posts through different servers updated at once are recounted from the index
+// entries of the restaurant's reviews.
+func (s *Review) readStats(ctx context.Context, restaurantID string, txn uint64) (*review.ReviewStats, []byte, error) {
+	getRecordResponse, err := s.reviewIndexClient.GetRecord(ctx, &mydatabase.GetRecordRequest{Key: restaurantID, TransactionId: txn})
+	if status.Code(err) == codes.NotFound {
+		return &review.ReviewStats{}, getRecordResponse.GetCausalContext(), nil
+	}
+	if err != nil {
+		return nil, nil, status.Errorf(codes.Internal, "Failed to read stats of %s: %v", restaurantID, err)
+	}
+	if siblings := getRecordResponse.GetSiblings(); len(siblings) > 1 {
+		stats, err := s.countStats(ctx, restaurantID)
+		if err != nil {
+			return nil, nil, err
 		}
-		if getRecordResponse, errGetItem := s.reviewDatabaseClient.GetRecord(ctx, getRecordMsg); errGetItem == nil {
-			rawValue := getRecordResponse.Record.Value
-			err = proto.Unmarshal(rawValue, searchResponse)
-			if err != nil {
-				err = status.Errorf(codes.Internal, "Failed to deserialize data")
-				return reviewResponse, err
-			} else {
-				err = status.Errorf(codes.OK, "Found value with Key: %s", restaurant_name)
-				// update the map to add the new review
-				username_reviews = searchResponse.ReviewsMap
+		for _, sibling := range siblings {
+			concurrent := &review.ReviewStats{}
+			if err := proto.Unmarshal(sibling.GetValue(), concurrent); err != nil {
+				return nil, nil, status.Errorf(codes.Internal, "Failed to deserialize data")
+			}
+			if concurrent.GetVersion() > stats.GetVersion() {
+				stats.Version = concurrent.GetVersion()
 			}
 		}
+		return stats, getRecordResponse.GetCausalContext(), nil
 	}
+	stats := &review.ReviewStats{}
+	if err := proto.Unmarshal(getRecordResponse.GetRecord().GetValue(), stats); err != nil {
+		return nil, nil, status.Errorf(codes.Internal, "Failed to deserialize data")
+	}
+	return stats, getRecordResponse.GetCausalContext(), nil
+}
 
-	username_reviews[username] = msg
-	searchResponse.ReviewsMap = username_reviews
-
-	data, err := proto.Marshal(searchResponse)
+// countStats sums the stats the entries of a restaurant's index of reviews hold.
+func (s *Review) countStats(ctx context.Context, restaurantID string) (*review.ReviewStats, error) {
+	stats := &review.ReviewStats{}
+	err := scanRecords(ctx, s.reviewIndexClient, &mydatabase.ScanRecordsRequest{Prefix: reviewIndexPrefix(restaurantID)}, func(record *mydatabase.DatabaseRecord) error {
+		entry := &review.ReviewStats{}
+		if err := proto.Unmarshal(record.GetValue(), entry); err != nil {
+			return fmt.Errorf("failed to deserialize index entry %s: %v", record.GetKey(), err)
+		}
+		stats.ReviewCount += entry.GetReviewCount()
+		stats.RatingTotal += entry.GetRatingTotal()
+		return nil
+	})
 	if err != nil {
-		// If serialization fails, return an internal error.
-		return reviewResponse, status.Errorf(codes.Internal, "Failed to serialize data")
+		return nil, status.Errorf(codes.Internal, "Failed to read stats of %s: %v", restaurantID, err)
 	}
+	return stats, nil
+}
 
-	reviewResponse.Status, err = updateDB(ctx, s.reviewCacheClient, s.reviewDatabaseClient, restaurant_name, data, s.CACHE_FLAG)
-	return reviewResponse, err
+// averageRating returns the average rating of a restaurant's reviews and their number.
+func averageRating(stats *review.ReviewStats) (float64, int32) {
+	if stats.GetReviewCount() == 0 {
+		return 0, 0
+	}
+	return float64(stats.GetRatingTotal()) / float64(stats.GetReviewCount()), stats.GetReviewCount()
 }
@@ -0,0 +1,256 @@
+package services
+
+import (
+	"container/heap"
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// PriorityKey is the metadata key carrying the priority class of a database request:
+// PriorityHigh, PriorityNormal or PriorityLow. Requests without one are PriorityNormal.
+const PriorityKey = "mydatabase-priority"
+
+const (
+	PriorityHigh   = "high"
+	PriorityNormal = "normal"
+	PriorityLow    = "low"
+)
+
+// priorityClasses orders the priority classes, most urgent first.
+var priorityClasses = []string{PriorityHigh, PriorityNormal, PriorityLow}
+
+// WithPriority returns a context sending database requests made with it in a priority class.
+func WithPriority(ctx context.Context, class string) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, PriorityKey, class)
+}
+
+// requestPriority returns the rank of the priority class of an incoming request in
+// priorityClasses, and the class itself.
+func requestPriority(ctx context.Context) (int, string, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok || len(md.Get(PriorityKey)) == 0 {
+		return 1, PriorityNormal, nil
+	}
+	class := md.Get(PriorityKey)[0]
+	for rank, c := range priorityClasses {
+		if c == class {
+			return rank, class, nil
+		}
+	}
+	return 0, "", status.Errorf(codes.InvalidArgument, "Invalid request priority %q: must be one of %s", class, strings.Join(priorityClasses, ", "))
+}
+
+// SchedulerOptions bounds how many requests a database server serves at once.
+type SchedulerOptions struct {
+	// MaxConcurrent is the number of requests served at once; the others wait in a queue.
+	// Zero serves every request as soon as it arrives, though requests whose deadline has
+	// passed are still dropped and every request is still counted in the metrics.
+	MaxConcurrent int
+}
+
+// schedulerWaiter is a request waiting in the queue for its turn.
+type schedulerWaiter struct {
+	rank     int
+	deadline time.Time // zero if the request has none
+	seq      uint64
+	ready    chan struct{} // closed when the request is admitted
+	index    int           // position in the queue, or -1 once removed from it
+}
+
+// schedulerQueue is a heap of waiting requests ordered by priority class, then earliest
+// deadline first, with requests without a deadline last, then order of arrival.
+type schedulerQueue []*schedulerWaiter
+
+func (q schedulerQueue) Len() int { return len(q) }
+
+func (q schedulerQueue) Less(i, j int) bool {
+	a, b := q[i], q[j]
+	switch {
+	case a.rank != b.rank:
+		return a.rank < b.rank
+	case !a.deadline.Equal(b.deadline):
+		if a.deadline.IsZero() || b.deadline.IsZero() {
+			return b.deadline.IsZero()
+		}
+		return a.deadline.Before(b.deadline)
+	}
+	return a.seq < b.seq
+}
+
+func (q schedulerQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *schedulerQueue) Push(x interface{}) {
+	w := x.(*schedulerWaiter)
+	w.index = len(*q)
+	*q = append(*q, w)
+}
+
+func (q *schedulerQueue) Pop() interface{} {
+	old := *q
+	w := old[len(old)-1]
+	old[len(old)-1] = nil
+	w.index = -1
+	*q = old[:len(old)-1]
+	return w
+}
+
+// scheduler admits at most slots requests at once, or any number if slots is zero, in the
+// order of its queue. Requests
+// whose deadline passes before they are admitted are dropped with DeadlineExceeded instead
+// of spending a slot on an answer nobody is waiting for.
+type scheduler struct {
+	slots int
+
+	mu      sync.Mutex
+	running int
+	queue   schedulerQueue
+	seq     uint64
+
+	admitted    map[string]uint64
+	queueDelay  map[string]time.Duration // total time admitted requests of each class waited
+	serviceTime time.Duration            // total time admitted requests were served for
+	served      uint64
+	expired     uint64 // requests dropped because their deadline passed
+	cancelled   uint64 // requests whose clients gave up while they were queued
+}
+
+func newScheduler(options SchedulerOptions) *scheduler {
+	return &scheduler{
+		slots:      options.MaxConcurrent,
+		admitted:   make(map[string]uint64),
+		queueDelay: make(map[string]time.Duration),
+	}
+}
+
+// acquire waits for a slot to serve a request of the given rank and class. It returns
+// DeadlineExceeded or Canceled if the request's context ends first.
+func (s *scheduler) acquire(ctx context.Context, rank int, class string) error {
+	arrived := time.Now()
+	deadline, _ := ctx.Deadline()
+	if !deadline.IsZero() && !arrived.Before(deadline) {
+		s.mu.Lock()
+		s.expired++
+		s.mu.Unlock()
+		return status.Errorf(codes.DeadlineExceeded, "Request deadline passed before it was scheduled")
+	}
+
+	s.mu.Lock()
+	if (s.slots <= 0 || s.running < s.slots) && s.queue.Len() == 0 {
+		s.running++
+		s.admitted[class]++
+		s.mu.Unlock()
+		return nil
+	}
+	s.seq++
+	w := &schedulerWaiter{rank: rank, deadline: deadline, seq: s.seq, ready: make(chan struct{})}
+	heap.Push(&s.queue, w)
+	s.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		if ctx.Err() == nil {
+			s.mu.Lock()
+			s.admitted[class]++
+			s.queueDelay[class] += time.Since(arrived)
+			s.mu.Unlock()
+			return nil
+		}
+		// admitted just as the context ended: hand the slot on
+		s.release(0)
+	case <-ctx.Done():
+		s.mu.Lock()
+		if w.index >= 0 {
+			heap.Remove(&s.queue, w.index)
+			s.mu.Unlock()
+		} else {
+			s.mu.Unlock()
+			s.release(0)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if ctx.Err() == context.DeadlineExceeded {
+		s.expired++
+		return status.Errorf(codes.DeadlineExceeded, "Request deadline passed after waiting %v to be scheduled", time.Since(arrived))
+	}
+	s.cancelled++
+	return status.Errorf(codes.Canceled, "Request canceled while waiting to be scheduled")
+}
+
+// release frees the slot of a request served for the given time, admitting the first
+// request in the queue in its place.
+func (s *scheduler) release(served time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if served > 0 {
+		s.serviceTime += served
+		s.served++
+	}
+	if s.queue.Len() == 0 {
+		s.running--
+		return
+	}
+	w := heap.Pop(&s.queue).(*schedulerWaiter)
+	close(w.ready)
+}
+
+// unaryInterceptor schedules the unary calls to the database service. Raft traffic is
+// served at once, and streaming calls, which last as long as their clients keep reading,
+// are not scheduled.
+func (s *scheduler) unaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if !strings.HasPrefix(info.FullMethod, "/mydatabase.DatabaseService/") {
+		return handler(ctx, req)
+	}
+	rank, class, err := requestPriority(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.acquire(ctx, rank, class); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	defer func() {
+		s.release(time.Since(start))
+	}()
+	return handler(ctx, req)
+}
+
+// Metrics reports the requests running and queued, the requests dropped, and the mean
+// time requests waited in the queue, by priority class, apart from the mean time they
+// were then served for.
+func (s *scheduler) Metrics() map[string]float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	metrics := map[string]float64{
+		"scheduler_slots":     float64(s.slots),
+		"scheduler_running":   float64(s.running),
+		"scheduler_queued":    float64(s.queue.Len()),
+		"scheduler_expired":   float64(s.expired),
+		"scheduler_cancelled": float64(s.cancelled),
+	}
+	if s.served > 0 {
+		metrics["scheduler_service_time_ms_mean"] = float64(s.serviceTime.Microseconds()) / 1000 / float64(s.served)
+	}
+	for _, class := range priorityClasses {
+		n := s.admitted[class]
+		metrics["scheduler_admitted_"+class] = float64(n)
+		if n > 0 {
+			metrics["scheduler_queue_delay_ms_mean_"+class] = float64(s.queueDelay[class].Microseconds()) / 1000 / float64(n)
+		}
+	}
+	return metrics
+}
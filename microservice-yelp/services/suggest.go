@@ -0,0 +1,254 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"gitlab.cs.washington.edu/syslab/cse453-welp/proto/detail"
+	"gitlab.cs.washington.edu/syslab/cse453-welp/proto/mydatabase"
+	"golang.org/x/text/unicode/norm"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	detailSuggestions       = 5    // suggestions attached to a NotFound error of GetDetail
+	minSuggestionSimilarity = 0.3  // trigram similarity a name needs to be suggested, unless it is a few edits away
+	maxSuggestionEdits      = 2    // edits a name can be away from the one looked up to be suggested regardless
+	suggestionCandidates    = 1000 // restaurants found per trigram; names sharing only common trigrams may be missed
+	suggestionReads         = 20   // restaurants sharing the most trigrams with a name whose details are read to rank them
+)
+
+// normalizeName folds the differences between restaurant names that people get wrong: it
+// lowercases the name, strips diacritics, drops apostrophes and turns every other run of
+// punctuation and spaces into a single space.
+func normalizeName(name string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range norm.NFKD.String(name) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			// a diacritic, split from its letter by the decomposition
+		case r == '\'' || r == '‘' || r == '’':
+			// "Cane's" and "Canes" are the same name
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if space && b.Len() > 0 {
+				b.WriteByte(' ')
+			}
+			space = false
+			b.WriteRune(unicode.ToLower(r))
+		default:
+			space = true
+		}
+	}
+	return b.String()
+}
+
+// trigrams returns the distinct runs of three characters of a normalized name, padded with
+// a space at either end so that its first and last characters count as much as the others.
+func trigrams(normalized string) []string {
+	if normalized == "" {
+		return nil
+	}
+	runes := []rune(" " + normalized + " ")
+	seen := make(map[string]bool)
+	var grams []string
+	for i := 0; i+3 <= len(runes); i++ {
+		if gram := string(runes[i : i+3]); !seen[gram] {
+			seen[gram] = true
+			grams = append(grams, gram)
+		}
+	}
+	return grams
+}
+
+// editDistance returns the Levenshtein distance between two strings, in characters.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = prev[j-1] + cost
+			if prev[j]+1 < cur[j] {
+				cur[j] = prev[j] + 1
+			}
+			if cur[j-1]+1 < cur[j] {
+				cur[j] = cur[j-1] + 1
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
+
+// normalizedName indexes detail records by the normalized name of their restaurant.
+func normalizedName(d *detail.GetDetailResponse) string {
+	return normalizeName(d.GetRestaurantName())
+}
+
+// nameTrigrams turns an index extractor of normalized names into one indexing records
+// under each trigram of their names.
+func nameTrigrams(extract func(key string, value []byte) []string) func(key string, value []byte) []string {
+	return func(key string, value []byte) []string {
+		seen := make(map[string]bool)
+		var grams []string
+		for _, name := range extract(key, value) {
+			for _, gram := range trigrams(name) {
+				if !seen[gram] {
+					seen[gram] = true
+					grams = append(grams, gram)
+				}
+			}
+		}
+		return grams
+	}
+}
+
+// detailNotFound returns the NotFound error of looking up a restaurant by name, with the
+// restaurants of similar names attached as RestaurantSuggestions. Suggestions are best
+// effort: if they cannot be found, the error carries none.
+func (s *Detail) detailNotFound(ctx context.Context, restaurantName string) error {
+	st := status.New(codes.NotFound, fmt.Sprintf("Item with Key: %s does not exist", restaurantName))
+	suggestions, err := s.suggestRestaurants(ctx, restaurantName)
+	if err != nil {
+		log.Printf("failed to suggest restaurants for %s: %v", restaurantName, err)
+		return st.Err()
+	}
+	if len(suggestions) == 0 {
+		return st.Err()
+	}
+	if withDetails, err := st.WithDetails(&detail.RestaurantSuggestions{Suggestions: suggestions}); err == nil {
+		st = withDetails
+	}
+	return st.Err()
+}
+
+// suggestRestaurants returns the restaurants whose names are most similar to a name that
+// was not found, most similar first. Names equal to it once normalized come first; the
+// others are ranked by the share of their trigrams they have in common with it, then by
+// how many edits away from it they are.
+func (s *Detail) suggestRestaurants(ctx context.Context, restaurantName string) ([]*detail.RestaurantSuggestion, error) {
+	normalized := normalizeName(restaurantName)
+	grams := trigrams(normalized)
+	if len(grams) == 0 {
+		return nil, nil
+	}
+
+	var mu sync.Mutex
+	shared := make(map[string]int) // trigrams each candidate has in common with the name
+	exact := make(map[string]bool) // candidates with the same normalized name
+	errs := make([]error, len(grams)+1)
+	var wg sync.WaitGroup
+	query := func(i int, condition *mydatabase.IndexCondition) {
+		defer wg.Done()
+		msg, err := s.detailDatabaseClient.QueryIndex(ctx, &mydatabase.QueryIndexRequest{
+			Conditions: []*mydatabase.IndexCondition{condition},
+			Limit:      suggestionCandidates,
+		})
+		if err != nil {
+			errs[i] = err
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		for _, key := range msg.GetKeys() {
+			if condition.GetIndex() == DetailNameIndex {
+				exact[key] = true
+			} else {
+				shared[key]++
+			}
+		}
+	}
+	wg.Add(len(grams) + 1)
+	go query(0, &mydatabase.IndexCondition{Index: DetailNameIndex, Value: normalized})
+	for i, gram := range grams {
+		go query(i+1, &mydatabase.IndexCondition{Index: DetailTrigramIndex, Value: gram})
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "Failed to query data storage: %v", err)
+		}
+	}
+
+	// the names of the restaurants are only in their details, so read the details of the
+	// ones sharing the most trigrams with the name, apart from those sharing its normalized
+	// name, and rank those
+	ids := make([]string, 0, len(exact))
+	for id := range exact {
+		ids = append(ids, id)
+	}
+	var others []string
+	for id := range shared {
+		if !exact[id] {
+			others = append(others, id)
+		}
+	}
+	sort.Slice(others, func(i, j int) bool {
+		if shared[others[i]] != shared[others[j]] {
+			return shared[others[i]] > shared[others[j]]
+		}
+		return others[i] < others[j]
+	})
+	if len(others) > suggestionReads {
+		others = others[:suggestionReads]
+	}
+	details, err := s.getDetails(ctx, append(ids, others...))
+	if err != nil {
+		return nil, err
+	}
+
+	type candidate struct {
+		suggestion *detail.RestaurantSuggestion
+		edits      int
+	}
+	var candidates []candidate
+	for _, d := range details {
+		suggestion := &detail.RestaurantSuggestion{RestaurantName: d.GetRestaurantName(), RestaurantId: d.GetRestaurantId(), Similarity: 1}
+		if exact[d.GetRestaurantId()] {
+			candidates = append(candidates, candidate{suggestion: suggestion})
+			continue
+		}
+		other := normalizeName(d.GetRestaurantName())
+		n := shared[d.GetRestaurantId()]
+		suggestion.Similarity = float64(n) / float64(len(grams)+len(trigrams(other))-n)
+		edits := editDistance(normalized, other)
+		if suggestion.Similarity >= minSuggestionSimilarity || edits <= maxSuggestionEdits {
+			candidates = append(candidates, candidate{suggestion: suggestion, edits: edits})
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		switch {
+		case a.suggestion.Similarity != b.suggestion.Similarity:
+			return a.suggestion.Similarity > b.suggestion.Similarity
+		case a.edits != b.edits:
+			return a.edits < b.edits
+		case a.suggestion.RestaurantName != b.suggestion.RestaurantName:
+			return a.suggestion.RestaurantName < b.suggestion.RestaurantName
+		}
+		return a.suggestion.RestaurantId < b.suggestion.RestaurantId
+	})
+	if len(candidates) > detailSuggestions {
+		candidates = candidates[:detailSuggestions]
+	}
+	suggestions := make([]*detail.RestaurantSuggestion, len(candidates))
+	for i, c := range candidates {
+		suggestions[i] = c.suggestion
+	}
+	return suggestions, nil
+}
@@ -4,12 +4,14 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"time"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 
 	"github.com/google/uuid"
 
@@ -77,6 +79,24 @@ func GetQueryUUID(restaurantName, userName string) (string, error) {
 	return uuidObj.String(), nil
 }
 
+// restaurantIDNamespace is the namespace of the restaurant IDs derived from names, apart
+// from the IDs GetQueryUUID derives.
+var restaurantIDNamespace = uuid.NewSHA1(uuid.Nil, []byte("restaurant"))
+
+// NewRestaurantID returns the ID of a new restaurant. Restaurants get IDs of their own, so
+// that several can share a name and keep their reviews and reservations when renamed.
+func NewRestaurantID() string {
+	return uuid.New().String()
+}
+
+// RestaurantIDFromName derives the ID of a restaurant that was keyed by its name before
+// restaurants had IDs, much as GetQueryUUID derives the ID of a restaurant and user pair.
+// The same name always gives the same ID, so the records of each service can be re-keyed
+// separately and still agree.
+func RestaurantIDFromName(restaurantName string) string {
+	return uuid.NewSHA1(restaurantIDNamespace, []byte(restaurantName)).String()
+}
+
 /******************************************************
  * TODO: place your utilities / helper functions here *
  ******************************************************/
@@ -109,7 +129,7 @@ func updateDB(ctx context.Context, cacheClient mycache.CacheServiceClient, dbCli
 			err = status.Errorf(codes.Internal, "Failed to update data storage")
 		} else {
 			statusVal = true
-			err = status.Errorf(codes.OK, "Updated data storage with key: ", key)
+			err = status.Errorf(codes.OK, "Updated data storage with key: %s", key)
 			if cacheFlag {
 				updateCache(ctx, cacheClient, key, val)
 			}
@@ -120,3 +140,34 @@ func updateDB(ctx context.Context, cacheClient mycache.CacheServiceClient, dbCli
 
 	return statusVal, err
 }
+
+// scanRecords calls fn with every record of the database req selects, resuming the scan
+// until it is complete and stopping at the first error.
+func scanRecords(ctx context.Context, db mydatabase.DatabaseServiceClient, req *mydatabase.ScanRecordsRequest, fn func(record *mydatabase.DatabaseRecord) error) error {
+	req = proto.Clone(req).(*mydatabase.ScanRecordsRequest)
+	for {
+		stream, err := db.ScanRecords(ctx, req)
+		if err != nil {
+			return fmt.Errorf("failed to scan database: %v", err)
+		}
+		req.ContinuationToken = ""
+		for {
+			msg, err := stream.Recv()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return fmt.Errorf("failed to scan database: %v", err)
+			}
+			for _, record := range msg.GetRecords() {
+				if err := fn(record); err != nil {
+					return err
+				}
+			}
+			req.ContinuationToken = msg.GetContinuationToken()
+		}
+		if req.ContinuationToken == "" {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,348 @@
+package services
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"gitlab.cs.washington.edu/syslab/cse453-welp/proto/detail"
+	"gitlab.cs.washington.edu/syslab/cse453-welp/proto/reservation"
+	"gitlab.cs.washington.edu/syslab/cse453-welp/proto/review"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Limits on the requests of the detail, review and reservation services, checked before
+// the requests reach them.
+const (
+	maxIDLength        = 64    // characters of a restaurant ID
+	maxNameLength      = 200   // characters of the name of a restaurant, user or author, or of a location or style
+	maxReviewLength    = 10000 // characters of a review
+	maxTextLength      = 1000  // characters of any other string
+	maxCapacity        = 100000
+	minRating          = 1
+	maxRating          = 5
+	maxTopK            = 1000 // most popular restaurants asked for at once
+	maxFieldViolations = 20   // fields an InvalidArgument error lists, at most
+)
+
+// stringFieldLimits are the lengths in characters of the string fields of requests, by
+// field name; other string fields are limited to maxTextLength.
+var stringFieldLimits = map[protoreflect.Name]int{
+	"restaurant_id":   maxIDLength,
+	"restaurant_name": maxNameLength,
+	"user_name":       maxNameLength,
+	"location":        maxNameLength,
+	"style":           maxNameLength,
+	"author":          maxNameLength,
+	"review":          maxReviewLength,
+}
+
+// latestTimeZone is the time zone each day ends last in. A date is in the past once it has
+// ended there, wherever the restaurant is.
+var latestTimeZone = time.FixedZone("UTC-12", -12*60*60)
+
+// validateUnary rejects the requests of unary calls that break the rules of their message
+// type before they reach the service.
+func validateUnary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if err := validateRequest(req); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// validateStream rejects the messages streaming calls receive that break the rules of
+// their message type, as validateUnary does the requests of unary calls.
+func validateStream(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return handler(srv, validatingStream{ss})
+}
+
+// validatingStream validates every message received on a server stream.
+type validatingStream struct {
+	grpc.ServerStream
+}
+
+func (s validatingStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	return validateRequest(m)
+}
+
+// validateRequest checks a request against the rules of its message type. It returns
+// InvalidArgument with a BadRequest detail listing the fields that break them, or nil if
+// none do. The length of every string field is checked before the rules of the message
+// type, which may quote the fields they check.
+func validateRequest(req interface{}) error {
+	m, ok := req.(proto.Message)
+	if !ok {
+		return nil
+	}
+	var v fieldViolations
+	v.checkStrings(m.ProtoReflect(), "")
+	if len(v) > 0 {
+		return v.err()
+	}
+
+	switch req := req.(type) {
+	case *detail.GetDetailRequest:
+		v.checkRestaurant(req.GetRestaurantId(), req.GetRestaurantName())
+	case *detail.PostDetailRequest:
+		v.required("restaurant_name", req.GetRestaurantName())
+		v.checkReviewedRestaurant(req.GetRestaurantId())
+		v.checkCapacity("capacity", req.GetCapacity())
+		if req.GetCoordinates() != nil {
+			v.check("coordinates", checkCoordinates(req.GetCoordinates()))
+		}
+		v.checkProfile("", req.GetHours(), req.GetPriceTier(), req.GetTags(), req.GetContact(), req.GetMenu())
+	case *detail.DeleteDetailRequest:
+		v.checkRestaurant(req.GetRestaurantId(), req.GetRestaurantName())
+	case *detail.PatchDetailRequest:
+		v.checkRestaurant(req.GetRestaurantId(), req.GetRestaurantName())
+		d := req.GetDetail()
+		v.checkCapacity("detail.capacity", d.GetCapacity())
+		if d.GetCoordinates() != nil {
+			v.check("detail.coordinates", checkCoordinates(d.GetCoordinates()))
+		}
+		v.checkProfile("detail.", d.GetHours(), d.GetPriceTier(), d.GetTags(), d.GetContact(), d.GetMenu())
+	case *detail.ListRestaurantsRequest:
+		v.checkCapacity("min_capacity", req.GetMinCapacity())
+		v.checkCapacity("max_capacity", req.GetMaxCapacity())
+		if req.GetMaxCapacity() > 0 && req.GetMaxCapacity() < req.GetMinCapacity() {
+			v.add("max_capacity", "must not be less than min_capacity %d, not %d", req.GetMinCapacity(), req.GetMaxCapacity())
+		}
+		if _, ok := detail.RestaurantOrder_name[int32(req.GetOrderBy())]; !ok {
+			v.add("order_by", "must be a listing order, not %d", req.GetOrderBy())
+		}
+		if req.GetPageSize() < 0 {
+			v.add("page_size", "must not be negative, not %d", req.GetPageSize())
+		}
+	case *detail.NearbyRestaurantsRequest:
+		if req.GetCenter() == nil {
+			v.add("center", "must be set")
+		} else {
+			v.check("center", checkCoordinates(req.GetCenter()))
+		}
+		if radius := req.GetRadiusKm(); !(radius > 0 && radius <= maxNearbyRadius) {
+			v.add("radius_km", "must be positive and at most %.0f, not %v", maxNearbyRadius, radius)
+		}
+		if req.GetLimit() < 0 {
+			v.add("limit", "must not be negative, not %d", req.GetLimit())
+		}
+	case *detail.IsOpenRequest:
+		v.checkRestaurant(req.GetRestaurantId(), req.GetRestaurantName())
+		if req.GetTime() != nil && req.GetDate() != nil {
+			v.add("date", "must not be set along with time")
+		}
+		if req.GetTime() != nil {
+			if err := req.GetTime().CheckValid(); err != nil {
+				v.add("time", "%v", err)
+			}
+		}
+		if date := req.GetDate(); date != nil {
+			v.checkDate("date", date.GetYear(), date.GetMonth(), date.GetDay())
+		}
+	case *detail.GetDetailHistoryRequest:
+		v.checkRestaurant(req.GetRestaurantId(), req.GetRestaurantName())
+		if req.GetLimit() < 0 {
+			v.add("limit", "must not be negative, not %d", req.GetLimit())
+		}
+		if req.GetBeforeRevision() < 0 {
+			v.add("before_revision", "must not be negative, not %d", req.GetBeforeRevision())
+		}
+	case *detail.RevertDetailRequest:
+		v.checkRestaurant(req.GetRestaurantId(), req.GetRestaurantName())
+		if req.GetRevision() < 1 {
+			v.add("revision", "must be positive, not %d", req.GetRevision())
+		}
+	case *detail.UpdateRatingRequest:
+		v.required("restaurant_id", req.GetRestaurantId())
+		if req.GetRating() < 0 || req.GetRating() > maxRating {
+			v.add("rating", "must be between 0 and %d, not %v", maxRating, req.GetRating())
+		}
+		if req.GetReviewCount() < 0 {
+			v.add("review_count", "must not be negative, not %d", req.GetReviewCount())
+		}
+		if req.GetRatingVersion() < 1 {
+			v.add("rating_version", "must be positive, not %d", req.GetRatingVersion())
+		}
+	case *detail.DownloadPhotoRequest:
+		if id, err := hex.DecodeString(req.GetPhotoId()); err != nil || len(id) != 32 || strings.ToLower(req.GetPhotoId()) != req.GetPhotoId() {
+			v.add("photo_id", "must be the SHA-256 hash of a photo, in lower case hexadecimal")
+		}
+	case *review.PostReviewRequest:
+		v.checkRestaurant(req.GetRestaurantId(), req.GetRestaurantName())
+		v.checkReviewedRestaurant(req.GetRestaurantId())
+		v.required("user_name", req.GetUserName())
+		if req.GetRating() < minRating || req.GetRating() > maxRating {
+			v.add("rating", "must be between %d and %d, not %d", minRating, maxRating, req.GetRating())
+		}
+	case *review.GetReviewRequest:
+		v.checkRestaurant(req.GetRestaurantId(), req.GetRestaurantName())
+		v.checkReviewedRestaurant(req.GetRestaurantId())
+		v.required("user_name", req.GetUserName())
+	case *review.SearchReviewsRequest:
+		v.checkRestaurant(req.GetRestaurantId(), req.GetRestaurantName())
+		v.checkReviewedRestaurant(req.GetRestaurantId())
+	case *reservation.MakeReservationRequest:
+		v.checkRestaurant(req.GetRestaurantId(), req.GetRestaurantName())
+		v.required("user_name", req.GetUserName())
+		date := req.GetTime()
+		if date == nil {
+			v.add("time", "must be set")
+		} else if v.checkDate("time", date.GetYear(), date.GetMonth(), date.GetDay()) {
+			y, m, d := time.Now().In(latestTimeZone).Date()
+			today := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+			if time.Date(int(date.GetYear()), time.Month(date.GetMonth()), int(date.GetDay()), 0, 0, 0, 0, time.UTC).Before(today) {
+				v.add("time", "must not be in the past")
+			}
+		}
+	case *reservation.GetReservationRequest:
+		v.required("user_name", req.GetUserName())
+	case *reservation.MostPopularRequest:
+		if req.GetTopK() < 1 || req.GetTopK() > maxTopK {
+			v.add("topK", "must be between 1 and %d, not %d", maxTopK, req.GetTopK())
+		}
+	}
+	return v.err()
+}
+
+// fieldViolations collects the fields of a request that break the rules of its message
+// type, named by their path from the request.
+type fieldViolations []*errdetails.BadRequest_FieldViolation
+
+// add records that field breaks a rule, described by format and args.
+func (v *fieldViolations) add(field, format string, args ...interface{}) {
+	if len(*v) < maxFieldViolations {
+		*v = append(*v, &errdetails.BadRequest_FieldViolation{Field: field, Description: fmt.Sprintf(format, args...)})
+	}
+}
+
+// check records that field breaks a rule if err, the result of a check of the field,
+// is not nil.
+func (v *fieldViolations) check(field string, err error) {
+	if err != nil {
+		v.add(field, "%s", status.Convert(err).Message())
+	}
+}
+
+// required records that field breaks a rule if its value is blank.
+func (v *fieldViolations) required(field, value string) {
+	if strings.TrimSpace(value) == "" {
+		v.add(field, "must be set")
+	}
+}
+
+// checkRestaurant records that a request names no restaurant, by ID or by name.
+func (v *fieldViolations) checkRestaurant(restaurantID, restaurantName string) {
+	if strings.TrimSpace(restaurantID) == "" && strings.TrimSpace(restaurantName) == "" {
+		v.add("restaurant_id", "must be set, unless restaurant_name is")
+	}
+}
+
+// checkReviewedRestaurant records a restaurant ID that reviews cannot be kept under, since
+// the reviews of a restaurant are indexed under its ID followed by a slash. Restaurants
+// cannot be added under such an ID either, so that every restaurant can be reviewed.
+func (v *fieldViolations) checkReviewedRestaurant(restaurantID string) {
+	if strings.Contains(restaurantID, "/") {
+		v.add("restaurant_id", "must not contain /")
+	}
+}
+
+// checkCapacity records a capacity that is negative or implausibly large.
+func (v *fieldViolations) checkCapacity(field string, capacity int32) {
+	if capacity < 0 || capacity > maxCapacity {
+		v.add(field, "must be between 0 and %d, not %d", maxCapacity, capacity)
+	}
+}
+
+// checkDate records a date that is not a day of the calendar, and reports whether it is
+// one.
+func (v *fieldViolations) checkDate(field string, year, month, day int32) bool {
+	switch {
+	case year < 1 || year > 9999:
+		v.add(field+".year", "must be between 1 and 9999, not %d", year)
+	case month < 1 || month > 12:
+		v.add(field+".month", "must be between 1 and 12, not %d", month)
+	case day < 1 || time.Date(int(year), time.Month(month), int(day), 0, 0, 0, 0, time.UTC).Day() != int(day):
+		v.add(field+".day", "must be a day of %04d-%02d, not %d", year, month, day)
+	default:
+		return true
+	}
+	return false
+}
+
+// checkProfile records the fields of a restaurant's profile, under prefix, that the
+// detail service would reject.
+func (v *fieldViolations) checkProfile(prefix string, hours *detail.OpeningHours, tier detail.PriceTier, tags []string, contact *detail.ContactInfo, menu *detail.Menu) {
+	v.check(prefix+"hours", checkOpeningHours(hours))
+	v.check(prefix+"price_tier", checkPriceTier(tier))
+	_, err := normalizeTags(tags)
+	v.check(prefix+"tags", err)
+	v.check(prefix+"contact", checkContact(contact))
+	v.check(prefix+"menu", checkMenu(menu))
+}
+
+// checkStrings records the string fields of a message, and of the messages it holds, that
+// are longer than their limits. Field names are prefixed with prefix.
+func (v *fieldViolations) checkStrings(m protoreflect.Message, prefix string) {
+	m.Range(func(fd protoreflect.FieldDescriptor, value protoreflect.Value) bool {
+		field := prefix + string(fd.Name())
+		switch {
+		case fd.IsList():
+			list := value.List()
+			for i := 0; i < list.Len(); i++ {
+				v.checkValue(fd, list.Get(i), fmt.Sprintf("%s[%d]", field, i))
+			}
+		case fd.IsMap():
+			value.Map().Range(func(k protoreflect.MapKey, mv protoreflect.Value) bool {
+				v.checkValue(fd.MapValue(), mv, fmt.Sprintf("%s[%v]", field, k.Interface()))
+				return true
+			})
+		default:
+			v.checkValue(fd, value, field)
+		}
+		return len(*v) < maxFieldViolations
+	})
+}
+
+// checkValue records a single value of a field, or the fields of a message, that is
+// longer than its limit.
+func (v *fieldViolations) checkValue(fd protoreflect.FieldDescriptor, value protoreflect.Value, field string) {
+	switch fd.Kind() {
+	case protoreflect.StringKind:
+		limit, ok := stringFieldLimits[fd.Name()]
+		if !ok {
+			limit = maxTextLength
+		}
+		if n := utf8.RuneCountInString(value.String()); n > limit {
+			v.add(field, "must be at most %d characters, not %d", limit, n)
+		}
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		v.checkStrings(value.Message(), field+".")
+	}
+}
+
+// err returns InvalidArgument listing the violations, as a BadRequest detail and in its
+// message, or nil if there are none.
+func (v fieldViolations) err() error {
+	if len(v) == 0 {
+		return nil
+	}
+	descriptions := make([]string, len(v))
+	for i, violation := range v {
+		descriptions[i] = violation.GetField() + ": " + violation.GetDescription()
+	}
+	st := status.New(codes.InvalidArgument, "Invalid request: "+strings.Join(descriptions, "; "))
+	if withDetails, err := st.WithDetails(&errdetails.BadRequest{FieldViolations: v}); err == nil {
+		st = withDetails
+	}
+	return st.Err()
+}
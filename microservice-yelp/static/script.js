@@ -5,11 +5,12 @@ async function fetchRestaurantDetails() {
 
     const restaurantDetailsDiv = document.getElementById('restaurant-details');
     // Build HTML content and update restaurantDetailsDiv
+    return restaurant;
 }
 
 // Fetch and display reviews
-async function fetchReviews() {
-    const response = await fetch('/get-review?restaurant_name=YourRestaurantName');
+async function fetchReviews(restaurantId) {
+    const response = await fetch(`/search-reviews?restaurant_id=${encodeURIComponent(restaurantId)}`);
     const reviews = await response.json();
 
     const reviewsDiv = document.getElementById('reviews');
@@ -21,11 +22,14 @@ async function makeReservation() {
     const reservationForm = document.getElementById('reservation-form');
     const userName = reservationForm.querySelector('#user-name').value;
     const restaurantName = reservationForm.querySelector('#restaurant-name').value;
+    // reservations name the restaurant by its ID
+    const detailResponse = await fetch(`/get-detail?restaurant_name=${encodeURIComponent(restaurantName)}`);
+    const restaurant = await detailResponse.json();
     const year = reservationForm.querySelector('#year').value;
     const month = reservationForm.querySelector('#month').value;
     const day = reservationForm.querySelector('#day').value;
 
-    const response = await fetch(`/make-reservation?user_name=${userName}&restaurant_name=${restaurantName}&year=${year}&month=${month}&day=${day}`, { method: 'POST' });
+    const response = await fetch(`/make-reservation?user_name=${userName}&restaurant_id=${restaurant.restaurant_id}&restaurant_name=${restaurantName}&year=${year}&month=${month}&day=${day}`, { method: 'POST' });
     const reservationResponse = await response.json();
 
     // Display reservation response message to the user
@@ -33,8 +37,7 @@ async function makeReservation() {
 
 // Add event listeners
 document.addEventListener('DOMContentLoaded', () => {
-    fetchRestaurantDetails();
-    fetchReviews();
+    fetchRestaurantDetails().then((restaurant) => fetchReviews(restaurant.restaurant_id));
 
     const reservationForm = document.getElementById('reservation-form');
     reservationForm.addEventListener('submit', (event) => {
@@ -46,6 +46,10 @@ for line in file:lines() do
 end
 file:close()
 
+-- Look up the IDs of the sampled restaurants before the threads start
+local restaurants = dofile("./workloads/restaurants.lua")
+setup = restaurants.setup(function() return samples end)
+
 local function post_detail()
     local method = "GET"
 
@@ -54,7 +58,7 @@ local function post_detail()
     local sample = samples[random_index]
 
     -- Construct the path using the selected sample
-    local path = url .. "/post-detail?restaurant_name=" .. urlencode(sample.restaurant_name) .. "&location=" .. urlencode(sample.location) .. "&style=" .. urlencode(sample.style) .. "&capacity=" .. sample.capacity
+    local path = url .. "/post-detail?restaurant_id=" .. urlencode(restaurant_ids[sample.restaurant_name]) .. "&restaurant_name=" .. urlencode(sample.restaurant_name) .. "&location=" .. urlencode(sample.location) .. "&style=" .. urlencode(sample.style) .. "&capacity=" .. sample.capacity
     -- print(path) -- (optional) uncomment me to print the URL query!
     local headers = {}
     return wrk.format(method, path, headers, nil)
@@ -68,7 +72,7 @@ local function get_detail()
     local sample = samples[random_index]
 
     -- Construct the path using the selected sample
-    local path = url .. "/get-detail?restaurant_name=" .. urlencode(sample.restaurant_name)
+    local path = url .. "/get-detail?restaurant_id=" .. urlencode(restaurant_ids[sample.restaurant_name])
     -- print(path) -- (optional) uncomment me to print the URL query!
     local headers = {}
     return wrk.format(method, path, headers, nil)
@@ -56,13 +56,8 @@ local function load_reservation_samples(file_path, t)
     file:read("*line")
 
     for line in file:lines() do
-        -- Split the line using commas
-        local fields = {}
-        for field in line:gmatch("[^,]+") do
-            fields[#fields + 1] = field
-        end
-    
-        local user_name, restaurant_name, year, month, day = fields[1], fields[2], tonumber(fields[3]), tonumber(fields[4]), tonumber(fields[5])
+        local user_name, restaurant_name, year, month, day = line:match("\"(.-)\",\"(.-)\",(%d+),(%d+),(%d+)")
+        year, month, day = tonumber(year), tonumber(month), tonumber(day)
         -- print(user_name, restaurant_name, year, month, day) -- (optional) uncomment to look at data
     
         -- Check if the fields are not nil
@@ -93,6 +88,11 @@ load_detail_samples("./samples/detail_samples.csv", detail_samples)
 load_reservation_samples("./samples/reservation_samples.csv", reservation_samples)
 load_review_samples("./samples/review_samples.csv", review_samples)
 
+-- Look up the IDs of the sampled restaurants before the threads start; every restaurant
+-- reviewed or reserved has a detail sample
+local restaurants = dofile("./workloads/restaurants.lua")
+setup = restaurants.setup(function() return detail_samples end)
+
 local function post_detail()
     local method = "GET"
 
@@ -101,7 +101,7 @@ local function post_detail()
     local sample = detail_samples[random_index]
 
     -- Construct the path using the selected sample
-    local path = url .. "/post-detail?restaurant_name=" .. urlencode(sample.restaurant_name) .. "&location=" .. urlencode(sample.location) .. "&style=" .. urlencode(sample.style) .. "&capacity=" .. sample.capacity
+    local path = url .. "/post-detail?restaurant_id=" .. urlencode(restaurant_ids[sample.restaurant_name]) .. "&restaurant_name=" .. urlencode(sample.restaurant_name) .. "&location=" .. urlencode(sample.location) .. "&style=" .. urlencode(sample.style) .. "&capacity=" .. sample.capacity
     local headers = {}
     return wrk.format(method, path, headers, nil)
 end
@@ -114,7 +114,7 @@ local function get_detail()
     local sample = detail_samples[random_index]
 
     -- Construct the path using the selected sample
-    local path = url .. "/get-detail?restaurant_name=" .. urlencode(sample.restaurant_name)
+    local path = url .. "/get-detail?restaurant_id=" .. urlencode(restaurant_ids[sample.restaurant_name])
     local headers = {}
     return wrk.format(method, path, headers, nil)
 end
@@ -127,7 +127,7 @@ local function make_reservation()
     local sample = reservation_samples[random_index]
 
     -- Construct the path using the selected sample
-    local path = url .. "/make-reservation?restaurant_name=" .. urlencode(sample.restaurant_name) .. "&user_name=" .. urlencode(sample.user_name) .. "&year=" .. sample.year .. "&month=" .. sample.month .. "&day=" .. sample.day
+    local path = url .. "/make-reservation?restaurant_id=" .. urlencode(restaurant_ids[sample.restaurant_name]) .. "&restaurant_name=" .. urlencode(sample.restaurant_name) .. "&user_name=" .. urlencode(sample.user_name) .. "&year=" .. sample.year .. "&month=" .. sample.month .. "&day=" .. sample.day
     local headers = {}
     return wrk.format(method, path, headers, nil)
 end
@@ -163,7 +163,7 @@ local function post_review()
     local sample = review_samples[random_index]
 
     -- Construct the path using the selected sample
-    local path = url .. "/post-review?restaurant_name=" .. urlencode(sample.restaurant_name) .. "&user_name=" .. urlencode(sample.user_name) .. "&review=" .. urlencode(sample.review) .. "&rating=" .. sample.rating
+    local path = url .. "/post-review?restaurant_id=" .. urlencode(restaurant_ids[sample.restaurant_name]) .. "&restaurant_name=" .. urlencode(sample.restaurant_name) .. "&user_name=" .. urlencode(sample.user_name) .. "&review=" .. urlencode(sample.review) .. "&rating=" .. sample.rating
     local headers = {}
     return wrk.format(method, path, headers, nil)
 end
@@ -176,7 +176,7 @@ local function get_review()
     local sample = review_samples[random_index]
 
     -- Construct the path using the selected sample
-    local path = url .. "/get-review?user_name=" .. urlencode(sample.user_name) .. "&restaurant_name=" .. urlencode(sample.restaurant_name)
+    local path = url .. "/get-review?user_name=" .. urlencode(sample.user_name) .. "&restaurant_id=" .. urlencode(restaurant_ids[sample.restaurant_name])
     local headers = {}
     return wrk.format(method, path, headers, nil)
 end
@@ -189,7 +189,7 @@ local function search_reviews()
     local sample = review_samples[random_index]
 
     -- Construct the path using the selected sample
-    local path = url .. "/search-reviews?restaurant_name=" .. urlencode(sample.restaurant_name)
+    local path = url .. "/search-reviews?restaurant_id=" .. urlencode(restaurant_ids[sample.restaurant_name])
     local headers = {}
     return wrk.format(method, path, headers, nil)
 end
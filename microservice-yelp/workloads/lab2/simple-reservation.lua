@@ -38,13 +38,8 @@ local file = io.open("./samples/reservation_samples.csv", "r")
 file:read("*line")
 
 for line in file:lines() do
-    -- Split the line using commas
-    local fields = {}
-    for field in line:gmatch("[^,]+") do
-        fields[#fields + 1] = field
-    end
-
-    local user_name, restaurant_name, year, month, day = fields[1], fields[2], tonumber(fields[3]), tonumber(fields[4]), tonumber(fields[5])
+    local user_name, restaurant_name, year, month, day = line:match("\"(.-)\",\"(.-)\",(%d+),(%d+),(%d+)")
+    year, month, day = tonumber(year), tonumber(month), tonumber(day)
     -- print(user_name, restaurant_name, year, month, day) -- (optional) uncomment to look at data
 
     -- Check if the fields are not nil
@@ -53,6 +48,10 @@ for line in file:lines() do
     end
 end
 
+-- Look up the IDs of the sampled restaurants before the threads start
+local restaurants = dofile("./workloads/restaurants.lua")
+setup = restaurants.setup(function() return samples end)
+
 local function make_reservation()
     local method = "GET"
 
@@ -61,7 +60,7 @@ local function make_reservation()
     local sample = samples[random_index]
 
     -- Construct the path using the selected sample
-    local path = url .. "/make-reservation?restaurant_name=" .. urlencode(sample.restaurant_name) .. "&user_name=" .. urlencode(sample.user_name) .. "&year=" .. sample.year .. "&month=" .. sample.month .. "&day=" .. sample.day
+    local path = url .. "/make-reservation?restaurant_id=" .. urlencode(restaurant_ids[sample.restaurant_name]) .. "&restaurant_name=" .. urlencode(sample.restaurant_name) .. "&user_name=" .. urlencode(sample.user_name) .. "&year=" .. sample.year .. "&month=" .. sample.month .. "&day=" .. sample.day
     -- print(path) -- (optional) uncomment me to print the URL query!
     local headers = {}
     return wrk.format(method, path, headers, nil)
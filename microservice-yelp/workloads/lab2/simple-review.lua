@@ -44,6 +44,10 @@ for line in file:lines() do
 end
 file:close()
 
+-- Look up the IDs of the sampled restaurants before the threads start
+local restaurants = dofile("./workloads/restaurants.lua")
+setup = restaurants.setup(function() return samples end)
+
 local function post_review()
     local method = "GET"
 
@@ -52,7 +56,7 @@ local function post_review()
     local sample = samples[random_index]
 
     -- Construct the path using the selected sample
-    local path = url .. "/post-review?restaurant_name=" .. urlencode(sample.restaurant_name) .. "&user_name=" .. urlencode(sample.user_name) .. "&review=" .. urlencode(sample.review) .. "&rating=" .. sample.rating
+    local path = url .. "/post-review?restaurant_id=" .. urlencode(restaurant_ids[sample.restaurant_name]) .. "&restaurant_name=" .. urlencode(sample.restaurant_name) .. "&user_name=" .. urlencode(sample.user_name) .. "&review=" .. urlencode(sample.review) .. "&rating=" .. sample.rating
     -- print(path) -- (optional) uncomment me to print the URL query!
     local headers = {}
     return wrk.format(method, path, headers, nil)
@@ -66,7 +70,7 @@ local function get_review()
     local sample = samples[random_index]
 
     -- Construct the path using the selected sample
-    local path = url .. "/get-review?user_name=" .. urlencode(sample.user_name) .. "&restaurant_name=" .. urlencode(sample.restaurant_name)
+    local path = url .. "/get-review?user_name=" .. urlencode(sample.user_name) .. "&restaurant_id=" .. urlencode(restaurant_ids[sample.restaurant_name])
     -- print(path) -- (optional) uncomment me to print the URL query!
     local headers = {}
     return wrk.format(method, path, headers, nil)
@@ -80,7 +84,7 @@ local function search_reviews()
     local sample = samples[random_index]
 
     -- Construct the path using the selected sample
-    local path = url .. "/search-reviews?restaurant_name=" .. urlencode(sample.restaurant_name)
+    local path = url .. "/search-reviews?restaurant_id=" .. urlencode(restaurant_ids[sample.restaurant_name])
     -- print(path) -- (optional) uncomment me to print the URL query!
     local headers = {}
     return wrk.format(method, path, headers, nil)
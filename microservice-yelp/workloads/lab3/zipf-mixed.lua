@@ -83,6 +83,17 @@ detailDatasetSize   = detailCacheCapacity * datasetMultiplier
 reviewDatasetSize   = reviewCacheCapacity * datasetMultiplier
 reservDatasetSize   = reservCacheCapacity * datasetMultiplier
 
+-- Look up the IDs of the restaurants of every dataset before the threads start, posting
+-- those that do not exist yet
+local restaurants = dofile("./workloads/restaurants.lua")
+setup = restaurants.setup(function()
+    local samples = {}
+    for i = 1, math.max(detailDatasetSize, reviewDatasetSize, reservDatasetSize) do
+        table.insert(samples, {restaurant_name = "restaurant" .. tostring(i), location = "location" .. tostring(i), style = "style" .. tostring(i), capacity = math.random(40, 250)})
+    end
+    return samples
+end)
+
 local function post_detail()
     local method = "GET"
 
@@ -90,13 +101,14 @@ local function post_detail()
     local rand_id = hash(sampleZipf(detailDatasetSize, alpha), detailDatasetSize)
 
     local restaurant_name = urlencode("restaurant" .. tostring(rand_id))
+    local restaurant_id = urlencode(restaurant_ids["restaurant" .. tostring(rand_id)])
     local location = urlencode("location" .. tostring(rand_id))
     local style = urlencode("style" .. tostring(rand_id))
     local capacity = tostring(math.random(40, 250))
     -- print(restaurant_name, location, style, capacity)
 
     -- Construct the path using the selected sample
-    local path = url .. "/post-detail?restaurant_name=" .. restaurant_name .. "&location=" .. location .. "&style=" .. style .. "&capacity=" .. capacity
+    local path = url .. "/post-detail?restaurant_id=" .. restaurant_id .. "&restaurant_name=" .. restaurant_name .. "&location=" .. location .. "&style=" .. style .. "&capacity=" .. capacity
     -- print(path) -- (optional) uncomment me to print the URL query!
     local headers = {}
     return wrk.format(method, path, headers, nil)
@@ -107,10 +119,10 @@ local function get_detail()
 
     -- Choose a random "sample" from detail dataset using zipf distribution
     local rand_id = hash(sampleZipf(detailDatasetSize, alpha), detailDatasetSize)
-    local restaurant_name = urlencode("restaurant" .. tostring(rand_id))
+    local restaurant_id = urlencode(restaurant_ids["restaurant" .. tostring(rand_id)])
 
     -- Construct the path using the selected sample
-    local path = url .. "/get-detail?restaurant_name=" .. restaurant_name
+    local path = url .. "/get-detail?restaurant_id=" .. restaurant_id
     -- print(path) -- (optional) uncomment me to print the URL query!
     local headers = {}
     return wrk.format(method, path, headers, nil)
@@ -123,13 +135,14 @@ local function post_review()
     local rand_id = hash(sampleZipf(reviewDatasetSize, alpha), reviewDatasetSize)
 
     local restaurant_name = urlencode("restaurant" .. tostring(rand_id))
+    local restaurant_id = urlencode(restaurant_ids["restaurant" .. tostring(rand_id)])
     local user_name = urlencode("user" .. tostring(math.random(1,10)))
     local review = urlencode("review" .. tostring(rand_id))
     local rating = urlencode(tostring(math.random(1, 5)))
     -- print(restaurant_name, location, style, capacity)
 
     -- Construct the path using the selected sample
-    local path = url .. "/post-review?restaurant_name=" .. restaurant_name .. "&user_name=" .. user_name .. "&review=" .. review .. "&rating=" .. rating
+    local path = url .. "/post-review?restaurant_id=" .. restaurant_id .. "&restaurant_name=" .. restaurant_name .. "&user_name=" .. user_name .. "&review=" .. review .. "&rating=" .. rating
     -- print(path) -- (optional) uncomment me to print the URL query!
     local headers = {}
     return wrk.format(method, path, headers, nil)
@@ -141,11 +154,11 @@ local function get_review()
     -- Choose a random "sample" from detail dataset using zipf distribution
     local rand_id = hash(sampleZipf(reviewDatasetSize, alpha), reviewDatasetSize)
 
-    local restaurant_name = urlencode("restaurant" .. tostring(rand_id))
+    local restaurant_id = urlencode(restaurant_ids["restaurant" .. tostring(rand_id)])
     local user_name = urlencode("user" .. tostring(math.random(1,10)))
 
     -- Construct the path using the selected sample
-    local path = url .. "/get-review?user_name=" .. user_name .. "&restaurant_name=" .. restaurant_name
+    local path = url .. "/get-review?user_name=" .. user_name .. "&restaurant_id=" .. restaurant_id
     -- print(path) -- (optional) uncomment me to print the URL query!
     local headers = {}
     return wrk.format(method, path, headers, nil)
@@ -156,10 +169,10 @@ local function search_reviews()
 
     -- Choose a random "sample" from detail dataset using zipf distribution
     local rand_id = hash(sampleZipf(reviewDatasetSize, alpha), reviewDatasetSize)
-    local restaurant_name = urlencode("restaurant" .. tostring(rand_id))
+    local restaurant_id = urlencode(restaurant_ids["restaurant" .. tostring(rand_id)])
 
     -- Construct the path using the selected sample
-    local path = url .. "/search-reviews?restaurant_name=" .. restaurant_name
+    local path = url .. "/search-reviews?restaurant_id=" .. restaurant_id
     -- print(path) -- (optional) uncomment me to print the URL query!
     local headers = {}
     return wrk.format(method, path, headers, nil)
@@ -173,6 +186,7 @@ local function make_reservation()
     -- print(rand_id)
 
     local restaurant_name = urlencode("restaurant" .. tostring(rand_id))
+    local restaurant_id = urlencode(restaurant_ids["restaurant" .. tostring(rand_id)])
     local user_name = urlencode("user" .. tostring(math.random(1,10)))
     local year = urlencode(tostring(math.random(2020, 2025)))
     local month = urlencode(tostring(math.random(1, 12)))
@@ -180,7 +194,7 @@ local function make_reservation()
 
 
     -- Construct the path using the selected sample
-    local path = url .. "/make-reservation?restaurant_name=" .. restaurant_name .. "&user_name=" .. user_name .. "&year=" .. year .. "&month=" .. month .. "&day=" .. day
+    local path = url .. "/make-reservation?restaurant_id=" .. restaurant_id .. "&restaurant_name=" .. restaurant_name .. "&user_name=" .. user_name .. "&year=" .. year .. "&month=" .. month .. "&day=" .. day
     -- print(path) -- (optional) uncomment me to print the URL query!
     local headers = {}
     return wrk.format(method, path, headers, nil)
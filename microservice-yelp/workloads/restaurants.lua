@@ -0,0 +1,73 @@
+-- Restaurants are named in requests by the IDs the server assigns them when they are first
+-- posted. A workload looks up the IDs of the restaurants it sends requests for in `setup`,
+-- once before its threads start, and hands them to every thread as `restaurant_ids`, a
+-- table from restaurant name to ID:
+--
+--     local restaurants = dofile("./workloads/restaurants.lua")
+--     setup = restaurants.setup(function() return samples end)
+--
+-- Samples with a location, style and capacity are posted if no restaurant has their name
+-- yet; the others must have been posted before, e.g. with scripts/generate-samples.py.
+local http = require("socket.http")
+
+local restaurants = {}
+
+local char_to_hex = function(c)
+    return string.format("%%%02X", string.byte(c))
+end
+
+local function urlencode(url)
+    url = url:gsub("\n", "\r\n")
+    url = url:gsub("([^%w ])", char_to_hex)
+    url = url:gsub(" ", "+")
+    return url
+end
+
+-- The address of the frontend wrk sends its requests to
+local function frontend()
+    return wrk.scheme .. "://" .. wrk.host .. ":" .. wrk.port
+end
+
+-- The restaurant ID a response from the frontend gives, if any
+local function restaurant_id(body)
+    return body and body:match("\"restaurant_id\":\"(.-)\"")
+end
+
+-- Returns the ID of the restaurant a sample names, posting the sample if no restaurant has
+-- its name and it holds the restaurant's details
+function restaurants.resolve(sample)
+    local name = sample.restaurant_name
+    local body, code = http.request(frontend() .. "/get-detail?restaurant_name=" .. urlencode(name))
+    if code == 200 and restaurant_id(body) then
+        return restaurant_id(body)
+    end
+    if code ~= 404 or not (sample.location and sample.style and sample.capacity) then
+        error("failed to look up the ID of " .. name .. ": status " .. tostring(code))
+    end
+
+    local path = "/post-detail?restaurant_name=" .. urlencode(name) .. "&location=" .. urlencode(sample.location) .. "&style=" .. urlencode(sample.style) .. "&capacity=" .. sample.capacity
+    body, code = http.request(frontend() .. path)
+    if code ~= 200 or not restaurant_id(body) then
+        error("failed to post " .. name .. ": status " .. tostring(code))
+    end
+    return restaurant_id(body)
+end
+
+-- Returns a wrk `setup` function that resolves the samples `list` returns to restaurant
+-- IDs, the first time it is called, and sets `restaurant_ids` in every thread
+function restaurants.setup(list)
+    local ids
+    return function(thread)
+        if ids == nil then
+            ids = {}
+            for _, sample in ipairs(list()) do
+                if ids[sample.restaurant_name] == nil then
+                    ids[sample.restaurant_name] = restaurants.resolve(sample)
+                end
+            end
+        end
+        thread:set("restaurant_ids", ids)
+    end
+end
+
+return restaurants